@@ -0,0 +1,201 @@
+package client
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The Fiber API doesn't sign its acknowledgments, so they can't be proven to a third party. An AckLog
+// is the next best thing: a local, tamper-evident record of every ack, where each record commits to the
+// previous one. Editing, reordering or removing a record breaks the chain, which VerifyAckLog detects.
+// Removing the last records can only be detected against a head that was published or stored
+// elsewhere, see AckLog.Head.
+
+// ErrAckLogTampered is returned by VerifyAckLog when the chain of records is broken.
+var ErrAckLogTampered = newCodedError(ErrCodeAckLogTampered, "ack log tampered")
+
+// AckRecord is one acknowledged transaction in an AckLog.
+type AckRecord struct {
+	Seq  uint64      `json:"seq"`
+	Hash common.Hash `json:"hash"`
+	// Timestamp is when the Fiber node received the transaction (us), as acknowledged.
+	Timestamp int64 `json:"timestamp"`
+	// LoggedAt is when the ack was logged (ns since the Unix epoch).
+	LoggedAt int64 `json:"logged_at"`
+	// Prev is the digest of the previous record, zero for the first one.
+	Prev   common.Hash `json:"prev"`
+	Digest common.Hash `json:"digest"`
+}
+
+// digest returns the digest the record should have.
+func (r *AckRecord) digest() common.Hash {
+	var buf [32 + 8 + 32 + 8 + 8]byte
+	copy(buf[:32], r.Prev[:])
+	binary.BigEndian.PutUint64(buf[32:], r.Seq)
+	copy(buf[40:], r.Hash[:])
+	binary.BigEndian.PutUint64(buf[72:], uint64(r.Timestamp))
+	binary.BigEndian.PutUint64(buf[80:], uint64(r.LoggedAt))
+
+	return crypto.Keccak256Hash(buf[:])
+}
+
+// AckLog appends hash-chained records of acks, one JSON object per line. It's safe for concurrent use.
+type AckLog struct {
+	mu   sync.Mutex
+	w    io.Writer
+	file *os.File
+	seq  uint64
+	head common.Hash
+	err  error
+}
+
+// NewAckLog returns a log writing new records to w.
+func NewAckLog(w io.Writer) *AckLog {
+	return &AckLog{w: w}
+}
+
+// OpenAckLog opens the log at path, creating it if needed. An existing log is verified, and new records
+// continue its chain. Every record is synced to disk before the send that was acked returns.
+func OpenAckLog(path string) (*AckLog, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening ack log: %w", err)
+	}
+
+	l := &AckLog{w: f, file: f}
+	if l.seq, l.head, err = VerifyAckLog(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// WithAckLog records the acks of all the transactions sent by the client, single and in sequences, in l.
+// Failing to record an ack doesn't fail the send, check AckLog.Err.
+func WithAckLog(l *AckLog) ClientOption {
+	return func(c *Client) {
+		c.ackLog = l
+	}
+}
+
+// Record appends the ack of a transaction, and returns its record.
+func (l *AckLog) Record(hash common.Hash, timestamp int64) (*AckRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	r := &AckRecord{
+		Seq:       l.seq + 1,
+		Hash:      hash,
+		Timestamp: timestamp,
+		LoggedAt:  time.Now().UnixNano(),
+		Prev:      l.head,
+	}
+	r.Digest = r.digest()
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, fmt.Errorf("encoding ack record: %w", err)
+	}
+
+	// A partial write would break the chain, so the log is unusable after any failure
+	if _, err := l.w.Write(append(b, '\n')); err != nil {
+		l.err = fmt.Errorf("writing ack record: %w", err)
+		return nil, l.err
+	}
+	if l.file != nil {
+		if err := l.file.Sync(); err != nil {
+			l.err = fmt.Errorf("syncing ack log: %w", err)
+			return nil, l.err
+		}
+	}
+
+	l.seq, l.head = r.Seq, r.Digest
+	return r, nil
+}
+
+// Head returns the number of records and the digest of the last one. Storing the head somewhere else
+// makes the removal of the last records detectable.
+func (l *AckLog) Head() (uint64, common.Hash) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.seq, l.head
+}
+
+// Err returns the error that made the log unusable, if any.
+func (l *AckLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// Close closes the file of a log opened with OpenAckLog.
+func (l *AckLog) Close() error {
+	if l.file == nil {
+		return nil
+	}
+
+	return l.file.Close()
+}
+
+// VerifyAckLog checks the chain of records read from r, and returns the number of records and the
+// digest of the last one, to compare with a stored head.
+func VerifyAckLog(r io.Reader) (uint64, common.Hash, error) {
+	var seq uint64
+	var head common.Hash
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var record AckRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return seq, head, fmt.Errorf("record %d: %v: %w", seq+1, err, ErrAckLogTampered)
+		}
+
+		if record.Seq != seq+1 || record.Prev != head || record.Digest != record.digest() {
+			return seq, head, fmt.Errorf("record %d: %w", seq+1, ErrAckLogTampered)
+		}
+
+		seq, head = record.Seq, record.Digest
+	}
+
+	if err := scanner.Err(); err != nil {
+		return seq, head, fmt.Errorf("reading ack log: %w", err)
+	}
+
+	return seq, head, nil
+}
+
+// logAck records an ack in the ack log of the client, if any.
+func (c *Client) logAck(hash string, timestamp int64) {
+	if c.ackLog != nil {
+		c.ackLog.Record(common.HexToHash(hash), timestamp)
+	}
+}
+
+// logSequenceAcks records the acks of the accepted transactions of a sequence, and passes the result through.
+func (c *Client) logSequenceAcks(res *SequenceResult, err error) (*SequenceResult, error) {
+	if c.ackLog != nil && res != nil {
+		for _, item := range res.Items {
+			if item.Position >= 0 {
+				c.ackLog.Record(item.Hash, item.Timestamp)
+			}
+		}
+	}
+
+	return res, err
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestAckLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "acks.log")
+
+	l, err := OpenAckLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewClient("", WithAckLog(l))
+	c.logAck(common.Hash{1}.Hex(), 100)
+	c.logSequenceAcks(&SequenceResult{Items: []SequenceItem{
+		{Hash: common.Hash{2}, Position: 0, Timestamp: 200},
+		{Hash: common.Hash{3}, Position: -1},
+	}}, nil)
+	l.Close()
+
+	// Reopening continues the chain
+	if l, err = OpenAckLog(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Record(common.Hash{4}, 300); err != nil {
+		t.Fatal(err)
+	}
+	n, head := l.Head()
+	l.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq, last, err := VerifyAckLog(bytes.NewReader(data)); err != nil || seq != 3 || seq != n || last != head {
+		t.Fatalf("expected 3 verified records with head %s, got %d %s %v", head, seq, last, err)
+	}
+
+	// Changing the timestamp of the first ack breaks the chain
+	tampered := bytes.Replace(data, []byte(`"timestamp":100`), []byte(`"timestamp":99`), 1)
+	if seq, _, err := VerifyAckLog(bytes.NewReader(tampered)); !errors.Is(err, ErrAckLogTampered) || seq != 0 {
+		t.Fatalf("expected the first record to fail, got %d %v", seq, err)
+	}
+
+	// So does removing a record
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	removed := append(append([]byte{}, lines[0]...), lines[2]...)
+	if seq, _, err := VerifyAckLog(bytes.NewReader(removed)); !errors.Is(err, ErrAckLogTampered) || seq != 1 {
+		t.Fatalf("expected the second record to fail, got %d %v", seq, err)
+	}
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Aggregator subscribes to the same stream on several clients, like one per Fiber region, and
+// delivers the first copy of every message. Copies are recognized by hash like with Pipeline.Dedup,
+// within a window of the last DefaultDedupWindow messages by default. It counts, per client, how many
+// messages it delivered first and how late its other copies were, to benchmark the regions.
+type Aggregator[T any] struct {
+	source  Source[T]
+	clients []*Client
+	window  int
+
+	mu    sync.Mutex
+	seen  *sightings
+	stats []AggregatorStats
+}
+
+// AggregatorStats are the counters of a client of an Aggregator.
+type AggregatorStats struct {
+	Target string
+	// Received is the number of messages received from the client, copies included.
+	Received uint64
+	// Won is the number of messages the client received first, which were delivered.
+	Won uint64
+	// Lag is the total time by which the copies from the client arrived after the first ones.
+	Lag time.Duration
+}
+
+// MeanLag returns by how much the copies from the client arrived after the first ones on average.
+func (s AggregatorStats) MeanLag() time.Duration {
+	if late := s.Received - s.Won; late > 0 {
+		return s.Lag / time.Duration(late)
+	}
+
+	return 0
+}
+
+// NewAggregator returns an aggregator of src on the connected clients.
+func NewAggregator[T any](src Source[T], clients ...*Client) *Aggregator[T] {
+	a := &Aggregator[T]{source: src, clients: clients, window: DefaultDedupWindow}
+	for _, c := range clients {
+		a.stats = append(a.stats, AggregatorStats{Target: c.target})
+	}
+
+	return a
+}
+
+// Window sets the number of recent messages the copies are recognized within. A copy arriving later
+// than that is delivered again.
+func (a *Aggregator[T]) Window(n int) *Aggregator[T] {
+	a.window = n
+	return a
+}
+
+// Stats returns the counters of the clients, in the order they were given.
+func (a *Aggregator[T]) Stats() []AggregatorStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]AggregatorStats(nil), a.stats...)
+}
+
+// Run subscribes on all the clients with opts, and delivers the first copy of every message on ch. It
+// keeps going while some of the subscriptions run, and returns once ctx is done or they all failed,
+// with the error of the first client. It never closes ch. The subscriptions can't share a handle, so
+// opts mustn't include WithHandle.
+func (a *Aggregator[T]) Run(ctx context.Context, ch chan<- T, opts ...SubscriptionOption) error {
+	if len(a.clients) == 0 {
+		return fmt.Errorf("aggregator: no clients")
+	}
+
+	a.mu.Lock()
+	a.seen = newSightings(a.window)
+	a.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(a.clients))
+	for i, c := range a.clients {
+		leg := make(chan T)
+		wg.Add(2)
+		go func(i int, c *Client) {
+			defer wg.Done()
+			errs[i] = a.source(c, leg, append(opts[:len(opts):len(opts)], withContext(ctx))...)
+		}(i, c)
+
+		go func(i int) {
+			defer wg.Done()
+
+			// Drains leg until the subscription closes it, so that it never blocks on a lost race
+			for msg := range leg {
+				if !a.first(i, msg) || ctx.Err() != nil {
+					continue
+				}
+
+				select {
+				case ch <- msg:
+				case <-ctx.Done():
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("subscribing on %s: %w", a.clients[0].target, errs[0])
+}
+
+// first records that the client i received msg, and returns whether it's the first copy.
+func (a *Aggregator[T]) first(i int, msg T) bool {
+	key, ok := dedupKey(msg)
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stats[i].Received++
+	if !ok {
+		a.stats[i].Won++
+		return true
+	}
+
+	first, ok := a.seen.see(key, now)
+	if ok {
+		a.stats[i].Won++
+		return true
+	}
+
+	a.stats[i].Lag += now.Sub(first)
+	return false
+}
+
+// sightings remembers when between max and 2*max recently seen hashes were first seen, in two
+// generations like dedupSet.
+type sightings struct {
+	max  int
+	cur  map[common.Hash]time.Time
+	prev map[common.Hash]time.Time
+}
+
+func newSightings(max int) *sightings {
+	return &sightings{max: max, cur: make(map[common.Hash]time.Time)}
+}
+
+// see records that h was seen at, and returns when it was first seen and whether that's now.
+func (s *sightings) see(h common.Hash, at time.Time) (time.Time, bool) {
+	if first, ok := s.cur[h]; ok {
+		return first, false
+	}
+	if first, ok := s.prev[h]; ok {
+		return first, false
+	}
+
+	if len(s.cur) >= s.max {
+		s.prev = s.cur
+		s.cur = make(map[common.Hash]time.Time, s.max)
+	}
+	s.cur[h] = at
+
+	return at, true
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/grpc"
+)
+
+func TestAggregator(t *testing.T) {
+	slow, fast := &Client{target: "slow"}, &Client{target: "fast"}
+	src := func(c *Client, ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error {
+		open := func(ctx context.Context, _ ...grpc.CallOption) (recvStream[*ExecutionPayloadHeader], error) {
+			i := 0
+			return recvFunc[*ExecutionPayloadHeader](func() (*ExecutionPayloadHeader, error) {
+				if i == 3 {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				if c == slow {
+					time.Sleep(20 * time.Millisecond)
+				}
+
+				i++
+				return &ExecutionPayloadHeader{Number: uint64(i), Hash: common.BigToHash(big.NewInt(int64(i)))}, nil
+			}), nil
+		}
+
+		return subscribe(c, "execution_headers", ch, opts, open, identity[*ExecutionPayloadHeader])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	agg := NewAggregator[*ExecutionPayloadHeader](src, slow, fast)
+
+	ch := make(chan *ExecutionPayloadHeader, 16)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- agg.Run(ctx, ch)
+	}()
+
+	waitFor(t, func() bool { return agg.Stats()[0].Received == 3 })
+	if len(ch) != 3 {
+		t.Fatalf("expected every header once, got %d", len(ch))
+	}
+
+	stats := agg.Stats()
+	if stats[0].Target != "slow" || stats[0].Won != 0 || stats[0].MeanLag() < 20*time.Millisecond {
+		t.Fatalf("unexpected stats of the slow client %+v", stats[0])
+	}
+	if stats[1].Received != 3 || stats[1].Won != 3 || stats[1].MeanLag() != 0 {
+		t.Fatalf("unexpected stats of the fast client %+v", stats[1])
+	}
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SendResponse is the acknowledgement of a transaction by Fiber.
+type SendResponse struct {
+	Hash string
+	// Timestamp is when the Fiber node received the transaction, in microseconds since the Unix epoch.
+	Timestamp int64
+}
+
+// asyncSend is a transaction sent by SendTransactionAsync, waiting for its acknowledgement.
+type asyncSend struct {
+	callback func(*SendResponse, error)
+	start    time.Time
+}
+
+// asyncSender sends transactions on its own stream without waiting for their acknowledgements, which
+// the server sends in order, and passes them to the callbacks of the sends from a single goroutine.
+type asyncSender struct {
+	c      *Client
+	stream api.API_SendTransactionClient
+
+	// sendMu orders the sends on the stream like their entries in pending.
+	sendMu  sync.Mutex
+	mu      sync.Mutex
+	pending []asyncSend
+	err     error
+}
+
+func newAsyncSender(c *Client, stream api.API_SendTransactionClient) *asyncSender {
+	s := &asyncSender{c: c, stream: stream}
+	go s.receive()
+	return s
+}
+
+// send sends tx, and returns an error if the stream already failed. The callback is then not called.
+func (s *asyncSender) send(tx *eth.Transaction, send asyncSend) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		return err
+	}
+	s.pending = append(s.pending, send)
+	s.mu.Unlock()
+
+	// A failed send ends the stream, and receive passes why to the pending callbacks
+	s.stream.Send(tx)
+	return nil
+}
+
+func (s *asyncSender) receive() {
+	for {
+		res, err := s.stream.Recv()
+
+		s.mu.Lock()
+		if err != nil {
+			err = sendError(err)
+			s.err = err
+			pending := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+
+			for _, send := range pending {
+				s.done(send, nil, err)
+			}
+			return
+		}
+
+		if len(s.pending) == 0 {
+			// Unsolicited response, there's nobody to correlate it with
+			s.mu.Unlock()
+			continue
+		}
+
+		send := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+
+		s.c.logAck(res.Hash, res.Timestamp)
+		s.done(send, &SendResponse{Hash: res.Hash, Timestamp: res.Timestamp}, nil)
+	}
+}
+
+func (s *asyncSender) done(send asyncSend, res *SendResponse, err error) {
+	s.c.metrics.sent("send_transaction_async", send.start, err)
+	s.c.endSend()
+	send.callback(res, err)
+}
+
+func (s *asyncSender) failed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err != nil
+}
+
+// asyncSenderFor returns the async sender of the client, opening its stream first if needed or if the
+// previous one failed.
+func (c *Client) asyncSenderFor() (*asyncSender, error) {
+	c.asyncMu.Lock()
+	defer c.asyncMu.Unlock()
+
+	if c.async != nil && !c.async.failed() {
+		return c.async, nil
+	}
+
+	stream, err := c.client.SendTransaction(c.streamCtx)
+	if err != nil {
+		return nil, fmt.Errorf("opening async stream: %w", streamError(err))
+	}
+	c.watchHeader("send_tx_async", stream)
+
+	c.async = newAsyncSender(c, stream)
+	return c.async, nil
+}
+
+// SendTransactionAsync sends the (signed) transaction to Fibernet without waiting for its
+// acknowledgement, and calls callback with it, or with the error that prevented it, once it arrives.
+// The sends go out in order on a stream of their own, so they don't block the other sends.
+//
+// callback is called exactly once, and may be nil. It's called from the calling goroutine if the
+// transaction couldn't be sent, and otherwise from a goroutine shared by all the asynchronous sends,
+// which it must not block.
+func (c *Client) SendTransactionAsync(tx *types.Transaction, callback func(resp *SendResponse, err error)) {
+	if callback == nil {
+		callback = func(*SendResponse, error) {}
+	}
+
+	if err := c.beginSend(); err != nil {
+		callback(nil, err)
+		return
+	}
+	send := asyncSend{callback: callback, start: time.Now()}
+
+	fail := func(err error) {
+		c.metrics.sent("send_transaction_async", send.start, err)
+		c.endSend()
+		callback(nil, err)
+	}
+
+	if err := c.auditTxs(context.Background(), []*types.Transaction{tx}); err != nil {
+		fail(err)
+		return
+	}
+
+	proto, err := TxToProto(tx)
+	if err != nil {
+		fail(fmt.Errorf("converting to protobuf: %w", err))
+		return
+	}
+
+	s, err := c.asyncSenderFor()
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if err := s.send(proto, send); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ackServer acknowledges transactions with their nonce as the timestamp, and rejects the ones with a
+// nonce of reject.
+type ackServer struct {
+	api.UnimplementedAPIServer
+	reject uint64
+}
+
+func (s *ackServer) SendTransaction(stream api.API_SendTransactionServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if msg.Nonce == s.reject {
+			return status.Error(codes.InvalidArgument, "nonce too low")
+		}
+
+		if err := stream.Send(&api.TransactionResponse{Hash: "0x", Timestamp: int64(msg.Nonce)}); err != nil {
+			return err
+		}
+	}
+}
+
+func TestSendTransactionAsync(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, &ackServer{reject: 3})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(lis.Addr().String())
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	type ack struct {
+		nonce uint64
+		res   *SendResponse
+		err   error
+	}
+	acks := make(chan ack, 8)
+	for nonce := uint64(0); nonce < 5; nonce++ {
+		nonce := nonce
+		tx := signTx(t, &types.DynamicFeeTx{Nonce: nonce})
+		c.SendTransactionAsync(tx, func(res *SendResponse, err error) {
+			acks <- ack{nonce, res, err}
+		})
+	}
+
+	// The acknowledgements arrive in order, until the stream fails on the rejected transaction
+	for nonce := uint64(0); nonce < 5; nonce++ {
+		var a ack
+		select {
+		case a = <-acks:
+		case <-ctx.Done():
+			t.Fatalf("no acknowledgement for %d", nonce)
+		}
+
+		switch {
+		case a.nonce != nonce:
+			t.Fatalf("expected the acknowledgement of %d, got %d", nonce, a.nonce)
+		case nonce < 3 && (a.err != nil || a.res.Timestamp != int64(nonce)):
+			t.Fatalf("unexpected acknowledgement %+v %v", a.res, a.err)
+		case nonce >= 3 && !errors.Is(a.err, ErrInvalidTx):
+			t.Fatalf("expected ErrInvalidTx for %d, got %v", nonce, a.err)
+		}
+	}
+
+	// The next send opens a new stream
+	done := make(chan error, 1)
+	c.SendTransactionAsync(signTx(t, &types.DynamicFeeTx{Nonce: 7}), func(res *SendResponse, err error) {
+		done <- err
+	})
+	if err := <-done; err != nil {
+		t.Fatalf("expected the send to succeed on a new stream, got %v", err)
+	}
+}
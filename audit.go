@@ -0,0 +1,114 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// OutboundTx is a transaction about to be sent, as the send hooks see it.
+type OutboundTx struct {
+	// Raw is the encoding of the transaction, as given to the raw sends, or the canonical one.
+	Raw []byte
+	// Tx is the decoded transaction. It's nil if go-ethereum can't decode Raw, like for blob
+	// transactions, which are in Blob instead.
+	Tx   *types.Transaction
+	Blob *BlobTx
+	// Index is the position of the transaction in its sequence, 0 for single sends, out of Count.
+	Index, Count int
+}
+
+// SendHook is called with every transaction before it's sent. Returning an error vetoes the send.
+type SendHook func(ctx context.Context, tx *OutboundTx) error
+
+// VetoError is returned by the sends vetoed by a send hook. A vetoed sequence isn't sent at all.
+type VetoError struct {
+	Index int
+	Err   error
+}
+
+func (e *VetoError) Error() string {
+	return fmt.Sprintf("send of transaction %d vetoed: %v", e.Index, e.Err)
+}
+
+func (e *VetoError) Unwrap() error {
+	return e.Err
+}
+
+func (e *VetoError) Code() ErrorCode {
+	return ErrCodeVetoed
+}
+
+// WithSendHook adds a hook called synchronously with every outbound transaction, single and in
+// sequences, right before it's sent, e.g. for a final pre-trade check. Decoding or encoding the
+// transaction for the hook costs an allocation or two per send. Hooks run in the order they were
+// added, until one vetoes the send.
+func WithSendHook(hook SendHook) ClientOption {
+	return func(c *Client) {
+		c.sendHooks = append(c.sendHooks, hook)
+	}
+}
+
+// auditTxs runs the send hooks on the transactions about to be sent, and archives them.
+func (c *Client) auditTxs(ctx context.Context, txs []*types.Transaction) error {
+	if len(c.sendHooks) == 0 && c.sendArchive == nil {
+		return nil
+	}
+
+	out := make([]*OutboundTx, len(txs))
+	for i, tx := range txs {
+		raw, err := tx.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("encoding transaction %d: %w", i, err)
+		}
+
+		out[i] = &OutboundTx{Raw: raw, Tx: tx, Index: i, Count: len(txs)}
+	}
+
+	return c.audit(ctx, out)
+}
+
+// auditRaw runs the send hooks on the raw transactions about to be sent, and archives them.
+func (c *Client) auditRaw(ctx context.Context, rawTxs [][]byte) error {
+	if len(c.sendHooks) == 0 && c.sendArchive == nil {
+		return nil
+	}
+
+	out := make([]*OutboundTx, len(rawTxs))
+	for i, raw := range rawTxs {
+		out[i] = &OutboundTx{Raw: raw, Index: i, Count: len(rawTxs)}
+		if blob, _, err := DecodeBlobTx(raw); err == nil {
+			out[i].Blob = blob
+		} else {
+			tx := new(types.Transaction)
+			if tx.UnmarshalBinary(raw) == nil {
+				out[i].Tx = tx
+			}
+		}
+	}
+
+	return c.audit(ctx, out)
+}
+
+// audit archives the transactions of a send once the send hooks let all of them through.
+func (c *Client) audit(ctx context.Context, txs []*OutboundTx) error {
+	for _, tx := range txs {
+		if err := c.runSendHooks(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	c.archive(ctx, txs)
+	return nil
+}
+
+func (c *Client) runSendHooks(ctx context.Context, tx *OutboundTx) error {
+	for _, hook := range c.sendHooks {
+		if err := hook(ctx, tx); err != nil {
+			return &VetoError{Index: tx.Index, Err: err}
+		}
+	}
+
+	return nil
+}
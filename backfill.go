@@ -0,0 +1,456 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// ErrBackfillUnsupported is returned by a BackfillSource that can't serve a stream.
+	ErrBackfillUnsupported = newCodedError(ErrCodeBackfillUnsupported, "backfill not supported for this stream")
+	// ErrBackfillOverflow is returned by a subscription started with WithStartAt if more live messages
+	// arrived than it can buffer during the backfill.
+	ErrBackfillOverflow = newCodedError(ErrCodeBackfillOverflow, "too many live messages buffered during backfill")
+)
+
+// defaultBackfillBuffer is the number of live messages buffered during a backfill by default.
+const defaultBackfillBuffer = 1 << 16
+
+// BackfillSource fetches historical messages for subscriptions started with WithStartAt. Fiber itself
+// only serves live data, so history comes from somewhere else, usually an archive node.
+type BackfillSource interface {
+	// ExecutionPayloadHeader returns the header of the block with the given number.
+	ExecutionPayloadHeader(ctx context.Context, number uint64) (*eth.ExecutionPayloadHeader, error)
+	// ExecutionPayload returns the block with the given number.
+	ExecutionPayload(ctx context.Context, number uint64) (*eth.ExecutionPayload, error)
+	// BeaconBlock returns the block at the given slot, or nil if the slot was missed.
+	BeaconBlock(ctx context.Context, slot uint64) (*eth.CompactBeaconBlock, error)
+}
+
+// WithStartAt makes a block or beacon subscription start at the given block number (or slot, for beacon
+// blocks). Everything from start up to the first live message is fetched from src and delivered before
+// the live stream, which is buffered in memory in the meantime, up to 65536 messages or the size set with
+// WithBackfillBuffer. Messages keep their order and aren't duplicated, so the subscription fails with
+// ErrBackfillOverflow rather than dropping live messages. Once caught up, the live stream is read
+// directly again, without limit.
+// Starting in the future or at the live head just subscribes.
+func WithStartAt(start uint64, src BackfillSource) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.startAt = start
+		cfg.backfill = src
+	}
+}
+
+// backfiller fetches historical messages of a stream. fetch returns false if there is no message
+// at the given position.
+type backfiller[P any] struct {
+	position func(P) uint64
+	fetch    func(ctx context.Context, src BackfillSource, position uint64) (P, bool, error)
+}
+
+var (
+	headerBackfiller = &backfiller[*eth.ExecutionPayloadHeader]{
+		position: func(h *eth.ExecutionPayloadHeader) uint64 { return h.BlockNumber },
+		fetch: func(ctx context.Context, src BackfillSource, number uint64) (*eth.ExecutionPayloadHeader, bool, error) {
+			h, err := src.ExecutionPayloadHeader(ctx, number)
+			return h, h != nil, err
+		},
+	}
+	payloadBackfiller = &backfiller[*eth.ExecutionPayload]{
+		position: func(p *eth.ExecutionPayload) uint64 { return p.Header.BlockNumber },
+		fetch: func(ctx context.Context, src BackfillSource, number uint64) (*eth.ExecutionPayload, bool, error) {
+			p, err := src.ExecutionPayload(ctx, number)
+			return p, p != nil, err
+		},
+	}
+	beaconBackfiller = &backfiller[*eth.CompactBeaconBlock]{
+		position: func(b *eth.CompactBeaconBlock) uint64 { return b.Slot },
+		fetch: func(ctx context.Context, src BackfillSource, slot uint64) (*eth.CompactBeaconBlock, bool, error) {
+			b, err := src.BeaconBlock(ctx, slot)
+			return b, b != nil, err
+		},
+	}
+)
+
+// backfillRecv returns a receive function that first returns the messages from cfg.startAt up to the first
+// live message, fetched from cfg.backfill, and then the live messages. The live stream is read into a
+// queue by a separate goroutine until then, and once the queue is drained it's read from directly.
+func backfillRecv[P any](ctx context.Context, cfg *subscriptionConfig, bf *backfiller[P], recv func() (P, error)) func() (P, error) {
+	limit := cfg.backfillBuffer
+	if limit <= 0 {
+		limit = defaultBackfillBuffer
+	}
+	// One more for the message the reader hands over after the backfill
+	live := newQueue[P](limit + 1)
+
+	// streamDone is cancelled when the reader returns: after the stream failed, in which case streamErr
+	// is set, or once it handed the stream over after stop was closed.
+	streamDone, cancelStream := context.WithCancel(ctx)
+	var streamErr error
+	stop := make(chan struct{})
+
+	go func() {
+		defer cancelStream()
+
+		for {
+			msg, err := recv()
+			if err != nil {
+				streamErr = err
+				return
+			}
+
+			select {
+			case <-stop:
+				live.push(msg)
+				return
+			default:
+			}
+
+			if live.Len() >= limit {
+				streamErr = ErrBackfillOverflow
+				return
+			}
+			live.push(msg)
+		}
+	}()
+
+	var (
+		zero                        P
+		first                       P
+		started, backfilled, direct bool
+		next, end                   uint64
+	)
+
+	pop := func() (P, error) {
+		msg, err := live.pop(streamDone)
+		if err != nil {
+			if ctx.Err() != nil {
+				return zero, ctx.Err()
+			}
+
+			// streamDone is only cancelled after streamErr is set
+			return zero, streamErr
+		}
+
+		return msg, nil
+	}
+
+	return func() (P, error) {
+		if direct {
+			return recv()
+		}
+
+		if backfilled {
+			msg, err := live.pop(streamDone)
+			if err == nil {
+				return msg, nil
+			}
+			if ctx.Err() != nil {
+				return zero, ctx.Err()
+			}
+			// streamDone is done, so streamErr is set if the stream failed
+			if streamErr != nil {
+				return zero, streamErr
+			}
+
+			// The reader handed the stream over, and its messages were all delivered
+			direct = true
+			return recv()
+		}
+
+		if !started {
+			msg, err := pop()
+			if err != nil {
+				return zero, err
+			}
+
+			started = true
+			first = msg
+			next, end = cfg.startAt, bf.position(msg)
+		}
+
+		for next < end {
+			msg, ok, err := bf.fetch(ctx, cfg.backfill, next)
+			if err != nil {
+				return zero, fmt.Errorf("backfilling %d: %w", next, err)
+			}
+
+			next++
+			if ok {
+				return msg, nil
+			}
+		}
+
+		backfilled = true
+		close(stop)
+		return first, nil
+	}
+}
+
+// WithBackfillBuffer sets how many live messages a subscription started with WithStartAt buffers while it
+// backfills, 65536 by default. It fails with ErrBackfillOverflow once more arrived.
+func WithBackfillBuffer(n int) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.backfillBuffer = n
+	}
+}
+
+// RPCBackfill is a BackfillSource backed by an execution client's JSON-RPC API and a beacon node's
+// REST API.
+type RPCBackfill struct {
+	execution *rpc.Client
+	beaconURL string
+	http      *http.Client
+}
+
+// NewRPCBackfill connects to the execution client at executionURL, and uses the beacon node at
+// beaconURL for beacon blocks. Either can be empty, in which case the corresponding streams return
+// ErrBackfillUnsupported.
+func NewRPCBackfill(ctx context.Context, executionURL, beaconURL string) (*RPCBackfill, error) {
+	b := &RPCBackfill{
+		beaconURL: strings.TrimSuffix(beaconURL, "/"),
+		http:      http.DefaultClient,
+	}
+
+	if executionURL != "" {
+		client, err := rpc.DialContext(ctx, executionURL)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to execution client: %w", err)
+		}
+
+		b.execution = client
+	}
+
+	return b, nil
+}
+
+// Close closes the connection to the execution client.
+func (b *RPCBackfill) Close() {
+	if b.execution != nil {
+		b.execution.Close()
+	}
+}
+
+// rpcHeader is the header part of an eth_getBlockByNumber response. The hash is taken as is, rather
+// than recomputed from fields go-ethereum may not know about.
+type rpcHeader struct {
+	Hash             common.Hash    `json:"hash"`
+	ParentHash       common.Hash    `json:"parentHash"`
+	Miner            common.Address `json:"miner"`
+	StateRoot        common.Hash    `json:"stateRoot"`
+	TransactionsRoot common.Hash    `json:"transactionsRoot"`
+	ReceiptsRoot     common.Hash    `json:"receiptsRoot"`
+	LogsBloom        hexutil.Bytes  `json:"logsBloom"`
+	MixHash          common.Hash    `json:"mixHash"`
+	Number           hexutil.Uint64 `json:"number"`
+	GasLimit         hexutil.Uint64 `json:"gasLimit"`
+	GasUsed          hexutil.Uint64 `json:"gasUsed"`
+	Timestamp        hexutil.Uint64 `json:"timestamp"`
+	ExtraData        hexutil.Bytes  `json:"extraData"`
+	BaseFeePerGas    *hexutil.Big   `json:"baseFeePerGas"`
+	WithdrawalsRoot  *common.Hash   `json:"withdrawalsRoot"`
+}
+
+// rpcTransaction is a transaction in an eth_getBlockByNumber response. It's decoded field by field,
+// since the go-ethereum version the client is built with rejects blob transactions (see BlobTx) and
+// later types.
+type rpcTransaction struct {
+	Type                 hexutil.Uint64   `json:"type"`
+	ChainID              *hexutil.Big     `json:"chainId"`
+	Nonce                hexutil.Uint64   `json:"nonce"`
+	Gas                  hexutil.Uint64   `json:"gas"`
+	GasPrice             *hexutil.Big     `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big     `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big     `json:"maxPriorityFeePerGas"`
+	To                   *common.Address  `json:"to"`
+	From                 common.Address   `json:"from"`
+	Hash                 common.Hash      `json:"hash"`
+	Input                hexutil.Bytes    `json:"input"`
+	Value                *hexutil.Big     `json:"value"`
+	V                    *hexutil.Big     `json:"v"`
+	R                    *hexutil.Big     `json:"r"`
+	S                    *hexutil.Big     `json:"s"`
+	AccessList           types.AccessList `json:"accessList"`
+}
+
+// bigBytes returns the big-endian bytes of b, which may be nil.
+func bigBytes(b *hexutil.Big) []byte {
+	if b == nil {
+		return nil
+	}
+
+	return b.ToInt().Bytes()
+}
+
+// bigUint64 returns b as a uint64, or 0 if it's nil.
+func bigUint64(b *hexutil.Big) uint64 {
+	if b == nil {
+		return 0
+	}
+
+	return b.ToInt().Uint64()
+}
+
+func (t *rpcTransaction) toProto() *eth.Transaction {
+	var to []byte
+	if t.To != nil {
+		to = t.To.Bytes()
+	}
+
+	var acl []*eth.AccessTuple
+	for _, tuple := range t.AccessList {
+		keys := make([][]byte, len(tuple.StorageKeys))
+		for i, key := range tuple.StorageKeys {
+			keys[i] = key.Bytes()
+		}
+
+		acl = append(acl, &eth.AccessTuple{Address: tuple.Address.Bytes(), StorageKeys: keys})
+	}
+
+	// Like go-ethereum, the gas price of dynamic fee transactions is their fee cap, not the effective
+	// gas price of the response. Legacy transactions have no fee cap or tip.
+	gasPrice, maxFee, priorityFee := bigUint64(t.GasPrice), bigUint64(t.GasPrice), bigUint64(t.GasPrice)
+	if t.MaxFeePerGas != nil {
+		gasPrice, maxFee, priorityFee = bigUint64(t.MaxFeePerGas), bigUint64(t.MaxFeePerGas), bigUint64(t.MaxPriorityFeePerGas)
+	}
+
+	return &eth.Transaction{
+		ChainId:     uint32(bigUint64(t.ChainID)),
+		Type:        uint32(t.Type),
+		Nonce:       uint64(t.Nonce),
+		Gas:         uint64(t.Gas),
+		GasPrice:    gasPrice,
+		MaxFee:      maxFee,
+		PriorityFee: priorityFee,
+		To:          to,
+		From:        t.From.Bytes(),
+		Hash:        t.Hash.Bytes(),
+		Input:       t.Input,
+		Value:       bigBytes(t.Value),
+		V:           bigUint64(t.V),
+		R:           bigBytes(t.R),
+		S:           bigBytes(t.S),
+		AccessList:  acl,
+	}
+}
+
+func (h *rpcHeader) toProto() *eth.ExecutionPayloadHeader {
+	header := &eth.ExecutionPayloadHeader{
+		ParentHash:       h.ParentHash.Bytes(),
+		FeeRecipient:     h.Miner.Bytes(),
+		StateRoot:        h.StateRoot.Bytes(),
+		ReceiptsRoot:     h.ReceiptsRoot.Bytes(),
+		LogsBloom:        h.LogsBloom,
+		PrevRandao:       h.MixHash.Bytes(),
+		BlockNumber:      uint64(h.Number),
+		GasLimit:         uint64(h.GasLimit),
+		GasUsed:          uint64(h.GasUsed),
+		Timestamp:        uint64(h.Timestamp),
+		ExtraData:        h.ExtraData,
+		BlockHash:        h.Hash.Bytes(),
+		TransactionsRoot: h.TransactionsRoot.Bytes(),
+	}
+
+	if h.BaseFeePerGas != nil {
+		header.BaseFeePerGas = h.BaseFeePerGas.ToInt().Bytes()
+	}
+	if h.WithdrawalsRoot != nil {
+		header.WithdrawalsRoot = h.WithdrawalsRoot.Bytes()
+	}
+
+	return header
+}
+
+func (b *RPCBackfill) getBlock(ctx context.Context, number uint64, fullTxs bool, result interface{}) error {
+	if b.execution == nil {
+		return ErrBackfillUnsupported
+	}
+
+	var raw json.RawMessage
+	if err := b.execution.CallContext(ctx, &raw, "eth_getBlockByNumber", hexutil.EncodeUint64(number), fullTxs); err != nil {
+		return fmt.Errorf("getting block %d: %w", number, err)
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return fmt.Errorf("getting block %d: %w", number, ethereum.NotFound)
+	}
+
+	return json.Unmarshal(raw, result)
+}
+
+func (b *RPCBackfill) ExecutionPayloadHeader(ctx context.Context, number uint64) (*eth.ExecutionPayloadHeader, error) {
+	var header rpcHeader
+	if err := b.getBlock(ctx, number, false, &header); err != nil {
+		return nil, err
+	}
+
+	return header.toProto(), nil
+}
+
+func (b *RPCBackfill) ExecutionPayload(ctx context.Context, number uint64) (*eth.ExecutionPayload, error) {
+	var block struct {
+		rpcHeader
+		Transactions []*rpcTransaction `json:"transactions"`
+	}
+	if err := b.getBlock(ctx, number, true, &block); err != nil {
+		return nil, err
+	}
+
+	txs := make([]*eth.Transaction, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		txs[i] = tx.toProto()
+	}
+
+	return &eth.ExecutionPayload{
+		Header:       block.rpcHeader.toProto(),
+		Transactions: txs,
+	}, nil
+}
+
+func (b *RPCBackfill) BeaconBlock(ctx context.Context, slot uint64) (*eth.CompactBeaconBlock, error) {
+	if b.beaconURL == "" {
+		return nil, ErrBackfillUnsupported
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/eth/v2/beacon/blocks/%d", b.beaconURL, slot), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := b.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("getting beacon block %d: %w", slot, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// Missed slot
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("getting beacon block %d: %s", slot, res.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Message beaconAPIBlock `json:"message"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding beacon block %d: %w", slot, err)
+	}
+
+	return body.Data.Message.toProto(), nil
+}
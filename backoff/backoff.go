@@ -0,0 +1,178 @@
+// Package backoff contains the retry and backoff policies used by the client for sends and
+// reconnects. They are exported so the same policies can be reused for application-level
+// fallback logic.
+package backoff
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Policy decides how long to wait before the next attempt.
+type Policy interface {
+	// Next returns the delay before retry number attempt (starting at 1), or false
+	// if no more attempts should be made.
+	Next(attempt int) (time.Duration, bool)
+}
+
+// Exponential is a capped exponential backoff policy with optional jitter.
+type Exponential struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+	// Max caps the delay between attempts. Zero means no cap.
+	Max time.Duration
+	// Multiplier is the growth factor between attempts. Defaults to 2.
+	Multiplier float64
+	// Jitter is the fraction (0-1) of the delay that is randomized, to avoid synchronized retries.
+	Jitter float64
+	// MaxAttempts is the maximum number of retries. Zero means unlimited.
+	MaxAttempts int
+}
+
+// Default returns the exponential policy used by the client when none is configured:
+// 100ms doubling up to 10s, with 20% jitter and unlimited attempts.
+func Default() *Exponential {
+	return &Exponential{
+		Initial:    100 * time.Millisecond,
+		Max:        10 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+func (e *Exponential) Next(attempt int) (time.Duration, bool) {
+	if e.MaxAttempts > 0 && attempt > e.MaxAttempts {
+		return 0, false
+	}
+
+	mult := e.Multiplier
+	if mult <= 0 {
+		mult = 2
+	}
+
+	delay := float64(e.Initial)
+	for i := 1; i < attempt; i++ {
+		delay *= mult
+		if e.Max > 0 && delay >= float64(e.Max) {
+			delay = float64(e.Max)
+			break
+		}
+	}
+
+	if e.Max > 0 && delay > float64(e.Max) {
+		delay = float64(e.Max)
+	}
+
+	return Jitter(time.Duration(delay), e.Jitter), true
+}
+
+// Constant waits the same delay between every attempt.
+type Constant struct {
+	Delay time.Duration
+	// MaxAttempts is the maximum number of retries. Zero means unlimited.
+	MaxAttempts int
+}
+
+func (c *Constant) Next(attempt int) (time.Duration, bool) {
+	if c.MaxAttempts > 0 && attempt > c.MaxAttempts {
+		return 0, false
+	}
+
+	return c.Delay, true
+}
+
+type never struct{}
+
+func (never) Next(int) (time.Duration, bool) { return 0, false }
+
+// Never is a policy that never retries.
+var Never Policy = never{}
+
+var (
+	rngMu sync.Mutex
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Jitter randomizes d by up to +/- fraction of its value.
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 || d <= 0 {
+		return d
+	}
+
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	rngMu.Lock()
+	r := rng.Float64()
+	rngMu.Unlock()
+
+	return time.Duration(float64(d) * (1 - fraction + 2*fraction*r))
+}
+
+type permanentError struct {
+	err error
+}
+
+func (p *permanentError) Error() string { return p.err.Error() }
+func (p *permanentError) Unwrap() error { return p.err }
+
+// Permanent wraps err so that Retry stops retrying and returns it immediately.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Retry calls fn until it succeeds, returns a permanent error, the policy gives up or ctx is done.
+// It returns the last error returned by fn.
+func Retry(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+
+		delay, ok := p.Next(attempt)
+		if !ok {
+			return err
+		}
+
+		if err := Sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Sleep waits for d or until ctx is done, in which case it returns the context error.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExponential(t *testing.T) {
+	p := &Exponential{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, MaxAttempts: 5}
+
+	expected := []time.Duration{10, 20, 40, 50, 50}
+	for i, exp := range expected {
+		d, ok := p.Next(i + 1)
+		if !ok {
+			t.Fatalf("attempt %d: expected retry", i+1)
+		}
+
+		if d != exp*time.Millisecond {
+			t.Fatalf("attempt %d: expected %s, got %s", i+1, exp*time.Millisecond, d)
+		}
+	}
+
+	if _, ok := p.Next(6); ok {
+		t.Fatal("expected policy to give up after MaxAttempts")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := Jitter(100*time.Millisecond, 0.2)
+		if d < 80*time.Millisecond || d > 120*time.Millisecond {
+			t.Fatalf("jittered delay out of range: %s", d)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var calls int
+	err := Retry(context.Background(), &Constant{MaxAttempts: 3}, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Fatalf("expected success after 3 calls, got %d calls and %v", calls, err)
+	}
+
+	sentinel := errors.New("fatal")
+	calls = 0
+	err = Retry(context.Background(), &Constant{}, func(ctx context.Context) error {
+		calls++
+		return Permanent(sentinel)
+	})
+	if !errors.Is(err, sentinel) || calls != 1 {
+		t.Fatalf("expected permanent error after 1 call, got %d calls and %v", calls, err)
+	}
+
+	if _, ok := Never.Next(1); ok {
+		t.Fatal("Never should not retry")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(100, 2, 0)
+	now := time.Now()
+
+	if d := l.reserve(now); d != 0 {
+		t.Fatalf("expected burst token, got delay %s", d)
+	}
+	if d := l.reserve(now); d != 0 {
+		t.Fatalf("expected burst token, got delay %s", d)
+	}
+
+	// The bucket is empty, the next two callers are spaced out at the rate
+	if d := l.reserve(now); d != 10*time.Millisecond {
+		t.Fatalf("expected 10ms delay, got %s", d)
+	}
+	if d := l.reserve(now); d != 20*time.Millisecond {
+		t.Fatalf("expected 20ms delay, got %s", d)
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	l := NewLimiter(1, 2, 0)
+
+	if !l.Allow() || !l.Allow() {
+		t.Fatal("expected the burst to be allowed")
+	}
+	if l.Allow() {
+		t.Fatal("expected the empty bucket to refuse")
+	}
+}
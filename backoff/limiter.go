@@ -0,0 +1,120 @@
+package backoff
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket that limits how often an action (e.g. reconnecting) can happen. A single
+// limiter can be shared between many clients in a process, so that when an endpoint flaps their
+// reconnects are spread out instead of hammering the server all at once.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	jitter time.Duration
+}
+
+// NewLimiter returns a limiter that allows rate actions per second on average, with bursts of up
+// to burst actions. Every action is additionally delayed by a random duration up to jitter.
+func NewLimiter(rate float64, burst int, jitter time.Duration) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		jitter: jitter,
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before the token is valid.
+func (l *Limiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// Allow takes a token if one is available right away, and returns whether it did.
+func (l *Limiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// Tokens returns the number of actions currently allowed without waiting. It's negative while
+// callers are waiting for tokens.
+func (l *Limiter) Tokens() float64 {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tokens := l.tokens + time.Since(l.last).Seconds()*l.rate
+	if tokens > l.burst {
+		tokens = l.burst
+	}
+
+	return tokens
+}
+
+// Wait blocks until the caller is allowed to act, or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	delay := l.reserve(time.Now())
+	if l.jitter > 0 {
+		rngMu.Lock()
+		delay += time.Duration(rng.Int63n(int64(l.jitter)))
+		rngMu.Unlock()
+	}
+
+	return Sleep(ctx, delay)
+}
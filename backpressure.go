@@ -0,0 +1,64 @@
+package client
+
+// Backpressure is what a buffered subscription does with a new message when its buffer is full, see
+// WithBackpressure.
+type Backpressure int
+
+const (
+	// DropOldest drops the oldest buffered message to make room, so a slow consumer gets the most
+	// recent messages.
+	DropOldest Backpressure = iota
+	// DropNewest drops the new message, so a slow consumer gets the messages in the buffer first.
+	DropNewest
+	// BlockWhenFull waits for the consumer to make room, holding back the stream like an unbuffered
+	// subscription does once the buffer is full.
+	BlockWhenFull
+)
+
+func (b Backpressure) String() string {
+	switch b {
+	case DropOldest:
+		return "drop_oldest"
+	case DropNewest:
+		return "drop_newest"
+	case BlockWhenFull:
+		return "block"
+	}
+
+	return "unknown"
+}
+
+// WithBackpressure buffers up to size messages between the stream and the channel, so that a consumer
+// that's briefly slower than the stream doesn't delay the messages behind it, and applies policy once
+// the buffer is full. Messages dropped by the policy are counted by SubscriptionStats.Overflowed. The
+// buffer also holds the messages received while paused, in place of the one of WithPauseBuffer if it's
+// larger.
+func WithBackpressure(size int, policy Backpressure) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.buffer = size
+		cfg.backpressure = policy
+	}
+}
+
+// bufferSize returns the size of the delivery buffer of the subscription, 0 if it's unbuffered.
+func (cfg *subscriptionConfig) bufferSize() int {
+	if cfg.buffer > cfg.pauseBuffer {
+		return cfg.buffer
+	}
+
+	return cfg.pauseBuffer
+}
+
+// overflowed counts a message dropped because the delivery buffer was full.
+func (s *Subscription) overflowed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats != nil {
+		s.stats.dropped++
+		s.stats.overflowed++
+	}
+	if s.onDrop != nil {
+		s.onDrop(1)
+	}
+}
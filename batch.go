@@ -0,0 +1,116 @@
+package client
+
+import (
+	"time"
+
+	"github.com/chainbound/fiber-go/filter"
+)
+
+// DefaultBatchSize is the maximum size of a batch by default.
+const DefaultBatchSize = 256
+
+// BatchOptions configures batched delivery.
+type BatchOptions struct {
+	// MaxSize is the maximum number of messages in a batch, DefaultBatchSize if 0.
+	MaxSize int
+	// FlushInterval is for how long a batch waits to fill up after its first message. If 0, a batch
+	// has the messages that were already received, so batches only grow under bursts.
+	FlushInterval time.Duration
+}
+
+// Batched delivers the messages of src in batches, which saves a channel handoff per message for
+// consumers that process many messages at once. The options are passed to src, so the batches are
+// subject to its drop policy, and ch is closed when src returns.
+func Batched[T any](src Source[T], batch BatchOptions) Source[[]T] {
+	if batch.MaxSize <= 0 {
+		batch.MaxSize = DefaultBatchSize
+	}
+
+	return func(c *Client, ch chan<- []T, opts ...SubscriptionOption) error {
+		defer close(ch)
+
+		in := make(chan T, batch.MaxSize)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- src(c, in, opts...)
+		}()
+
+		var timer *time.Timer
+		if batch.FlushInterval > 0 {
+			timer = time.NewTimer(batch.FlushInterval)
+			defer timer.Stop()
+		}
+
+		for {
+			var msgs []T
+			select {
+			case msg, ok := <-in:
+				if !ok {
+					return <-errc
+				}
+				msgs = append(make([]T, 0, batch.MaxSize), msg)
+			case err := <-errc:
+				// The subscription failed without closing in, so nothing else will arrive
+				if msgs = drain(in, nil, batch.MaxSize); len(msgs) > 0 {
+					ch <- msgs
+				}
+				return err
+			}
+
+			if timer == nil {
+				msgs = drain(in, msgs, batch.MaxSize)
+			} else {
+				msgs = fill(in, msgs, batch.MaxSize, timer, batch.FlushInterval)
+			}
+
+			ch <- msgs
+		}
+	}
+}
+
+// drain appends the messages available on in to msgs, up to max.
+func drain[T any](in <-chan T, msgs []T, max int) []T {
+	for len(msgs) < max {
+		select {
+		case msg, ok := <-in:
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		default:
+			return msgs
+		}
+	}
+
+	return msgs
+}
+
+// fill appends the messages arriving on in to msgs, until there are max of them or interval elapsed.
+func fill[T any](in <-chan T, msgs []T, max int, timer *time.Timer, interval time.Duration) []T {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(interval)
+
+	for len(msgs) < max {
+		select {
+		case msg, ok := <-in:
+			if !ok {
+				return msgs
+			}
+			msgs = append(msgs, msg)
+		case <-timer.C:
+			return msgs
+		}
+	}
+
+	return msgs
+}
+
+// SubscribeNewTxsBatched is SubscribeNewTxs, delivering the transactions in batches. See Batched.
+func (c *Client) SubscribeNewTxsBatched(f *filter.Filter, ch chan<- []*Transaction, batch BatchOptions, opts ...SubscriptionOption) error {
+	return Batched(TxStream(f), batch)(c, ch, opts...)
+}
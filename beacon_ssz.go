@@ -0,0 +1,662 @@
+package client
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/chainbound/fiber-go/ssz"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Mainnet preset list limits, see https://github.com/ethereum/consensus-specs/blob/dev/presets/mainnet
+const (
+	maxProposerSlashings       = 16
+	maxAttesterSlashings       = 2
+	maxAttestations            = 128
+	maxDeposits                = 16
+	maxVoluntaryExits          = 16
+	maxBlsToExecutionChanges   = 16
+	maxValidatorsPerCommittee  = 2048
+	depositProofLength         = 33
+	syncCommitteeSize          = 512
+	maxExtraDataBytes          = 32
+	maxBlobCommitmentsPerBlock = 4096
+
+	blsSignatureLength = 96
+	blsPubkeyLength    = 48
+)
+
+// ErrMissingExecutionPayload is returned when computing the root of a beacon block body without the
+// header of its execution payload.
+var ErrMissingExecutionPayload = newCodedError(ErrCodeMissingExecutionPayload, "execution payload header required")
+
+// fork is a consensus layer fork, which determines the fields of a beacon block body.
+type fork int
+
+const (
+	phase0 fork = iota
+	altair
+	bellatrix
+	capella
+	deneb
+)
+
+// payloadFork returns the fork of a block with the given execution payload header.
+func payloadFork(payload *ExecutionPayloadHeader) fork {
+	switch {
+	case payload.BlobGasUsed != nil:
+		return deneb
+	case payload.WithdrawalsRoot != nil:
+		return capella
+	default:
+		return bellatrix
+	}
+}
+
+// HashTreeRoot returns the block root, which is what checkpoints and the parent roots of the next
+// blocks refer to.
+//
+// The beacon block stream doesn't carry the execution payload, so the root can only be computed
+// together with the header of the block's execution payload (from SubscribeNewExecutionPayloadHeaders
+// or SubscribeNewExecutionPayloads). SSZ defines the root of an execution payload to be equal to the
+// root of its header, so the result is the canonical root. Bellatrix, Capella and Deneb blocks are
+// supported, the fork is detected from the fields of the payload header. The streams don't carry the
+// Deneb fields yet: for Deneb blocks, set the BlobGasUsed and ExcessBlobGas of the header and the
+// BlobKZGCommitments of the body, e.g. from the execution and beacon node APIs.
+func (b *BeaconBlock) HashTreeRoot(payload *ExecutionPayloadHeader) (common.Hash, error) {
+	header, err := b.Header(payload)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return header.HashTreeRoot(), nil
+}
+
+// Header returns the header of the block, with the body root computed from the body and the given
+// execution payload header.
+func (b *BeaconBlock) Header(payload *ExecutionPayloadHeader) (*BeaconBlockHeader, error) {
+	if payload == nil {
+		return nil, fmt.Errorf("computing body root: %w", ErrMissingExecutionPayload)
+	}
+
+	return b.header(payloadFork(payload), payload)
+}
+
+func (b *BeaconBlock) header(f fork, payload *ExecutionPayloadHeader) (*BeaconBlockHeader, error) {
+	if b.Body == nil {
+		return nil, fmt.Errorf("beacon block without body")
+	}
+
+	bodyRoot, err := b.Body.hashTreeRoot(f, payload)
+	if err != nil {
+		return nil, fmt.Errorf("computing body root: %w", err)
+	}
+
+	return &BeaconBlockHeader{
+		Slot:          b.Slot,
+		ProposerIndex: b.ProposerIndex,
+		ParentRoot:    b.ParentRoot,
+		StateRoot:     b.StateRoot,
+		BodyRoot:      bodyRoot,
+	}, nil
+}
+
+// HashTreeRoot returns the root of the body, see BeaconBlock.HashTreeRoot.
+func (b *BeaconBlockBody) HashTreeRoot(payload *ExecutionPayloadHeader) (common.Hash, error) {
+	if payload == nil {
+		return common.Hash{}, ErrMissingExecutionPayload
+	}
+
+	return b.hashTreeRoot(payloadFork(payload), payload)
+}
+
+func (b *BeaconBlockBody) hashTreeRoot(f fork, payload *ExecutionPayloadHeader) (common.Hash, error) {
+	roots := func(n int, root func(i int) (ssz.Root, error)) ([]ssz.Root, error) {
+		out := make([]ssz.Root, n)
+		for i := range out {
+			r, err := root(i)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = r
+		}
+		return out, nil
+	}
+
+	proposerSlashings, _ := roots(len(b.ProposerSlashingsList), func(i int) (ssz.Root, error) {
+		return b.ProposerSlashingsList[i].hashTreeRoot(), nil
+	})
+	attesterSlashings, _ := roots(len(b.AttesterSlashingsList), func(i int) (ssz.Root, error) {
+		return b.AttesterSlashingsList[i].hashTreeRoot(), nil
+	})
+	attestations, err := roots(len(b.AttestationsList), func(i int) (ssz.Root, error) {
+		return b.AttestationsList[i].hashTreeRoot()
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("attestation: %w", err)
+	}
+	deposits, _ := roots(len(b.DepositsList), func(i int) (ssz.Root, error) {
+		return b.DepositsList[i].hashTreeRoot(), nil
+	})
+	exits, _ := roots(len(b.VoluntaryExitsList), func(i int) (ssz.Root, error) {
+		return b.VoluntaryExitsList[i].hashTreeRoot(), nil
+	})
+
+	fields := []ssz.Root{
+		ssz.ByteVector(b.RandaoReveal, blsSignatureLength),
+		b.eth1Data().hashTreeRoot(),
+		b.Graffiti,
+		ssz.List(proposerSlashings, maxProposerSlashings),
+		ssz.List(attesterSlashings, maxAttesterSlashings),
+		ssz.List(attestations, maxAttestations),
+		ssz.List(deposits, maxDeposits),
+		ssz.List(exits, maxVoluntaryExits),
+	}
+
+	if f >= altair {
+		fields = append(fields, b.syncAggregate().hashTreeRoot())
+	}
+	if f >= bellatrix {
+		fields = append(fields, payload.HashTreeRoot())
+	}
+	if f >= capella {
+		changes, _ := roots(len(b.BlsToExecutionChangesList), func(i int) (ssz.Root, error) {
+			return b.BlsToExecutionChangesList[i].hashTreeRoot(), nil
+		})
+		fields = append(fields, ssz.List(changes, maxBlsToExecutionChanges))
+	}
+	if f >= deneb {
+		commitments, _ := roots(len(b.BlobKZGCommitments), func(i int) (ssz.Root, error) {
+			return ssz.ByteVector(b.BlobKZGCommitments[i][:], len(KZGCommitment{})), nil
+		})
+		fields = append(fields, ssz.List(commitments, maxBlobCommitmentsPerBlock))
+	}
+
+	return ssz.Container(fields...), nil
+}
+
+func (b *BeaconBlockBody) eth1Data() *Eth1Data {
+	if b.Eth1Data == nil {
+		return &Eth1Data{}
+	}
+
+	return b.Eth1Data
+}
+
+func (b *BeaconBlockBody) syncAggregate() *SyncAggregate {
+	if b.SyncAggregate == nil {
+		return &SyncAggregate{}
+	}
+
+	return b.SyncAggregate
+}
+
+// HashTreeRoot returns the root of the execution payload header, which is equal to the root of the
+// execution payload itself.
+func (h *ExecutionPayloadHeader) HashTreeRoot() common.Hash {
+	var baseFee []byte
+	if h.BaseFeePerGas != nil {
+		baseFee = h.BaseFeePerGas.Bytes()
+	}
+
+	fields := []ssz.Root{
+		h.ParentHash,
+		ssz.ByteVector(h.FeeRecipient.Bytes(), common.AddressLength),
+		h.StateRoot,
+		h.ReceiptRoot,
+		ssz.ByteVector(h.LogsBloom.Bytes(), len(h.LogsBloom)),
+		h.PrevRandao,
+		ssz.Uint64(h.Number),
+		ssz.Uint64(h.GasLimit),
+		ssz.Uint64(h.GasUsed),
+		ssz.Uint64(h.Timestamp),
+		ssz.ByteList(h.ExtraData, maxExtraDataBytes),
+		ssz.Uint256(baseFee),
+		h.Hash,
+		h.TransactionsRoot,
+	}
+
+	f := payloadFork(h)
+	if f >= capella {
+		var withdrawalsRoot common.Hash
+		if h.WithdrawalsRoot != nil {
+			withdrawalsRoot = *h.WithdrawalsRoot
+		}
+		fields = append(fields, withdrawalsRoot)
+	}
+	if f >= deneb {
+		fields = append(fields, ssz.Uint64(derefUint64(h.BlobGasUsed)), ssz.Uint64(derefUint64(h.ExcessBlobGas)))
+	}
+
+	return ssz.Container(fields...)
+}
+
+func derefUint64(v *uint64) uint64 {
+	if v == nil {
+		return 0
+	}
+
+	return *v
+}
+
+// HashTreeRoot returns the root of the header, which is equal to the root of the block it describes.
+func (h *BeaconBlockHeader) HashTreeRoot() common.Hash {
+	return ssz.Container(
+		ssz.Uint64(h.Slot),
+		ssz.Uint64(h.ProposerIndex),
+		h.ParentRoot,
+		h.StateRoot,
+		h.BodyRoot,
+	)
+}
+
+// MarshalSSZ returns the SSZ serialization of the header.
+func (h *BeaconBlockHeader) MarshalSSZ() []byte {
+	buf := make([]byte, 112)
+	binary.LittleEndian.PutUint64(buf[0:], h.Slot)
+	binary.LittleEndian.PutUint64(buf[8:], h.ProposerIndex)
+	copy(buf[16:], h.ParentRoot[:])
+	copy(buf[48:], h.StateRoot[:])
+	copy(buf[80:], h.BodyRoot[:])
+	return buf
+}
+
+// MarshalSSZ returns the SSZ serialization of the signed header.
+func (h *SignedBeaconBlockHeader) MarshalSSZ() []byte {
+	if h == nil {
+		h = &SignedBeaconBlockHeader{}
+	}
+
+	message := h.Message
+	if message == nil {
+		message = &BeaconBlockHeader{}
+	}
+
+	sig := make([]byte, blsSignatureLength)
+	copy(sig, h.Signature)
+	return append(message.MarshalSSZ(), sig...)
+}
+
+// MarshalSSZ returns the SSZ serialization of the block with the given execution payload header, see
+// BeaconBlockBody.MarshalSSZ.
+func (b *BeaconBlock) MarshalSSZ(payload *ExecutionPayloadHeader) ([]byte, error) {
+	if payload == nil {
+		return nil, ErrMissingExecutionPayload
+	}
+
+	return b.marshalSSZ(payloadFork(payload), payload)
+}
+
+func (b *BeaconBlock) marshalSSZ(f fork, payload *ExecutionPayloadHeader) ([]byte, error) {
+	if b.Body == nil {
+		return nil, fmt.Errorf("beacon block without body")
+	}
+
+	var enc sszEncoder
+	enc.uint64(b.Slot)
+	enc.uint64(b.ProposerIndex)
+	enc.fixed(b.ParentRoot[:])
+	enc.fixed(b.StateRoot[:])
+	enc.variable(b.Body.marshalSSZ(f, payload))
+	return enc.bytes(), nil
+}
+
+// MarshalSSZ returns the SSZ serialization of the body with the given execution payload header. Since
+// the stream doesn't carry the execution payload, this is the serialization of the blinded body, as
+// used by the builder API, which has the execution payload header in place of the payload. Its root is
+// the root of the full body. See BeaconBlock.HashTreeRoot for the supported forks.
+func (b *BeaconBlockBody) MarshalSSZ(payload *ExecutionPayloadHeader) ([]byte, error) {
+	if payload == nil {
+		return nil, ErrMissingExecutionPayload
+	}
+
+	return b.marshalSSZ(payloadFork(payload), payload), nil
+}
+
+func (b *BeaconBlockBody) marshalSSZ(f fork, payload *ExecutionPayloadHeader) []byte {
+	var enc sszEncoder
+	enc.vector(b.RandaoReveal, blsSignatureLength)
+
+	eth1 := b.eth1Data()
+	enc.fixed(eth1.DepositRoot[:])
+	enc.uint64(eth1.DepositCount)
+	enc.fixed(eth1.BlockHash[:])
+	enc.fixed(b.Graffiti[:])
+
+	var list []byte
+	for _, slashing := range b.ProposerSlashingsList {
+		list = append(append(list, slashing.Header1.MarshalSSZ()...), slashing.Header2.MarshalSSZ()...)
+	}
+	enc.variable(list)
+
+	var slashings sszEncoder
+	for _, slashing := range b.AttesterSlashingsList {
+		var enc sszEncoder
+		enc.variable(slashing.Attestation1.marshalSSZ())
+		enc.variable(slashing.Attestation2.marshalSSZ())
+		slashings.variable(enc.bytes())
+	}
+	enc.variable(slashings.bytes())
+
+	var attestations sszEncoder
+	for _, attestation := range b.AttestationsList {
+		var enc sszEncoder
+		enc.variable(attestation.AggregationBits)
+		enc.fixed(attestation.Data.marshalSSZ())
+		enc.vector(attestation.Signature, blsSignatureLength)
+		attestations.variable(enc.bytes())
+	}
+	enc.variable(attestations.bytes())
+
+	var deposits sszEncoder
+	for _, deposit := range b.DepositsList {
+		for i := 0; i < depositProofLength; i++ {
+			var node common.Hash
+			if i < len(deposit.ProofList) {
+				node = deposit.ProofList[i]
+			}
+			deposits.fixed(node[:])
+		}
+
+		data := deposit.Data
+		if data == nil {
+			data = &DepositData{}
+		}
+		deposits.vector(data.Pubkey, blsPubkeyLength)
+		deposits.fixed(data.WithdrawalCredentials[:])
+		deposits.uint64(data.Amount)
+		deposits.vector(data.Signature, blsSignatureLength)
+	}
+	enc.variable(deposits.bytes())
+
+	var exits sszEncoder
+	for _, exit := range b.VoluntaryExitsList {
+		message := exit.Message
+		if message == nil {
+			message = &VoluntaryExitMessage{}
+		}
+		exits.uint64(message.Epoch)
+		exits.uint64(message.ValidatorIndex)
+		exits.vector(exit.Signature, blsSignatureLength)
+	}
+	enc.variable(exits.bytes())
+
+	if f >= altair {
+		syncAggregate := b.syncAggregate()
+		enc.vector(syncAggregate.SyncCommitteeBits, syncCommitteeSize/8)
+		enc.vector(syncAggregate.SyncCommitteeSignature, blsSignatureLength)
+	}
+	if f >= bellatrix {
+		enc.variable(payload.MarshalSSZ())
+	}
+	if f >= capella {
+		var changes sszEncoder
+		for _, change := range b.BlsToExecutionChangesList {
+			message := change.Message
+			if message == nil {
+				message = &ExecutionChangeMessage{}
+			}
+			changes.uint64(message.ValidatorIndex)
+			changes.vector(message.FromBlsPubkey, blsPubkeyLength)
+			changes.fixed(message.ToExecutionAddress[:])
+			changes.vector(change.Signature, blsSignatureLength)
+		}
+		enc.variable(changes.bytes())
+	}
+	if f >= deneb {
+		var commitments []byte
+		for _, commitment := range b.BlobKZGCommitments {
+			commitments = append(commitments, commitment[:]...)
+		}
+		enc.variable(commitments)
+	}
+
+	return enc.bytes()
+}
+
+// MarshalSSZ returns the SSZ serialization of the execution payload header, of the fork its fields
+// belong to.
+func (h *ExecutionPayloadHeader) MarshalSSZ() []byte {
+	var baseFee [32]byte
+	if h.BaseFeePerGas != nil {
+		// Little endian
+		for i, b := range h.BaseFeePerGas.Bytes() {
+			baseFee[len(h.BaseFeePerGas.Bytes())-1-i] = b
+		}
+	}
+
+	var enc sszEncoder
+	enc.fixed(h.ParentHash[:])
+	enc.fixed(h.FeeRecipient[:])
+	enc.fixed(h.StateRoot[:])
+	enc.fixed(h.ReceiptRoot[:])
+	enc.fixed(h.LogsBloom[:])
+	enc.fixed(h.PrevRandao[:])
+	enc.uint64(h.Number)
+	enc.uint64(h.GasLimit)
+	enc.uint64(h.GasUsed)
+	enc.uint64(h.Timestamp)
+	enc.variable(h.ExtraData)
+	enc.fixed(baseFee[:])
+	enc.fixed(h.Hash[:])
+	enc.fixed(h.TransactionsRoot[:])
+
+	f := payloadFork(h)
+	if f >= capella {
+		var withdrawalsRoot common.Hash
+		if h.WithdrawalsRoot != nil {
+			withdrawalsRoot = *h.WithdrawalsRoot
+		}
+		enc.fixed(withdrawalsRoot[:])
+	}
+	if f >= deneb {
+		enc.uint64(derefUint64(h.BlobGasUsed))
+		enc.uint64(derefUint64(h.ExcessBlobGas))
+	}
+
+	return enc.bytes()
+}
+
+func (a *IndexedAttestation) marshalSSZ() []byte {
+	if a == nil {
+		a = &IndexedAttestation{}
+	}
+
+	indices := make([]byte, 8*len(a.AttestingIndicesList))
+	for i, index := range a.AttestingIndicesList {
+		binary.LittleEndian.PutUint64(indices[8*i:], index)
+	}
+
+	var enc sszEncoder
+	enc.variable(indices)
+	enc.fixed(a.Data.marshalSSZ())
+	enc.vector(a.Signature, blsSignatureLength)
+	return enc.bytes()
+}
+
+func (d *AttestationData) marshalSSZ() []byte {
+	if d == nil {
+		d = &AttestationData{}
+	}
+
+	var enc sszEncoder
+	enc.uint64(d.Slot)
+	enc.uint64(d.Index)
+	enc.fixed(d.BeaconBlockRoot[:])
+	for _, c := range []*Checkpoint{d.Source, d.Target} {
+		if c == nil {
+			c = &Checkpoint{}
+		}
+		enc.uint64(c.Epoch)
+		enc.fixed(c.Root[:])
+	}
+	return enc.bytes()
+}
+
+// sszEncoder serializes an SSZ container: the fixed size fields (and the offsets of the variable size
+// ones) followed by the variable size fields. A list of variable size elements is encoded like a
+// container of them.
+type sszEncoder struct {
+	fixedPart []byte
+	offsets   []int
+	variables [][]byte
+}
+
+func (e *sszEncoder) fixed(b []byte) {
+	e.fixedPart = append(e.fixedPart, b...)
+}
+
+// vector appends b as a byte vector of n bytes, zero-padded.
+func (e *sszEncoder) vector(b []byte, n int) {
+	v := make([]byte, n)
+	copy(v, b)
+	e.fixed(v)
+}
+
+func (e *sszEncoder) uint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.fixed(b[:])
+}
+
+func (e *sszEncoder) variable(b []byte) {
+	e.offsets = append(e.offsets, len(e.fixedPart))
+	e.fixedPart = append(e.fixedPart, 0, 0, 0, 0)
+	e.variables = append(e.variables, b)
+}
+
+func (e *sszEncoder) bytes() []byte {
+	out := e.fixedPart
+	for i, b := range e.variables {
+		binary.LittleEndian.PutUint32(out[e.offsets[i]:], uint32(len(out)))
+		out = append(out, b...)
+	}
+
+	return out
+}
+
+func (h *SignedBeaconBlockHeader) hashTreeRoot() ssz.Root {
+	if h == nil {
+		h = &SignedBeaconBlockHeader{}
+	}
+
+	message := h.Message
+	if message == nil {
+		message = &BeaconBlockHeader{}
+	}
+
+	return ssz.Container(message.HashTreeRoot(), ssz.ByteVector(h.Signature, blsSignatureLength))
+}
+
+func (e *Eth1Data) hashTreeRoot() ssz.Root {
+	return ssz.Container(e.DepositRoot, ssz.Uint64(e.DepositCount), e.BlockHash)
+}
+
+func (p ProposerSlashing) hashTreeRoot() ssz.Root {
+	return ssz.Container(p.Header1.hashTreeRoot(), p.Header2.hashTreeRoot())
+}
+
+func (c *Checkpoint) hashTreeRoot() ssz.Root {
+	if c == nil {
+		c = &Checkpoint{}
+	}
+
+	return ssz.Container(ssz.Uint64(c.Epoch), c.Root)
+}
+
+func (d *AttestationData) hashTreeRoot() ssz.Root {
+	if d == nil {
+		d = &AttestationData{}
+	}
+
+	return ssz.Container(
+		ssz.Uint64(d.Slot),
+		ssz.Uint64(d.Index),
+		d.BeaconBlockRoot,
+		d.Source.hashTreeRoot(),
+		d.Target.hashTreeRoot(),
+	)
+}
+
+func (a *IndexedAttestation) hashTreeRoot() ssz.Root {
+	if a == nil {
+		a = &IndexedAttestation{}
+	}
+
+	return ssz.Container(
+		ssz.Uint64List(a.AttestingIndicesList, maxValidatorsPerCommittee),
+		a.Data.hashTreeRoot(),
+		ssz.ByteVector(a.Signature, blsSignatureLength),
+	)
+}
+
+func (a AttesterSlashing) hashTreeRoot() ssz.Root {
+	return ssz.Container(a.Attestation1.hashTreeRoot(), a.Attestation2.hashTreeRoot())
+}
+
+func (a Attestation) hashTreeRoot() (ssz.Root, error) {
+	bits, err := ssz.Bitlist(a.AggregationBits, maxValidatorsPerCommittee)
+	if err != nil {
+		return ssz.Root{}, err
+	}
+
+	return ssz.Container(bits, a.Data.hashTreeRoot(), ssz.ByteVector(a.Signature, blsSignatureLength)), nil
+}
+
+func (d Deposit) hashTreeRoot() ssz.Root {
+	proof := make([]ssz.Root, depositProofLength)
+	for i := 0; i < len(d.ProofList) && i < depositProofLength; i++ {
+		proof[i] = d.ProofList[i]
+	}
+
+	data := d.Data
+	if data == nil {
+		data = &DepositData{}
+	}
+
+	return ssz.Container(ssz.Vector(proof, depositProofLength), data.hashTreeRoot())
+}
+
+func (d *DepositData) hashTreeRoot() ssz.Root {
+	return ssz.Container(
+		ssz.ByteVector(d.Pubkey, blsPubkeyLength),
+		d.WithdrawalCredentials,
+		ssz.Uint64(d.Amount),
+		ssz.ByteVector(d.Signature, blsSignatureLength),
+	)
+}
+
+func (e VoluntaryExit) hashTreeRoot() ssz.Root {
+	message := e.Message
+	if message == nil {
+		message = &VoluntaryExitMessage{}
+	}
+
+	return ssz.Container(
+		ssz.Container(ssz.Uint64(message.Epoch), ssz.Uint64(message.ValidatorIndex)),
+		ssz.ByteVector(e.Signature, blsSignatureLength),
+	)
+}
+
+func (s *SyncAggregate) hashTreeRoot() ssz.Root {
+	return ssz.Container(
+		ssz.Bitvector(s.SyncCommitteeBits, syncCommitteeSize),
+		ssz.ByteVector(s.SyncCommitteeSignature, blsSignatureLength),
+	)
+}
+
+func (c ExecutionChange) hashTreeRoot() ssz.Root {
+	message := c.Message
+	if message == nil {
+		message = &ExecutionChangeMessage{}
+	}
+
+	return ssz.Container(
+		ssz.Container(
+			ssz.Uint64(message.ValidatorIndex),
+			ssz.ByteVector(message.FromBlsPubkey, blsPubkeyLength),
+			ssz.ByteVector(message.ToExecutionAddress.Bytes(), common.AddressLength),
+		),
+		ssz.ByteVector(c.Signature, blsSignatureLength),
+	)
+}
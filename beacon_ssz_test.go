@@ -0,0 +1,97 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The mainnet genesis block, whose body is empty.
+var (
+	genesisStateRoot = common.HexToHash("0x7e76880eb67bbdc86250aa578958e9d0675e64e714337855204fb5abaaf82c2b")
+	genesisBlockRoot = common.HexToHash("0x4d611d5b93fdab69013a7f0a2f961caca0c853f87cfe9595fe50038163079360")
+)
+
+func TestBeaconBlockRoot(t *testing.T) {
+	genesis := &BeaconBlock{StateRoot: genesisStateRoot, Body: &BeaconBlockBody{}}
+
+	header, err := genesis.header(phase0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root := header.HashTreeRoot(); root != genesisBlockRoot {
+		t.Fatalf("expected the genesis block root %s, got %s", genesisBlockRoot, root)
+	}
+
+	if _, err := genesis.HashTreeRoot(nil); err == nil {
+		t.Fatal("expected an error without the execution payload header")
+	}
+}
+
+func TestBeaconBlockRootDeneb(t *testing.T) {
+	withdrawals := common.Hash{0x01}
+	payload := &ExecutionPayloadHeader{Number: 1, BaseFeePerGas: big.NewInt(7), WithdrawalsRoot: &withdrawals}
+	block := &BeaconBlock{Slot: 1, Body: &BeaconBlockBody{BlobKZGCommitments: []KZGCommitment{{0xc0}}}}
+
+	// Capella blocks have no commitments
+	capella, err := block.HashTreeRoot(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Body.BlobKZGCommitments = nil
+	if root, _ := block.HashTreeRoot(payload); root != capella {
+		t.Fatal("expected the commitments to be ignored before Deneb")
+	}
+
+	var blobGasUsed uint64 = 131072
+	payload.BlobGasUsed, payload.ExcessBlobGas = &blobGasUsed, new(uint64)
+	empty, _ := block.HashTreeRoot(payload)
+	block.Body.BlobKZGCommitments = []KZGCommitment{{0xc0}}
+	deneb, _ := block.HashTreeRoot(payload)
+	if empty == capella || deneb == empty {
+		t.Fatal("expected the Deneb fields to change the root")
+	}
+}
+
+func TestBeaconBlockMarshalSSZ(t *testing.T) {
+	genesis := &BeaconBlock{StateRoot: genesisStateRoot, Body: &BeaconBlockBody{}}
+
+	// 84 bytes of block fields, and an empty body of 220 bytes of fixed fields and offsets
+	b, err := genesis.marshalSSZ(phase0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 84+220 || binary.LittleEndian.Uint32(b[80:]) != 84 || !bytes.Equal(b[48:80], genesisStateRoot[:]) {
+		t.Fatalf("unexpected genesis block serialization %x", b)
+	}
+	if binary.LittleEndian.Uint32(b[84+200:]) != 220 {
+		t.Fatal("expected the lists of the body to start after its fixed part")
+	}
+
+	var blobGasUsed uint64 = 131072
+	withdrawals := common.Hash{0x01}
+	payload := &ExecutionPayloadHeader{ExtraData: []byte("fiber"), BaseFeePerGas: big.NewInt(0x0102), WithdrawalsRoot: &withdrawals, BlobGasUsed: &blobGasUsed, ExcessBlobGas: new(uint64)}
+	header := payload.MarshalSSZ()
+	if len(header) != 584+5 || !bytes.Equal(header[584:], []byte("fiber")) {
+		t.Fatalf("unexpected payload header serialization %x", header)
+	}
+	// The base fee is a little endian uint256
+	if header[440] != 0x02 || header[441] != 0x01 {
+		t.Fatalf("unexpected base fee %x", header[440:472])
+	}
+
+	commitments := []KZGCommitment{{0xc0}, {0xc1}}
+	block := &BeaconBlock{Slot: 1, Body: &BeaconBlockBody{BlobKZGCommitments: commitments}}
+	b, err = block.MarshalSSZ(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The payload header and the commitments are the last fields of the body
+	suffix := append(append(append([]byte(nil), header...), commitments[0][:]...), commitments[1][:]...)
+	if !bytes.HasSuffix(b, suffix) {
+		t.Fatalf("unexpected Deneb block serialization %x", b)
+	}
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// JSON encoding of beacon blocks in the standard beacon node API, where integers are quoted decimals.
+
+type quoted uint64
+
+func (q *quoted) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	*q = quoted(n)
+	return nil
+}
+
+type beaconAPIBlock struct {
+	Slot          quoted        `json:"slot"`
+	ProposerIndex quoted        `json:"proposer_index"`
+	ParentRoot    hexutil.Bytes `json:"parent_root"`
+	StateRoot     hexutil.Bytes `json:"state_root"`
+	Body          struct {
+		RandaoReveal hexutil.Bytes `json:"randao_reveal"`
+		Eth1Data     struct {
+			DepositRoot  hexutil.Bytes `json:"deposit_root"`
+			DepositCount quoted        `json:"deposit_count"`
+			BlockHash    hexutil.Bytes `json:"block_hash"`
+		} `json:"eth1_data"`
+		Graffiti          hexutil.Bytes `json:"graffiti"`
+		ProposerSlashings []struct {
+			Header1 beaconAPISignedHeader `json:"signed_header_1"`
+			Header2 beaconAPISignedHeader `json:"signed_header_2"`
+		} `json:"proposer_slashings"`
+		AttesterSlashings []struct {
+			Attestation1 beaconAPIIndexedAttestation `json:"attestation_1"`
+			Attestation2 beaconAPIIndexedAttestation `json:"attestation_2"`
+		} `json:"attester_slashings"`
+		Attestations []struct {
+			AggregationBits hexutil.Bytes            `json:"aggregation_bits"`
+			Data            beaconAPIAttestationData `json:"data"`
+			Signature       hexutil.Bytes            `json:"signature"`
+		} `json:"attestations"`
+		Deposits []struct {
+			Proof []hexutil.Bytes `json:"proof"`
+			Data  struct {
+				Pubkey                hexutil.Bytes `json:"pubkey"`
+				WithdrawalCredentials hexutil.Bytes `json:"withdrawal_credentials"`
+				Amount                quoted        `json:"amount"`
+				Signature             hexutil.Bytes `json:"signature"`
+			} `json:"data"`
+		} `json:"deposits"`
+		VoluntaryExits []struct {
+			Message struct {
+				Epoch          quoted `json:"epoch"`
+				ValidatorIndex quoted `json:"validator_index"`
+			} `json:"message"`
+			Signature hexutil.Bytes `json:"signature"`
+		} `json:"voluntary_exits"`
+		SyncAggregate *struct {
+			SyncCommitteeBits      hexutil.Bytes `json:"sync_committee_bits"`
+			SyncCommitteeSignature hexutil.Bytes `json:"sync_committee_signature"`
+		} `json:"sync_aggregate"`
+		BLSToExecutionChanges []struct {
+			Message struct {
+				ValidatorIndex     quoted        `json:"validator_index"`
+				FromBLSPubkey      hexutil.Bytes `json:"from_bls_pubkey"`
+				ToExecutionAddress hexutil.Bytes `json:"to_execution_address"`
+			} `json:"message"`
+			Signature hexutil.Bytes `json:"signature"`
+		} `json:"bls_to_execution_changes"`
+	} `json:"body"`
+}
+
+type beaconAPISignedHeader struct {
+	Message struct {
+		Slot          quoted        `json:"slot"`
+		ProposerIndex quoted        `json:"proposer_index"`
+		ParentRoot    hexutil.Bytes `json:"parent_root"`
+		StateRoot     hexutil.Bytes `json:"state_root"`
+		BodyRoot      hexutil.Bytes `json:"body_root"`
+	} `json:"message"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+type beaconAPICheckpoint struct {
+	Epoch quoted        `json:"epoch"`
+	Root  hexutil.Bytes `json:"root"`
+}
+
+type beaconAPIAttestationData struct {
+	Slot            quoted              `json:"slot"`
+	Index           quoted              `json:"index"`
+	BeaconBlockRoot hexutil.Bytes       `json:"beacon_block_root"`
+	Source          beaconAPICheckpoint `json:"source"`
+	Target          beaconAPICheckpoint `json:"target"`
+}
+
+type beaconAPIIndexedAttestation struct {
+	AttestingIndices []quoted                 `json:"attesting_indices"`
+	Data             beaconAPIAttestationData `json:"data"`
+	Signature        hexutil.Bytes            `json:"signature"`
+}
+
+func (h *beaconAPISignedHeader) toProto() *eth.SignedBeaconBlockHeader {
+	return &eth.SignedBeaconBlockHeader{
+		Message: &eth.BeaconBlockHeader{
+			Slot:          uint64(h.Message.Slot),
+			ProposerIndex: uint64(h.Message.ProposerIndex),
+			ParentRoot:    h.Message.ParentRoot,
+			StateRoot:     h.Message.StateRoot,
+			BodyRoot:      h.Message.BodyRoot,
+		},
+		Signature: h.Signature,
+	}
+}
+
+func (d *beaconAPIAttestationData) toProto() *eth.AttestationData {
+	return &eth.AttestationData{
+		Slot:            uint64(d.Slot),
+		Index:           uint64(d.Index),
+		BeaconBlockRoot: d.BeaconBlockRoot,
+		Source:          &eth.Checkpoint{Epoch: uint64(d.Source.Epoch), Root: d.Source.Root},
+		Target:          &eth.Checkpoint{Epoch: uint64(d.Target.Epoch), Root: d.Target.Root},
+	}
+}
+
+func (a *beaconAPIIndexedAttestation) toProto() *eth.IndexedAttestation {
+	indices := make([]uint64, len(a.AttestingIndices))
+	for i, index := range a.AttestingIndices {
+		indices[i] = uint64(index)
+	}
+
+	return &eth.IndexedAttestation{
+		AttestingIndices: indices,
+		Data:             a.Data.toProto(),
+		Signature:        a.Signature,
+	}
+}
+
+func (b *beaconAPIBlock) toProto() *eth.CompactBeaconBlock {
+	in := &b.Body
+	body := &eth.CompactBeaconBlockBody{
+		RandaoReveal: in.RandaoReveal,
+		Eth1Data: &eth.Eth1Data{
+			DepositRoot:  in.Eth1Data.DepositRoot,
+			DepositCount: uint64(in.Eth1Data.DepositCount),
+			BlockHash:    in.Eth1Data.BlockHash,
+		},
+		Graffiti: in.Graffiti,
+	}
+
+	for _, s := range in.ProposerSlashings {
+		body.ProposerSlashings = append(body.ProposerSlashings, &eth.ProposerSlashing{
+			Header_1: s.Header1.toProto(),
+			Header_2: s.Header2.toProto(),
+		})
+	}
+
+	for _, s := range in.AttesterSlashings {
+		body.AttesterSlashings = append(body.AttesterSlashings, &eth.AttesterSlashing{
+			Attestation_1: s.Attestation1.toProto(),
+			Attestation_2: s.Attestation2.toProto(),
+		})
+	}
+
+	for _, a := range in.Attestations {
+		body.Attestations = append(body.Attestations, &eth.Attestation{
+			AggregationBits: a.AggregationBits,
+			Data:            a.Data.toProto(),
+			Signature:       a.Signature,
+		})
+	}
+
+	for _, d := range in.Deposits {
+		proof := make([][]byte, len(d.Proof))
+		for i, p := range d.Proof {
+			proof[i] = p
+		}
+
+		body.Deposits = append(body.Deposits, &eth.Deposit{
+			Proof: proof,
+			Data: &eth.DepositData{
+				Pubkey:                d.Data.Pubkey,
+				WithdrawalCredentials: d.Data.WithdrawalCredentials,
+				Amount:                uint64(d.Data.Amount),
+				Signature:             d.Data.Signature,
+			},
+		})
+	}
+
+	for _, e := range in.VoluntaryExits {
+		body.VoluntaryExits = append(body.VoluntaryExits, &eth.SignedVoluntaryExit{
+			Message: &eth.VoluntaryExit{
+				Epoch:          uint64(e.Message.Epoch),
+				ValidatorIndex: uint64(e.Message.ValidatorIndex),
+			},
+			Signature: e.Signature,
+		})
+	}
+
+	if in.SyncAggregate != nil {
+		body.SyncAggregate = &eth.SyncAggregate{
+			SyncCommitteeBits:      in.SyncAggregate.SyncCommitteeBits,
+			SyncCommitteeSignature: in.SyncAggregate.SyncCommitteeSignature,
+		}
+	}
+
+	for _, c := range in.BLSToExecutionChanges {
+		body.BlsToExecutionChanges = append(body.BlsToExecutionChanges, &eth.SignedBLSToExecutionChange{
+			Message: &eth.BLSToExecutionChange{
+				ValidatorIndex:     uint64(c.Message.ValidatorIndex),
+				FromBlsPubkey:      c.Message.FromBLSPubkey,
+				ToExecutionAddress: c.Message.ToExecutionAddress,
+			},
+			Signature: c.Signature,
+		})
+	}
+
+	return &eth.CompactBeaconBlock{
+		Slot:          uint64(b.Slot),
+		ProposerIndex: uint64(b.ProposerIndex),
+		ParentRoot:    b.ParentRoot,
+		StateRoot:     b.StateRoot,
+		Body:          body,
+	}
+}
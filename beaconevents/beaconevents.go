@@ -0,0 +1,293 @@
+// Package beaconevents serves Fiber's beacon blocks as a standard beacon node API event stream
+// (GET /eth/v1/events), so tooling written against a beacon node can consume Fiber's feed unmodified.
+// The block and head topics are supported.
+package beaconevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	client "github.com/chainbound/fiber-go"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// MainnetGenesisTime is the genesis time of the mainnet beacon chain.
+	MainnetGenesisTime = 1606824023
+
+	secondsPerSlot = 12
+	slotsPerEpoch  = 32
+
+	// Number of recent slots for which block roots and unmatched messages are kept.
+	history = 3 * slotsPerEpoch
+
+	// Number of events buffered per listener, after which events are dropped for that listener.
+	listenerBuffer = 64
+)
+
+// Supported topics.
+const (
+	TopicBlock = "block"
+	TopicHead  = "head"
+)
+
+type event struct {
+	topic string
+	data  []byte
+}
+
+// Handler is an http.Handler serving the event stream. Feed it beacon blocks and execution payload
+// headers from a Fiber client with Run: blocks are only published once the header of their execution
+// payload arrived, since it's needed to compute the block root. Every new block is published as head.
+type Handler struct {
+	genesisTime uint64
+
+	mu        sync.Mutex
+	listeners map[chan event]map[string]bool
+	blocks    map[uint64]*client.BeaconBlock
+	headers   map[uint64]*client.ExecutionPayloadHeader
+	roots     map[uint64]common.Hash
+}
+
+// NewHandler returns a handler for the chain with the given genesis time, e.g. MainnetGenesisTime.
+func NewHandler(genesisTime uint64) *Handler {
+	return &Handler{
+		genesisTime: genesisTime,
+		listeners:   make(map[chan event]map[string]bool),
+		blocks:      make(map[uint64]*client.BeaconBlock),
+		headers:     make(map[uint64]*client.ExecutionPayloadHeader),
+		roots:       make(map[uint64]common.Hash),
+	}
+}
+
+// Run publishes the messages from the given channels, as returned by SubscribeNewBeaconBlocks and
+// SubscribeNewExecutionPayloadHeaders, until ctx is done or a channel is closed.
+func (h *Handler) Run(ctx context.Context, blocks <-chan *client.BeaconBlock, headers <-chan *client.ExecutionPayloadHeader) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case block, ok := <-blocks:
+			if !ok {
+				return fmt.Errorf("beacon block channel closed")
+			}
+
+			h.addBlock(block)
+		case header, ok := <-headers:
+			if !ok {
+				return fmt.Errorf("execution header channel closed")
+			}
+
+			h.addHeader(header)
+		}
+	}
+}
+
+func (h *Handler) addBlock(block *client.BeaconBlock) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if header, ok := h.headers[block.Slot]; ok {
+		delete(h.headers, block.Slot)
+		h.publish(block, header)
+		return
+	}
+
+	h.blocks[block.Slot] = block
+}
+
+func (h *Handler) addHeader(header *client.ExecutionPayloadHeader) {
+	if header.Timestamp < h.genesisTime {
+		return
+	}
+	slot := (header.Timestamp - h.genesisTime) / secondsPerSlot
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if block, ok := h.blocks[slot]; ok {
+		delete(h.blocks, slot)
+		h.publish(block, header)
+		return
+	}
+
+	h.headers[slot] = header
+}
+
+type blockEvent struct {
+	Slot                string      `json:"slot"`
+	Block               common.Hash `json:"block"`
+	ExecutionOptimistic bool        `json:"execution_optimistic"`
+}
+
+type headEvent struct {
+	Slot                      string      `json:"slot"`
+	Block                     common.Hash `json:"block"`
+	State                     common.Hash `json:"state"`
+	EpochTransition           bool        `json:"epoch_transition"`
+	PreviousDutyDependentRoot common.Hash `json:"previous_duty_dependent_root"`
+	CurrentDutyDependentRoot  common.Hash `json:"current_duty_dependent_root"`
+	ExecutionOptimistic       bool        `json:"execution_optimistic"`
+}
+
+// publish sends the events of a block to the listeners. Must be called with mu held.
+func (h *Handler) publish(block *client.BeaconBlock, header *client.ExecutionPayloadHeader) {
+	root, err := block.HashTreeRoot(header)
+	if err != nil {
+		return
+	}
+
+	h.roots[block.Slot] = root
+	h.prune(block.Slot)
+
+	slot := strconv.FormatUint(block.Slot, 10)
+	epoch := block.Slot / slotsPerEpoch
+
+	blockData, _ := json.Marshal(blockEvent{Slot: slot, Block: root})
+	headData, _ := json.Marshal(headEvent{
+		Slot:                      slot,
+		Block:                     root,
+		State:                     block.StateRoot,
+		EpochTransition:           block.Slot%slotsPerEpoch == 0,
+		PreviousDutyDependentRoot: h.previousDependentRoot(epoch),
+		CurrentDutyDependentRoot:  h.dependentRoot(epoch),
+	})
+
+	h.send(event{topic: TopicBlock, data: blockData})
+	h.send(event{topic: TopicHead, data: headData})
+}
+
+// dependentRoot returns the root of the latest known block before the start of the given epoch, or
+// the zero hash if there is none.
+func (h *Handler) dependentRoot(epoch uint64) common.Hash {
+	start := epoch * slotsPerEpoch
+	for slot := start; slot > 0 && start-slot < history; slot-- {
+		if root, ok := h.roots[slot-1]; ok {
+			return root
+		}
+	}
+
+	return common.Hash{}
+}
+
+func (h *Handler) previousDependentRoot(epoch uint64) common.Hash {
+	if epoch == 0 {
+		return common.Hash{}
+	}
+
+	return h.dependentRoot(epoch - 1)
+}
+
+// prune drops everything older than the history before slot.
+func (h *Handler) prune(slot uint64) {
+	if slot < history {
+		return
+	}
+
+	for s := range h.roots {
+		if s < slot-history {
+			delete(h.roots, s)
+		}
+	}
+	for s := range h.blocks {
+		if s < slot-history {
+			delete(h.blocks, s)
+		}
+	}
+	for s := range h.headers {
+		if s < slot-history {
+			delete(h.headers, s)
+		}
+	}
+}
+
+// send delivers e to the listeners of its topic. Slow listeners miss events.
+func (h *Handler) send(e event) {
+	for ch, topics := range h.listeners {
+		if !topics[e.topic] {
+			continue
+		}
+
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(apiError{Code: code, Message: msg})
+}
+
+// ServeHTTP serves the event stream for the topics in the topics query parameter.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	topics := make(map[string]bool)
+	for _, param := range r.URL.Query()["topics"] {
+		for _, topic := range strings.Split(param, ",") {
+			switch topic {
+			case TopicBlock, TopicHead:
+				topics[topic] = true
+			default:
+				writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid topic: %s", topic))
+				return
+			}
+		}
+	}
+	if len(topics) == 0 {
+		writeError(w, http.StatusBadRequest, "No topics requested")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	ch := make(chan event, listenerBuffer)
+	h.mu.Lock()
+	h.listeners[ch] = topics
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.listeners, ch)
+		h.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-ch:
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.topic, e.data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package beaconevents
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	client "github.com/chainbound/fiber-go"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestEventStream(t *testing.T) {
+	h := NewHandler(0)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/eth/v1/events?topics=head,block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks := make(chan *client.BeaconBlock, 1)
+	headers := make(chan *client.ExecutionPayloadHeader, 1)
+	go h.Run(ctx, blocks, headers)
+
+	block := &client.BeaconBlock{Slot: 33, StateRoot: common.Hash{1}, Body: &client.BeaconBlockBody{}}
+	header := &client.ExecutionPayloadHeader{Number: 1, Timestamp: 33 * secondsPerSlot}
+	root, err := block.HashTreeRoot(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers <- header
+	blocks <- block
+
+	scanner := bufio.NewScanner(res.Body)
+	var events []string
+	for len(events) < 2 && scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+
+		var e struct {
+			Slot  string      `json:"slot"`
+			Block common.Hash `json:"block"`
+			State common.Hash `json:"state"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Slot != "33" || e.Block != root {
+			t.Fatalf("unexpected event %s", line)
+		}
+		events = append(events, line)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected a block and a head event, got %v", events)
+	}
+}
+
+func TestInvalidTopic(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/eth/v1/events?topics=attestation", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
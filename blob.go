@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// BlobTxType is the EIP-2718 type of EIP-4844 blob transactions.
+const BlobTxType = 3
+
+// The go-ethereum version the client is built with predates EIP-4844, so blob transactions are encoded,
+// signed and decoded here. Fiber's protobuf transaction doesn't carry the blob fields either: the blob
+// transactions of the streams have everything but BlobFeeCap and BlobHashes, and their ToNative is nil.
+
+var (
+	// ErrNotBlobTx is returned when decoding a transaction that isn't a blob transaction.
+	ErrNotBlobTx = newCodedError(ErrCodeNotBlobTx, "not a blob transaction")
+	// ErrBlobSidecarMismatch is returned when a sidecar doesn't match the blob hashes of its transaction.
+	ErrBlobSidecarMismatch = newCodedError(ErrCodeBlobSidecarMismatch, "blob sidecar doesn't match the transaction")
+)
+
+const (
+	// BlobSize is the size of a blob.
+	BlobSize = 4096 * 32
+	// BlobHashVersionKZG is the version byte of the versioned hash of a KZG commitment.
+	BlobHashVersionKZG = 0x01
+)
+
+type (
+	Blob          [BlobSize]byte
+	KZGCommitment [48]byte
+	KZGProof      [48]byte
+)
+
+// BlobTx is a signed EIP-4844 transaction. Unlike other transactions, it can't create a contract.
+type BlobTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+	BlobFeeCap *big.Int
+	BlobHashes []common.Hash
+
+	// The signature, where V is the y-parity.
+	V, R, S *big.Int
+}
+
+// BlobSidecar has the blobs of a transaction, which aren't part of it but are sent along with it. The
+// commitments and proofs have to be computed with a KZG library.
+type BlobSidecar struct {
+	Blobs       []Blob
+	Commitments []KZGCommitment
+	Proofs      []KZGProof
+}
+
+// VersionedHashes returns the versioned hashes of the commitments, which are the BlobHashes of the transaction.
+func (s *BlobSidecar) VersionedHashes() []common.Hash {
+	hashes := make([]common.Hash, len(s.Commitments))
+	for i, commitment := range s.Commitments {
+		hashes[i] = sha256.Sum256(commitment[:])
+		hashes[i][0] = BlobHashVersionKZG
+	}
+
+	return hashes
+}
+
+// SigningHash returns the hash the transaction is signed over.
+func (tx *BlobTx) SigningHash() common.Hash {
+	b, _ := rlp.EncodeToBytes([]interface{}{
+		tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas, tx.To, tx.Value, tx.Data, tx.AccessList,
+		tx.BlobFeeCap, tx.BlobHashes,
+	})
+
+	return crypto.Keccak256Hash([]byte{BlobTxType}, b)
+}
+
+// Sign signs the transaction with key.
+func (tx *BlobTx) Sign(key *ecdsa.PrivateKey) error {
+	h := tx.SigningHash()
+	sig, err := crypto.Sign(h[:], key)
+	if err != nil {
+		return fmt.Errorf("signing blob transaction: %w", err)
+	}
+
+	tx.R = new(big.Int).SetBytes(sig[:32])
+	tx.S = new(big.Int).SetBytes(sig[32:64])
+	tx.V = new(big.Int).SetUint64(uint64(sig[64]))
+	return nil
+}
+
+// Sender recovers the sender of the transaction from its signature.
+func (tx *BlobTx) Sender() (common.Address, error) {
+	if tx.V == nil || tx.R == nil || tx.S == nil || tx.V.BitLen() > 1 {
+		return common.Address{}, fmt.Errorf("invalid blob transaction signature")
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	tx.R.FillBytes(sig[:32])
+	tx.S.FillBytes(sig[32:64])
+	sig[64] = byte(tx.V.Uint64())
+
+	h := tx.SigningHash()
+	pub, err := crypto.SigToPub(h[:], sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("recovering blob transaction sender: %w", err)
+	}
+
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// MarshalBinary returns the canonical encoding of the transaction, without its blobs.
+func (tx *BlobTx) MarshalBinary() ([]byte, error) {
+	b, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, fmt.Errorf("encoding blob transaction: %w", err)
+	}
+
+	return append([]byte{BlobTxType}, b...), nil
+}
+
+// Hash returns the hash of the transaction.
+func (tx *BlobTx) Hash() common.Hash {
+	b, _ := tx.MarshalBinary()
+	return crypto.Keccak256Hash(b)
+}
+
+// ToProto converts the transaction to its protobuf message, which doesn't have the blob fields.
+func (tx *BlobTx) ToProto() (*eth.Transaction, error) {
+	sender, err := tx.Sender()
+	if err != nil {
+		return nil, err
+	}
+
+	var acl []*eth.AccessTuple
+	for _, tuple := range tx.AccessList {
+		keys := make([][]byte, len(tuple.StorageKeys))
+		for i, key := range tuple.StorageKeys {
+			keys[i] = key.Bytes()
+		}
+
+		acl = append(acl, &eth.AccessTuple{Address: tuple.Address.Bytes(), StorageKeys: keys})
+	}
+
+	return &eth.Transaction{
+		ChainId:     uint32(tx.ChainID.Uint64()),
+		Type:        BlobTxType,
+		Nonce:       tx.Nonce,
+		GasPrice:    tx.GasFeeCap.Uint64(),
+		MaxFee:      tx.GasFeeCap.Uint64(),
+		PriorityFee: tx.GasTipCap.Uint64(),
+		Gas:         tx.Gas,
+		To:          tx.To.Bytes(),
+		From:        sender.Bytes(),
+		Hash:        tx.Hash().Bytes(),
+		Value:       tx.Value.Bytes(),
+		Input:       tx.Data,
+		V:           tx.V.Uint64(),
+		R:           tx.R.Bytes(),
+		S:           tx.S.Bytes(),
+		AccessList:  acl,
+	}, nil
+}
+
+// blobTxWithSidecar is the network encoding of a blob transaction, in which it's sent.
+type blobTxWithSidecar struct {
+	Tx          *BlobTx
+	Blobs       []Blob
+	Commitments []KZGCommitment
+	Proofs      []KZGProof
+}
+
+// EncodeBlobTx returns the network encoding of the transaction with its sidecar, which is what nodes
+// accept. It checks that the sidecar matches the blob hashes of the transaction.
+func EncodeBlobTx(tx *BlobTx, sidecar *BlobSidecar) ([]byte, error) {
+	if sidecar == nil {
+		return nil, fmt.Errorf("%w: no sidecar", ErrBlobSidecarMismatch)
+	}
+
+	n := len(tx.BlobHashes)
+	if n == 0 || len(sidecar.Blobs) != n || len(sidecar.Commitments) != n || len(sidecar.Proofs) != n {
+		return nil, fmt.Errorf("%w: %d blob hashes, %d blobs, %d commitments and %d proofs", ErrBlobSidecarMismatch,
+			n, len(sidecar.Blobs), len(sidecar.Commitments), len(sidecar.Proofs))
+	}
+
+	for i, h := range sidecar.VersionedHashes() {
+		if h != tx.BlobHashes[i] {
+			return nil, fmt.Errorf("%w: commitment %d hashes to %s, expected %s", ErrBlobSidecarMismatch, i, h, tx.BlobHashes[i])
+		}
+	}
+
+	b, err := rlp.EncodeToBytes(&blobTxWithSidecar{tx, sidecar.Blobs, sidecar.Commitments, sidecar.Proofs})
+	if err != nil {
+		return nil, fmt.Errorf("encoding blob transaction: %w", err)
+	}
+
+	return append([]byte{BlobTxType}, b...), nil
+}
+
+// DecodeBlobTx decodes a blob transaction in its canonical or network encoding. The sidecar is nil
+// for the canonical encoding.
+func DecodeBlobTx(b []byte) (*BlobTx, *BlobSidecar, error) {
+	if len(b) == 0 || b[0] != BlobTxType {
+		return nil, nil, ErrNotBlobTx
+	}
+
+	_, content, _, err := rlp.Split(b[1:])
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding blob transaction: %w", err)
+	}
+	kind, _, _, err := rlp.Split(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decoding blob transaction: %w", err)
+	}
+
+	// The network encoding wraps the transaction in a list, where the canonical one starts with the chain ID
+	if kind != rlp.List {
+		tx := new(BlobTx)
+		if err := rlp.DecodeBytes(b[1:], tx); err != nil {
+			return nil, nil, fmt.Errorf("decoding blob transaction: %w", err)
+		}
+		return tx, nil, nil
+	}
+
+	var network blobTxWithSidecar
+	if err := rlp.DecodeBytes(b[1:], &network); err != nil {
+		return nil, nil, fmt.Errorf("decoding blob transaction: %w", err)
+	}
+
+	return network.Tx, &BlobSidecar{Blobs: network.Blobs, Commitments: network.Commitments, Proofs: network.Proofs}, nil
+}
+
+// SendBlobTransaction sends the signed blob transaction with its sidecar. See SendRawTransaction.
+func (c *Client) SendBlobTransaction(ctx context.Context, tx *BlobTx, sidecar *BlobSidecar) (string, int64, error) {
+	raw, err := EncodeBlobTx(tx, sidecar)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return c.SendRawTransaction(ctx, raw)
+}
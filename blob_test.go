@@ -0,0 +1,81 @@
+package client
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestBlobTx(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sidecar := &BlobSidecar{
+		Blobs:       []Blob{{1, 2, 3}},
+		Commitments: []KZGCommitment{{0xc0}},
+		Proofs:      []KZGProof{{0xf0}},
+	}
+	tx := &BlobTx{
+		ChainID:    common.Big1,
+		Nonce:      7,
+		GasTipCap:  big.NewInt(1),
+		GasFeeCap:  big.NewInt(20),
+		Gas:        21000,
+		To:         common.HexToAddress("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC"),
+		Value:      big.NewInt(0),
+		Data:       []byte{},
+		BlobFeeCap: big.NewInt(100),
+		BlobHashes: sidecar.VersionedHashes(),
+	}
+	if tx.BlobHashes[0][0] != BlobHashVersionKZG {
+		t.Fatalf("expected a versioned hash, got %s", tx.BlobHashes[0])
+	}
+	if err := tx.Sign(pk); err != nil {
+		t.Fatal(err)
+	}
+	if sender, err := tx.Sender(); err != nil || sender != crypto.PubkeyToAddress(pk.PublicKey) {
+		t.Fatalf("unexpected sender %s %v", sender, err)
+	}
+
+	canonical, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, none, err := DecodeBlobTx(canonical)
+	if err != nil || none != nil {
+		t.Fatalf("unexpected decoding %v %v", none, err)
+	}
+	if decoded.Hash() != tx.Hash() || decoded.Hash() != crypto.Keccak256Hash(canonical) {
+		t.Fatal("expected the hash to survive decoding")
+	}
+
+	network, err := EncodeBlobTx(tx, sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, decodedSidecar, err := DecodeBlobTx(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Hash() != tx.Hash() || !reflect.DeepEqual(decodedSidecar, sidecar) {
+		t.Fatal("expected the transaction and sidecar to survive decoding")
+	}
+
+	if proto, err := tx.ToProto(); err != nil || !ProtoToTx(proto).KnownType() || ProtoToTx(proto).Hash != tx.Hash() {
+		t.Fatalf("unexpected protobuf message %v %v", proto, err)
+	}
+
+	sidecar.Commitments[0][0] = 0xc1
+	if _, err := EncodeBlobTx(tx, sidecar); !errors.Is(err, ErrBlobSidecarMismatch) {
+		t.Fatalf("expected a sidecar mismatch, got %v", err)
+	}
+	if _, _, err := DecodeBlobTx([]byte{0x02, 0xc0}); err != ErrNotBlobTx {
+		t.Fatalf("expected a type error, got %v", err)
+	}
+}
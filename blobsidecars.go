@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// BeaconBlobSidecar is a blob with its KZG commitment and proof, as the consensus layer propagates it.
+type BeaconBlobSidecar struct {
+	// Index is the index of the blob in the block.
+	Index         uint64
+	Blob          *Blob
+	KZGCommitment KZGCommitment
+	KZGProof      KZGProof
+	// BlockHeader is the header of the block the blob is in.
+	BlockHeader *SignedBeaconBlockHeader
+}
+
+// BlobSidecarSource fetches blob sidecars. Fiber doesn't stream them, so SubscribeBlobSidecars
+// fetches them from a source, usually a beacon node, when the block arrives on Fiber.
+type BlobSidecarSource interface {
+	// BlobSidecars returns the blob sidecars of the block at slot. found is false if the source
+	// doesn't know the block, or not yet.
+	BlobSidecars(ctx context.Context, slot uint64) (sidecars []*BeaconBlobSidecar, found bool, err error)
+}
+
+const (
+	// How long SubscribeBlobSidecars waits for the source to have the sidecars of a block: a slot
+	blobSidecarWait = 12 * time.Second
+	// How often the source is asked for the sidecars meanwhile
+	blobSidecarRetry = 100 * time.Millisecond
+)
+
+// SubscribeBlobSidecars subscribes to the new beacon blocks, and sends the blob sidecars of every
+// block, fetched from src, on the given channel in order. The sidecars of a block are skipped if src
+// doesn't have the block within a slot, as is the case for a missed or orphaned block. The options
+// are passed to the beacon block subscription. This function blocks and should be called in a
+// goroutine. It closes the channel and returns the error of the subscription, or of src.
+func (c *Client) SubscribeBlobSidecars(src BlobSidecarSource, ch chan<- *BeaconBlobSidecar, opts ...SubscriptionOption) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	blocks := make(chan *BeaconBlock, 16)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.SubscribeNewBeaconBlocks(blocks, append([]SubscriptionOption{withContext(ctx)}, opts...)...)
+	}()
+
+	for {
+		var block *BeaconBlock
+		select {
+		case b, ok := <-blocks:
+			if !ok {
+				close(ch)
+				return <-errc
+			}
+			block = b
+		case err := <-errc:
+			// The subscription failed without closing blocks
+			close(ch)
+			return err
+		}
+
+		sidecars, err := fetchBlobSidecars(ctx, src, block.Slot)
+		if err != nil {
+			cancel()
+			for range blocks {
+			}
+			<-errc
+			close(ch)
+			return err
+		}
+
+		for _, sidecar := range sidecars {
+			ch <- sidecar
+		}
+	}
+}
+
+// fetchBlobSidecars asks src for the sidecars at slot until it has them, for at most blobSidecarWait.
+func fetchBlobSidecars(ctx context.Context, src BlobSidecarSource, slot uint64) ([]*BeaconBlobSidecar, error) {
+	deadline := time.Now().Add(blobSidecarWait)
+	for {
+		sidecars, found, err := src.BlobSidecars(ctx, slot)
+		if err != nil || found || time.Now().After(deadline) {
+			return sidecars, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(blobSidecarRetry):
+		}
+	}
+}
+
+type beaconAPIBlobSidecar struct {
+	Index             quoted                `json:"index"`
+	Blob              hexutil.Bytes         `json:"blob"`
+	KZGCommitment     hexutil.Bytes         `json:"kzg_commitment"`
+	KZGProof          hexutil.Bytes         `json:"kzg_proof"`
+	SignedBlockHeader beaconAPISignedHeader `json:"signed_block_header"`
+}
+
+// BlobSidecars returns the blob sidecars of the block at slot from the beacon node. It makes
+// RPCBackfill a BlobSidecarSource.
+func (b *RPCBackfill) BlobSidecars(ctx context.Context, slot uint64) ([]*BeaconBlobSidecar, bool, error) {
+	if b.beaconURL == "" {
+		return nil, false, ErrBackfillUnsupported
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/eth/v1/beacon/blob_sidecars/%d", b.beaconURL, slot), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := b.http.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("getting blob sidecars %d: %w", slot, err)
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("getting blob sidecars %d: %s", slot, res.Status)
+	}
+
+	var body struct {
+		Data []beaconAPIBlobSidecar `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, false, fmt.Errorf("decoding blob sidecars %d: %w", slot, err)
+	}
+
+	sidecars := make([]*BeaconBlobSidecar, len(body.Data))
+	for i, s := range body.Data {
+		if len(s.Blob) != BlobSize || len(s.KZGCommitment) != len(KZGCommitment{}) || len(s.KZGProof) != len(KZGProof{}) {
+			return nil, false, fmt.Errorf("decoding blob sidecars %d: invalid sidecar %d", slot, s.Index)
+		}
+
+		sidecar := &BeaconBlobSidecar{
+			Index:       uint64(s.Index),
+			Blob:        new(Blob),
+			BlockHeader: fromProtoSignedBeaconBlockHeader(s.SignedBlockHeader.toProto()),
+		}
+		copy(sidecar.Blob[:], s.Blob)
+		copy(sidecar.KZGCommitment[:], s.KZGCommitment)
+		copy(sidecar.KZGProof[:], s.KZGProof)
+		sidecars[i] = sidecar
+	}
+
+	return sidecars, true, nil
+}
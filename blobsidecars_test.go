@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// beaconServer streams beacon blocks at the given slots.
+type beaconServer struct {
+	api.UnimplementedAPIServer
+	slots []uint64
+}
+
+func (s *beaconServer) SubscribeBeaconBlocks(_ *emptypb.Empty, stream api.API_SubscribeBeaconBlocksServer) error {
+	for _, slot := range s.slots {
+		if err := stream.Send(&eth.CompactBeaconBlock{Slot: slot, Body: &eth.CompactBeaconBlockBody{}}); err != nil {
+			return err
+		}
+	}
+
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestSubscribeBlobSidecars(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, &beaconServer{slots: []uint64{10, 11}})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	// The sidecars of slot 10 are only available on the second try, slot 11 has no blobs
+	var tries int32
+	commitment := "0x" + strings.Repeat("c0", 48)
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/beacon/blob_sidecars/10":
+			if atomic.AddInt32(&tries, 1) == 1 {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, `{"data":[{"index":"1","blob":"0x%s","kzg_commitment":"%s","kzg_proof":"%s",
+				"signed_block_header":{"message":{"slot":"10","proposer_index":"3"},"signature":"0x"}}]}`,
+				strings.Repeat("01", BlobSize), commitment, commitment)
+		case "/eth/v1/beacon/blob_sidecars/11":
+			fmt.Fprint(w, `{"data":[]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer node.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	src, err := NewRPCBackfill(ctx, "", node.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(lis.Addr().String())
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var sub Subscription
+	ch := make(chan *BeaconBlobSidecar, 2)
+	errc := make(chan error, 1)
+	go func() { errc <- c.SubscribeBlobSidecars(src, ch, WithHandle(&sub)) }()
+
+	sidecar := <-ch
+	if sidecar.Index != 1 || sidecar.Blob[0] != 1 || sidecar.KZGCommitment[0] != 0xc0 || sidecar.BlockHeader.Message.ProposerIndex != 3 {
+		t.Fatalf("unexpected sidecar %+v", sidecar)
+	}
+
+	for sub.Stats().Messages < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	sub.Unsubscribe()
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("expected the subscription to be cancelled, got %v", err)
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("expected no more sidecars")
+	}
+}
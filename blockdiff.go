@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultDiffCompetitors is the number of competitors a DiffTracker keeps per block by default.
+const DefaultDiffCompetitors = 1 << 12
+
+// BlockDiff reconciles the watched pending transactions of a DiffTracker with a new block.
+type BlockDiff struct {
+	Header *ExecutionPayloadHeader
+	// Included are the watched transactions in the block, in block order. They're not watched anymore.
+	Included []*Transaction
+	// Pending are the watched transactions still pending, in the order they were watched.
+	Pending []*Transaction
+	// Competitors are the transactions seen since the previous block, that weren't watched but called
+	// a contract a watched transaction calls, in the order they were received.
+	Competitors []*Transaction
+}
+
+// DiffTracker watches pending transactions, and for every block reports which of them were included,
+// which are still pending, and the competing transactions to the same contracts that appeared in the
+// meantime. Pending transactions are added with AddTransaction, and blocks with AddPayload.
+type DiffTracker struct {
+	mu      sync.Mutex
+	max     int
+	watched map[common.Hash]*Transaction
+	// watch order, for reporting the pending transactions
+	order []common.Hash
+	// number of watched transactions calling each contract
+	targets     map[common.Address]int
+	competitors []*Transaction
+	seen        map[common.Hash]struct{}
+}
+
+// NewDiffTracker returns a tracker without watched transactions, keeping up to max competitors per
+// block, 0 for DefaultDiffCompetitors.
+func NewDiffTracker(max int) *DiffTracker {
+	if max <= 0 {
+		max = DefaultDiffCompetitors
+	}
+
+	return &DiffTracker{
+		max:     max,
+		watched: make(map[common.Hash]*Transaction),
+		targets: make(map[common.Address]int),
+		seen:    make(map[common.Hash]struct{}),
+	}
+}
+
+// Watch starts watching the pending transaction tx. It's watched until it's included.
+func (d *DiffTracker) Watch(tx *Transaction) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.watched[tx.Hash]; ok {
+		return
+	}
+
+	d.watched[tx.Hash] = tx
+	d.order = append(d.order, tx.Hash)
+	if tx.To != nil {
+		d.targets[*tx.To]++
+	}
+}
+
+// Unwatch stops watching the transaction with the given hash.
+func (d *DiffTracker) Unwatch(hash common.Hash) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.unwatch(hash)
+	d.compact()
+}
+
+func (d *DiffTracker) unwatch(hash common.Hash) {
+	tx, ok := d.watched[hash]
+	if !ok {
+		return
+	}
+
+	delete(d.watched, hash)
+	if tx.To != nil {
+		if d.targets[*tx.To]--; d.targets[*tx.To] == 0 {
+			delete(d.targets, *tx.To)
+		}
+	}
+}
+
+// compact drops the hashes that aren't watched anymore from the watch order.
+func (d *DiffTracker) compact() {
+	order := d.order[:0]
+	for _, hash := range d.order {
+		if _, ok := d.watched[hash]; ok {
+			order = append(order, hash)
+		}
+	}
+	d.order = order
+}
+
+// Watching returns the number of watched transactions.
+func (d *DiffTracker) Watching() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.watched)
+}
+
+// AddTransaction adds a pending transaction, which is a competitor if it calls a contract a watched
+// transaction calls.
+func (d *DiffTracker) AddTransaction(tx *Transaction) {
+	if tx.To == nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.watched[tx.Hash]; ok || d.targets[*tx.To] == 0 || len(d.competitors) >= d.max {
+		return
+	}
+	if _, ok := d.seen[tx.Hash]; ok {
+		return
+	}
+
+	d.seen[tx.Hash] = struct{}{}
+	d.competitors = append(d.competitors, tx)
+}
+
+// AddPayload reconciles the watched transactions with the block p, and starts collecting the
+// competitors of the next block.
+func (d *DiffTracker) AddPayload(p *ExecutionPayload) *BlockDiff {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	diff := &BlockDiff{Header: p.Header, Competitors: d.competitors}
+	for _, tx := range p.Transactions {
+		if watched, ok := d.watched[tx.Hash]; ok {
+			diff.Included = append(diff.Included, watched)
+			d.unwatch(tx.Hash)
+		}
+	}
+
+	d.compact()
+	for _, hash := range d.order {
+		diff.Pending = append(diff.Pending, d.watched[hash])
+	}
+
+	d.competitors = nil
+	d.seen = make(map[common.Hash]struct{})
+	return diff
+}
+
+// Run subscribes to transactions and execution payloads on c, and sends a diff on diffs for every
+// payload. It blocks until ctx is done or a subscription fails, and never closes diffs.
+func (d *DiffTracker) Run(ctx context.Context, c *Client, diffs chan<- *BlockDiff) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 2)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := fn()
+			if ctx.Err() == nil {
+				errc <- err
+			}
+			cancel()
+		}()
+	}
+
+	run(func() error {
+		return NewPipeline(TxStream(nil)).
+			Sink(SinkFunc[*Transaction](func(ctx context.Context, tx *Transaction) error {
+				d.AddTransaction(tx)
+				return nil
+			})).
+			Run(ctx, c)
+	})
+	run(func() error {
+		return NewPipeline(ExecutionPayloadStream()).
+			Sink(SinkFunc[*ExecutionPayload](func(ctx context.Context, p *ExecutionPayload) error {
+				return ChanSink(diffs).Write(ctx, d.AddPayload(p))
+			})).
+			Run(ctx, c)
+	})
+
+	wg.Wait()
+	select {
+	case err := <-errc:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDiffTracker(t *testing.T) {
+	router, other := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+	tx := func(n int64, to common.Address) *Transaction {
+		return &Transaction{Hash: common.BigToHash(big.NewInt(n)), To: &to}
+	}
+
+	d := NewDiffTracker(0)
+	first, second := tx(1, router), tx(2, router)
+	d.Watch(first)
+	d.Watch(second)
+
+	competitor := tx(3, router)
+	d.AddTransaction(competitor)
+	d.AddTransaction(competitor)
+	d.AddTransaction(tx(4, other))
+	d.AddTransaction(first)
+
+	diff := d.AddPayload(&ExecutionPayload{Header: &ExecutionPayloadHeader{Number: 1}, Transactions: []*Transaction{competitor, first}})
+	if len(diff.Included) != 1 || diff.Included[0] != first {
+		t.Fatalf("expected the first transaction to be included, got %v", diff.Included)
+	}
+	if len(diff.Pending) != 1 || diff.Pending[0] != second {
+		t.Fatalf("expected the second transaction to be pending, got %v", diff.Pending)
+	}
+	if len(diff.Competitors) != 1 || diff.Competitors[0] != competitor {
+		t.Fatalf("expected one competitor, got %v", diff.Competitors)
+	}
+
+	// The competitors are per block, and stop once nothing watched calls the contract
+	if diff := d.AddPayload(&ExecutionPayload{Header: &ExecutionPayloadHeader{Number: 2}}); len(diff.Competitors) != 0 || len(diff.Pending) != 1 {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+	d.Unwatch(second.Hash)
+	d.AddTransaction(tx(5, router))
+	if diff := d.AddPayload(&ExecutionPayload{Header: &ExecutionPayloadHeader{Number: 3}}); len(diff.Competitors) != 0 || len(diff.Pending) != 0 || d.Watching() != 0 {
+		t.Fatalf("unexpected diff %+v", diff)
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Broker subscribes to a stream once and fans its messages out to several in-process consumers, every
+// one with its own channel. The consumers share the subscription, so a consumer that doesn't keep up
+// holds back all of them: the broker accounts, per consumer, how long it waited for the consumer to
+// take the messages, and how long after their reception they were delivered, to find the one slowing
+// down the stream.
+type Broker[T any] struct {
+	source Source[T]
+	sub    Subscription
+
+	mu        sync.Mutex
+	consumers []*consumer[T]
+}
+
+type consumer[T any] struct {
+	ch    chan T
+	stats ConsumerStats
+}
+
+// ConsumerStats are the counters of a consumer of a Broker.
+type ConsumerStats struct {
+	Name string
+	// Delivered is the number of messages the consumer took.
+	Delivered uint64
+	// Blocked is the total time the broker waited for the consumer to take the messages, during which
+	// the stream was held back for all the consumers. MaxBlocked is the longest wait.
+	Blocked    time.Duration
+	MaxBlocked time.Duration
+	// Latency is the total time from the reception of the messages by the broker to their delivery to
+	// the consumer. It includes the waits for the consumers added before it, which get every message
+	// first.
+	Latency time.Duration
+}
+
+// MeanLatency returns how long after their reception the messages were delivered to the consumer on
+// average.
+func (s ConsumerStats) MeanLatency() time.Duration {
+	if s.Delivered > 0 {
+		return s.Latency / time.Duration(s.Delivered)
+	}
+
+	return 0
+}
+
+// NewBroker returns a broker of src, without consumers.
+func NewBroker[T any](src Source[T]) *Broker[T] {
+	return &Broker[T]{source: src}
+}
+
+// Consumer adds a consumer called name, which receives every message on the returned channel, buffering
+// up to buffer of them. Consumers must be added before Run, and get the messages in the order they were
+// added.
+func (b *Broker[T]) Consumer(name string, buffer int) <-chan T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := &consumer[T]{ch: make(chan T, buffer), stats: ConsumerStats{Name: name}}
+	b.consumers = append(b.consumers, c)
+	return c.ch
+}
+
+// Stats returns the counters of the consumers, in the order they were added.
+func (b *Broker[T]) Stats() []ConsumerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]ConsumerStats, len(b.consumers))
+	for i, c := range b.consumers {
+		stats[i] = c.stats
+	}
+
+	return stats
+}
+
+// Run subscribes on c with opts, and delivers every message to all the consumers. It blocks until ctx
+// is done or the subscription fails, and closes the channels of the consumers when it returns, so a
+// broker runs once. The subscription has the handle of the broker, so opts mustn't include WithHandle.
+func (b *Broker[T]) Run(ctx context.Context, c *Client, opts ...SubscriptionOption) error {
+	b.mu.Lock()
+	consumers := b.consumers
+	b.mu.Unlock()
+
+	if len(consumers) == 0 {
+		return fmt.Errorf("broker: no consumers")
+	}
+	defer func() {
+		for _, cons := range consumers {
+			close(cons.ch)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan T)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- b.source(c, ch, append(opts[:len(opts):len(opts)], withContext(ctx), WithHandle(&b.sub))...)
+	}()
+
+	// Drains ch until the subscription closes it, so that it never blocks once ctx is done
+	for msg := range ch {
+		received := time.Now()
+		for _, cons := range consumers {
+			if ctx.Err() != nil {
+				break
+			}
+
+			start := time.Now()
+			select {
+			case cons.ch <- msg:
+			case <-ctx.Done():
+				continue
+			}
+
+			now := time.Now()
+			b.delivered(c, cons, now.Sub(start), now.Sub(received))
+		}
+	}
+
+	err := <-errc
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// delivered records that cons took a message after being waited for blocked, latency after its
+// reception.
+func (b *Broker[T]) delivered(c *Client, cons *consumer[T], blocked, latency time.Duration) {
+	b.mu.Lock()
+	s := &cons.stats
+	s.Delivered++
+	s.Blocked += blocked
+	if blocked > s.MaxBlocked {
+		s.MaxBlocked = blocked
+	}
+	s.Latency += latency
+	b.mu.Unlock()
+
+	c.metrics.consumed(b.sub.Stream(), s.Name, blocked, latency)
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/metrics"
+)
+
+func TestBroker(t *testing.T) {
+	reg := metrics.NewRegistry()
+	c := NewClient("", WithMetrics(reg))
+	src := func(c *Client, ch chan<- int, opts ...SubscriptionOption) error {
+		return subscribe(c, "test", ch, opts, fakeStream(1, 2, 3), identity[int])
+	}
+
+	b := NewBroker[int](src)
+	slow, fast := b.Consumer("slow", 0), b.Consumer("fast", 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- b.Run(ctx, c)
+	}()
+
+	for want := 1; want <= 3; want++ {
+		time.Sleep(10 * time.Millisecond)
+		if msg := <-slow; msg != want {
+			t.Fatalf("expected message %d, got %d", want, msg)
+		}
+	}
+	waitFor(t, func() bool { return b.Stats()[1].Delivered == 3 })
+	for want := 1; want <= 3; want++ {
+		if msg := <-fast; msg != want {
+			t.Fatalf("expected message %d, got %d", want, msg)
+		}
+	}
+
+	// The slow consumer blocked the stream, and delayed the fast one
+	stats := b.Stats()
+	if s := stats[0]; s.Name != "slow" || s.Delivered != 3 || s.Blocked < 20*time.Millisecond || s.MaxBlocked < 5*time.Millisecond {
+		t.Fatalf("unexpected stats of the slow consumer %+v", s)
+	}
+	if s := stats[1]; s.Name != "fast" || s.Blocked > stats[0].Blocked/2 || s.MeanLatency() < 5*time.Millisecond {
+		t.Fatalf("unexpected stats of the fast consumer %+v", s)
+	}
+
+	for _, name := range []string{"slow", "fast"} {
+		if n := c.metrics.consumerBlocked.Count("test", name); n != 3 {
+			t.Fatalf("expected 3 deliveries to %s in the metrics, got %d", name, n)
+		}
+	}
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, ok := <-slow; ok {
+		t.Fatal("expected the channels of the consumers to be closed")
+	}
+
+	if err := NewBroker[int](src).Run(context.Background(), c); err == nil {
+		t.Fatal("expected a broker without consumers to fail")
+	}
+}
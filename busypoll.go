@@ -0,0 +1,34 @@
+package client
+
+import "time"
+
+// WithBusyPoll trades CPU for tail latency, for deployments pinning cores to their subscriptions. The
+// subscription's goroutine is locked to its OS thread, and the handoffs it owns busy-poll for up to spin
+// before parking: the delivery on the channel, and the pause buffer (see WithPauseBuffer) if any.
+//
+// gRPC doesn't expose a non-blocking receive, so waiting for the next message from the server still
+// parks. Every busy-polling subscription keeps a core busy while it spins, so GOMAXPROCS must leave
+// room for them, and the consumer should spin on the channel too for the handoff to avoid parking.
+func WithBusyPoll(spin time.Duration) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.busyPoll = spin
+	}
+}
+
+// spinSend tries to send msg on ch without blocking for up to spin, and returns whether it did.
+func spinSend[T any](ch chan<- T, msg T, spin time.Duration) bool {
+	deadline := time.Now().Add(spin)
+
+	for i := 0; ; i++ {
+		select {
+		case ch <- msg:
+			return true
+		default:
+		}
+
+		// Reading the clock costs more than a poll
+		if i%64 == 63 && !time.Now().Before(deadline) {
+			return false
+		}
+	}
+}
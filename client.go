@@ -6,310 +6,621 @@ package client
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
+	"github.com/chainbound/fiber-go/backoff"
 	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/metadata"
 	"github.com/chainbound/fiber-go/protobuf/api"
 	"github.com/chainbound/fiber-go/protobuf/eth"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/encoding/gzip"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 type Client struct {
-	target string
-	conn   *grpc.ClientConn
-	client api.APIClient
-	key    string
+	target      string
+	conn        *grpc.ClientConn
+	client      api.APIClient
+	key         string
+	connectedAt time.Time
+	// sharedConn is set if the connection was passed to NewClientWithConn, in which case it's not
+	// dialed nor closed by the client.
+	sharedConn bool
+
+	reconnectLimiter *backoff.Limiter
+	dial             dialConfig
+	responseHook     func(stream string, md metadata.MD, trailer bool)
+	sendVersion      bool
 
 	// streams
 	txStream       api.API_SendTransactionClient
 	rawTxStream    api.API_SendRawTransactionClient
 	txSeqStream    api.API_SendTransactionSequenceClient
 	rawTxSeqStream api.API_SendRawTransactionSequenceClient
+
+	// gzip compressed variants of the send streams, opened on the first send with SendOptions.Compress.
+	streamCtx        context.Context
+	gzMu             sync.Mutex
+	gzTxStream       api.API_SendTransactionClient
+	gzRawTxStream    api.API_SendRawTransactionClient
+	gzTxSeqStream    api.API_SendTransactionSequenceClient
+	gzRawTxSeqStream api.API_SendRawTransactionSequenceClient
+
+	// sequencers correlating the responses on the sequence streams, created on the first send.
+	maxInFlightSequences int
+	seqMu                sync.Mutex
+	txSeq                *sequencer[*api.TxSequenceMsg]
+	rawTxSeq             *sequencer[*api.RawTxSequenceMsg]
+	gzTxSeq              *sequencer[*api.TxSequenceMsg]
+	gzRawTxSeq           *sequencer[*api.RawTxSequenceMsg]
+	// sequences sent with a replacement UUID, see OutstandingSequences.
+	replacements replacements
+
+	// sender of SendTransactionAsync, on its own stream opened on the first send.
+	asyncMu sync.Mutex
+	async   *asyncSender
+
+	// running subscriptions, for Snapshot
+	subsMu sync.Mutex
+	subs   map[*Subscription]struct{}
+
+	shutdown *ShutdownConfig
+	state    shutdownState
+
+	// drop policies per message class, and the number of messages they shed
+	dropPolicies [numMessageClasses]DropPolicy
+	shed         [numMessageClasses]uint64
+
+	// invalid fields seen by the validating subscriptions, see ValidationErrors
+	validationMu     sync.Mutex
+	validationErrors map[string]uint64
+
+	quota *quotaGuard
+
+	ackLog      *AckLog
+	sendHooks   []SendHook
+	sendArchive *SendArchive
+	metrics     *clientMetrics
+	tracer      Tracer
+
+	// streamCompression is the compressor of the subscription streams, see WithStreamCompression.
+	streamCompression string
+	// profileBuffers are the buffers of the subscriptions per stream, see WithRuntimeProfile.
+	profileBuffers map[StreamKind]int
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithReconnectLimiter makes the client wait for the limiter before connecting and before every
+// re-subscription on a reused Subscription handle. Share one limiter between all the clients in a
+// process to bound the reconnect rate when an endpoint flaps.
+func WithReconnectLimiter(l *backoff.Limiter) ClientOption {
+	return func(c *Client) {
+		c.reconnectLimiter = l
+	}
 }
 
-func NewClient(target, apiKey string) *Client {
-	return &Client{
-		target: target,
-		key:    apiKey,
+// NewClient returns a client for the Fiber API at target, configured by opts. Set the API key with
+// WithAPIKey.
+func NewClient(target string, opts ...ClientOption) *Client {
+	c := &Client{target: target}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
+}
+
+// NewClientWithConn returns a client using an existing connection, for applications that manage their
+// gRPC connections themselves. Connect opens the streams without dialing, and Close leaves conn open.
+// The dial options of the client don't apply.
+func NewClientWithConn(conn *grpc.ClientConn, opts ...ClientOption) *Client {
+	c := NewClient(conn.Target(), opts...)
+	c.conn = conn
+	c.sharedConn = true
+
+	return c
+}
+
+// Target returns the target of the Fiber endpoint the client connects to.
+func (c *Client) Target() string {
+	return c.target
 }
 
 // Connects sets up the gRPC channel and creates the stub. It blocks until connected or the given context expires.
-// Always use a context with timeout.
+// Always use a context with timeout. Connecting a connected client does nothing, and a closed one fails with
+// ErrClientClosed. If it fails, the client is left unconnected, and Connect can be retried.
 func (c *Client) Connect(ctx context.Context) error {
-	conn, err := grpc.DialContext(ctx, c.target,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithBlock(),
-		grpc.WithReadBufferSize(0),
-		grpc.WithWriteBufferSize(0),
-	)
-	if err != nil {
+	prev, ok := c.transition(StateConnecting, StateNew)
+	switch {
+	case prev == StateReady:
+		return nil
+	case prev == StateClosing || prev == StateClosed:
+		return ErrClientClosed
+	case !ok:
+		return fmt.Errorf("connect while %s: %w", prev, ErrInvalidState)
+	}
+
+	if err := c.connect(ctx); err != nil {
+		c.closeStreams()
+		if !c.sharedConn && c.conn != nil {
+			c.conn.Close()
+			c.conn = nil
+		}
+		c.transition(StateNew, StateConnecting)
 		return err
 	}
 
-	c.conn = conn
+	c.transition(StateReady, StateConnecting)
+	return nil
+}
+
+func (c *Client) connect(ctx context.Context) error {
+	if c.dial.connectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.dial.connectTimeout)
+		defer cancel()
+	}
+
+	if err := c.reconnectLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	if !c.sharedConn {
+		// A handshake failure only shows as a timeout of the blocking dial
+		if c.dial.clientCert != nil {
+			if _, err := c.dial.clientCert(); err != nil {
+				return err
+			}
+		}
+
+		conn, err := grpc.DialContext(ctx, c.target, c.dialOptions()...)
+		if err != nil {
+			return err
+		}
+
+		c.conn = conn
+	}
+
+	c.connectedAt = time.Now()
 
 	// Create the stub (client) with the channel
-	c.client = api.NewAPIClient(conn)
+	c.client = api.NewAPIClient(c.conn)
 
-	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-api-key", c.key)
+	var err error
+	ctx = c.outgoingContext(context.Background())
+	c.streamCtx = ctx
 	c.txStream, err = c.client.SendTransaction(ctx)
 	if err != nil {
-		return err
+		return streamError(err)
 	}
+	c.watchHeader("send_tx", c.txStream)
 
 	c.rawTxStream, err = c.client.SendRawTransaction(ctx)
 	if err != nil {
-		return err
+		return streamError(err)
 	}
+	c.watchHeader("send_raw_tx", c.rawTxStream)
 
 	c.txSeqStream, err = c.client.SendTransactionSequence(ctx)
 	if err != nil {
-		return err
+		return streamError(err)
 	}
+	c.watchHeader("send_tx_sequence", c.txSeqStream)
 
 	c.rawTxSeqStream, err = c.client.SendRawTransactionSequence(ctx)
 	if err != nil {
-		return err
+		return streamError(err)
 	}
+	c.watchHeader("send_raw_tx_sequence", c.rawTxSeqStream)
 
 	return nil
 }
 
-// Close closes all the streams and then the underlying connection. IMPORTANT: you should call this
-// to ensure correct API accounting.
+// outgoingContext returns a copy of ctx with the metadata sent on every call.
+func (c *Client) outgoingContext(ctx context.Context) context.Context {
+	ctx = metadata.WithAPIKey(ctx, c.key)
+	if c.sendVersion {
+		ctx = metadata.WithClientVersion(ctx, "fiber-go/"+Version())
+	}
+	return ctx
+}
+
+// Close closes all the streams and then the underlying connection, unless it was passed to NewClientWithConn.
+// IMPORTANT: you should call this to ensure correct API accounting. Closing a closed client does nothing, and
+// waits for a Close in progress to finish; closing a client while it connects fails with ErrInvalidState.
 func (c *Client) Close() error {
-	c.txStream.CloseSend()
-	c.rawTxStream.CloseSend()
-	c.txSeqStream.CloseSend()
-	c.rawTxSeqStream.CloseSend()
+	prev, ok := c.transition(StateClosing, StateNew, StateReady)
+	if !ok {
+		if prev == StateConnecting {
+			return fmt.Errorf("close while %s: %w", prev, ErrInvalidState)
+		}
+
+		<-c.closed()
+		return nil
+	}
+	defer c.finishClose()
+
+	if prev == StateNew {
+		return nil
+	}
+
+	var shutdownErr error
+	if c.shutdown != nil {
+		shutdownErr = c.gracefulShutdown()
+	}
+
+	c.closeStreams()
+
+	if c.sharedConn {
+		return shutdownErr
+	}
+
+	if err := c.conn.Close(); err != nil {
+		return err
+	}
 
-	return c.conn.Close()
+	return shutdownErr
+}
+
+// closeStreams closes the send streams that were opened.
+func (c *Client) closeStreams() {
+	for _, stream := range []interface{ CloseSend() error }{c.txStream, c.rawTxStream, c.txSeqStream, c.rawTxSeqStream} {
+		if stream != nil {
+			stream.CloseSend()
+		}
+	}
+
+	c.gzMu.Lock()
+	for _, stream := range []interface{ CloseSend() error }{c.gzTxStream, c.gzRawTxStream, c.gzTxSeqStream, c.gzRawTxSeqStream} {
+		if stream != nil {
+			stream.CloseSend()
+		}
+	}
+	c.gzMu.Unlock()
+
+	c.asyncMu.Lock()
+	if c.async != nil {
+		c.async.stream.CloseSend()
+	}
+	c.asyncMu.Unlock()
+}
+
+// compressedStream returns the gzip compressed stream stored in s, opening it first if needed.
+func compressedStream[S grpc.ClientStream](c *Client, name string, s *S, open func(context.Context, ...grpc.CallOption) (S, error)) (S, error) {
+	c.gzMu.Lock()
+	defer c.gzMu.Unlock()
+
+	if any(*s) != nil {
+		return *s, nil
+	}
+
+	stream, err := open(c.streamCtx, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return stream, fmt.Errorf("opening compressed stream: %w", streamError(err))
+	}
+
+	*s = stream
+	c.watchHeader(name, stream)
+	return stream, nil
 }
 
 // SendTransaction sends the (signed) transaction to Fibernet and returns the hash and a timestamp (us).
+// The timestamp is when the Fiber node received the transaction, in microseconds since the Unix epoch.
 // It blocks until the transaction was sent.
-func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) (string, int64, error) {
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) (hash string, timestamp int64, err error) {
+	ctx, span := startSpan(c.tracer, ctx, "fiber.SendTransaction")
+	defer func() { endSend(span, hash, timestamp, err) }()
+
+	if err := c.beginSend(); err != nil {
+		return "", 0, err
+	}
+	defer c.endSend()
+
+	opts := SendOptionsFromContext(ctx)
+	if opts.VerifyEncoding {
+		if err := verifyEncoding(tx); err != nil {
+			return "", 0, fmt.Errorf("verifying encoding: %w", err)
+		}
+	}
+
+	if err := c.auditTxs(ctx, []*types.Transaction{tx}); err != nil {
+		return "", 0, err
+	}
+
 	proto, err := TxToProto(tx)
 	if err != nil {
 		return "", 0, fmt.Errorf("converting to protobuf: %w", err)
 	}
 
-	errc := make(chan error)
-	go func() {
-		if err := c.txStream.Send(proto); err != nil {
-			errc <- err
+	stream := c.txStream
+	if opts.Compress {
+		if stream, err = compressedStream(c, "gz_send_tx", &c.gzTxStream, c.client.SendTransaction); err != nil {
+			return "", 0, err
 		}
+	}
+
+	start := time.Now()
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		// A failed send ends the stream, and Recv returns why
+		stream.Send(proto)
 	}()
 
-	for {
-		select {
-		case err := <-errc:
-			return "", 0, err
-		default:
-		}
+	res, err := stream.Recv()
+	// The stream must not be used after returning, e.g. closed, while the send is still running
+	<-sent
+	c.metrics.sent("send_transaction", start, err)
+	opts.capture(stream, err, err != nil)
+	if err != nil {
+		return "", 0, sendError(err)
+	}
 
-		res, err := c.txStream.Recv()
-		if err != nil {
+	c.logAck(res.Hash, res.Timestamp)
+	return res.Hash, res.Timestamp, nil
+}
+
+// SendRawTransaction sends the RLP encoded, signed transaction to Fibernet and returns the hash and a
+// timestamp (us), like SendTransaction.
+func (c *Client) SendRawTransaction(ctx context.Context, rawTx []byte) (hash string, timestamp int64, err error) {
+	ctx, span := startSpan(c.tracer, ctx, "fiber.SendRawTransaction")
+	defer func() { endSend(span, hash, timestamp, err) }()
+
+	if err := c.beginSend(); err != nil {
+		return "", 0, err
+	}
+	defer c.endSend()
+
+	if err := c.auditRaw(ctx, [][]byte{rawTx}); err != nil {
+		return "", 0, err
+	}
+
+	opts := SendOptionsFromContext(ctx)
+	stream := c.rawTxStream
+	if opts.Compress {
+		var err error
+		if stream, err = compressedStream(c, "gz_send_raw_tx", &c.gzRawTxStream, c.client.SendRawTransaction); err != nil {
 			return "", 0, err
-		} else {
-			return res.Hash, res.Timestamp, nil
 		}
 	}
-}
 
-func (c *Client) SendRawTransaction(ctx context.Context, rawTx []byte) (string, int64, error) {
-	errc := make(chan error)
+	start := time.Now()
+	sent := make(chan struct{})
 	go func() {
-		if err := c.rawTxStream.Send(&api.RawTxMsg{RawTx: rawTx}); err != nil {
-			errc <- err
-		}
+		defer close(sent)
+		// A failed send ends the stream, and Recv returns why
+		stream.Send(&api.RawTxMsg{RawTx: rawTx})
 	}()
 
-	for {
-		select {
-		case err := <-errc:
-			return "", 0, err
-		default:
-		}
-
-		res, err := c.rawTxStream.Recv()
-		if err != nil {
-			return "", 0, err
-		} else {
-			return res.Hash, res.Timestamp, nil
-		}
+	res, err := stream.Recv()
+	// The stream must not be used after returning, e.g. closed, while the send is still running
+	<-sent
+	c.metrics.sent("send_raw_transaction", start, err)
+	opts.capture(stream, err, err != nil)
+	if err != nil {
+		return "", 0, sendError(err)
 	}
+
+	c.logAck(res.Hash, res.Timestamp)
+	return res.Hash, res.Timestamp, nil
 }
 
+// SendTransactionSequence sends the transactions as a sequence, and returns their hashes and the
+// timestamp (us) of the first one. It returns a *SequenceError if the server didn't accept the whole
+// sequence in order.
 func (c *Client) SendTransactionSequence(ctx context.Context, transactions ...*types.Transaction) ([]string, int64, error) {
-	errc := make(chan error)
+	res, err := c.SendTransactionSequenceResult(ctx, transactions...)
+	if res == nil {
+		return nil, 0, err
+	}
+
+	return res.Hashes(), res.Timestamp(), err
+}
+
+// SendTransactionSequenceResult is SendTransactionSequence, returning the response for every transaction.
+// The result is also returned with a *SequenceError.
+func (c *Client) SendTransactionSequenceResult(ctx context.Context, transactions ...*types.Transaction) (*SequenceResult, error) {
+	if err := c.beginSend(); err != nil {
+		return nil, err
+	}
+	defer c.endSend()
 
 	protoSeq := make([]*eth.Transaction, len(transactions))
+	hashes := make([]common.Hash, len(transactions))
+	opts := SendOptionsFromContext(ctx)
 
 	for i, tx := range transactions {
+		if opts.VerifyEncoding {
+			if err := verifyEncoding(tx); err != nil {
+				return nil, fmt.Errorf("verifying encoding of transaction %d: %w", i, err)
+			}
+		}
+
 		proto, err := TxToProto(tx)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 
 		protoSeq[i] = proto
+		hashes[i] = tx.Hash()
 	}
 
-	go func() {
-		if err := c.txSeqStream.Send(&api.TxSequenceMsg{Sequence: protoSeq}); err != nil {
-			errc <- err
-		}
-	}()
+	if err := c.auditTxs(ctx, transactions); err != nil {
+		return nil, err
+	}
 
-	for {
-		select {
-		case err := <-errc:
-			return nil, 0, err
-		default:
+	var seq *sequencer[*api.TxSequenceMsg]
+	stream := c.txSeqStream
+	if opts.Compress {
+		var err error
+		if stream, err = compressedStream(c, "gz_send_tx_sequence", &c.gzTxSeqStream, c.client.SendTransactionSequence); err != nil {
+			return nil, err
 		}
+		seq = sequencerFor(c, &c.gzTxSeq, sequenceStream[*api.TxSequenceMsg](stream))
+	} else {
+		seq = sequencerFor(c, &c.txSeq, sequenceStream[*api.TxSequenceMsg](stream))
+	}
 
-		res, err := c.txSeqStream.Recv()
-		if err != nil {
-			return nil, 0, err
-		}
-
-		hashes := make([]string, len(res.SequenceResponse))
-		ts := res.SequenceResponse[0].Timestamp
-
-		for i, response := range res.SequenceResponse {
-			hashes[i] = response.Hash
-		}
+	start := time.Now()
+	res, err := seq.submit(ctx, &api.TxSequenceMsg{Sequence: protoSeq})
+	c.metrics.sent("send_transaction_sequence", start, err)
+	// The trailer is only safe to read once the receiving side failed
+	opts.capture(stream, err, seq.recvErr() != nil)
+	if err != nil {
+		return nil, err
+	}
 
-		return hashes, ts, nil
+	result, err := c.logSequenceAcks(verifySequence(hashes, res))
+	if err == nil {
+		c.replacements.track(opts.ReplacementUUID, hashes)
 	}
+	return result, err
 }
 
+// SendRawTransactionSequence sends the raw transactions as a sequence, see SendTransactionSequence.
 func (c *Client) SendRawTransactionSequence(ctx context.Context, rawTransactions ...[]byte) ([]string, int64, error) {
-	errc := make(chan error)
-
-	go func() {
-		if err := c.rawTxSeqStream.Send(&api.RawTxSequenceMsg{RawTxs: rawTransactions}); err != nil {
-			errc <- err
-		}
-	}()
+	res, err := c.SendRawTransactionSequenceResult(ctx, rawTransactions...)
+	if res == nil {
+		return nil, 0, err
+	}
 
-	for {
-		select {
-		case err := <-errc:
-			return nil, 0, err
-		default:
-		}
+	return res.Hashes(), res.Timestamp(), err
+}
 
-		res, err := c.rawTxSeqStream.Recv()
-		if err != nil {
-			return nil, 0, err
-		}
+// SendRawTransactionSequenceResult is SendRawTransactionSequence, returning the response for every
+// transaction. The result is also returned with a *SequenceError.
+func (c *Client) SendRawTransactionSequenceResult(ctx context.Context, rawTransactions ...[]byte) (*SequenceResult, error) {
+	if err := c.beginSend(); err != nil {
+		return nil, err
+	}
+	defer c.endSend()
 
-		hashes := make([]string, len(res.SequenceResponse))
+	hashes := make([]common.Hash, len(rawTransactions))
+	for i, raw := range rawTransactions {
+		hashes[i] = crypto.Keccak256Hash(raw)
+	}
 
-		ts := res.SequenceResponse[0].Timestamp
+	if err := c.auditRaw(ctx, rawTransactions); err != nil {
+		return nil, err
+	}
 
-		for i, response := range res.SequenceResponse {
-			hashes[i] = response.Hash
+	opts := SendOptionsFromContext(ctx)
+	var seq *sequencer[*api.RawTxSequenceMsg]
+	stream := c.rawTxSeqStream
+	if opts.Compress {
+		var err error
+		if stream, err = compressedStream(c, "gz_send_raw_tx_sequence", &c.gzRawTxSeqStream, c.client.SendRawTransactionSequence); err != nil {
+			return nil, err
 		}
+		seq = sequencerFor(c, &c.gzRawTxSeq, sequenceStream[*api.RawTxSequenceMsg](stream))
+	} else {
+		seq = sequencerFor(c, &c.rawTxSeq, sequenceStream[*api.RawTxSequenceMsg](stream))
+	}
 
-		return hashes, ts, nil
+	start := time.Now()
+	res, err := seq.submit(ctx, &api.RawTxSequenceMsg{RawTxs: rawTransactions})
+	c.metrics.sent("send_raw_transaction_sequence", start, err)
+	// The trailer is only safe to read once the receiving side failed
+	opts.capture(stream, err, seq.recvErr() != nil)
+	if err != nil {
+		return nil, err
 	}
+
+	result, err := c.logSequenceAcks(verifySequence(hashes, res))
+	if err == nil {
+		c.replacements.track(opts.ReplacementUUID, hashes)
+	}
+	return result, err
 }
 
 // SubscribeNewTxs subscribes to new transactions, and sends transactions on the given
 // channel according to the filter. This function blocks and should be called in a goroutine.
 // If there's an error receiving the new message it will close the channel and return the error.
-func (c *Client) SubscribeNewTxs(filter *filter.Filter, ch chan<- *Transaction) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", c.key)
-
+// A malformed filter is rejected before subscribing (see filter.Filter.Validate). The filter can be
+// replaced while the subscription runs, with UpdateFilter on its handle.
+func (c *Client) SubscribeNewTxs(filter *filter.Filter, ch chan<- *Transaction, opts ...SubscriptionOption) error {
 	protoFilter := &api.TxFilter{}
 	if filter != nil {
+		if err := filter.Validate(); err != nil {
+			return err
+		}
 		protoFilter.Encoded = filter.Encode()
 	}
 
-	res, err := c.client.SubscribeNewTxs(ctx, protoFilter)
-	if err != nil {
-		return fmt.Errorf("subscribing to transactions: %w", err)
-	}
-
-	for {
-		proto, err := res.Recv()
+	live := &liveFilter{proto: protoFilter}
+	opts = append(opts[:len(opts):len(opts)], withLiveFilter(live))
+	return subscribe(c, StreamTxs, ch, opts, func(ctx context.Context, callOpts ...grpc.CallOption) (recvStream[*eth.Transaction], error) {
+		res, err := c.client.SubscribeNewTxs(ctx, live.get(), callOpts...)
 		if err != nil {
-			close(ch)
-			return err
+			return nil, fmt.Errorf("subscribing to transactions: %w", err)
 		}
 
-		ch <- ProtoToTx(proto)
-	}
+		return res, nil
+	}, ProtoToTx)
 }
 
-func (c *Client) SubscribeNewExecutionPayloadHeaders(ch chan<- *ExecutionPayloadHeader) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", c.key)
-
-	res, err := c.client.SubscribeExecutionHeaders(ctx, &emptypb.Empty{})
-	if err != nil {
-		return fmt.Errorf("subscribing to blocks: %w", err)
-	}
-
-	for {
-		proto, err := res.Recv()
+func (c *Client) SubscribeNewExecutionPayloadHeaders(ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error {
+	return subscribeFrom(c, StreamHeaders, ch, opts, func(ctx context.Context, callOpts ...grpc.CallOption) (recvStream[*eth.ExecutionPayloadHeader], error) {
+		res, err := c.client.SubscribeExecutionHeaders(ctx, &emptypb.Empty{}, callOpts...)
 		if err != nil {
-			close(ch)
-			return err
+			return nil, fmt.Errorf("subscribing to blocks: %w", err)
 		}
 
-		ch <- ProtoToHeader(proto)
-	}
+		return res, nil
+	}, ProtoToHeader, headerBackfiller)
 }
 
-func (c *Client) SubscribeNewExecutionPayloads(ch chan<- *ExecutionPayload) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", c.key)
+func (c *Client) SubscribeNewExecutionPayloads(ch chan<- *ExecutionPayload, opts ...SubscriptionOption) error {
+	return subscribeFrom(c, StreamPayloads, ch, opts, func(ctx context.Context, callOpts ...grpc.CallOption) (recvStream[*eth.ExecutionPayload], error) {
+		res, err := c.client.SubscribeExecutionPayloads(ctx, &emptypb.Empty{}, callOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("subscribing to blocks: %w", err)
+		}
 
-	res, err := c.client.SubscribeExecutionPayloads(ctx, &emptypb.Empty{})
-	if err != nil {
-		return fmt.Errorf("subscribing to blocks: %w", err)
-	}
+		return res, nil
+	}, ProtoToBlock, payloadBackfiller)
+}
 
-	for {
-		proto, err := res.Recv()
+func (c *Client) SubscribeNewBeaconBlocks(ch chan<- *BeaconBlock, opts ...SubscriptionOption) error {
+	return subscribeFrom(c, StreamBeacon, ch, opts, func(ctx context.Context, callOpts ...grpc.CallOption) (recvStream[*eth.CompactBeaconBlock], error) {
+		res, err := c.client.SubscribeBeaconBlocks(ctx, &emptypb.Empty{}, callOpts...)
 		if err != nil {
-			close(ch)
-			return err
+			return nil, fmt.Errorf("subscribing to blocks: %w", err)
 		}
 
-		ch <- ProtoToBlock(proto)
-	}
+		return res, nil
+	}, ProtoToBeaconBlock, beaconBackfiller)
 }
 
-func (c *Client) SubscribeNewBeaconBlocks(ch chan<- *BeaconBlock) error {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", c.key)
+// SubscribeNewTxsWithContext is SubscribeNewTxs, ending the subscription with ctx.Err() once ctx is
+// done. The values of ctx are visible to the enrichers (see WithValues).
+func (c *Client) SubscribeNewTxsWithContext(ctx context.Context, filter *filter.Filter, ch chan<- *Transaction, opts ...SubscriptionOption) error {
+	return c.SubscribeNewTxs(filter, ch, append(opts[:len(opts):len(opts)], withContext(ctx))...)
+}
 
-	res, err := c.client.SubscribeBeaconBlocks(ctx, &emptypb.Empty{})
-	if err != nil {
-		return fmt.Errorf("subscribing to blocks: %w", err)
-	}
+// SubscribeNewExecutionPayloadHeadersWithContext is SubscribeNewExecutionPayloadHeaders, ending the
+// subscription with ctx.Err() once ctx is done.
+func (c *Client) SubscribeNewExecutionPayloadHeadersWithContext(ctx context.Context, ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error {
+	return c.SubscribeNewExecutionPayloadHeaders(ch, append(opts[:len(opts):len(opts)], withContext(ctx))...)
+}
 
-	for {
-		proto, err := res.Recv()
-		if err != nil {
-			close(ch)
-			return err
-		}
+// SubscribeNewExecutionPayloadsWithContext is SubscribeNewExecutionPayloads, ending the subscription
+// with ctx.Err() once ctx is done.
+func (c *Client) SubscribeNewExecutionPayloadsWithContext(ctx context.Context, ch chan<- *ExecutionPayload, opts ...SubscriptionOption) error {
+	return c.SubscribeNewExecutionPayloads(ch, append(opts[:len(opts):len(opts)], withContext(ctx))...)
+}
 
-		ch <- ProtoToBeaconBlock(proto)
-	}
+// SubscribeNewBeaconBlocksWithContext is SubscribeNewBeaconBlocks, ending the subscription with
+// ctx.Err() once ctx is done.
+func (c *Client) SubscribeNewBeaconBlocksWithContext(ctx context.Context, ch chan<- *BeaconBlock, opts ...SubscriptionOption) error {
+	return c.SubscribeNewBeaconBlocks(ch, append(opts[:len(opts):len(opts)], withContext(ctx))...)
 }
@@ -0,0 +1,370 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/metadata"
+	"github.com/chainbound/fiber-go/metrics"
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+func TestClientOptions(t *testing.T) {
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, api.UnimplementedAPIServer{})
+
+	srv := httptest.NewUnstartedServer(server)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	var keys []string
+	intercept := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		key, _ := metadata.OutgoingAPIKey(ctx)
+		keys = append(keys, key)
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+
+	c := NewClient(srv.Listener.Addr().String(),
+		WithAPIKey("key"),
+		WithTLS(&tls.Config{RootCAs: roots}),
+		WithInterceptors(nil, []grpc.StreamClientInterceptor{intercept}),
+		WithBufferSizes(32<<10, 32<<10),
+	)
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+
+	// The send streams are opened on connect
+	if len(keys) != 4 || keys[0] != "key" {
+		t.Fatalf("expected 4 streams with the API key, got %v", keys)
+	}
+
+	// The default configuration doesn't trust the test certificate
+	if err := NewClient(srv.Listener.Addr().String(), WithTLS(nil), WithConnectTimeout(200*time.Millisecond)).Connect(context.Background()); err == nil {
+		t.Fatal("expected the certificate to be rejected")
+	}
+}
+
+func TestKeepalive(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 10 * time.Second, PermitWithoutStream: true}))
+	api.RegisterAPIServer(server, api.UnimplementedAPIServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(lis.Addr().String(), WithKeepalive(30*time.Second, 5*time.Second, true),
+		WithInitialWindowSize(1<<20), WithInitialConnWindowSize(1<<22))
+	if _, err := c.ConnectionInfo(); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState before connecting, got %v", err)
+	}
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	info, err := c.ConnectionInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.KeepaliveInterval != 30*time.Second || info.KeepaliveTimeout != 5*time.Second {
+		t.Fatalf("unexpected keepalive %s/%s", info.KeepaliveInterval, info.KeepaliveTimeout)
+	}
+	if info.InitialWindowSize != 1<<20 || info.InitialConnWindowSize != 1<<22 {
+		t.Fatalf("unexpected windows %d/%d", info.InitialWindowSize, info.InitialConnWindowSize)
+	}
+}
+
+// writeCertificate writes a self-signed certificate with the serial, and its key, to the files.
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The modification times are set explicitly, for file systems with a coarse resolution
+	modified := time.Now().Add(time.Duration(serial) * time.Second)
+	for file, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, api.UnimplementedAPIServer{})
+
+	var mu sync.Mutex
+	var serials []int64
+	srv := httptest.NewUnstartedServer(server)
+	srv.EnableHTTP2 = true
+	srv.TLS = &tls.Config{
+		ClientAuth: tls.RequireAnyClientCert,
+		VerifyPeerCertificate: func(raw [][]byte, _ [][]*x509.Certificate) error {
+			cert, err := x509.ParseCertificate(raw[0])
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			serials = append(serials, cert.SerialNumber.Int64())
+			mu.Unlock()
+			return nil
+		},
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	roots := srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+	opts := []ClientOption{WithTLS(&tls.Config{RootCAs: roots}), WithClientCertificate(certFile, keyFile)}
+
+	if err := NewClient(srv.Listener.Addr().String(), opts...).Connect(ctx); err == nil {
+		t.Fatal("expected the missing certificate to fail the connection")
+	}
+
+	connect := func() {
+		t.Helper()
+
+		c := NewClient(srv.Listener.Addr().String(), opts...)
+		if err := c.Connect(ctx); err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+	}
+
+	writeCertificate(t, certFile, keyFile, 1)
+	connect()
+
+	// A rotation caught halfway keeps the previous certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	connect()
+
+	writeCertificate(t, certFile, keyFile, 2)
+	connect()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(serials) != 3 || serials[0] != 1 || serials[1] != 1 || serials[2] != 2 {
+		t.Fatalf("expected the certificates 1, 1 and 2, got %v", serials)
+	}
+}
+
+func TestSendHook(t *testing.T) {
+	server, addr := startServer(t, 1)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	vetoed := errors.New("nonce too high")
+	var seen []uint64
+	c := NewClient(addr, WithSendHook(func(ctx context.Context, tx *OutboundTx) error {
+		seen = append(seen, tx.Tx.Nonce())
+		if tx.Tx.Nonce() > 1 {
+			return vetoed
+		}
+		return nil
+	}))
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	raw := func(nonce uint64) []byte {
+		b, err := signTx(t, &types.DynamicFeeTx{ChainID: common.Big1, Nonce: nonce, Gas: 21000}).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	if _, _, err := c.SendRawTransaction(ctx, raw(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := c.SendRawTransactionSequenceResult(ctx, raw(0), raw(2))
+	var vetoErr *VetoError
+	if !errors.As(err, &vetoErr) || vetoErr.Index != 1 || !errors.Is(err, vetoed) {
+		t.Fatalf("expected the sequence to be vetoed, got %v", err)
+	}
+	if len(seen) != 3 || seen[0] != 1 || seen[1] != 0 || seen[2] != 2 {
+		t.Fatalf("expected the hook to see every transaction, got %v", seen)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	server, addr := startServer(t, 1)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reg := metrics.NewRegistry()
+	c := NewClient(addr, WithMetrics(reg))
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, _, err := c.SendRawTransaction(ctx, []byte{1}); err != nil {
+		t.Fatal(err)
+	}
+
+	var sub Subscription
+	ch := make(chan *Transaction, 2)
+	go subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub), WithUnknownTxPolicy(SkipUnknownTxs, nil)},
+		fakeStream(&Transaction{Type: 0x7f}, &Transaction{}), identity[*Transaction])
+	defer sub.Unsubscribe()
+	<-ch
+
+	var b strings.Builder
+	reg.Write(&b)
+	for _, line := range []string{
+		`fiber_messages_received_total{stream="test"} 2`,
+		`fiber_messages_dropped_total{stream="test"} 1`,
+		`fiber_decode_seconds_count{stream="test"} 2`,
+		`fiber_send_seconds_count{method="send_raw_transaction"} 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("expected %s in:\n%s", line, b.String())
+		}
+	}
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+func (s *testSpan) RecordError(err error) { s.err = err }
+func (s *testSpan) End()                  { s.ended = true }
+
+type testTracer struct {
+	mu    sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	span := &testSpan{name: name, attrs: make(map[string]interface{})}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestTracer(t *testing.T) {
+	server, addr := startServer(t, 1)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tracer := &testTracer{}
+	c := NewClient(addr, WithTracer(tracer))
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	hash, timestamp, err := c.SendRawTransaction(ctx, []byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sub Subscription
+	ch := make(chan *Transaction, 1)
+	tx := &Transaction{Hash: common.HexToHash("0x01")}
+	done := make(chan error)
+	go func() {
+		done <- subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub)}, fakeStream(tx), identity[*Transaction])
+	}()
+	<-ch
+	sub.Unsubscribe()
+	<-done
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(tracer.spans))
+	}
+
+	send := tracer.spans[0]
+	if send.name != "fiber.SendRawTransaction" || !send.ended || send.attrs[AttrTxHash] != hash || send.attrs[AttrTimestamp] != timestamp {
+		t.Errorf("unexpected send span %+v", send)
+	}
+
+	msg := tracer.spans[1]
+	if msg.name != "fiber.test" || !msg.ended || msg.err != nil || msg.attrs[AttrTxHash] != tx.Hash.Hex() || msg.attrs[AttrReceivedAt] == nil {
+		t.Errorf("unexpected message span %+v", msg)
+	}
+}
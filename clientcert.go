@@ -0,0 +1,75 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithClientCertificate authenticates the client with the certificate and key in the given PEM files,
+// for endpoints requiring mutual TLS. It implies WithTLS(nil) if WithTLS isn't set. The files are
+// checked for changes on every handshake, so a rotated certificate is used from the next connection
+// on: the open connections keep theirs, and no connection is torn down because of a rotation. A
+// rotation caught halfway, like a new certificate with the old key, keeps the previous certificate
+// until both files match. The clients created with the same option share the loaded certificate.
+func WithClientCertificate(certFile, keyFile string) ClientOption {
+	loader := &certFiles{certFile: certFile, keyFile: keyFile}
+	return func(c *Client) {
+		c.dial.clientCert = loader.get
+	}
+}
+
+// WithClientCertificateFunc authenticates the client with the certificate returned by get, which is
+// called on every handshake, for certificates managed by the application. It implies WithTLS(nil) if
+// WithTLS isn't set.
+func WithClientCertificateFunc(get func() (*tls.Certificate, error)) ClientOption {
+	return func(c *Client) {
+		c.dial.clientCert = get
+	}
+}
+
+// certFiles loads a certificate from files, reloading it when they change.
+type certFiles struct {
+	certFile, keyFile string
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modified [2]time.Time
+}
+
+func (f *certFiles) get() (*tls.Certificate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var modified [2]time.Time
+	for i, name := range []string{f.certFile, f.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return f.loaded(fmt.Errorf("loading client certificate: %w", err))
+		}
+		modified[i] = info.ModTime()
+	}
+
+	if f.cert != nil && modified == f.modified {
+		return f.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return f.loaded(fmt.Errorf("loading client certificate: %w", err))
+	}
+
+	f.cert, f.modified = &cert, modified
+	return f.cert, nil
+}
+
+// loaded returns the certificate loaded before, if any, or err.
+func (f *certFiles) loaded(err error) (*tls.Certificate, error) {
+	if f.cert != nil {
+		return f.cert, nil
+	}
+
+	return nil, err
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"time"
+
+	"github.com/chainbound/fiber-go/metrics"
+)
+
+// clientMetrics are the metrics of a client, labeled by stream or send method.
+type clientMetrics struct {
+	received   *metrics.Counter
+	dropped    *metrics.Counter
+	reconnects *metrics.Counter
+	decode     *metrics.Histogram
+	send       *metrics.Histogram
+	sendErrors *metrics.Counter
+	invalids   *metrics.Counter
+	// of the consumers of the brokers
+	consumerBlocked *metrics.Histogram
+	consumerLatency *metrics.Histogram
+}
+
+// WithMetrics exports the metrics of the client to reg: messages received and dropped, reconnects and
+// decode time per stream, send latency and errors per send method, and the wait for and delivery latency
+// of every consumer of a Broker. Clients can share a registry.
+func WithMetrics(reg *metrics.Registry) ClientOption {
+	return func(c *Client) {
+		c.metrics = &clientMetrics{
+			received:   reg.Counter("fiber_messages_received_total", "Messages received per stream.", "stream"),
+			dropped:    reg.Counter("fiber_messages_dropped_total", "Messages received but never delivered to the consumer per stream.", "stream"),
+			reconnects: reg.Counter("fiber_reconnects_total", "Stream reconnects, forced or by re-subscribing with a handle.", "stream"),
+			decode:     reg.Histogram("fiber_decode_seconds", "Time to convert a message from protobuf per stream.", metrics.DefaultLatencyBuckets, "stream"),
+			send:       reg.Histogram("fiber_send_seconds", "Time from sending to the response per send method.", metrics.DefaultLatencyBuckets, "method"),
+			sendErrors: reg.Counter("fiber_send_errors_total", "Failed sends per send method.", "method"),
+			invalids:   reg.Counter("fiber_validation_errors_total", "Missing or malformed fields per stream and field, see WithValidation.", "stream", "field"),
+			consumerBlocked: reg.Histogram("fiber_consumer_blocked_seconds", "Time a Broker waited for a consumer to take a message per stream and consumer.",
+				metrics.DefaultLatencyBuckets, "stream", "consumer"),
+			consumerLatency: reg.Histogram("fiber_consumer_delivery_seconds", "Time from the reception of a message by a Broker to its delivery per stream and consumer.",
+				metrics.DefaultLatencyBuckets, "stream", "consumer"),
+		}
+	}
+}
+
+// timedConvert counts and times the conversions of the messages of a stream.
+func timedConvert[P, T any](m *clientMetrics, stream StreamKind, convert func(P) T) func(P) T {
+	if m == nil {
+		return convert
+	}
+
+	return func(proto P) T {
+		start := time.Now()
+		msg := convert(proto)
+		m.decode.Observe(time.Since(start).Seconds(), string(stream))
+		m.received.Add(1, string(stream))
+		return msg
+	}
+}
+
+func (m *clientMetrics) reconnected(stream StreamKind) {
+	if m != nil {
+		m.reconnects.Add(1, string(stream))
+	}
+}
+
+// onDrop returns the drop counter of a stream, for Subscription.dropped.
+func (m *clientMetrics) onDrop(stream StreamKind) func(n uint64) {
+	if m == nil {
+		return nil
+	}
+
+	return func(n uint64) {
+		m.dropped.Add(n, string(stream))
+	}
+}
+
+// invalid counts the invalid fields of a message of a stream.
+func (m *clientMetrics) invalid(stream StreamKind, err *ValidationError) {
+	if m == nil {
+		return
+	}
+
+	for _, field := range err.Fields {
+		m.invalids.Add(1, string(stream), err.Message+"."+field)
+	}
+}
+
+// sent records a send of the method that started at start.
+func (m *clientMetrics) sent(method string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+
+	m.send.Observe(time.Since(start).Seconds(), method)
+	if err != nil {
+		m.sendErrors.Add(1, method)
+	}
+}
+
+// consumed records that the consumer of a broker of stream took a message after being waited for
+// blocked, latency after its reception.
+func (m *clientMetrics) consumed(stream StreamKind, consumer string, blocked, latency time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.consumerBlocked.Observe(blocked.Seconds(), string(stream), consumer)
+	m.consumerLatency.Observe(latency.Seconds(), string(stream), consumer)
+}
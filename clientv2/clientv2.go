@@ -0,0 +1,266 @@
+// Package clientv2 is the next version of the Fiber client API. Every call takes a context, subscriptions
+// return a handle instead of blocking, and sends are safe for concurrent use.
+//
+// It's built on the current client package, which keeps working unchanged. A v1 client can be wrapped
+// with Wrap, and the v1 client of a v2 one is returned by V1, so both APIs can be used on the same
+// connection during a migration.
+package clientv2
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/filter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Client is a Fiber client. It's safe for concurrent use.
+type Client struct {
+	v1 *client.Client
+
+	// The v1 single sends share a stream per kind and aren't safe for concurrent use, so they're
+	// serialized here. Sequences are correlated by the v1 client already.
+	txMu    sync.Mutex
+	rawTxMu sync.Mutex
+}
+
+// Dial connects to the Fiber API at target. It blocks until connected or ctx is done.
+func Dial(ctx context.Context, target, apiKey string, opts ...client.ClientOption) (*Client, error) {
+	v1 := client.NewClient(target, append([]client.ClientOption{client.WithAPIKey(apiKey)}, opts...)...)
+	if err := v1.Connect(ctx); err != nil {
+		return nil, err
+	}
+
+	return Wrap(v1), nil
+}
+
+// Wrap returns a v2 client using the connected v1 client c. While c is shared, sends through c itself
+// are not serialized with the ones through the v2 client.
+func Wrap(c *client.Client) *Client {
+	return &Client{v1: c}
+}
+
+// V1 returns the underlying v1 client.
+func (c *Client) V1() *client.Client {
+	return c.v1
+}
+
+// Close closes the client. See client.Client.Close.
+func (c *Client) Close() error {
+	return c.v1.Close()
+}
+
+// SendReceipt is the acknowledgement of a single transaction.
+type SendReceipt struct {
+	Hash common.Hash
+	// Timestamp is when the Fiber node received the transaction, the only server timing in the API.
+	Timestamp time.Time
+	// Endpoint is the target of the Fiber endpoint that acknowledged the transaction.
+	Endpoint string
+	// Attempt is the attempt the transaction was acknowledged on, starting at 1. The client doesn't
+	// retry sends, so it's always 1 for now.
+	Attempt int
+}
+
+// send runs fn under mu, returning early if ctx is done before it does. The v1 sends can't be
+// interrupted, so the send still completes in the background.
+func (c *Client) send(ctx context.Context, mu *sync.Mutex, fn func() (string, int64, error)) (*SendReceipt, error) {
+	type result struct {
+		hash      string
+		timestamp int64
+		err       error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := ctx.Err(); err != nil {
+			done <- result{err: err}
+			return
+		}
+
+		hash, timestamp, err := fn()
+		done <- result{hash, timestamp, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return &SendReceipt{
+			Hash:      common.HexToHash(r.hash),
+			Timestamp: time.UnixMicro(r.timestamp),
+			Endpoint:  c.v1.Target(),
+			Attempt:   1,
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SendTransaction sends a signed transaction.
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) (*SendReceipt, error) {
+	return c.send(ctx, &c.txMu, func() (string, int64, error) {
+		return c.v1.SendTransaction(ctx, tx)
+	})
+}
+
+// SendRawTransaction sends an RLP encoded, signed transaction.
+func (c *Client) SendRawTransaction(ctx context.Context, rawTx []byte) (*SendReceipt, error) {
+	return c.send(ctx, &c.rawTxMu, func() (string, int64, error) {
+		return c.v1.SendRawTransaction(ctx, rawTx)
+	})
+}
+
+// SendTransactionSequence sends the transactions as a sequence. See
+// client.Client.SendTransactionSequenceResult.
+func (c *Client) SendTransactionSequence(ctx context.Context, txs ...*types.Transaction) (*client.SequenceResult, error) {
+	return c.v1.SendTransactionSequenceResult(ctx, txs...)
+}
+
+// SendRawTransactionSequence sends the RLP encoded transactions as a sequence. See
+// client.Client.SendRawTransactionSequenceResult.
+func (c *Client) SendRawTransactionSequence(ctx context.Context, rawTxs ...[]byte) (*client.SequenceResult, error) {
+	return c.v1.SendRawTransactionSequenceResult(ctx, rawTxs...)
+}
+
+// Subscription is a running subscription. Messages are received on C, which is closed when the
+// subscription ends, after which Err returns why.
+type Subscription[T any] struct {
+	ch     chan T
+	handle client.Subscription
+	done   chan struct{}
+	err    error
+}
+
+// C returns the channel the messages are delivered on.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Done is closed when the subscription ended.
+func (s *Subscription[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that ended the subscription, nil while it's running.
+func (s *Subscription[T]) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// Stats returns the counters of the subscription.
+func (s *Subscription[T]) Stats() client.SubscriptionStats {
+	return s.handle.Stats()
+}
+
+// Handle returns the v1 handle of the subscription, for the features of the v1 API that aren't in v2.
+func (s *Subscription[T]) Handle() *client.Subscription {
+	return &s.handle
+}
+
+// Close stops the subscription and waits for it to end.
+func (s *Subscription[T]) Close() {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	// Unsubscribe is a no-op until the subscription started
+	for {
+		s.handle.Unsubscribe()
+
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// subscribe runs src in the background until ctx is done or the subscription fails.
+func subscribe[T any](ctx context.Context, c *Client, src client.Source[T], opts []client.SubscriptionOption) *Subscription[T] {
+	s := &Subscription[T]{
+		ch:   make(chan T),
+		done: make(chan struct{}),
+	}
+
+	// v1 subscriptions don't close the channel if they fail to start, so the messages go through
+	// another one, and C is closed here in every case.
+	in := make(chan T)
+	ended := make(chan struct{})
+
+	opts = append(opts[:len(opts):len(opts)], client.WithHandle(&s.handle))
+	go func() {
+		defer close(ended)
+
+		s.err = src(c.v1, in, opts...)
+		if ctx.Err() != nil {
+			s.err = ctx.Err()
+		}
+	}()
+
+	go func() {
+		defer close(s.done)
+		defer close(s.ch)
+
+	forward:
+		for {
+			select {
+			case msg, ok := <-in:
+				if !ok {
+					break forward
+				}
+
+				select {
+				case s.ch <- msg:
+				case <-ended:
+					break forward
+				}
+			case <-ended:
+				break forward
+			}
+		}
+
+		<-ended
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-s.done:
+		}
+	}()
+
+	return s
+}
+
+// SubscribeNewTxs subscribes to the new transactions matching f, nil for all of them, until ctx is done.
+func (c *Client) SubscribeNewTxs(ctx context.Context, f *filter.Filter, opts ...client.SubscriptionOption) *Subscription[*client.Transaction] {
+	return subscribe(ctx, c, client.TxStream(f), opts)
+}
+
+// SubscribeNewExecutionPayloadHeaders subscribes to the new execution payload headers until ctx is done.
+func (c *Client) SubscribeNewExecutionPayloadHeaders(ctx context.Context, opts ...client.SubscriptionOption) *Subscription[*client.ExecutionPayloadHeader] {
+	return subscribe(ctx, c, client.ExecutionHeaderStream(), opts)
+}
+
+// SubscribeNewExecutionPayloads subscribes to the new execution payloads until ctx is done.
+func (c *Client) SubscribeNewExecutionPayloads(ctx context.Context, opts ...client.SubscriptionOption) *Subscription[*client.ExecutionPayload] {
+	return subscribe(ctx, c, client.ExecutionPayloadStream(), opts)
+}
+
+// SubscribeNewBeaconBlocks subscribes to the new beacon blocks until ctx is done.
+func (c *Client) SubscribeNewBeaconBlocks(ctx context.Context, opts ...client.SubscriptionOption) *Subscription[*client.BeaconBlock] {
+	return subscribe(ctx, c, client.BeaconBlockStream(), opts)
+}
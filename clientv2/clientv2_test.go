@@ -0,0 +1,102 @@
+package clientv2
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+)
+
+func TestSendSerialized(t *testing.T) {
+	c := Wrap(client.NewClient("fiber.example:8080"))
+	var mu sync.Mutex
+	var running, maxRunning int32
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := c.send(context.Background(), &mu, func() (string, int64, error) {
+				n := atomic.AddInt32(&running, 1)
+				if n > atomic.LoadInt32(&maxRunning) {
+					atomic.StoreInt32(&maxRunning, n)
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&running, -1)
+
+				return "0x01", 1_000_000, nil
+			})
+			if err != nil || res.Timestamp.Unix() != 1 || res.Endpoint != "fiber.example:8080" || res.Attempt != 1 {
+				t.Errorf("unexpected result %v, %v", res, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning != 1 {
+		t.Fatalf("expected sends to be serialized, %d ran concurrently", maxRunning)
+	}
+}
+
+func TestSendContext(t *testing.T) {
+	c := Wrap(client.NewClient("fiber.example:8080"))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var mu sync.Mutex
+	if _, err := c.send(ctx, &mu, func() (string, int64, error) {
+		t.Error("send after the context is done")
+		return "", 0, nil
+	}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestSubscriptionContext(t *testing.T) {
+	c := Wrap(nil)
+	src := func(_ *client.Client, ch chan<- int, opts ...client.SubscriptionOption) error {
+		ch <- 1
+		// Stand-in for the subscription ending when ctx is done
+		time.Sleep(20 * time.Millisecond)
+		close(ch)
+		return errors.New("stream closed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := subscribe[int](ctx, c, src, nil)
+
+	if msg := <-sub.C(); msg != 1 {
+		t.Fatalf("expected 1, got %d", msg)
+	}
+	if sub.Err() != nil {
+		t.Fatal("running subscription should have no error")
+	}
+
+	cancel()
+	for range sub.C() {
+	}
+	if !errors.Is(sub.Err(), context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", sub.Err())
+	}
+}
+
+func TestSubscriptionStartFailure(t *testing.T) {
+	errStart := errors.New("can't subscribe")
+	src := func(*client.Client, chan<- int, ...client.SubscriptionOption) error {
+		return errStart
+	}
+
+	sub := subscribe[int](context.Background(), Wrap(nil), src, nil)
+	if _, ok := <-sub.C(); ok {
+		t.Fatal("expected C to be closed")
+	}
+	if !errors.Is(sub.Err(), errStart) {
+		t.Fatalf("expected the start error, got %v", sub.Err())
+	}
+}
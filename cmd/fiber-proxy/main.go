@@ -0,0 +1,70 @@
+// Command fiber-proxy is a sidecar serving the Fiber API to the client processes of a host from a
+// single upstream connection. The downstream clients connect to it like to Fiber, with any API key,
+// and share one subscription per stream and the quota of the proxy's API key. Transaction filters are
+// applied by the proxy, for every downstream subscription.
+//
+//	fiber-proxy -upstream beta.fiberapi.io:8080 -key $FIBER_API_KEY -listen 127.0.0.1:8080
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/protobuf/api"
+
+	"google.golang.org/grpc"
+)
+
+func main() {
+	upstream := flag.String("upstream", "", "address of the Fiber endpoint")
+	key := flag.String("key", os.Getenv("FIBER_API_KEY"), "Fiber API key, $FIBER_API_KEY by default")
+	useTLS := flag.Bool("tls", false, "connect to the Fiber endpoint over TLS")
+	listen := flag.String("listen", "127.0.0.1:8080", "address to serve the downstream clients on")
+	buffer := flag.Int("buffer", 4096, "messages buffered per downstream subscription before it's ended for being too slow")
+	flag.Parse()
+
+	if *upstream == "" {
+		log.Fatal("-upstream is required")
+	}
+
+	opts := []client.ClientOption{client.WithAPIKey(*key)}
+	if *useTLS {
+		opts = append(opts, client.WithTLS(nil))
+	}
+	c := client.NewClient(*upstream, opts...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err := c.Connect(ctx)
+	cancel()
+	if err != nil {
+		log.Fatalf("connecting to %s: %v", *upstream, err)
+	}
+	defer c.Close()
+
+	lis, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, newProxy(c, *buffer))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	log.Printf("serving %s on %s", *upstream, lis.Addr())
+	if err := server.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/clientv2"
+	"github.com/chainbound/fiber-go/codec"
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// errTooSlow ends the subscriptions of the downstream clients that don't keep up, instead of silently
+// dropping their messages or holding back the other clients.
+var errTooSlow = status.Error(codes.ResourceExhausted, "downstream client too slow")
+
+// proxy serves the Fiber API to downstream clients from a single upstream client. Every stream has a
+// single upstream subscription shared by all its downstream subscriptions, which is opened with the
+// first one and closed with the last one. Transactions are subscribed to unfiltered, and filtered for
+// every downstream subscription.
+type proxy struct {
+	api.UnimplementedAPIServer
+
+	upstream *clientv2.Client
+
+	txs      *hub[*eth.Transaction]
+	headers  *hub[*eth.ExecutionPayloadHeader]
+	payloads *hub[*eth.ExecutionPayload]
+	blocks   *hub[*eth.CompactBeaconBlock]
+}
+
+// newProxy returns a proxy to the connected client c, buffering up to buffer messages per downstream
+// subscription.
+func newProxy(c *client.Client, buffer int) *proxy {
+	resubscribe := client.WithAutoResubscribe(nil, func(gap time.Duration, err error) {
+		log.Printf("upstream stream down for %s: %v", gap, err)
+	})
+
+	return &proxy{
+		upstream: clientv2.Wrap(c),
+		txs: newHub(buffer, func(ctx context.Context, publish func(*eth.Transaction)) error {
+			return relay(ctx, func(ch chan<- *client.Transaction) error {
+				return c.SubscribeNewTxsWithContext(ctx, nil, ch, resubscribe)
+			}, codec.EncodeTx, publish)
+		}),
+		headers: newHub(buffer, func(ctx context.Context, publish func(*eth.ExecutionPayloadHeader)) error {
+			return relay(ctx, func(ch chan<- *client.ExecutionPayloadHeader) error {
+				return c.SubscribeNewExecutionPayloadHeadersWithContext(ctx, ch, resubscribe)
+			}, codec.EncodeHeader, publish)
+		}),
+		payloads: newHub(buffer, func(ctx context.Context, publish func(*eth.ExecutionPayload)) error {
+			return relay(ctx, func(ch chan<- *client.ExecutionPayload) error {
+				return c.SubscribeNewExecutionPayloadsWithContext(ctx, ch, resubscribe)
+			}, codec.EncodePayload, publish)
+		}),
+		blocks: newHub(buffer, func(ctx context.Context, publish func(*eth.CompactBeaconBlock)) error {
+			return relay(ctx, func(ch chan<- *client.BeaconBlock) error {
+				return c.SubscribeNewBeaconBlocksWithContext(ctx, ch, resubscribe)
+			}, codec.EncodeBeaconBlock, publish)
+		}),
+	}
+}
+
+// relay runs the subscription subscribe, and publishes its messages encoded with encode.
+func relay[T, P any](ctx context.Context, subscribe func(ch chan<- T) error, encode func(T) P, publish func(P)) error {
+	ch := make(chan T, 1024)
+	errc := make(chan error, 1)
+	go func() {
+		// The subscription closes ch when it ends
+		errc <- subscribe(ch)
+	}()
+
+	for msg := range ch {
+		publish(encode(msg))
+	}
+
+	return <-errc
+}
+
+// toStatus returns err as a gRPC status error, with the code of the upstream error if it has one.
+func toStatus(err error) error {
+	return status.Convert(err).Err()
+}
+
+func (p *proxy) SubscribeNewTxs(f *api.TxFilter, stream api.API_SubscribeNewTxsServer) error {
+	match := func(*eth.Transaction) bool { return true }
+	if len(f.Encoded) > 0 {
+		decoded, err := filter.Decode(f.Encoded)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := decoded.Validate(); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		match = decoded.Matches
+	}
+
+	return p.txs.serve(stream.Context(), func(tx *eth.Transaction) error {
+		if !match(tx) {
+			return nil
+		}
+		return stream.Send(tx)
+	})
+}
+
+func (p *proxy) SubscribeExecutionHeaders(_ *emptypb.Empty, stream api.API_SubscribeExecutionHeadersServer) error {
+	return p.headers.serve(stream.Context(), stream.Send)
+}
+
+func (p *proxy) SubscribeExecutionPayloads(_ *emptypb.Empty, stream api.API_SubscribeExecutionPayloadsServer) error {
+	return p.payloads.serve(stream.Context(), stream.Send)
+}
+
+func (p *proxy) SubscribeBeaconBlocks(_ *emptypb.Empty, stream api.API_SubscribeBeaconBlocksServer) error {
+	return p.blocks.serve(stream.Context(), stream.Send)
+}
+
+func (p *proxy) SendTransaction(stream api.API_SendTransactionServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		res, err := p.upstream.SendTransaction(stream.Context(), codec.DecodeNativeTx(msg))
+		if err != nil {
+			return toStatus(err)
+		}
+
+		if err := stream.Send(&api.TransactionResponse{Hash: res.Hash.Hex(), Timestamp: res.Timestamp.UnixMicro()}); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *proxy) SendRawTransaction(stream api.API_SendRawTransactionServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		res, err := p.upstream.SendRawTransaction(stream.Context(), msg.RawTx)
+		if err != nil {
+			return toStatus(err)
+		}
+
+		if err := stream.Send(&api.TransactionResponse{Hash: res.Hash.Hex(), Timestamp: res.Timestamp.UnixMicro()}); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *proxy) SendTransactionSequence(stream api.API_SendTransactionSequenceServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		txs := make([]*types.Transaction, len(msg.Sequence))
+		for i, proto := range msg.Sequence {
+			txs[i] = codec.DecodeNativeTx(proto)
+		}
+
+		res, err := p.upstream.SendTransactionSequence(stream.Context(), txs...)
+		if err := sendSequenceResponse(stream, res, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *proxy) SendRawTransactionSequence(stream api.API_SendRawTransactionSequenceServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		res, err := p.upstream.SendRawTransactionSequence(stream.Context(), msg.RawTxs...)
+		if err := sendSequenceResponse(stream, res, err); err != nil {
+			return err
+		}
+	}
+}
+
+// sendSequenceResponse sends the upstream response to a sequence as the upstream server sent it, so
+// that the downstream client sees the same rejections and reorderings.
+func sendSequenceResponse(stream interface {
+	Send(*api.TxSequenceResponse) error
+}, res *client.SequenceResult, err error) error {
+	// A *client.SequenceError comes with the response
+	if res == nil {
+		return toStatus(err)
+	}
+
+	var accepted []client.SequenceItem
+	for _, item := range res.Items {
+		if item.Position >= 0 {
+			accepted = append(accepted, item)
+		}
+	}
+	sort.Slice(accepted, func(i, j int) bool { return accepted[i].Position < accepted[j].Position })
+
+	responses := make([]*api.TransactionResponse, len(accepted))
+	for i, item := range accepted {
+		responses[i] = &api.TransactionResponse{Hash: item.Hash.Hex(), Timestamp: item.Timestamp}
+	}
+
+	return stream.Send(&api.TxSequenceResponse{SequenceResponse: responses})
+}
+
+// hub fans the messages of an upstream subscription out to the downstream subscriptions.
+type hub[P any] struct {
+	buffer int
+	run    func(ctx context.Context, publish func(P)) error
+
+	mu   sync.Mutex
+	subs map[*downstream[P]]struct{}
+	// stop stops the running upstream subscription, nil if none is running.
+	stop context.CancelFunc
+}
+
+// downstream is a downstream subscription. done is closed when it was removed by the hub, after
+// which err is why.
+type downstream[P any] struct {
+	ch   chan P
+	done chan struct{}
+	err  error
+}
+
+func newHub[P any](buffer int, run func(ctx context.Context, publish func(P)) error) *hub[P] {
+	return &hub[P]{buffer: buffer, run: run, subs: make(map[*downstream[P]]struct{})}
+}
+
+// serve sends the messages of the upstream subscription with send until ctx is done, send fails or
+// the hub removes the downstream subscription.
+func (h *hub[P]) serve(ctx context.Context, send func(P) error) error {
+	d := h.join()
+	defer h.remove(d, nil)
+
+	for {
+		select {
+		case msg := <-d.ch:
+			if err := send(msg); err != nil {
+				return err
+			}
+		case <-d.done:
+			return d.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// join adds a downstream subscription, starting the upstream subscription if it's the first one.
+func (h *hub[P]) join() *downstream[P] {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	d := &downstream[P]{ch: make(chan P, h.buffer), done: make(chan struct{})}
+	h.subs[d] = struct{}{}
+
+	if h.stop == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		h.stop = cancel
+		go h.upstream(ctx)
+	}
+
+	return d
+}
+
+// upstream runs the upstream subscription, and ends the downstream subscriptions if it fails.
+func (h *hub[P]) upstream(ctx context.Context) {
+	err := h.run(ctx, h.publish)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Stopped, possibly with a new upstream subscription running already
+	if ctx.Err() != nil {
+		return
+	}
+
+	log.Printf("upstream subscription failed: %v", err)
+	for d := range h.subs {
+		h.removeLocked(d, toStatus(err))
+	}
+}
+
+func (h *hub[P]) publish(msg P) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for d := range h.subs {
+		select {
+		case d.ch <- msg:
+		default:
+			h.removeLocked(d, errTooSlow)
+		}
+	}
+}
+
+// remove removes d if it wasn't already, stopping the upstream subscription if it was the last one.
+func (h *hub[P]) remove(d *downstream[P], err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.removeLocked(d, err)
+}
+
+func (h *hub[P]) removeLocked(d *downstream[P], err error) {
+	if _, ok := h.subs[d]; !ok {
+		return
+	}
+
+	delete(h.subs, d)
+	d.err = err
+	close(d.done)
+
+	if len(h.subs) == 0 && h.stop != nil {
+		h.stop()
+		h.stop = nil
+	}
+}
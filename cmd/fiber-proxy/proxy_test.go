@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/fibertest"
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/protobuf/api"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+)
+
+func signTx(t *testing.T, to common.Address) *types.Transaction {
+	t.Helper()
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   common.Big1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       21000,
+		To:        &to,
+	}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tx
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestProxy(t *testing.T) {
+	upstream := fibertest.NewServer()
+	defer upstream.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := upstream.NewClient()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	p := newProxy(c, 16)
+	api.RegisterAPIServer(server, p)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	router := common.HexToAddress("0x01")
+	downstream := func() *client.Client {
+		d := client.NewClient(lis.Addr().String())
+		if err := d.Connect(ctx); err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	first, second := downstream(), downstream()
+	defer first.Close()
+	defer second.Close()
+
+	f, err := filter.Build().To(router).Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var filtered, all client.Subscription
+	filteredCh, allCh := make(chan *client.Transaction, 4), make(chan *client.Transaction, 4)
+	go first.SubscribeNewTxs(f, filteredCh, client.WithHandle(&filtered))
+	go second.SubscribeNewTxs(nil, allCh, client.WithHandle(&all))
+
+	// The proxy doesn't replay what it relayed before a downstream subscription joined
+	waitFor(t, func() bool {
+		p.txs.mu.Lock()
+		defer p.txs.mu.Unlock()
+
+		return len(p.txs.subs) == 2
+	})
+	for _, tx := range []*types.Transaction{signTx(t, common.HexToAddress("0x02")), signTx(t, router)} {
+		if err := upstream.PublishNativeTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case tx := <-filteredCh:
+		if *tx.To != router {
+			t.Fatalf("expected the transaction to the router, got %s", tx.To)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+	waitFor(t, func() bool { return len(allCh) == 2 })
+	if len(filteredCh) != 0 {
+		t.Fatal("expected the filter to be applied by the proxy")
+	}
+
+	// Both downstream subscriptions share one upstream subscription, closed after the last one
+	if n := len(c.Snapshot().Subscriptions); n != 1 {
+		t.Fatalf("expected 1 upstream subscription, got %d", n)
+	}
+	filtered.Unsubscribe()
+	all.Unsubscribe()
+	waitFor(t, func() bool { return len(c.Snapshot().Subscriptions) == 0 })
+
+	tx := signTx(t, router)
+	raw, _ := tx.MarshalBinary()
+	if hash, _, err := first.SendRawTransaction(ctx, raw); err != nil || hash != tx.Hash().Hex() {
+		t.Fatalf("expected %s, got %s %v", tx.Hash(), hash, err)
+	}
+	if hashes, _, err := second.SendTransactionSequence(ctx, tx, signTx(t, router)); err != nil || hashes[0] != tx.Hash().Hex() {
+		t.Fatalf("unexpected sequence response %v %v", hashes, err)
+	}
+	if sent := upstream.Sent(); len(sent) != 3 || sent[0].Hash != tx.Hash() {
+		t.Fatalf("expected the sends to be forwarded upstream, got %+v", sent)
+	}
+}
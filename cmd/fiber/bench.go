@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// bench subscribes to transactions and headers for a while, optionally sending a transaction over and
+// over, and prints the connection time, the stream rates and the latencies.
+func bench(ctx context.Context, c *client.Client, connected time.Duration, w io.Writer, args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	duration := flags.Duration("duration", 30*time.Second, "how long to measure for")
+	rawTx := flags.String("rawtx", "", "hex encoded, signed transaction to time the sends with. Sending it again is harmless, it has the same hash")
+	sends := flags.Int("sends", 10, "number of sends of -rawtx")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	var raw []byte
+	if *rawTx != "" {
+		var err error
+		if raw, err = hexutil.Decode(*rawTx); err != nil {
+			return fmt.Errorf("decoding transaction: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+	start := time.Now()
+
+	var (
+		wg          sync.WaitGroup
+		firstTx     time.Duration
+		txs         int
+		headerDelay []time.Duration
+		roundTrips  []time.Duration
+		errs        = make([]error, 3)
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+
+		errs[0] = tail(ctx, 0, func(ctx context.Context, ch chan<- *client.Transaction) error {
+			return c.SubscribeNewTxsWithContext(ctx, nil, ch)
+		}, func(*client.Transaction) error {
+			if txs++; txs == 1 {
+				firstTx = time.Since(start)
+			}
+			return nil
+		})
+	}()
+	go func() {
+		defer wg.Done()
+
+		errs[1] = tail(ctx, 0, func(ctx context.Context, ch chan<- *client.ExecutionPayloadHeader) error {
+			return c.SubscribeNewExecutionPayloadHeadersWithContext(ctx, ch)
+		}, func(h *client.ExecutionPayloadHeader) error {
+			headerDelay = append(headerDelay, time.Since(time.Unix(int64(h.Timestamp), 0)))
+			return nil
+		})
+	}()
+
+	if raw != nil {
+		for i := 0; i < *sends && ctx.Err() == nil; i++ {
+			sent := time.Now()
+			if _, _, err := c.SendRawTransaction(ctx, raw); err != nil {
+				errs[2] = err
+				break
+			}
+			roundTrips = append(roundTrips, time.Since(sent))
+		}
+	}
+
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	fmt.Fprintf(w, "connect       %s\n", connected.Round(time.Microsecond))
+	if txs > 0 {
+		fmt.Fprintf(w, "first tx      %s\n", firstTx.Round(time.Microsecond))
+	}
+	fmt.Fprintf(w, "txs           %d (%.1f/s)\n", txs, float64(txs)/elapsed.Seconds())
+	fmt.Fprintf(w, "headers       %d, %s\n", len(headerDelay), percentiles(headerDelay))
+	if raw != nil {
+		fmt.Fprintf(w, "send rtt      %d, %s\n", len(roundTrips), percentiles(roundTrips))
+	}
+
+	// Running out of time is how the measurement ends
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// percentiles describes the distribution of the durations d.
+func percentiles(d []time.Duration) string {
+	if len(d) == 0 {
+		return "no samples"
+	}
+
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	at := func(p float64) time.Duration {
+		return d[int(p*float64(len(d)-1))].Round(time.Microsecond)
+	}
+
+	return fmt.Sprintf("p50 %s p99 %s max %s", at(0.5), at(0.99), at(1))
+}
@@ -0,0 +1,111 @@
+// Command fiber is a command line client of the Fiber API, to check an API key and measure latency
+// without writing a program:
+//
+//	fiber -endpoint beta.fiberapi.io:8080 -key $FIBER_API_KEY tail txs
+//	fiber tail blocks
+//	fiber send 0x02f8...
+//	fiber bench -duration 1m
+//
+// The endpoint and key default to $FIBER_ENDPOINT and $FIBER_API_KEY.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+)
+
+const usage = `usage: fiber [flags] <command> [command flags]
+
+commands:
+  tail txs      print the new transactions
+  tail blocks   print the new execution payload headers
+  send <rawtx>  send a hex encoded, signed transaction
+  bench         measure the connection and stream latency
+
+flags:
+`
+
+func main() {
+	flags := flag.NewFlagSet("fiber", flag.ExitOnError)
+	endpoint := flags.String("endpoint", os.Getenv("FIBER_ENDPOINT"), "address of the Fiber endpoint, $FIBER_ENDPOINT by default")
+	key := flags.String("key", os.Getenv("FIBER_API_KEY"), "Fiber API key, $FIBER_API_KEY by default")
+	useTLS := flags.Bool("tls", false, "connect over TLS")
+	timeout := flags.Duration("timeout", 30*time.Second, "time to connect")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if *endpoint == "" || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := []client.ClientOption{client.WithAPIKey(*key)}
+	if *useTLS {
+		opts = append(opts, client.WithTLS(nil))
+	}
+	c := client.NewClient(*endpoint, opts...)
+
+	start := time.Now()
+	connectCtx, cancel := context.WithTimeout(ctx, *timeout)
+	err := c.Connect(connectCtx)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connecting to %s: %v\n", *endpoint, err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	err = run(ctx, c, time.Since(start), os.Stdout, flags.Args())
+	if errors.Is(err, errUsage) {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// errUsage is returned by the commands called with invalid arguments.
+var errUsage = errors.New("invalid arguments")
+
+// run runs the command args on the connected client c, which took connected to connect.
+func run(ctx context.Context, c *client.Client, connected time.Duration, w io.Writer, args []string) error {
+	switch {
+	case len(args) >= 2 && args[0] == "tail" && args[1] == "txs":
+		return tailTxs(ctx, c, w, args[2:])
+	case len(args) >= 2 && args[0] == "tail" && args[1] == "blocks":
+		return tailBlocks(ctx, c, w, args[2:])
+	case len(args) >= 1 && args[0] == "send":
+		return send(ctx, c, w, args[1:])
+	case len(args) >= 1 && args[0] == "bench":
+		return bench(ctx, c, connected, w, args[1:])
+	}
+
+	return errUsage
+}
+
+// parse parses the flags of a command, which takes nargs positional arguments. Invalid flags are
+// reported with the usage of the command by flags itself.
+func parse(flags *flag.FlagSet, args []string, nargs int) error {
+	if err := flags.Parse(args); err != nil || flags.NArg() != nargs {
+		return errUsage
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/fibertest"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func signTx(t *testing.T, to common.Address) *types.Transaction {
+	t.Helper()
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   common.Big1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       21000,
+		To:        &to,
+	}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tx
+}
+
+func TestCommands(t *testing.T) {
+	server := fibertest.NewServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := server.NewClient()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	router := common.HexToAddress("0x01")
+	other, routed := signTx(t, common.HexToAddress("0x02")), signTx(t, router)
+	for _, tx := range []*types.Transaction{other, routed} {
+		if err := server.PublishNativeTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.PublishHeader(&client.ExecutionPayloadHeader{Number: 7, BaseFeePerGas: big.NewInt(3), Timestamp: uint64(time.Now().Unix())})
+
+	raw, _ := routed.MarshalBinary()
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"tail", "txs", "-n", "1", "-to", router.Hex()}, []string{routed.Hash().Hex()}},
+		{[]string{"tail", "txs", "-n", "2", "-json"}, []string{`"Hash":"` + other.Hash().Hex(), `"Hash":"` + routed.Hash().Hex()}},
+		{[]string{"tail", "blocks", "-n", "1"}, []string{"7 ", "base fee 3"}},
+		{[]string{"send", hexutil.Encode(raw)}, []string{routed.Hash().Hex() + " received at"}},
+		{[]string{"bench", "-duration", "100ms", "-rawtx", hexutil.Encode(raw), "-sends", "2"}, []string{"txs           2", "headers       1", "send rtt      2"}},
+	} {
+		var out bytes.Buffer
+		if err := run(ctx, c, time.Millisecond, &out, tt.args); err != nil {
+			t.Fatalf("%v: %v", tt.args, err)
+		}
+
+		for _, want := range tt.want {
+			if !strings.Contains(out.String(), want) {
+				t.Fatalf("%v: expected %q in the output, got:\n%s", tt.args, want, out.String())
+			}
+		}
+	}
+
+	for _, args := range [][]string{{"tail"}, {"send"}, {"tail", "txs", "extra"}, {"bench", "-unknown"}} {
+		if err := run(ctx, c, 0, new(bytes.Buffer), args); !errors.Is(err, errUsage) {
+			t.Fatalf("%v: expected a usage error, got %v", args, err)
+		}
+	}
+}
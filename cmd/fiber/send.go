@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+)
+
+// send sends a hex encoded, signed transaction, and prints its hash, when Fiber received it and the
+// round trip time.
+func send(ctx context.Context, c *client.Client, w io.Writer, args []string) error {
+	flags := flag.NewFlagSet("send", flag.ContinueOnError)
+	if err := parse(flags, args, 1); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	hash, timestamp, err := c.SendRawTransactionHex(ctx, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s received at %s, round trip %s\n", hash, time.UnixMicro(timestamp).Format(time.RFC3339Nano), time.Since(start).Round(time.Microsecond))
+	return err
+}
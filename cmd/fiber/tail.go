@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/filter"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// tailTxs prints the new transactions, one per line.
+func tailTxs(ctx context.Context, c *client.Client, w io.Writer, args []string) error {
+	flags := flag.NewFlagSet("tail txs", flag.ContinueOnError)
+	to := flags.String("to", "", "only the transactions to this address")
+	from := flags.String("from", "", "only the transactions from this address")
+	n := flags.Int("n", 0, "exit after this many transactions, 0 to never exit")
+	asJSON := flags.Bool("json", false, "print the transactions as JSON")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	b := filter.Build()
+	for _, addr := range []struct {
+		value string
+		add   func(common.Address) *filter.Builder
+	}{{*to, b.To}, {*from, b.From}} {
+		if addr.value == "" {
+			continue
+		}
+		if !common.IsHexAddress(addr.value) {
+			return fmt.Errorf("invalid address %q", addr.value)
+		}
+		addr.add(common.HexToAddress(addr.value))
+	}
+	f, err := b.Filter()
+	if err != nil {
+		return err
+	}
+
+	return tail(ctx, *n, func(ctx context.Context, ch chan<- *client.Transaction) error {
+		return c.SubscribeNewTxsWithContext(ctx, f, ch)
+	}, func(tx *client.Transaction) error {
+		if *asJSON {
+			return json.NewEncoder(w).Encode(tx)
+		}
+
+		to := "contract creation"
+		if tx.To != nil {
+			to = tx.To.Hex()
+		}
+		_, err := fmt.Fprintf(w, "%s %s from %s to %s value %s\n", time.Now().Format(time.RFC3339Nano), tx.Hash.Hex(), tx.From.Hex(), to, tx.Value)
+		return err
+	})
+}
+
+// tailBlocks prints the new execution payload headers, one per line, with how long after their
+// timestamp they arrived.
+func tailBlocks(ctx context.Context, c *client.Client, w io.Writer, args []string) error {
+	flags := flag.NewFlagSet("tail blocks", flag.ContinueOnError)
+	n := flags.Int("n", 0, "exit after this many blocks, 0 to never exit")
+	asJSON := flags.Bool("json", false, "print the headers as JSON")
+	if err := parse(flags, args, 0); err != nil {
+		return err
+	}
+
+	return tail(ctx, *n, func(ctx context.Context, ch chan<- *client.ExecutionPayloadHeader) error {
+		return c.SubscribeNewExecutionPayloadHeadersWithContext(ctx, ch)
+	}, func(h *client.ExecutionPayloadHeader) error {
+		if *asJSON {
+			return json.NewEncoder(w).Encode(h)
+		}
+
+		delay := time.Since(time.Unix(int64(h.Timestamp), 0)).Round(time.Millisecond)
+		_, err := fmt.Fprintf(w, "%d %s gas used %d base fee %s, %s after its timestamp\n", h.Number, h.Hash.Hex(), h.GasUsed, h.BaseFeePerGas, delay)
+		return err
+	})
+}
+
+// tail runs the subscription subscribe, and prints its first n messages with print, all of them if
+// n is 0.
+func tail[T any](ctx context.Context, n int, subscribe func(ctx context.Context, ch chan<- T) error, print func(T) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan T, 256)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- subscribe(ctx, ch)
+	}()
+
+	for i := 0; n == 0 || i < n; i++ {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return <-errc
+			}
+			if err := print(msg); err != nil {
+				return err
+			}
+		// A subscription that fails to start doesn't close ch
+		case err := <-errc:
+			return err
+		}
+	}
+
+	cancel()
+	<-errc
+	return nil
+}
@@ -0,0 +1,72 @@
+// Package codec converts between the Fiber protobuf messages, the types of the client package and the
+// go-ethereum transactions, for building test fixtures or re-publishing messages.
+//
+// Stability: the functions of this package keep their signatures and behavior across minor versions.
+// Decoding then encoding a message received from Fiber gives the same message back, except for the V
+// of typed transactions, which is kept as the y-parity. Any new message type gets its Encode and Decode
+// pair here.
+package codec
+
+import (
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// EncodeNativeTx converts a signed go-ethereum transaction to its protobuf message. It fails if the
+// sender can't be recovered from the signature.
+func EncodeNativeTx(tx *types.Transaction) (*eth.Transaction, error) {
+	return client.TxToProto(tx)
+}
+
+// DecodeNativeTx converts a protobuf transaction to a go-ethereum transaction.
+func DecodeNativeTx(proto *eth.Transaction) *types.Transaction {
+	return client.ProtoToTx(proto).ToNative()
+}
+
+// EncodeBlobTx converts a signed blob transaction to its protobuf message, which doesn't have the blob
+// fields. It fails if the sender can't be recovered from the signature.
+func EncodeBlobTx(tx *client.BlobTx) (*eth.Transaction, error) {
+	return tx.ToProto()
+}
+
+// EncodeTx converts a transaction to its protobuf message.
+func EncodeTx(tx *client.Transaction) *eth.Transaction {
+	return tx.ToProto()
+}
+
+// DecodeTx converts a protobuf transaction to a transaction.
+func DecodeTx(proto *eth.Transaction) *client.Transaction {
+	return client.ProtoToTx(proto)
+}
+
+// EncodeHeader converts an execution payload header to its protobuf message.
+func EncodeHeader(header *client.ExecutionPayloadHeader) *eth.ExecutionPayloadHeader {
+	return header.ToProto()
+}
+
+// DecodeHeader converts a protobuf execution payload header.
+func DecodeHeader(proto *eth.ExecutionPayloadHeader) *client.ExecutionPayloadHeader {
+	return client.ProtoToHeader(proto)
+}
+
+// EncodePayload converts an execution payload to its protobuf message.
+func EncodePayload(payload *client.ExecutionPayload) *eth.ExecutionPayload {
+	return payload.ToProto()
+}
+
+// DecodePayload converts a protobuf execution payload.
+func DecodePayload(proto *eth.ExecutionPayload) *client.ExecutionPayload {
+	return client.ProtoToBlock(proto)
+}
+
+// EncodeBeaconBlock converts a beacon block to its protobuf message.
+func EncodeBeaconBlock(block *client.BeaconBlock) *eth.CompactBeaconBlock {
+	return block.ToProto()
+}
+
+// DecodeBeaconBlock converts a protobuf beacon block.
+func DecodeBeaconBlock(proto *eth.CompactBeaconBlock) *client.BeaconBlock {
+	return client.ProtoToBeaconBlock(proto)
+}
@@ -0,0 +1,105 @@
+package codec
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/protobuf/proto"
+)
+
+func hash(b byte) []byte {
+	return common.BytesToHash([]byte{b}).Bytes()
+}
+
+func header() *eth.ExecutionPayloadHeader {
+	return &eth.ExecutionPayloadHeader{
+		ParentHash:       hash(1),
+		FeeRecipient:     common.BytesToAddress([]byte{2}).Bytes(),
+		StateRoot:        hash(3),
+		ReceiptsRoot:     hash(4),
+		LogsBloom:        types.BytesToBloom([]byte{5}).Bytes(),
+		PrevRandao:       hash(6),
+		BlockNumber:      7,
+		GasLimit:         30_000_000,
+		GasUsed:          21_000,
+		Timestamp:        1700000000,
+		ExtraData:        []byte("fiber"),
+		BaseFeePerGas:    big.NewInt(10).Bytes(),
+		BlockHash:        hash(8),
+		TransactionsRoot: hash(9),
+		WithdrawalsRoot:  hash(10),
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC")
+	signed, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID: common.Big1, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(20), Gas: 50000,
+		To: &to, Value: big.NewInt(100), Data: []byte{0xa9, 0x05, 0x9c, 0xbb},
+	}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := EncodeNativeTx(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if native := DecodeNativeTx(tx); native.Hash() != signed.Hash() {
+		t.Fatalf("expected hash %s, got %s", signed.Hash(), native.Hash())
+	}
+	if got := EncodeTx(DecodeTx(tx)); !proto.Equal(got, tx) {
+		t.Fatalf("transaction changed: %v", got)
+	}
+
+	if got := EncodeHeader(DecodeHeader(header())); !proto.Equal(got, header()) {
+		t.Fatalf("header changed: %v", got)
+	}
+
+	payload := &eth.ExecutionPayload{Header: header(), Transactions: []*eth.Transaction{tx}}
+	if got := EncodePayload(DecodePayload(payload)); !proto.Equal(got, payload) {
+		t.Fatalf("payload changed: %v", got)
+	}
+
+	block := &eth.CompactBeaconBlock{
+		Slot:          100,
+		ProposerIndex: 5,
+		ParentRoot:    hash(1),
+		StateRoot:     hash(2),
+		Body: &eth.CompactBeaconBlockBody{
+			RandaoReveal: bytes.Repeat([]byte{3}, 96),
+			Eth1Data:     &eth.Eth1Data{DepositRoot: hash(4), DepositCount: 6, BlockHash: hash(5)},
+			Graffiti:     hash(6),
+			Attestations: []*eth.Attestation{{
+				AggregationBits: []byte{0x0f},
+				Data: &eth.AttestationData{
+					Slot: 99, Index: 1, BeaconBlockRoot: hash(7),
+					Source: &eth.Checkpoint{Epoch: 2, Root: hash(8)},
+					Target: &eth.Checkpoint{Epoch: 3, Root: hash(9)},
+				},
+				Signature: bytes.Repeat([]byte{10}, 96),
+			}},
+			VoluntaryExits: []*eth.SignedVoluntaryExit{{
+				Message:   &eth.VoluntaryExit{Epoch: 3, ValidatorIndex: 42},
+				Signature: bytes.Repeat([]byte{11}, 96),
+			}},
+			SyncAggregate: &eth.SyncAggregate{
+				SyncCommitteeBits:      bytes.Repeat([]byte{0xff}, 64),
+				SyncCommitteeSignature: bytes.Repeat([]byte{12}, 96),
+			},
+		},
+	}
+	if got := EncodeBeaconBlock(DecodeBeaconBlock(block)); !proto.Equal(got, block) {
+		t.Fatalf("beacon block changed: %v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// The compressors of WithStreamCompression.
+const (
+	// CompressionGzip is always available.
+	CompressionGzip = gzip.Name
+	// CompressionZstd must be registered by the application with encoding.RegisterCompressor, from a zstd
+	// implementation of its choice, since the client doesn't depend on one.
+	CompressionZstd = "zstd"
+)
+
+// ErrCompressorNotRegistered is returned by the subscriptions of a client configured with a
+// compressor that isn't registered with gRPC.
+var ErrCompressorNotRegistered = newCodedError(ErrCodeCompressorNotRegistered, "compressor not registered")
+
+// WithStreamCompression asks for the subscription streams to be compressed with the named gRPC
+// compressor, like CompressionGzip, trading CPU for bandwidth. Full execution payloads are large, so
+// it pays off over WAN links. The server compresses its messages if it supports the compressor, and
+// sends them uncompressed otherwise.
+func WithStreamCompression(name string) ClientOption {
+	return func(c *Client) {
+		c.streamCompression = name
+	}
+}
+
+// compressionCallOptions returns the call options the subscription streams are opened with.
+func (c *Client) compressionCallOptions() ([]grpc.CallOption, error) {
+	if c.streamCompression == "" {
+		return nil, nil
+	}
+
+	if encoding.GetCompressor(c.streamCompression) == nil {
+		return nil, fmt.Errorf("%w: %q", ErrCompressorNotRegistered, c.streamCompression)
+	}
+
+	return []grpc.CallOption{grpc.UseCompressor(c.streamCompression)}, nil
+}
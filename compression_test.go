@@ -0,0 +1,88 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// countingCompressor is gzip counting the messages it decompresses.
+type countingCompressor struct {
+	encoding.Compressor
+	decompressed *int64
+}
+
+func (c countingCompressor) Name() string { return "counting-gzip" }
+
+func (c countingCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	atomic.AddInt64(c.decompressed, 1)
+	return c.Compressor.Decompress(r)
+}
+
+type headerServer struct {
+	api.UnimplementedAPIServer
+}
+
+func (headerServer) SubscribeExecutionHeaders(_ *emptypb.Empty, stream api.API_SubscribeExecutionHeadersServer) error {
+	if err := stream.Send(&eth.ExecutionPayloadHeader{BlockNumber: 1}); err != nil {
+		return err
+	}
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestStreamCompression(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, headerServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var decompressed int64
+	encoding.RegisterCompressor(countingCompressor{encoding.GetCompressor(gzip.Name), &decompressed})
+
+	c := NewClient(lis.Addr().String(), WithStreamCompression("counting-gzip"))
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ch := make(chan *ExecutionPayloadHeader, 1)
+	go c.SubscribeNewExecutionPayloadHeadersWithContext(ctx, ch)
+	if h := <-ch; h.Number != 1 {
+		t.Fatalf("unexpected header %d", h.Number)
+	}
+	// The request on the server and the header on the client
+	if n := atomic.LoadInt64(&decompressed); n != 2 {
+		t.Fatalf("expected 2 compressed messages, got %d", n)
+	}
+
+	// Nothing registers zstd in the tests
+	zstd := NewClient(lis.Addr().String(), WithStreamCompression(CompressionZstd))
+	if err := zstd.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer zstd.Close()
+
+	if err := zstd.SubscribeNewExecutionPayloadHeaders(ch); !errors.Is(err, ErrCompressorNotRegistered) {
+		t.Fatalf("expected ErrCompressorNotRegistered, got %v", err)
+	}
+}
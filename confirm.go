@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Confirmation is the inclusion of a transaction sent with SendAndConfirm.
+type Confirmation struct {
+	Hash common.Hash
+	// Timestamp is when Fiber received the transaction (us), as returned by SendTransaction.
+	Timestamp   int64
+	BlockNumber uint64
+	BlockHash   common.Hash
+	// Index is the position of the transaction in the block.
+	Index int
+	// Delay is the time from the send to the reception of the block including the transaction.
+	Delay time.Duration
+	// Confirmations is the number of blocks received from the including one on, itself included.
+	Confirmations int
+}
+
+// SendAndConfirm sends tx like SendTransaction, then watches the execution payload stream until it's
+// included and confirmations blocks were received from the including one on, so 0 and 1 both return on
+// inclusion. The stream is opened before sending, so the block can't be missed. If a reorg replaces the
+// including block, it waits for the transaction to be included again. Once ctx is done, it returns its
+// error, with the confirmation so far if the transaction was included.
+func (c *Client) SendAndConfirm(ctx context.Context, tx *types.Transaction, confirmations int) (*Confirmation, error) {
+	send := func(ctx context.Context) (int64, error) {
+		_, timestamp, err := c.SendTransaction(ctx, tx)
+		return timestamp, err
+	}
+
+	return confirm(ctx, c, ExecutionPayloadStream(), tx.Hash(), send, confirmations)
+}
+
+// confirm sends with send once src is live on c, and waits for the confirmations of hash on it.
+func confirm(ctx context.Context, c *Client, src Source[*ExecutionPayload], hash common.Hash, send func(context.Context) (int64, error), confirmations int) (*Confirmation, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	live := make(chan struct{})
+	blocks := make(chan *ExecutionPayload, 16)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- src(c, blocks, withContext(ctx), withOnLive(func() { close(live) }))
+	}()
+
+	select {
+	case <-live:
+	case err := <-errc:
+		return nil, fmt.Errorf("subscribing to execution payloads: %w", err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	sentAt := time.Now()
+	timestamp, err := send(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var conf *Confirmation
+	for {
+		var block *ExecutionPayload
+		select {
+		case b, ok := <-blocks:
+			if !ok {
+				return conf, fmt.Errorf("watching for inclusion: %w", <-errc)
+			}
+			block = b
+		case <-ctx.Done():
+			return conf, ctx.Err()
+		}
+
+		if conf != nil {
+			if block.Header.Number > conf.BlockNumber {
+				conf.Confirmations = int(block.Header.Number-conf.BlockNumber) + 1
+			} else if block.Header.Hash != conf.BlockHash {
+				// Reorged out
+				conf = nil
+			}
+		}
+
+		if conf == nil {
+			for i, tx := range block.Transactions {
+				if tx.Hash == hash {
+					conf = &Confirmation{
+						Hash:          hash,
+						Timestamp:     timestamp,
+						BlockNumber:   block.Header.Number,
+						BlockHash:     block.Header.Hash,
+						Index:         i,
+						Delay:         time.Since(sentAt),
+						Confirmations: 1,
+					}
+					break
+				}
+			}
+		}
+
+		if conf != nil && conf.Confirmations >= confirmations {
+			return conf, nil
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestConfirm(t *testing.T) {
+	hash := common.HexToHash("0x01")
+	header := func(number uint64, h string) *ExecutionPayloadHeader {
+		return &ExecutionPayloadHeader{Number: number, Hash: common.HexToHash(h)}
+	}
+	tx := &Transaction{Hash: hash}
+	other := &Transaction{Hash: common.HexToHash("0x02")}
+
+	// Included in block 2, which is reorged out, then in block 3
+	src := func(c *Client, ch chan<- *ExecutionPayload, opts ...SubscriptionOption) error {
+		return subscribe(c, "test", ch, opts, fakeStream(
+			&ExecutionPayload{Header: header(1, "0xa1")},
+			&ExecutionPayload{Header: header(2, "0xa2"), Transactions: []*Transaction{tx}},
+			&ExecutionPayload{Header: header(2, "0xb2")},
+			&ExecutionPayload{Header: header(3, "0xb3"), Transactions: []*Transaction{other, tx}},
+			&ExecutionPayload{Header: header(4, "0xb4")},
+		), identity[*ExecutionPayload])
+	}
+
+	sent := 0
+	send := func(context.Context) (int64, error) {
+		sent++
+		return 42, nil
+	}
+
+	conf, err := confirm(context.Background(), &Client{}, src, hash, send, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || conf.BlockNumber != 3 || conf.BlockHash != common.HexToHash("0xb3") || conf.Index != 1 ||
+		conf.Confirmations != 2 || conf.Timestamp != 42 || conf.Delay <= 0 {
+		t.Fatalf("unexpected confirmation %+v", conf)
+	}
+
+	// Not enough blocks: the inclusion so far is returned with the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	conf, err = confirm(ctx, &Client{}, src, hash, send, 5)
+	if !errors.Is(err, context.DeadlineExceeded) || conf == nil || conf.Confirmations != 2 {
+		t.Fatalf("expected the deadline with 2 confirmations, got %+v, %v", conf, err)
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ConnectionInfo describes the transport of a connected client.
+type ConnectionInfo struct {
+	Target     string
+	RemoteAddr string
+	// State is the connectivity state of the underlying gRPC channel, e.g. READY.
+	State string
+
+	TLS         bool
+	TLSVersion  string
+	CipherSuite string
+	ServerName  string
+
+	// The buffer sizes set with WithBufferSizes. Dial options added with WithDialOptions aren't
+	// reflected, grpc-go doesn't expose the effective transport settings.
+	ReadBufferSize  int
+	WriteBufferSize int
+
+	// The keepalive parameters set with WithKeepalive, 0 without pings.
+	KeepaliveInterval time.Duration
+	KeepaliveTimeout  time.Duration
+
+	// The flow control windows set with WithInitialWindowSize and WithInitialConnWindowSize, 0 for
+	// the dynamic windows of grpc-go.
+	InitialWindowSize     int32
+	InitialConnWindowSize int32
+
+	ConnectedAt time.Time
+	Age         time.Duration
+}
+
+// ConnectionInfo reports the transport parameters of the connection, so operators can verify
+// that the latency-relevant settings are what they expect. It fails with ErrInvalidState if the
+// client isn't connected.
+func (c *Client) ConnectionInfo() (*ConnectionInfo, error) {
+	// The connection and the streams are set by Connect before the client is ready, and stay put
+	// until it's closing.
+	c.state.mu.Lock()
+	state := c.state.lifecycle
+	conn, txStream := c.conn, c.txStream
+	c.state.mu.Unlock()
+
+	if state != StateReady {
+		return nil, fmt.Errorf("connection info while %s: %w", state, ErrInvalidState)
+	}
+
+	info := &ConnectionInfo{
+		Target:                c.target,
+		State:                 conn.GetState().String(),
+		ReadBufferSize:        c.dial.readBuffer,
+		WriteBufferSize:       c.dial.writeBuffer,
+		InitialWindowSize:     c.dial.windowSize,
+		InitialConnWindowSize: c.dial.connWindowSize,
+		ConnectedAt:           c.connectedAt,
+		Age:                   time.Since(c.connectedAt),
+	}
+
+	if ka := c.dial.keepalive; ka != nil {
+		info.KeepaliveInterval = ka.Time
+		info.KeepaliveTimeout = ka.Timeout
+	}
+
+	// The long-lived send streams carry the peer of the connection.
+	if txStream != nil {
+		if p, ok := peer.FromContext(txStream.Context()); ok {
+			if p.Addr != nil {
+				info.RemoteAddr = p.Addr.String()
+			}
+
+			if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+				info.TLS = true
+				info.TLSVersion = tlsVersionName(tlsInfo.State.Version)
+				info.CipherSuite = tls.CipherSuiteName(tlsInfo.State.CipherSuite)
+				info.ServerName = tlsInfo.State.ServerName
+			}
+		}
+	}
+
+	return info, nil
+}
+
+func tlsVersionName(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", version)
+	}
+}
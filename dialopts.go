@@ -0,0 +1,166 @@
+package client
+
+import (
+	"crypto/tls"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// dialConfig is how Connect dials, set by the client options below.
+type dialConfig struct {
+	// tls is nil for plaintext connections, see WithTLS.
+	tls *tls.Config
+	// clientCert returns the certificate of the client for mutual TLS, see WithClientCertificate.
+	clientCert     func() (*tls.Certificate, error)
+	connectTimeout time.Duration
+	// The buffers are disabled by default, so that messages are written and read as soon as possible.
+	readBuffer, writeBuffer int
+	// keepalive is nil without pings, see WithKeepalive.
+	keepalive *keepalive.ClientParameters
+	// The HTTP/2 flow control windows are 0 for the dynamic windows of grpc-go, see WithInitialWindowSize.
+	windowSize, connWindowSize int32
+
+	unaryInterceptors  []grpc.UnaryClientInterceptor
+	streamInterceptors []grpc.StreamClientInterceptor
+	extra              []grpc.DialOption
+}
+
+// WithAPIKey sets the API key the client authenticates with.
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.key = key
+	}
+}
+
+// WithTLS connects over TLS with the given configuration, for TLS-terminated endpoints. A nil config
+// verifies the server certificate against the system roots. Without it, the connection is plaintext.
+func WithTLS(cfg *tls.Config) ClientOption {
+	return func(c *Client) {
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+
+		c.dial.tls = cfg.Clone()
+	}
+}
+
+// WithConnectTimeout bounds Connect to d, on top of the deadline of its context.
+func WithConnectTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.dial.connectTimeout = d
+	}
+}
+
+// WithBufferSizes sets the sizes of the read and write buffers of the connection. They default to 0,
+// which disables buffering for the lowest latency, at the cost of more syscalls.
+func WithBufferSizes(read, write int) ClientOption {
+	return func(c *Client) {
+		c.dial.readBuffer = read
+		c.dial.writeBuffer = write
+	}
+}
+
+// WithKeepalive pings the server after interval without activity on the connection, and closes it if
+// the ping isn't answered within timeout, so that a connection silently dropped by a NAT or a firewall
+// idle timeout fails and the subscriptions reconnect, instead of waiting forever. With
+// permitWithoutStream, the connection is also pinged while no stream is open. grpc-go pings at most
+// every 10 seconds, and servers close connections pinging more often than they allow (every 5 minutes
+// by default), so pick an interval under the idle timeout of the network, not the shortest possible.
+func WithKeepalive(interval, timeout time.Duration, permitWithoutStream bool) ClientOption {
+	return func(c *Client) {
+		c.dial.keepalive = &keepalive.ClientParameters{
+			Time:                interval,
+			Timeout:             timeout,
+			PermitWithoutStream: permitWithoutStream,
+		}
+	}
+}
+
+// WithInitialWindowSize sets the initial HTTP/2 flow control window of every stream, so that a burst of
+// messages on a subscription isn't held back waiting for window updates. By default, grpc-go sizes the
+// windows dynamically from the estimated bandwidth-delay product, which setting a window disables.
+// Sizes under 64KB are ignored.
+func WithInitialWindowSize(size int32) ClientOption {
+	return func(c *Client) {
+		c.dial.windowSize = size
+	}
+}
+
+// WithInitialConnWindowSize sets the initial HTTP/2 flow control window of the connection, shared by all
+// its streams, like WithInitialWindowSize does for each stream.
+func WithInitialConnWindowSize(size int32) ClientOption {
+	return func(c *Client) {
+		c.dial.connWindowSize = size
+	}
+}
+
+// WithInterceptors adds gRPC interceptors to the connection, run in the order they were added.
+// Streams (all the subscriptions and sends) go through the stream interceptors.
+func WithInterceptors(unary []grpc.UnaryClientInterceptor, stream []grpc.StreamClientInterceptor) ClientOption {
+	return func(c *Client) {
+		c.dial.unaryInterceptors = append(c.dial.unaryInterceptors, unary...)
+		c.dial.streamInterceptors = append(c.dial.streamInterceptors, stream...)
+	}
+}
+
+// WithDialOptions adds gRPC dial options, applied after the ones of the other client options.
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *Client) {
+		c.dial.extra = append(c.dial.extra, opts...)
+	}
+}
+
+// transportCredentials returns the credentials Connect dials with.
+func (c *Client) transportCredentials() credentials.TransportCredentials {
+	cfg := c.dial.tls
+	if c.dial.clientCert != nil {
+		if cfg == nil {
+			cfg = &tls.Config{MinVersion: tls.VersionTLS12}
+		} else {
+			cfg = cfg.Clone()
+		}
+
+		get := c.dial.clientCert
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return get()
+		}
+	}
+
+	if cfg == nil {
+		return insecure.NewCredentials()
+	}
+
+	return credentials.NewTLS(cfg)
+}
+
+// dialOptions returns the options Connect dials with.
+func (c *Client) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(c.transportCredentials()),
+		grpc.WithBlock(),
+		grpc.WithReadBufferSize(c.dial.readBuffer),
+		grpc.WithWriteBufferSize(c.dial.writeBuffer),
+	}
+
+	if c.dial.keepalive != nil {
+		opts = append(opts, grpc.WithKeepaliveParams(*c.dial.keepalive))
+	}
+	if c.dial.windowSize > 0 {
+		opts = append(opts, grpc.WithInitialWindowSize(c.dial.windowSize))
+	}
+	if c.dial.connWindowSize > 0 {
+		opts = append(opts, grpc.WithInitialConnWindowSize(c.dial.connWindowSize))
+	}
+	if len(c.dial.unaryInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(c.dial.unaryInterceptors...))
+	}
+	if len(c.dial.streamInterceptors) > 0 {
+		opts = append(opts, grpc.WithChainStreamInterceptor(c.dial.streamInterceptors...))
+	}
+
+	return append(opts, c.dial.extra...)
+}
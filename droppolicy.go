@@ -0,0 +1,85 @@
+package client
+
+import "sync/atomic"
+
+// MessageClass is the kind of messages of a stream, which drop policies are configured by.
+type MessageClass int
+
+const (
+	Transactions MessageClass = iota
+	ExecutionHeaders
+	ExecutionPayloads
+	BeaconBlocks
+
+	numMessageClasses
+)
+
+func (m MessageClass) String() string {
+	switch m {
+	case Transactions:
+		return "transactions"
+	case ExecutionHeaders:
+		return "execution_headers"
+	case ExecutionPayloads:
+		return "execution_payloads"
+	case BeaconBlocks:
+		return "beacon_blocks"
+	}
+
+	return "unknown"
+}
+
+// DropPolicy is what a subscription does when its consumer is slower than the stream.
+type DropPolicy int
+
+const (
+	// NeverDrop waits for the consumer, so that no message is lost. This is the default.
+	NeverDrop DropPolicy = iota
+	// ShedWhenSlow drops the messages that can't be delivered right away because the channel is full,
+	// so a slow consumer gets a subset of the stream instead of falling behind.
+	ShedWhenSlow
+)
+
+// WithDropPolicy sets the drop policy of the subscriptions to a class of messages, for example to shed
+// mempool transactions under pressure while never dropping blocks. Shed messages are counted by
+// Client.Shed and SubscriptionStats.Shed.
+func WithDropPolicy(class MessageClass, policy DropPolicy) ClientOption {
+	return func(c *Client) {
+		if class >= 0 && class < numMessageClasses {
+			c.dropPolicies[class] = policy
+		}
+	}
+}
+
+// Shed returns the number of messages of the class that were dropped by its drop policy.
+func (c *Client) Shed(class MessageClass) uint64 {
+	if class < 0 || class >= numMessageClasses {
+		return 0
+	}
+
+	return atomic.LoadUint64(&c.shed[class])
+}
+
+// configureDrops applies the drop policy of the stream's class to cfg.
+func (c *Client) configureDrops(stream StreamKind, cfg *subscriptionConfig) {
+	class, ok := stream.Class()
+	if !ok || c.dropPolicies[class] != ShedWhenSlow {
+		return
+	}
+
+	sub := cfg.handle
+	cfg.onShed = func() {
+		atomic.AddUint64(&c.shed[class], 1)
+		sub.shedOne()
+	}
+}
+
+func (s *Subscription) shedOne() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats != nil {
+		s.stats.dropped++
+		s.stats.shed++
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrorCode is a stable, machine-readable identifier of a failure, for alerting rules and logs that
+// shouldn't depend on the error messages. Codes are never renamed or reused.
+type ErrorCode string
+
+// The codes of the errors of this package. ErrCodeUnknown is the code of any other error.
+const (
+	ErrCodeUnknown          ErrorCode = "UNKNOWN"
+	ErrCodeCanceled         ErrorCode = "CANCELED"
+	ErrCodeDeadlineExceeded ErrorCode = "DEADLINE_EXCEEDED"
+
+	// Failures of the Fiber API
+	ErrCodeUnauthenticated ErrorCode = "UNAUTHENTICATED"
+	ErrCodeRateLimited     ErrorCode = "RATE_LIMITED"
+	ErrCodeStreamClosed    ErrorCode = "STREAM_CLOSED"
+	ErrCodeInvalidTx       ErrorCode = "INVALID_TX"
+
+	// Client lifecycle, quota and endpoints
+	ErrCodeClientClosed            ErrorCode = "CLIENT_CLOSED"
+	ErrCodeShutdownTimeout         ErrorCode = "SHUTDOWN_TIMEOUT"
+	ErrCodeInvalidState            ErrorCode = "INVALID_STATE"
+	ErrCodeQuotaExceeded           ErrorCode = "QUOTA_EXCEEDED"
+	ErrCodeNoHealthyEndpoint       ErrorCode = "NO_HEALTHY_ENDPOINT"
+	ErrCodeForcedReconnect         ErrorCode = "FORCED_RECONNECT"
+	ErrCodeCompressorNotRegistered ErrorCode = "COMPRESSOR_NOT_REGISTERED"
+
+	// Subscriptions
+	ErrCodeNoFirstMessage        ErrorCode = "NO_FIRST_MESSAGE"
+	ErrCodeSubscriptionAbandoned ErrorCode = "SUBSCRIPTION_ABANDONED"
+	ErrCodeBackfillUnsupported   ErrorCode = "BACKFILL_UNSUPPORTED"
+	ErrCodeBackfillOverflow      ErrorCode = "BACKFILL_OVERFLOW"
+	ErrCodeUnknownTxType         ErrorCode = "UNKNOWN_TX_TYPE"
+	ErrCodeInvalidMessage        ErrorCode = "INVALID_MESSAGE"
+	ErrCodeFeedDiverged          ErrorCode = "FEED_DIVERGED"
+	ErrCodeNotFilterable         ErrorCode = "NOT_FILTERABLE"
+
+	// Sends
+	ErrCodeSequenceRejected       ErrorCode = "SEQUENCE_REJECTED"
+	ErrCodeSequenceReordered      ErrorCode = "SEQUENCE_REORDERED"
+	ErrCodeVetoed                 ErrorCode = "VETOED"
+	ErrCodeSendCancelled          ErrorCode = "SEND_CANCELLED"
+	ErrCodeSchedulerStopped       ErrorCode = "SCHEDULER_STOPPED"
+	ErrCodeNotBlobTx              ErrorCode = "NOT_BLOB_TX"
+	ErrCodeBlobSidecarMismatch    ErrorCode = "BLOB_SIDECAR_MISMATCH"
+	ErrCodeIntentsUnsupported     ErrorCode = "INTENTS_UNSUPPORTED"
+	ErrCodeReplacementUnsupported ErrorCode = "REPLACEMENT_UNSUPPORTED"
+	ErrCodeAckLogTampered         ErrorCode = "ACK_LOG_TAMPERED"
+	ErrCodeNoSendArchive          ErrorCode = "NO_SEND_ARCHIVE"
+	ErrCodeMalformedTx            ErrorCode = "MALFORMED_TX"
+	ErrCodeNonceUnknown           ErrorCode = "NONCE_UNKNOWN"
+
+	// Beacon blocks
+	ErrCodeMissingExecutionPayload ErrorCode = "MISSING_EXECUTION_PAYLOAD"
+)
+
+// CodedError is implemented by the errors of this package, and the errors wrapping them.
+type CodedError interface {
+	error
+	Code() ErrorCode
+}
+
+// codedError is a sentinel error with a code.
+type codedError struct {
+	code ErrorCode
+	msg  string
+}
+
+func newCodedError(code ErrorCode, msg string) error {
+	return &codedError{code: code, msg: msg}
+}
+
+func (e *codedError) Error() string {
+	return e.msg
+}
+
+func (e *codedError) Code() ErrorCode {
+	return e.code
+}
+
+// Code returns the code of the outermost CodedError err wraps, ErrCodeUnknown if there's none, and
+// "" for a nil error.
+func Code(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+
+	var coded CodedError
+	switch {
+	case errors.As(err, &coded):
+		return coded.Code()
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeDeadlineExceeded
+	default:
+		return ErrCodeUnknown
+	}
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"errors"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The failure modes of the Fiber API. The errors returned by the sends, the subscriptions and Connect
+// match them with errors.Is, and still carry the gRPC status (see status.FromError).
+var (
+	// ErrUnauthenticated is returned when the API key is missing, invalid or not allowed to make the call.
+	ErrUnauthenticated = newCodedError(ErrCodeUnauthenticated, "unauthenticated")
+	// ErrRateLimited is returned when the server rejected the call because the API key exceeded its limits.
+	ErrRateLimited = newCodedError(ErrCodeRateLimited, "rate limited")
+	// ErrStreamClosed is returned when the server ended, or the connection lost, the stream.
+	ErrStreamClosed = newCodedError(ErrCodeStreamClosed, "stream closed")
+	// ErrInvalidTx is returned by the sends when the server rejected the transaction.
+	ErrInvalidTx = newCodedError(ErrCodeInvalidTx, "invalid transaction")
+)
+
+// apiError is an error of the Fiber API, matching the sentinel error of its failure mode.
+type apiError struct {
+	err  error
+	kind error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+func (e *apiError) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *apiError) Code() ErrorCode {
+	return Code(e.kind)
+}
+
+// GRPCStatus returns the status of the wrapped error, so that status.FromError and status.Code keep
+// working on it.
+func (e *apiError) GRPCStatus() *status.Status {
+	s, _ := status.FromError(e.err)
+	return s
+}
+
+// streamError classifies an error returned by a stream, for the sentinel errors above. Errors that
+// aren't from the server are returned as is.
+func streamError(err error) error {
+	return classify(err, false)
+}
+
+// sendError is streamError for the streams that send transactions, on which the requests can be invalid.
+func sendError(err error) error {
+	return classify(err, true)
+}
+
+func classify(err error, send bool) error {
+	var apiErr *apiError
+	if err == nil || errors.As(err, &apiErr) {
+		return err
+	}
+
+	// The server ended the stream without an error
+	if errors.Is(err, io.EOF) {
+		return &apiError{err: err, kind: ErrStreamClosed}
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	var kind error
+	switch s.Code() {
+	case codes.Unauthenticated, codes.PermissionDenied:
+		kind = ErrUnauthenticated
+	case codes.ResourceExhausted:
+		kind = ErrRateLimited
+	case codes.Unavailable, codes.Aborted:
+		kind = ErrStreamClosed
+	case codes.InvalidArgument, codes.AlreadyExists, codes.FailedPrecondition:
+		if send {
+			kind = ErrInvalidTx
+		}
+	}
+
+	if kind == nil {
+		return err
+	}
+
+	return &apiError{err: err, kind: kind}
+}
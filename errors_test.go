@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// rejectingServer rejects every raw transaction and rate limits the header subscriptions.
+type rejectingServer struct {
+	api.UnimplementedAPIServer
+}
+
+func (rejectingServer) SendRawTransaction(stream api.API_SendRawTransactionServer) error {
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+
+	return status.Error(codes.InvalidArgument, "invalid signature")
+}
+
+func (rejectingServer) SubscribeExecutionHeaders(*emptypb.Empty, api.API_SubscribeExecutionHeadersServer) error {
+	return status.Error(codes.ResourceExhausted, "too many streams")
+}
+
+func TestSentinelErrors(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, rejectingServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(lis.Addr().String())
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_, _, err = c.SendRawTransaction(ctx, []byte{1})
+	if !errors.Is(err, ErrInvalidTx) || errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrInvalidTx, got %v", err)
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected the status to be kept, got %v", status.Code(err))
+	}
+
+	err = c.SubscribeNewExecutionPayloadHeaders(make(chan *ExecutionPayloadHeader))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+
+	// The beacon blocks aren't implemented by the server
+	err = c.SubscribeNewBeaconBlocks(make(chan *BeaconBlock))
+	if err == nil || errors.Is(err, ErrStreamClosed) || errors.Is(err, ErrInvalidTx) {
+		t.Fatalf("expected an unclassified error, got %v", err)
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	if err := streamError(io.EOF); !errors.Is(err, ErrStreamClosed) || !errors.Is(err, io.EOF) {
+		t.Fatalf("expected the end of the stream to be ErrStreamClosed, got %v", err)
+	}
+	if err := streamError(status.Error(codes.InvalidArgument, "bad filter")); errors.Is(err, ErrInvalidTx) {
+		t.Fatal("expected ErrInvalidTx only on sends")
+	}
+	if err := sendError(status.Error(codes.Unauthenticated, "no key")); !errors.Is(err, ErrUnauthenticated) {
+		t.Fatalf("expected ErrUnauthenticated, got %v", err)
+	}
+	if err := streamError(ErrClientClosed); err != ErrClientClosed {
+		t.Fatalf("expected other errors to be unchanged, got %v", err)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	for err, want := range map[error]ErrorCode{
+		nil:                           "",
+		ErrClientClosed:               ErrCodeClientClosed,
+		&QuotaError{Limit: "streams"}: ErrCodeQuotaExceeded,
+		&SequenceError{Err: ErrSequenceReordered}:                                        ErrCodeSequenceReordered,
+		&VetoError{Err: errors.New("blocked")}:                                           ErrCodeVetoed,
+		fmt.Errorf("sending: %w", sendError(status.Error(codes.AlreadyExists, "known"))): ErrCodeInvalidTx,
+		fmt.Errorf("waiting: %w", context.DeadlineExceeded):                              ErrCodeDeadlineExceeded,
+		status.Error(codes.Internal, "internal"):                                         ErrCodeUnknown,
+	} {
+		if got := Code(err); got != want {
+			t.Errorf("expected the code of %v to be %q, got %q", err, want, got)
+		}
+	}
+}
@@ -0,0 +1,509 @@
+// Package fakeclient is a fake client.FiberClient driven by channels, for the unit tests of code
+// using fiber-go. The messages sent on its channels are delivered to its subscriptions, and the
+// transactions sent with it are recorded.
+package fakeclient
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/intent"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Sent is a transaction sent with the fake client.
+type Sent struct {
+	// Raw is the transaction as the client would send it: the network form for blob transactions.
+	Raw []byte
+	// Tx is the transaction, nil for raw transactions go-ethereum can't decode.
+	Tx   *types.Transaction
+	Hash common.Hash
+	// Sequence is the number of the sequence the transaction was sent in, counting from 1, or 0 if it
+	// wasn't sent in a sequence.
+	Sequence int
+}
+
+// Client is a fake client.FiberClient. The messages sent on its channels are delivered to the
+// subscriptions of their stream, each to one of them if there are several. A subscription closes its
+// channel and returns nil once the channel feeding it is closed, ctx.Err() once its context is done,
+// and client.ErrClientClosed once the client is closed. The filters and subscription options are
+// ignored: the tests feed the messages the code under test expects. Batched subscriptions batch the
+// transactions of Txs, and blob sidecar subscriptions deliver BlobSidecars without asking their source.
+type Client struct {
+	Txs          chan *client.Transaction
+	Headers      chan *client.ExecutionPayloadHeader
+	Payloads     chan *client.ExecutionPayload
+	BeaconBlocks chan *client.BeaconBlock
+	BlobSidecars chan *client.BeaconBlobSidecar
+
+	// OnSend is called with every transaction sent, if set. Its error fails the send.
+	OnSend func(sent Sent) error
+
+	mu        sync.Mutex
+	sent      []Sent
+	intents   []*intent.Signed
+	sequences int
+	// outstanding are the sequences sent with a replacement UUID, and cancelled the UUIDs cancelled.
+	outstanding map[string][]common.Hash
+	cancelled   []string
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+var _ client.FiberClient = (*Client)(nil)
+
+// New returns a fake client with unbuffered channels, so that feeding a message blocks until a
+// subscription received it.
+func New() *Client {
+	return &Client{
+		Txs:          make(chan *client.Transaction),
+		Headers:      make(chan *client.ExecutionPayloadHeader),
+		Payloads:     make(chan *client.ExecutionPayload),
+		BeaconBlocks: make(chan *client.BeaconBlock),
+		BlobSidecars: make(chan *client.BeaconBlobSidecar),
+		closed:       make(chan struct{}),
+	}
+}
+
+// Sent returns the transactions sent so far, in order.
+func (c *Client) Sent() []Sent {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Sent(nil), c.sent...)
+}
+
+// Intents returns the intents sent so far, in order.
+func (c *Client) Intents() []*intent.Signed {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]*intent.Signed(nil), c.intents...)
+}
+
+func (c *Client) Connect(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// Close ends the subscriptions, and fails the sends from then on with client.ErrClientClosed.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// send records the transactions sent together, and returns their timestamp.
+func (c *Client) send(ctx context.Context, sent []Sent, sequence bool) (int64, error) {
+	if c.isClosed() {
+		return 0, client.ErrClientClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if c.OnSend != nil {
+		for _, s := range sent {
+			if err := c.OnSend(s); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var seq int
+	if sequence {
+		c.sequences++
+		seq = c.sequences
+	}
+	for i := range sent {
+		sent[i].Sequence = seq
+		c.sent = append(c.sent, sent[i])
+	}
+
+	return time.Now().UnixMicro(), nil
+}
+
+func nativeSent(tx *types.Transaction) (Sent, error) {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return Sent{}, err
+	}
+
+	return Sent{Raw: raw, Tx: tx, Hash: tx.Hash()}, nil
+}
+
+func rawSent(raw []byte) Sent {
+	sent := Sent{Raw: raw, Hash: crypto.Keccak256Hash(raw)}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err == nil {
+		sent.Tx = tx
+	}
+
+	return sent
+}
+
+func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) (string, int64, error) {
+	sent, err := nativeSent(tx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	timestamp, err := c.send(ctx, []Sent{sent}, false)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return sent.Hash.Hex(), timestamp, nil
+}
+
+// SendTransactionAsync is SendTransaction, calling callback with the result from another goroutine.
+func (c *Client) SendTransactionAsync(tx *types.Transaction, callback func(resp *client.SendResponse, err error)) {
+	go func() {
+		hash, timestamp, err := c.SendTransaction(context.Background(), tx)
+		if callback == nil {
+			return
+		}
+		if err != nil {
+			callback(nil, err)
+			return
+		}
+
+		callback(&client.SendResponse{Hash: hash, Timestamp: timestamp}, nil)
+	}()
+}
+
+// SendAndConfirm sends tx like SendTransaction, then reads the payloads fed on Payloads until one
+// includes it and confirmations blocks were fed from that one on, like client.Client, including when
+// another block replaces the including one. The payloads it reads aren't delivered to the subscriptions.
+func (c *Client) SendAndConfirm(ctx context.Context, tx *types.Transaction, confirmations int) (*client.Confirmation, error) {
+	sentAt := time.Now()
+	_, timestamp, err := c.SendTransaction(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var conf *client.Confirmation
+	for {
+		var block *client.ExecutionPayload
+		select {
+		case b, ok := <-c.Payloads:
+			if !ok {
+				return conf, fmt.Errorf("fakeclient: Payloads closed before %s was confirmed", tx.Hash())
+			}
+			block = b
+		case <-ctx.Done():
+			return conf, ctx.Err()
+		case <-c.closed:
+			return conf, client.ErrClientClosed
+		}
+
+		if conf != nil {
+			if block.Header.Number > conf.BlockNumber {
+				conf.Confirmations = int(block.Header.Number-conf.BlockNumber) + 1
+			} else if block.Header.Hash != conf.BlockHash {
+				conf = nil
+			}
+		}
+
+		if conf == nil {
+			for i, included := range block.Transactions {
+				if included.Hash == tx.Hash() {
+					conf = &client.Confirmation{
+						Hash:          included.Hash,
+						Timestamp:     timestamp,
+						BlockNumber:   block.Header.Number,
+						BlockHash:     block.Header.Hash,
+						Index:         i,
+						Delay:         time.Since(sentAt),
+						Confirmations: 1,
+					}
+					break
+				}
+			}
+		}
+
+		if conf != nil && conf.Confirmations >= confirmations {
+			return conf, nil
+		}
+	}
+}
+
+func (c *Client) SendRawTransaction(ctx context.Context, rawTx []byte) (string, int64, error) {
+	sent := rawSent(rawTx)
+	timestamp, err := c.send(ctx, []Sent{sent}, false)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return sent.Hash.Hex(), timestamp, nil
+}
+
+// SendRawTransactionHex decodes and checks the transaction like client.Client, failing with
+// client.ErrMalformedTx, and sends it with SendRawTransaction.
+func (c *Client) SendRawTransactionHex(ctx context.Context, rawTx string) (string, int64, error) {
+	raw, err := client.DecodeRawTxHex(rawTx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return c.SendRawTransaction(ctx, raw)
+}
+
+func (c *Client) SendBlobTransaction(ctx context.Context, tx *client.BlobTx, sidecar *client.BlobSidecar) (string, int64, error) {
+	raw, err := client.EncodeBlobTx(tx, sidecar)
+	if err != nil {
+		return "", 0, err
+	}
+
+	sent := Sent{Raw: raw, Hash: tx.Hash()}
+	timestamp, err := c.send(ctx, []Sent{sent}, false)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return sent.Hash.Hex(), timestamp, nil
+}
+
+// SendIntent checks the signature of the intent and records it. Unlike client.Client, which returns
+// client.ErrIntentsUnsupported until Fiber accepts intents, it returns the signing hash. OnSend isn't
+// called for intents.
+func (c *Client) SendIntent(ctx context.Context, signed *intent.Signed) (string, int64, error) {
+	if c.isClosed() {
+		return "", 0, client.ErrClientClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+	if err := signed.Verify(); err != nil {
+		return "", 0, err
+	}
+
+	hash, err := intent.Hash(signed.Data)
+	if err != nil {
+		return "", 0, err
+	}
+
+	c.mu.Lock()
+	c.intents = append(c.intents, signed)
+	c.mu.Unlock()
+
+	return hash.Hex(), time.Now().UnixMicro(), nil
+}
+
+// sendSequence records the sequence, under its replacement UUID if it has one, and returns its result.
+func (c *Client) sendSequence(ctx context.Context, sent []Sent) (*client.SequenceResult, error) {
+	timestamp, err := c.send(ctx, sent, true)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &client.SequenceResult{Items: make([]client.SequenceItem, len(sent))}
+	hashes := make([]common.Hash, len(sent))
+	for i, s := range sent {
+		res.Items[i] = client.SequenceItem{Hash: s.Hash, Position: i, Timestamp: timestamp}
+		hashes[i] = s.Hash
+	}
+
+	if uuid := client.SendOptionsFromContext(ctx).ReplacementUUID; uuid != "" {
+		c.mu.Lock()
+		if c.outstanding == nil {
+			c.outstanding = make(map[string][]common.Hash)
+		}
+		c.outstanding[uuid] = hashes
+		c.mu.Unlock()
+	}
+
+	return res, nil
+}
+
+// Cancelled returns the replacement UUIDs of the sequences cancelled so far, in order.
+func (c *Client) Cancelled() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.cancelled...)
+}
+
+// OutstandingSequences returns the hashes of the sequences sent with a client.SendOptions.ReplacementUUID,
+// by UUID, until they're cancelled, replaced or forgotten.
+func (c *Client) OutstandingSequences() map[string][]common.Hash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seqs := make(map[string][]common.Hash, len(c.outstanding))
+	for uuid, hashes := range c.outstanding {
+		seqs[uuid] = append([]common.Hash(nil), hashes...)
+	}
+
+	return seqs
+}
+
+func (c *Client) ForgetSequence(uuid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.outstanding, uuid)
+}
+
+// CancelSequence cancels the outstanding sequence sent with the replacement UUID uuid, and records it.
+// Unlike client.Client, which returns client.ErrReplacementUnsupported until Fiber carries replacement
+// UUIDs, it succeeds.
+func (c *Client) CancelSequence(ctx context.Context, uuid string) error {
+	if c.isClosed() {
+		return client.ErrClientClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.outstanding[uuid]; !ok {
+		return fmt.Errorf("cancelling sequence: no outstanding sequence with replacement uuid %q", uuid)
+	}
+	delete(c.outstanding, uuid)
+	c.cancelled = append(c.cancelled, uuid)
+
+	return nil
+}
+
+// ReplaceSequence cancels the outstanding sequence sent with the replacement UUID uuid like
+// CancelSequence, and sends transactions in its place with the same UUID.
+func (c *Client) ReplaceSequence(ctx context.Context, uuid string, transactions ...*types.Transaction) (*client.SequenceResult, error) {
+	if err := c.CancelSequence(ctx, uuid); err != nil {
+		return nil, err
+	}
+
+	opts := client.SendOptionsFromContext(ctx)
+	opts.ReplacementUUID = uuid
+	return c.SendTransactionSequenceResult(client.WithSendOptions(ctx, opts), transactions...)
+}
+
+func (c *Client) SendTransactionSequenceResult(ctx context.Context, transactions ...*types.Transaction) (*client.SequenceResult, error) {
+	sent := make([]Sent, len(transactions))
+	for i, tx := range transactions {
+		var err error
+		if sent[i], err = nativeSent(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.sendSequence(ctx, sent)
+}
+
+func (c *Client) SendRawTransactionSequenceResult(ctx context.Context, rawTransactions ...[]byte) (*client.SequenceResult, error) {
+	sent := make([]Sent, len(rawTransactions))
+	for i, raw := range rawTransactions {
+		sent[i] = rawSent(raw)
+	}
+
+	return c.sendSequence(ctx, sent)
+}
+
+func (c *Client) SendTransactionSequence(ctx context.Context, transactions ...*types.Transaction) ([]string, int64, error) {
+	res, err := c.SendTransactionSequenceResult(ctx, transactions...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res.Hashes(), res.Timestamp(), nil
+}
+
+func (c *Client) SendRawTransactionSequence(ctx context.Context, rawTransactions ...[]byte) ([]string, int64, error) {
+	res, err := c.SendRawTransactionSequenceResult(ctx, rawTransactions...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return res.Hashes(), res.Timestamp(), nil
+}
+
+// forward delivers the messages of feed on ch until feed is closed, ctx is done or c is closed.
+func forward[T any](ctx context.Context, c *Client, feed <-chan T, ch chan<- T) error {
+	defer close(ch)
+
+	for {
+		select {
+		case msg, ok := <-feed:
+			if !ok {
+				return nil
+			}
+
+			select {
+			case ch <- msg:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-c.closed:
+				return client.ErrClientClosed
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.closed:
+			return client.ErrClientClosed
+		}
+	}
+}
+
+func (c *Client) SubscribeNewTxs(_ *filter.Filter, ch chan<- *client.Transaction, _ ...client.SubscriptionOption) error {
+	return forward(context.Background(), c, c.Txs, ch)
+}
+
+func (c *Client) SubscribeNewExecutionPayloadHeaders(ch chan<- *client.ExecutionPayloadHeader, _ ...client.SubscriptionOption) error {
+	return forward(context.Background(), c, c.Headers, ch)
+}
+
+func (c *Client) SubscribeNewExecutionPayloads(ch chan<- *client.ExecutionPayload, _ ...client.SubscriptionOption) error {
+	return forward(context.Background(), c, c.Payloads, ch)
+}
+
+func (c *Client) SubscribeNewBeaconBlocks(ch chan<- *client.BeaconBlock, _ ...client.SubscriptionOption) error {
+	return forward(context.Background(), c, c.BeaconBlocks, ch)
+}
+
+func (c *Client) SubscribeNewTxsBatched(_ *filter.Filter, ch chan<- []*client.Transaction, batch client.BatchOptions, _ ...client.SubscriptionOption) error {
+	txs := func(_ *client.Client, ch chan<- *client.Transaction, _ ...client.SubscriptionOption) error {
+		return forward(context.Background(), c, c.Txs, ch)
+	}
+
+	return client.Batched(txs, batch)(nil, ch)
+}
+
+func (c *Client) SubscribeBlobSidecars(_ client.BlobSidecarSource, ch chan<- *client.BeaconBlobSidecar, _ ...client.SubscriptionOption) error {
+	return forward(context.Background(), c, c.BlobSidecars, ch)
+}
+
+func (c *Client) SubscribeNewTxsWithContext(ctx context.Context, _ *filter.Filter, ch chan<- *client.Transaction, _ ...client.SubscriptionOption) error {
+	return forward(ctx, c, c.Txs, ch)
+}
+
+func (c *Client) SubscribeNewExecutionPayloadHeadersWithContext(ctx context.Context, ch chan<- *client.ExecutionPayloadHeader, _ ...client.SubscriptionOption) error {
+	return forward(ctx, c, c.Headers, ch)
+}
+
+func (c *Client) SubscribeNewExecutionPayloadsWithContext(ctx context.Context, ch chan<- *client.ExecutionPayload, _ ...client.SubscriptionOption) error {
+	return forward(ctx, c, c.Payloads, ch)
+}
+
+func (c *Client) SubscribeNewBeaconBlocksWithContext(ctx context.Context, ch chan<- *client.BeaconBlock, _ ...client.SubscriptionOption) error {
+	return forward(ctx, c, c.BeaconBlocks, ch)
+}
@@ -0,0 +1,172 @@
+package fakeclient
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestClient(t *testing.T) {
+	c := New()
+
+	txs := make(chan *client.Transaction)
+	errc := make(chan error, 1)
+	go func() { errc <- c.SubscribeNewTxs(nil, txs) }()
+
+	want := &client.Transaction{Hash: common.HexToHash("0x01")}
+	go func() { c.Txs <- want }()
+	if got := <-txs; got != want {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1)}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, _ := tx.MarshalBinary()
+	if _, _, err := c.SendTransactionSequence(context.Background(), tx); err != nil {
+		t.Fatal(err)
+	}
+	if hash, _, err := c.SendRawTransaction(context.Background(), raw); err != nil || hash != tx.Hash().Hex() {
+		t.Fatalf("expected %s, got %s %v", tx.Hash(), hash, err)
+	}
+	if _, _, err := c.SendRawTransactionHex(context.Background(), "0x02"); !errors.Is(err, client.ErrMalformedTx) {
+		t.Fatalf("expected ErrMalformedTx, got %v", err)
+	}
+	if hash, _, err := c.SendRawTransactionHex(context.Background(), hexutil.Encode(raw)); err != nil || hash != tx.Hash().Hex() {
+		t.Fatalf("expected %s, got %s %v", tx.Hash(), hash, err)
+	}
+
+	rejected := errors.New("rejected")
+	c.OnSend = func(Sent) error { return rejected }
+	if _, _, err := c.SendTransaction(context.Background(), tx); !errors.Is(err, rejected) {
+		t.Fatalf("expected the send to be rejected, got %v", err)
+	}
+
+	c.OnSend = nil
+	acked := make(chan *client.SendResponse, 1)
+	c.SendTransactionAsync(tx, func(res *client.SendResponse, err error) { acked <- res })
+	if res := <-acked; res == nil || res.Hash != tx.Hash().Hex() {
+		t.Fatalf("unexpected acknowledgement %+v", res)
+	}
+
+	sent := c.Sent()
+	if len(sent) != 4 || sent[0].Sequence != 1 || sent[1].Sequence != 0 || sent[1].Tx.Hash() != tx.Hash() {
+		t.Fatalf("unexpected sent transactions %+v", sent)
+	}
+
+	c.Close()
+	if err := <-errc; !errors.Is(err, client.ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+	if _, ok := <-txs; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+}
+
+func TestBatched(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	batches := make(chan []*client.Transaction)
+	go c.SubscribeNewTxsBatched(nil, batches, client.BatchOptions{MaxSize: 2, FlushInterval: time.Second})
+
+	go func() {
+		c.Txs <- &client.Transaction{Nonce: 1}
+		c.Txs <- &client.Transaction{Nonce: 2}
+	}()
+	if batch := <-batches; len(batch) != 2 || batch[1].Nonce != 2 {
+		t.Fatalf("expected a batch of 2 transactions, got %v", batch)
+	}
+}
+
+func TestSendAndConfirm(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignTx(types.NewTx(&types.LegacyTx{Gas: 21000, GasPrice: big.NewInt(1)}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := func(n uint64, hash byte) *client.ExecutionPayloadHeader {
+		return &client.ExecutionPayloadHeader{Number: n, Hash: common.Hash{hash}}
+	}
+	go func() {
+		c.Payloads <- &client.ExecutionPayload{Header: header(1, 1)}
+		c.Payloads <- &client.ExecutionPayload{Header: header(2, 2), Transactions: []*client.Transaction{{}, {Hash: tx.Hash()}}}
+		c.Payloads <- &client.ExecutionPayload{Header: header(3, 3)}
+	}()
+
+	conf, err := c.SendAndConfirm(context.Background(), tx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conf.BlockNumber != 2 || conf.Index != 1 || conf.Confirmations != 2 || len(c.Sent()) != 1 {
+		t.Fatalf("unexpected confirmation %+v", conf)
+	}
+
+	c.Close()
+	if _, err := c.SendAndConfirm(context.Background(), tx, 1); !errors.Is(err, client.ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+}
+
+func TestReplaceSequence(t *testing.T) {
+	c := New()
+	defer c.Close()
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(nonce uint64) *types.Transaction {
+		tx, err := types.SignTx(types.NewTx(&types.LegacyTx{Nonce: nonce, Gas: 21000, GasPrice: big.NewInt(1)}), types.NewLondonSigner(common.Big1), pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+
+	const uuid = "d3b07384-d9a0-4c9b-8a1d-8f4a8c3e7e21"
+	ctx := client.WithSendOptions(context.Background(), client.SendOptions{ReplacementUUID: uuid})
+	if _, err := c.SendTransactionSequenceResult(ctx, sign(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	replacement := sign(2)
+	if _, err := c.ReplaceSequence(context.Background(), uuid, replacement); err != nil {
+		t.Fatal(err)
+	}
+	if seqs := c.OutstandingSequences(); len(seqs[uuid]) != 1 || seqs[uuid][0] != replacement.Hash() {
+		t.Fatalf("expected the replacement to be outstanding, got %v", seqs)
+	}
+
+	if err := c.CancelSequence(context.Background(), uuid); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CancelSequence(context.Background(), uuid); err == nil {
+		t.Fatal("expected a cancelled sequence not to be outstanding")
+	}
+	if cancelled := c.Cancelled(); len(cancelled) != 2 || len(c.OutstandingSequences()) != 0 || len(c.Sent()) != 2 {
+		t.Fatalf("unexpected cancellations %v", cancelled)
+	}
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// FeedSink is a Sink sending every message on feed, for codebases built around go-ethereum's event
+// feeds, like forks of geth. Subscribers receive values of type T, and the feed panics if they
+// subscribed with another type. Like every send on a feed, Write blocks until all the subscribers
+// received the message, so a subscriber that stops reading without unsubscribing stalls the pipeline.
+func FeedSink[T any](feed *event.Feed) Sink[T] {
+	return SinkFunc[T](func(_ context.Context, msg T) error {
+		feed.Send(msg)
+		return nil
+	})
+}
+
+// NativeTxFeedSink is a Sink sending every transaction on feed as a go-ethereum transaction, see
+// Transaction.ToNative. The transactions go-ethereum can't represent are skipped.
+func NativeTxFeedSink(feed *event.Feed) Sink[*Transaction] {
+	return SinkFunc[*Transaction](func(_ context.Context, tx *Transaction) error {
+		if native := tx.ToNative(); native != nil {
+			feed.Send(native)
+		}
+		return nil
+	})
+}
+
+// NativeHeaderFeedSink is a Sink sending every execution payload header on feed as a go-ethereum
+// header, see ExecutionPayloadHeader.ToNative.
+func NativeHeaderFeedSink(feed *event.Feed) Sink[*ExecutionPayloadHeader] {
+	return SinkFunc[*ExecutionPayloadHeader](func(_ context.Context, h *ExecutionPayloadHeader) error {
+		feed.Send(h.ToNative())
+		return nil
+	})
+}
+
+// ToNative converts the header to a go-ethereum header, with the fields fixed since the merge: no
+// uncles, difficulty and nonce. The go-ethereum version the client builds with predates the
+// withdrawals root, so the hash of the header only matches Hash for blocks before Capella.
+func (h *ExecutionPayloadHeader) ToNative() *types.Header {
+	return &types.Header{
+		ParentHash:  h.ParentHash,
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    h.FeeRecipient,
+		Root:        h.StateRoot,
+		TxHash:      h.TransactionsRoot,
+		ReceiptHash: h.ReceiptRoot,
+		Bloom:       h.LogsBloom,
+		Difficulty:  new(big.Int),
+		Number:      new(big.Int).SetUint64(h.Number),
+		GasLimit:    h.GasLimit,
+		GasUsed:     h.GasUsed,
+		Time:        h.Timestamp,
+		Extra:       h.ExtraData,
+		MixDigest:   h.PrevRandao,
+		BaseFee:     h.BaseFeePerGas,
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+func TestNativeHeaderFeedSink(t *testing.T) {
+	var feed event.Feed
+	headers := make(chan *types.Header, 2)
+	sub := feed.Subscribe(headers)
+	defer sub.Unsubscribe()
+
+	p := NewPipeline(headerSource(
+		&eth.ExecutionPayloadHeader{BlockNumber: 1, BlockHash: []byte{1}},
+		&eth.ExecutionPayloadHeader{BlockNumber: 2, BlockHash: []byte{2}},
+	)).Sink(NativeHeaderFeedSink(&feed))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- p.Run(ctx, &Client{}) }()
+
+	for want := uint64(1); want <= 2; want++ {
+		if h := <-headers; h.Number.Uint64() != want {
+			t.Fatalf("expected header %d, got %d", want, h.Number)
+		}
+	}
+	cancel()
+	<-errc
+}
+
+func TestHeaderToNative(t *testing.T) {
+	native := &types.Header{
+		ParentHash:  common.HexToHash("0x01"),
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    common.HexToAddress("0x02"),
+		Root:        common.HexToHash("0x03"),
+		TxHash:      common.HexToHash("0x04"),
+		ReceiptHash: common.HexToHash("0x05"),
+		Difficulty:  new(big.Int),
+		Number:      big.NewInt(15537394),
+		GasLimit:    30000000,
+		GasUsed:     12000000,
+		Time:        1663224179,
+		Extra:       []byte("fiber"),
+		MixDigest:   common.HexToHash("0x06"),
+		BaseFee:     big.NewInt(7),
+	}
+
+	h := &ExecutionPayloadHeader{
+		Number:           15537394,
+		Hash:             native.Hash(),
+		ParentHash:       native.ParentHash,
+		PrevRandao:       native.MixDigest,
+		StateRoot:        native.Root,
+		ReceiptRoot:      native.ReceiptHash,
+		FeeRecipient:     native.Coinbase,
+		ExtraData:        native.Extra,
+		GasLimit:         native.GasLimit,
+		GasUsed:          native.GasUsed,
+		Timestamp:        native.Time,
+		BaseFeePerGas:    native.BaseFee,
+		TransactionsRoot: native.TxHash,
+	}
+
+	if hash := h.ToNative().Hash(); hash != h.Hash {
+		t.Fatalf("expected hash %s, got %s", h.Hash, hash)
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// legacyFees returns whether the fees of tx are a single gas price, rather than a fee cap and a tip cap.
+func (tx *Transaction) legacyFees() bool {
+	return tx.Type == types.LegacyTxType || tx.Type == types.AccessListTxType || tx.MaxFee == nil
+}
+
+// GasFeeCap returns the most the transaction pays per gas: its gas price, or for the dynamic fee and
+// blob transactions its max fee. It's never nil.
+func (tx *Transaction) GasFeeCap() *big.Int {
+	if tx.legacyFees() {
+		return orZero(tx.GasPrice)
+	}
+
+	return new(big.Int).Set(tx.MaxFee)
+}
+
+// GasTipCap returns the most the transaction tips per gas: its gas price, or for the dynamic fee and
+// blob transactions its max priority fee. It's never nil.
+func (tx *Transaction) GasTipCap() *big.Int {
+	if tx.legacyFees() {
+		return orZero(tx.GasPrice)
+	}
+
+	return orZero(tx.PriorityFee)
+}
+
+// EffectiveTip returns what the transaction tips per gas in a block with baseFee: the tip cap, capped
+// at the fee cap minus baseFee. It's negative if the fee cap is below baseFee, when the transaction
+// can't be included, and the tip cap if baseFee is nil.
+func (tx *Transaction) EffectiveTip(baseFee *big.Int) *big.Int {
+	tip := tx.GasTipCap()
+	if baseFee == nil {
+		return tip
+	}
+
+	if headroom := new(big.Int).Sub(tx.GasFeeCap(), baseFee); headroom.Cmp(tip) < 0 {
+		return headroom
+	}
+
+	return tip
+}
+
+// EffectiveGasPrice returns what the transaction pays per gas in a block with baseFee: baseFee plus the
+// effective tip, which is the gas price of the legacy transactions. It's the fee cap if baseFee is nil.
+func (tx *Transaction) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if tx.legacyFees() || baseFee == nil {
+		return tx.GasFeeCap()
+	}
+
+	return new(big.Int).Add(baseFee, tx.EffectiveTip(baseFee))
+}
+
+// MaxCostWei returns the most the transaction can cost its sender: its value plus its gas limit at the
+// fee cap. For blob transactions it excludes the blob gas, whose fee cap the transaction stream doesn't
+// carry.
+func (tx *Transaction) MaxCostWei() *big.Int {
+	cost := new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas))
+	if tx.Value != nil {
+		cost.Add(cost, tx.Value)
+	}
+
+	return cost
+}
+
+// orZero returns a copy of v, 0 if it's nil.
+func orZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+
+	return new(big.Int).Set(v)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFees(t *testing.T) {
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9)) }
+
+	for name, tt := range map[string]struct {
+		tx               *Transaction
+		baseFee          *big.Int
+		feeCap, tipCap   *big.Int
+		tip, price, cost *big.Int
+	}{
+		"legacy": {
+			tx:      &Transaction{Type: 0, GasPrice: gwei(30), MaxFee: new(big.Int), PriorityFee: new(big.Int), Gas: 21000, Value: big.NewInt(1)},
+			baseFee: gwei(20),
+			feeCap:  gwei(30), tipCap: gwei(30), tip: gwei(10), price: gwei(30),
+			cost: new(big.Int).Add(new(big.Int).Mul(gwei(30), big.NewInt(21000)), big.NewInt(1)),
+		},
+		"access list": {
+			tx:      &Transaction{Type: 1, GasPrice: gwei(30), Gas: 1},
+			baseFee: gwei(25),
+			feeCap:  gwei(30), tipCap: gwei(30), tip: gwei(5), price: gwei(30), cost: gwei(30),
+		},
+		"dynamic fee capped by the tip": {
+			tx:      &Transaction{Type: 2, GasPrice: new(big.Int), MaxFee: gwei(50), PriorityFee: gwei(2), Gas: 1},
+			baseFee: gwei(20),
+			feeCap:  gwei(50), tipCap: gwei(2), tip: gwei(2), price: gwei(22), cost: gwei(50),
+		},
+		"dynamic fee capped by the fee cap": {
+			tx:      &Transaction{Type: 2, MaxFee: gwei(21), PriorityFee: gwei(2), Gas: 1},
+			baseFee: gwei(20),
+			feeCap:  gwei(21), tipCap: gwei(2), tip: gwei(1), price: gwei(21), cost: gwei(21),
+		},
+		"dynamic fee below the base fee": {
+			tx:      &Transaction{Type: 2, MaxFee: gwei(15), PriorityFee: gwei(2), Gas: 1},
+			baseFee: gwei(20),
+			feeCap:  gwei(15), tipCap: gwei(2), tip: gwei(-5), price: gwei(15), cost: gwei(15),
+		},
+		"dynamic fee without base fee": {
+			tx:     &Transaction{Type: 2, MaxFee: gwei(50), PriorityFee: gwei(2), Gas: 1},
+			feeCap: gwei(50), tipCap: gwei(2), tip: gwei(2), price: gwei(50), cost: gwei(50),
+		},
+		"blob": {
+			tx:      &Transaction{Type: BlobTxType, MaxFee: gwei(40), PriorityFee: gwei(3), Gas: 2},
+			baseFee: gwei(30),
+			feeCap:  gwei(40), tipCap: gwei(3), tip: gwei(3), price: gwei(33), cost: gwei(80),
+		},
+		"missing fields": {
+			tx:      &Transaction{Type: 2},
+			baseFee: gwei(1),
+			feeCap:  new(big.Int), tipCap: new(big.Int), tip: gwei(-1), price: new(big.Int), cost: new(big.Int),
+		},
+	} {
+		for field, got := range map[string][2]*big.Int{
+			"fee cap":             {tt.tx.GasFeeCap(), tt.feeCap},
+			"tip cap":             {tt.tx.GasTipCap(), tt.tipCap},
+			"effective tip":       {tt.tx.EffectiveTip(tt.baseFee), tt.tip},
+			"effective gas price": {tt.tx.EffectiveGasPrice(tt.baseFee), tt.price},
+			"max cost":            {tt.tx.MaxCostWei(), tt.cost},
+		} {
+			if got[0].Cmp(got[1]) != 0 {
+				t.Errorf("%s: expected %s %s, got %s", name, field, got[1], got[0])
+			}
+		}
+	}
+
+	// The accessors return copies
+	tx := &Transaction{Type: 2, MaxFee: gwei(1), PriorityFee: gwei(1)}
+	tx.GasFeeCap().SetInt64(0)
+	tx.GasTipCap().SetInt64(0)
+	if tx.MaxFee.Cmp(gwei(1)) != 0 || tx.PriorityFee.Cmp(gwei(1)) != 0 {
+		t.Fatal("expected the fees of the transaction to be unchanged")
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/intent"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// FiberClient is the interface of the send and subscribe methods of Client, for code that takes a
+// client as a dependency and mocks it in its tests. The fakeclient package implements it with channels.
+type FiberClient interface {
+	Connect(ctx context.Context) error
+	Close() error
+
+	SendTransaction(ctx context.Context, tx *types.Transaction) (string, int64, error)
+	SendTransactionAsync(tx *types.Transaction, callback func(resp *SendResponse, err error))
+	SendAndConfirm(ctx context.Context, tx *types.Transaction, confirmations int) (*Confirmation, error)
+	SendRawTransaction(ctx context.Context, rawTx []byte) (string, int64, error)
+	SendRawTransactionHex(ctx context.Context, rawTx string) (string, int64, error)
+	SendTransactionSequence(ctx context.Context, transactions ...*types.Transaction) ([]string, int64, error)
+	SendTransactionSequenceResult(ctx context.Context, transactions ...*types.Transaction) (*SequenceResult, error)
+	SendRawTransactionSequence(ctx context.Context, rawTransactions ...[]byte) ([]string, int64, error)
+	SendRawTransactionSequenceResult(ctx context.Context, rawTransactions ...[]byte) (*SequenceResult, error)
+	SendBlobTransaction(ctx context.Context, tx *BlobTx, sidecar *BlobSidecar) (string, int64, error)
+	SendIntent(ctx context.Context, signed *intent.Signed) (string, int64, error)
+
+	CancelSequence(ctx context.Context, uuid string) error
+	ReplaceSequence(ctx context.Context, uuid string, transactions ...*types.Transaction) (*SequenceResult, error)
+	OutstandingSequences() map[string][]common.Hash
+	ForgetSequence(uuid string)
+
+	SubscribeNewTxs(filter *filter.Filter, ch chan<- *Transaction, opts ...SubscriptionOption) error
+	SubscribeNewExecutionPayloadHeaders(ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error
+	SubscribeNewExecutionPayloads(ch chan<- *ExecutionPayload, opts ...SubscriptionOption) error
+	SubscribeNewBeaconBlocks(ch chan<- *BeaconBlock, opts ...SubscriptionOption) error
+	SubscribeNewTxsBatched(filter *filter.Filter, ch chan<- []*Transaction, batch BatchOptions, opts ...SubscriptionOption) error
+	SubscribeBlobSidecars(src BlobSidecarSource, ch chan<- *BeaconBlobSidecar, opts ...SubscriptionOption) error
+
+	SubscribeNewTxsWithContext(ctx context.Context, filter *filter.Filter, ch chan<- *Transaction, opts ...SubscriptionOption) error
+	SubscribeNewExecutionPayloadHeadersWithContext(ctx context.Context, ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error
+	SubscribeNewExecutionPayloadsWithContext(ctx context.Context, ch chan<- *ExecutionPayload, opts ...SubscriptionOption) error
+	SubscribeNewBeaconBlocksWithContext(ctx context.Context, ch chan<- *BeaconBlock, opts ...SubscriptionOption) error
+}
+
+var _ FiberClient = (*Client)(nil)
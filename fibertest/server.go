@@ -0,0 +1,311 @@
+// Package fibertest is an in-memory Fiber API server for the tests of applications using fiber-go. It
+// streams the transactions, payloads and blocks it's fed to the subscriptions of a client, and records
+// the transactions sent to it, without any network access.
+package fibertest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// listenerBuffer is the size of the in-memory connections.
+const listenerBuffer = 1 << 20
+
+// Sent is a transaction received by one of the send methods of the server.
+type Sent struct {
+	// Raw is the transaction as sent to SendRawTransaction, or encoded from the message of SendTransaction.
+	Raw []byte
+	// Tx is the decoded transaction, nil if go-ethereum can't decode Raw.
+	Tx        *types.Transaction
+	Hash      common.Hash
+	Timestamp int64
+	// Sequence is the number of the sequence the transaction was sent in, counting from 1, or 0 if it
+	// wasn't sent in a sequence.
+	Sequence int
+}
+
+// Server is an in-memory Fiber API server. Every subscription receives all the messages published on
+// its stream so far, then the new ones, so that messages can be published before subscribing.
+type Server struct {
+	api.UnimplementedAPIServer
+
+	lis    *bufconn.Listener
+	server *grpc.Server
+
+	txs      feed[*eth.Transaction]
+	headers  feed[*eth.ExecutionPayloadHeader]
+	payloads feed[*eth.ExecutionPayload]
+	blocks   feed[*eth.CompactBeaconBlock]
+
+	mu        sync.Mutex
+	sent      []Sent
+	sequences int
+}
+
+// NewServer returns a running server. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		lis:    bufconn.Listen(listenerBuffer),
+		server: grpc.NewServer(),
+	}
+
+	api.RegisterAPIServer(s.server, s)
+	go s.server.Serve(s.lis)
+
+	return s
+}
+
+// Dialer is the client option connecting a client to the server.
+func (s *Server) Dialer() client.ClientOption {
+	return client.WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return s.lis.DialContext(ctx)
+	}))
+}
+
+// NewClient returns a client of the server, configured by opts. It still needs to be connected.
+func (s *Server) NewClient(opts ...client.ClientOption) *client.Client {
+	return client.NewClient("fibertest", append([]client.ClientOption{s.Dialer()}, opts...)...)
+}
+
+// Close stops the server, which ends the streams of its clients.
+func (s *Server) Close() {
+	s.server.Stop()
+}
+
+// PublishTx streams tx to the transaction subscriptions whose filter it matches.
+func (s *Server) PublishTx(tx *client.Transaction) {
+	s.txs.publish(tx.ToProto())
+}
+
+// PublishNativeTx streams a signed go-ethereum transaction, like PublishTx. It fails if the sender
+// can't be recovered from the signature.
+func (s *Server) PublishNativeTx(tx *types.Transaction) error {
+	proto, err := client.TxToProto(tx)
+	if err != nil {
+		return err
+	}
+
+	s.txs.publish(proto)
+	return nil
+}
+
+// PublishHeader streams an execution payload header.
+func (s *Server) PublishHeader(header *client.ExecutionPayloadHeader) {
+	s.headers.publish(header.ToProto())
+}
+
+// PublishPayload streams an execution payload.
+func (s *Server) PublishPayload(payload *client.ExecutionPayload) {
+	s.payloads.publish(payload.ToProto())
+}
+
+// PublishBeaconBlock streams a beacon block.
+func (s *Server) PublishBeaconBlock(block *client.BeaconBlock) {
+	s.blocks.publish(block.ToProto())
+}
+
+// Sent returns the transactions sent to the server so far, in the order they were received.
+func (s *Server) Sent() []Sent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Sent(nil), s.sent...)
+}
+
+// receive records the raw transactions sent together, and returns their responses.
+func (s *Server) receive(raws [][]byte, sequence bool) []*api.TransactionResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var seq int
+	if sequence {
+		s.sequences++
+		seq = s.sequences
+	}
+
+	res := make([]*api.TransactionResponse, len(raws))
+	timestamp := time.Now().UnixMicro()
+	for i, raw := range raws {
+		sent := Sent{Raw: raw, Hash: crypto.Keccak256Hash(raw), Timestamp: timestamp, Sequence: seq}
+
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err == nil {
+			sent.Tx = tx
+		}
+
+		s.sent = append(s.sent, sent)
+		res[i] = &api.TransactionResponse{Hash: sent.Hash.Hex(), Timestamp: timestamp}
+	}
+
+	return res
+}
+
+// encode returns the encoding of a transaction message, the way SendRawTransaction receives it.
+func encode(proto *eth.Transaction) ([]byte, error) {
+	raw, err := client.ProtoToTx(proto).ToNative().MarshalBinary()
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "encoding transaction: %v", err)
+	}
+
+	return raw, nil
+}
+
+func (s *Server) SendTransaction(stream api.API_SendTransactionServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		raw, err := encode(msg)
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(s.receive([][]byte{raw}, false)[0]); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) SendRawTransaction(stream api.API_SendRawTransactionServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(s.receive([][]byte{msg.RawTx}, false)[0]); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) SendTransactionSequence(stream api.API_SendTransactionSequenceServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		raws := make([][]byte, len(msg.Sequence))
+		for i, proto := range msg.Sequence {
+			if raws[i], err = encode(proto); err != nil {
+				return err
+			}
+		}
+
+		if err := stream.Send(&api.TxSequenceResponse{SequenceResponse: s.receive(raws, true)}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) SendRawTransactionSequence(stream api.API_SendRawTransactionSequenceServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(&api.TxSequenceResponse{SequenceResponse: s.receive(msg.RawTxs, true)}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) SubscribeNewTxs(f *api.TxFilter, stream api.API_SubscribeNewTxsServer) error {
+	match := func(*eth.Transaction) bool { return true }
+	if len(f.Encoded) > 0 {
+		decoded, err := filter.Decode(f.Encoded)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := decoded.Validate(); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		match = decoded.Matches
+	}
+
+	return s.txs.serve(stream.Context(), func(tx *eth.Transaction) error {
+		if !match(tx) {
+			return nil
+		}
+		return stream.Send(tx)
+	})
+}
+
+func (s *Server) SubscribeExecutionHeaders(_ *emptypb.Empty, stream api.API_SubscribeExecutionHeadersServer) error {
+	return s.headers.serve(stream.Context(), stream.Send)
+}
+
+func (s *Server) SubscribeExecutionPayloads(_ *emptypb.Empty, stream api.API_SubscribeExecutionPayloadsServer) error {
+	return s.payloads.serve(stream.Context(), stream.Send)
+}
+
+func (s *Server) SubscribeBeaconBlocks(_ *emptypb.Empty, stream api.API_SubscribeBeaconBlocksServer) error {
+	return s.blocks.serve(stream.Context(), stream.Send)
+}
+
+// feed is the messages published on a stream. Publishing closes changed, to wake up the subscriptions.
+type feed[P any] struct {
+	mu      sync.Mutex
+	msgs    []P
+	changed chan struct{}
+}
+
+func (f *feed[P]) publish(msg P) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.msgs = append(f.msgs, msg)
+	if f.changed != nil {
+		close(f.changed)
+		f.changed = nil
+	}
+}
+
+// serve sends the messages of the feed until ctx is done.
+func (f *feed[P]) serve(ctx context.Context, send func(P) error) error {
+	next := 0
+	for {
+		f.mu.Lock()
+		msgs := f.msgs[next:]
+		next = len(f.msgs)
+		if f.changed == nil {
+			f.changed = make(chan struct{})
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		for _, msg := range msgs {
+			if err := send(msg); err != nil {
+				return fmt.Errorf("sending: %w", err)
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
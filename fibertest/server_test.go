@@ -0,0 +1,91 @@
+package fibertest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/filter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func signTx(t *testing.T, to common.Address, value int64) *types.Transaction {
+	t.Helper()
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   common.Big1,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(value),
+	}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tx
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := s.NewClient()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	to := common.HexToAddress("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC")
+	matching := signTx(t, to, 2)
+	for _, tx := range []*types.Transaction{signTx(t, common.Address{1}, 2), matching} {
+		if err := s.PublishNativeTx(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.PublishHeader(&client.ExecutionPayloadHeader{Number: 7})
+
+	f, err := filter.Build().To(to).ValueGte(big.NewInt(1)).Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txs := make(chan *client.Transaction)
+	go c.SubscribeNewTxsWithContext(ctx, f, txs)
+	if tx := <-txs; tx.Hash != matching.Hash() {
+		t.Errorf("expected %s, got %s", matching.Hash(), tx.Hash)
+	}
+
+	headers := make(chan *client.ExecutionPayloadHeader)
+	go c.SubscribeNewExecutionPayloadHeadersWithContext(ctx, headers)
+	if header := <-headers; header.Number != 7 {
+		t.Errorf("expected header 7, got %d", header.Number)
+	}
+
+	hash, _, err := c.SendTransaction(ctx, matching)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.SendTransactionSequence(ctx, matching, signTx(t, to, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	sent := s.Sent()
+	if len(sent) != 3 || sent[0].Hash.Hex() != hash || sent[0].Tx.Hash() != matching.Hash() || sent[0].Sequence != 0 || sent[2].Sequence != 1 {
+		t.Errorf("unexpected sent transactions %+v", sent)
+	}
+}
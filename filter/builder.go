@@ -0,0 +1,270 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrInvalidFilter is wrapped by the errors of Validate.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// Builder builds a filter with typed operands. The operands are combined with AND, and Or starts a
+// new group of them, so AND binds tighter than OR:
+//
+//	filter.Build().To(router).Or().From(sender).MethodID(transfer)
+//
+// matches the transactions to router, or from sender calling transfer.
+type Builder struct {
+	groups [][]*Node
+	err    error
+}
+
+// Build returns an empty builder.
+func Build() *Builder {
+	return &Builder{groups: [][]*Node{nil}}
+}
+
+func (b *Builder) add(n *Node) *Builder {
+	last := len(b.groups) - 1
+	b.groups[last] = append(b.groups[last], n)
+	return b
+}
+
+func operand(key string, value []byte) *Node {
+	return &Node{Operand: &FilterKV{key, value}}
+}
+
+// To matches the transactions to addr.
+func (b *Builder) To(addr common.Address) *Builder {
+	return b.add(operand("to", addr.Bytes()))
+}
+
+// From matches the transactions from addr.
+func (b *Builder) From(addr common.Address) *Builder {
+	return b.add(operand("from", addr.Bytes()))
+}
+
+// MethodID matches the transactions calling the method with the given selector.
+func (b *Builder) MethodID(id [4]byte) *Builder {
+	return b.add(operand("method", id[:]))
+}
+
+// MethodIDs matches the transactions calling any of the methods with the given selectors.
+func (b *Builder) MethodIDs(ids ...[4]byte) *Builder {
+	n := &Node{Operator: OR}
+	for _, id := range ids {
+		n.Children = append(n.Children, operand("method", append([]byte(nil), id[:]...)))
+	}
+
+	return b.add(n)
+}
+
+func (b *Builder) value(key string, v *big.Int) *Builder {
+	if v == nil || v.Sign() < 0 {
+		if b.err == nil {
+			b.err = fmt.Errorf("%w: %s needs a non-negative value", ErrInvalidFilter, key)
+		}
+		return b
+	}
+
+	return b.add(operand(key, v.Bytes()))
+}
+
+// ValueEq matches the transactions transferring exactly v wei.
+func (b *Builder) ValueEq(v *big.Int) *Builder {
+	return b.value("value_eq", v)
+}
+
+// ValueGte matches the transactions transferring at least v wei.
+func (b *Builder) ValueGte(v *big.Int) *Builder {
+	return b.value("value_gte", v)
+}
+
+// ValueLte matches the transactions transferring at most v wei.
+func (b *Builder) ValueLte(v *big.Int) *Builder {
+	return b.value("value_lte", v)
+}
+
+// MinValue matches the transactions transferring at least wei, like ValueGte.
+func (b *Builder) MinValue(wei *big.Int) *Builder {
+	return b.ValueGte(wei)
+}
+
+// MaxValue matches the transactions transferring at most wei, like ValueLte.
+func (b *Builder) MaxValue(wei *big.Int) *Builder {
+	return b.ValueLte(wei)
+}
+
+func anyOf(key string, addrs []common.Address) *Node {
+	n := &Node{Operator: OR}
+	for _, addr := range addrs {
+		n.Children = append(n.Children, operand(key, addr.Bytes()))
+	}
+
+	return n
+}
+
+// NotTo excludes the transactions to any of addrs.
+func (b *Builder) NotTo(addrs ...common.Address) *Builder {
+	return b.add(&Node{Operator: NOT, Children: []*Node{anyOf("to", addrs)}})
+}
+
+// NotFrom excludes the transactions from any of addrs.
+func (b *Builder) NotFrom(addrs ...common.Address) *Builder {
+	return b.add(&Node{Operator: NOT, Children: []*Node{anyOf("from", addrs)}})
+}
+
+// Group matches the filter of the inner builder, like parentheses.
+func (b *Builder) Group(inner *Builder) *Builder {
+	n, err := inner.root()
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+
+	return b.add(n)
+}
+
+// Not excludes the transactions matching the filter of the inner builder.
+func (b *Builder) Not(inner *Builder) *Builder {
+	n, err := inner.root()
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+
+	return b.add(&Node{Operator: NOT, Children: []*Node{n}})
+}
+
+// Match adds an operation of the untyped API, like And or ValueGte.
+func (b *Builder) Match(op FilterOp) *Builder {
+	parent := &Node{}
+	op(&Filter{}, parent)
+	for _, n := range parent.Children {
+		b.add(n)
+	}
+
+	return b
+}
+
+// Or starts a new group of operands, matched if the previous ones aren't.
+func (b *Builder) Or() *Builder {
+	b.groups = append(b.groups, nil)
+	return b
+}
+
+func (b *Builder) root() (*Node, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var alternatives []*Node
+	for i, group := range b.groups {
+		switch len(group) {
+		case 0:
+			if len(b.groups) == 1 {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("%w: OR without operands on side %d", ErrInvalidFilter, i)
+		case 1:
+			alternatives = append(alternatives, group[0])
+		default:
+			alternatives = append(alternatives, &Node{Operator: AND, Children: group})
+		}
+	}
+
+	if len(alternatives) == 1 {
+		return alternatives[0], nil
+	}
+
+	return &Node{Operator: OR, Children: alternatives}, nil
+}
+
+// Filter returns the validated filter. An empty builder gives an empty filter, which matches all
+// transactions.
+func (b *Builder) Filter() (*Filter, error) {
+	root, err := b.root()
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Filter{Root: root}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Validate checks that the filter is well formed: operators have operands (exactly one for NOT),
+// operands have known keys, and their values have the right size, so that a malformed filter is
+// rejected before opening a stream with it.
+func (f Filter) Validate() error {
+	if f.Root == nil {
+		return nil
+	}
+
+	return validate(f.Root, "root")
+}
+
+func validate(n *Node, path string) error {
+	if n == nil {
+		return fmt.Errorf("%w: %s is empty", ErrInvalidFilter, path)
+	}
+
+	if n.Operand != nil {
+		if n.Operator != 0 || len(n.Children) > 0 {
+			return fmt.Errorf("%w: %s is both an operand and an operator", ErrInvalidFilter, path)
+		}
+
+		return validateOperand(n.Operand, path)
+	}
+
+	switch n.Operator {
+	case AND, OR:
+		if len(n.Children) == 0 {
+			return fmt.Errorf("%w: %s %s without operands", ErrInvalidFilter, path, operatorName(n.Operator))
+		}
+	case NOT:
+		if len(n.Children) != 1 {
+			return fmt.Errorf("%w: %s NOT with %d operands, expected 1", ErrInvalidFilter, path, len(n.Children))
+		}
+	default:
+		return fmt.Errorf("%w: %s has unknown %s", ErrInvalidFilter, path, operatorName(n.Operator))
+	}
+
+	for i, child := range n.Children {
+		if err := validate(child, fmt.Sprintf("%s.%d", path, i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func validateOperand(kv *FilterKV, path string) error {
+	var ok bool
+	switch kv.Key {
+	case "to", "from":
+		ok = len(kv.Value) == common.AddressLength
+	case "method":
+		ok = len(kv.Value) == 4
+	case "value_eq", "value_gte", "value_lte":
+		ok = len(kv.Value) <= 32
+	default:
+		return fmt.Errorf("%w: %s has unknown key %q", ErrInvalidFilter, path, kv.Key)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: %s has a %d byte value for %s", ErrInvalidFilter, path, len(kv.Value), kv.Key)
+	}
+
+	return nil
+}
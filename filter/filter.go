@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 type Operator = int
@@ -13,6 +16,7 @@ type Operator = int
 const (
 	AND Operator = 1
 	OR  Operator = 2
+	NOT Operator = 3
 )
 
 type Node struct {
@@ -98,6 +102,37 @@ func Or(ops ...FilterOp) FilterOp {
 	}
 }
 
+// Not negates the given operations. If more than one operation is given, they are
+// combined with AND first, so Not(a, b) matches when a AND b doesn't hold.
+func Not(ops ...FilterOp) FilterOp {
+	return func(f *Filter, n *Node) {
+		// If we're at the first node (empty)
+		var new *Node
+		if n == nil {
+			new = &Node{
+				Operator: NOT,
+			}
+
+			f.Root = new
+		} else {
+			new = &Node{
+				Operator: NOT,
+			}
+
+			n.Children = append(n.Children, new)
+		}
+
+		if len(ops) > 1 {
+			And(ops...)(f, new)
+			return
+		}
+
+		for _, op := range ops {
+			op(f, new)
+		}
+	}
+}
+
 // Operands
 func To(to string) FilterOp {
 	return func(f *Filter, n *Node) {
@@ -200,3 +235,144 @@ func ValueLte(v *big.Int) FilterOp {
 		}
 	}
 }
+
+// MinValue matches all transactions transferring at least wei, like ValueGte.
+func MinValue(wei *big.Int) FilterOp {
+	return ValueGte(wei)
+}
+
+// MaxValue matches all transactions transferring at most wei, like ValueLte.
+func MaxValue(wei *big.Int) FilterOp {
+	return ValueLte(wei)
+}
+
+// MethodIDs matches all transactions calling any of the methods with the given selectors.
+func MethodIDs(ids ...string) FilterOp {
+	ops := make([]FilterOp, len(ids))
+	for i, id := range ids {
+		ops[i] = MethodID(id)
+	}
+
+	return Or(ops...)
+}
+
+// Selector returns the 4-byte selector of a method from its canonical signature, like
+// "transfer(address,uint256)", for MethodID.
+func Selector(signature string) [4]byte {
+	var id [4]byte
+	copy(id[:], crypto.Keccak256([]byte(signature)))
+	return id
+}
+
+// NotTo excludes all transactions sent to any of the given addresses.
+func NotTo(addresses ...string) FilterOp {
+	ops := make([]FilterOp, len(addresses))
+	for i, addr := range addresses {
+		ops[i] = To(addr)
+	}
+
+	return Not(Or(ops...))
+}
+
+// NotFrom excludes all transactions sent from any of the given addresses.
+func NotFrom(addresses ...string) FilterOp {
+	ops := make([]FilterOp, len(addresses))
+	for i, addr := range addresses {
+		ops[i] = From(addr)
+	}
+
+	return Not(Or(ops...))
+}
+
+// Decode parses a filter in the encoded form returned by Encode.
+func Decode(b []byte) (*Filter, error) {
+	f := &Filter{}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, fmt.Errorf("decoding filter: %w", err)
+	}
+
+	return f, nil
+}
+
+// Description is a human-readable form of a filter node: either an operator with children, or an
+// operand with its key and value.
+type Description struct {
+	Operator string        `json:"operator,omitempty"`
+	Key      string        `json:"key,omitempty"`
+	Value    string        `json:"value,omitempty"`
+	Children []Description `json:"children,omitempty"`
+}
+
+// Describe returns the structure of the filter, with values decoded by key: checksummed addresses,
+// hex method IDs and decimal values. It's empty for an empty filter.
+func (f Filter) Describe() Description {
+	if f.Root == nil {
+		return Description{}
+	}
+
+	return describe(f.Root)
+}
+
+func describe(n *Node) Description {
+	if n.Operand != nil {
+		return Description{Key: n.Operand.Key, Value: describeValue(n.Operand)}
+	}
+
+	d := Description{Operator: operatorName(n.Operator)}
+	for _, child := range n.Children {
+		d.Children = append(d.Children, describe(child))
+	}
+
+	return d
+}
+
+func operatorName(op Operator) string {
+	switch op {
+	case AND:
+		return "AND"
+	case OR:
+		return "OR"
+	case NOT:
+		return "NOT"
+	}
+
+	return fmt.Sprintf("operator(%d)", op)
+}
+
+func describeValue(kv *FilterKV) string {
+	switch kv.Key {
+	case "to", "from":
+		return common.BytesToAddress(kv.Value).Hex()
+	case "method":
+		return hexutil.Encode(kv.Value)
+	case "value_eq", "value_gte", "value_lte":
+		return new(big.Int).SetBytes(kv.Value).String()
+	}
+
+	return hexutil.Encode(kv.Value)
+}
+
+// String renders the description as an indented tree, one node per line.
+func (d Description) String() string {
+	var b strings.Builder
+	d.write(&b, 0)
+	return b.String()
+}
+
+func (d Description) write(b *strings.Builder, depth int) {
+	if d.Operator == "" && d.Key == "" {
+		return
+	}
+
+	b.WriteString(strings.Repeat("  ", depth))
+	if d.Operator != "" {
+		b.WriteString(d.Operator)
+	} else {
+		fmt.Fprintf(b, "%s = %s", d.Key, d.Value)
+	}
+	b.WriteByte('\n')
+
+	for _, child := range d.Children {
+		child.write(b, depth+1)
+	}
+}
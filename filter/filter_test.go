@@ -1,10 +1,16 @@
 package filter
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func traverse(n *Node) {
@@ -94,3 +100,205 @@ func TestValueGteFilter(t *testing.T) {
 
 	fmt.Println(string(v))
 }
+
+func TestNotToFilter(t *testing.T) {
+	f := New(
+		And(
+			MethodID("0xa9059cbb"),
+			NotTo(
+				"0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC",
+				"0x34Be5b8C30eE4fDe069DC87D989686aBE98abcde",
+			),
+		),
+	)
+
+	traverse(f.Root)
+
+	not := f.Root.Children[1]
+	if not.Operator != NOT || len(not.Children) != 1 {
+		t.Fatalf("expected NOT node with a single child, got %+v", not)
+	}
+
+	if or := not.Children[0]; or.Operator != OR || len(or.Children) != 2 {
+		t.Fatalf("expected OR node with 2 children, got %+v", or)
+	}
+}
+
+func TestNotFromFilter(t *testing.T) {
+	f := New(NotFrom("0x34Be5b8C30eE4fDe069DC87D989686aBE98abcde"))
+
+	traverse(f.Root)
+
+	if f.Root.Operator != NOT {
+		t.Fatalf("expected NOT root, got %d", f.Root.Operator)
+	}
+
+	v, err := json.MarshalIndent(f, "", "    ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmt.Println(string(v))
+}
+
+func TestDescribe(t *testing.T) {
+	f := New(And(
+		MethodID("0xa9059cbb"),
+		ValueGte(big.NewInt(1000)),
+		NotTo("0xdc6c276d357e82c7d38d73061ceed2e33990e5bc"),
+	))
+
+	expected := `AND
+  method = 0xa9059cbb
+  value_gte = 1000
+  NOT
+    OR
+      to = 0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC
+`
+	if got := f.Describe().String(); got != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, got)
+	}
+
+	decoded, err := Decode(f.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Describe().String() != expected {
+		t.Fatalf("decoded filter differs: %s", decoded.Describe())
+	}
+	if (Filter{}).Describe().String() != "" {
+		t.Fatal("expected an empty description")
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	router := common.HexToAddress("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC")
+	sender := common.HexToAddress("0x34Be5b8C30eE4fDe069DC87D989686aBE98abcde")
+	transfer := [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+
+	f, err := Build().To(router).Or().From(sender).MethodID(transfer).Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := New(Or(
+		To(router.Hex()),
+		And(From(sender.Hex()), MethodID("0xa9059cbb")),
+	))
+	if !bytes.Equal(f.Encode(), expected.Encode()) {
+		t.Fatalf("expected %s, got %s", expected.Encode(), f.Encode())
+	}
+
+	f, err = Build().Not(Build().To(router).Or().To(sender)).Match(ValueGte(big.NewInt(1))).Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = New(And(NotTo(router.Hex(), sender.Hex()), ValueGte(big.NewInt(1))))
+	if !bytes.Equal(f.Encode(), expected.Encode()) {
+		t.Fatalf("expected %s, got %s", expected.Encode(), f.Encode())
+	}
+
+	if f, err := Build().Filter(); err != nil || f.Root != nil {
+		t.Fatalf("expected an empty filter, got %v %v", f, err)
+	}
+
+	invalid := map[string]*Builder{
+		"trailing or":    Build().To(router).Or(),
+		"negative value": Build().ValueGte(big.NewInt(-1)),
+		"nil value":      Build().ValueEq(nil),
+		"empty not":      Build().NotTo(),
+		"empty group":    Build().Group(Build().Or().To(router)),
+	}
+	for name, b := range invalid {
+		if _, err := b.Filter(); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%s: expected an invalid filter, got %v", name, err)
+		}
+	}
+}
+
+func TestMethodIDs(t *testing.T) {
+	transfer := Selector("transfer(address,uint256)")
+	if transfer != [4]byte{0xa9, 0x05, 0x9c, 0xbb} {
+		t.Fatalf("unexpected selector of transfer %x", transfer)
+	}
+	approve := Selector("approve(address,uint256)")
+
+	f, err := Build().MethodIDs(transfer, approve).Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := New(MethodIDs("0xa9059cbb", "0x095ea7b3"))
+	if !bytes.Equal(f.Encode(), expected.Encode()) {
+		t.Fatalf("expected %s, got %s", expected.Encode(), f.Encode())
+	}
+
+	for input, want := range map[string]bool{
+		"0xa9059cbb00": true,
+		"0x095ea7b3":   true,
+		"0x23b872dd00": false,
+		"0xa905":       false,
+	} {
+		if got := f.Matches(&eth.Transaction{Input: common.FromHex(input)}); got != want {
+			t.Errorf("%s: expected %v, got %v", input, want, got)
+		}
+	}
+
+	if _, err := Build().MethodIDs().Filter(); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("expected an invalid filter without selectors, got %v", err)
+	}
+}
+
+func TestValueRangeFilter(t *testing.T) {
+	eth1 := big.NewInt(1e18)
+	f, err := Build().MinValue(eth1).MaxValue(new(big.Int).Mul(eth1, big.NewInt(100))).Filter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := New(And(MinValue(eth1), MaxValue(new(big.Int).Mul(eth1, big.NewInt(100)))))
+	if !bytes.Equal(f.Encode(), expected.Encode()) {
+		t.Fatalf("expected %s, got %s", expected.Encode(), f.Encode())
+	}
+
+	for value, want := range map[int64]bool{0: false, 1e17: false, 1e18: true, 5e18: true} {
+		if got := f.Matches(&eth.Transaction{Value: big.NewInt(value).Bytes()}); got != want {
+			t.Errorf("%d wei: expected %v, got %v", value, want, got)
+		}
+	}
+	if f.Matches(&eth.Transaction{Value: new(big.Int).Mul(eth1, big.NewInt(101)).Bytes()}) {
+		t.Error("expected a transfer above the maximum not to match")
+	}
+
+	if _, err := Build().MaxValue(nil).Filter(); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("expected a nil value to be invalid, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := []*Filter{
+		{},
+		New(And(To("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC"), ValueLte(big.NewInt(5)))),
+		New(NotFrom("0x34Be5b8C30eE4fDe069DC87D989686aBE98abcde")),
+	}
+	for _, f := range valid {
+		if err := f.Validate(); err != nil {
+			t.Errorf("expected %s to be valid, got %v", f.Encode(), err)
+		}
+	}
+
+	invalid := []*Filter{
+		New(And()),
+		{Root: &Node{Operator: NOT, Children: []*Node{{Operand: &FilterKV{"to", make([]byte, 20)}}, {Operand: &FilterKV{"to", make([]byte, 20)}}}}},
+		{Root: &Node{Operator: 7, Children: []*Node{{Operand: &FilterKV{"to", make([]byte, 20)}}}}},
+		{Root: &Node{Operand: &FilterKV{"gas", []byte{1}}}},
+		{Root: &Node{Operand: &FilterKV{"method", []byte{1, 2}}}},
+		{Root: &Node{Operator: OR, Children: []*Node{nil}}},
+		New(MethodID("0x1234")),
+	}
+	for _, f := range invalid {
+		if err := f.Validate(); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("expected %s to be invalid, got %v", f.Encode(), err)
+		}
+	}
+}
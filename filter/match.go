@@ -0,0 +1,64 @@
+package filter
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+)
+
+// Matches returns whether the transaction message tx matches the filter, the way the Fiber API
+// evaluates it. A filter without root matches every transaction. The filter must be valid (see Validate).
+func (f Filter) Matches(tx *eth.Transaction) bool {
+	if f.Root == nil {
+		return true
+	}
+
+	return matches(f.Root, tx)
+}
+
+// matches returns whether tx matches the filter node n, which was validated.
+func matches(n *Node, tx *eth.Transaction) bool {
+	if kv := n.Operand; kv != nil {
+		return matchesOperand(kv, tx)
+	}
+
+	switch n.Operator {
+	case AND:
+		for _, child := range n.Children {
+			if !matches(child, tx) {
+				return false
+			}
+		}
+		return true
+	case OR:
+		for _, child := range n.Children {
+			if matches(child, tx) {
+				return true
+			}
+		}
+		return false
+	default:
+		return !matches(n.Children[0], tx)
+	}
+}
+
+func matchesOperand(kv *FilterKV, tx *eth.Transaction) bool {
+	value := new(big.Int).SetBytes(tx.Value)
+	switch kv.Key {
+	case "to":
+		return bytes.Equal(tx.To, kv.Value)
+	case "from":
+		return bytes.Equal(tx.From, kv.Value)
+	case "method":
+		return len(tx.Input) >= 4 && bytes.Equal(tx.Input[:4], kv.Value)
+	case "value_eq":
+		return value.Cmp(new(big.Int).SetBytes(kv.Value)) == 0
+	case "value_gte":
+		return value.Cmp(new(big.Int).SetBytes(kv.Value)) >= 0
+	case "value_lte":
+		return value.Cmp(new(big.Int).SetBytes(kv.Value)) <= 0
+	}
+
+	return false
+}
@@ -0,0 +1,176 @@
+// Package presets contains ready-made transaction filters for common use cases.
+package presets
+
+import (
+	"math/big"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/filter"
+)
+
+// Method IDs of the ERC-20 transfer functions.
+const (
+	TransferMethodID     = "0xa9059cbb"
+	TransferFromMethodID = "0x23b872dd"
+)
+
+// DEXRouters are the mainnet router contracts matched by DEXTrades.
+var DEXRouters = []string{
+	"0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D", // Uniswap V2 Router02
+	"0xE592427A0AEce92De3Edee1F18E0157C05861564", // Uniswap V3 SwapRouter
+	"0x68b3465833fb72A70ecDF485E0e4C7bD8665Fc45", // Uniswap V3 SwapRouter02
+	"0xEf1c6E67703c7BD7107eed8303Fbe6EC2554BF6B", // Uniswap Universal Router
+	"0x3fC91A3afd70395Cd496C647d5a6CC9D4B2b7FAD", // Uniswap Universal Router 2
+	"0xd9e1cE17f2641f24aE83637ab66a2cca9C378B9F", // SushiSwap Router
+	"0x1111111254EEB25477B68fb85Ed929f73A960582", // 1inch Aggregation Router V5
+	"0xDef1C0ded9bec7F1a1670819833240f027b25EfF", // 0x Exchange Proxy
+}
+
+// Preset is a transaction filter. Filter is applied by Fiber, and Match, if set, refines it on the client
+// for what the filter language can't express.
+type Preset struct {
+	Filter *filter.Filter
+	Match  func(tx *client.Transaction) bool
+}
+
+// Matches returns whether tx passes the client side part of the preset.
+func (p Preset) Matches(tx *client.Transaction) bool {
+	return p.Match == nil || p.Match(tx)
+}
+
+// MinGasPrice matches the transactions bidding at least wei per gas, by their GasFeeCap: the gas price,
+// or for the dynamic fee ones the max fee. It's matched on the client, so it doesn't reduce what Fiber
+// streams: combine it with a filter using Where.
+func MinGasPrice(wei *big.Int) Preset {
+	return Preset{
+		Match: func(tx *client.Transaction) bool {
+			return tx.GasFeeCap().Cmp(wei) >= 0
+		},
+	}
+}
+
+// MaxGasPrice matches the transactions bidding at most wei per gas, like MinGasPrice.
+func MaxGasPrice(wei *big.Int) Preset {
+	return Preset{
+		Match: func(tx *client.Transaction) bool {
+			return tx.GasFeeCap().Cmp(wei) <= 0
+		},
+	}
+}
+
+// MinPriorityFee matches the transactions tipping at least wei per gas, by their GasTipCap: the max
+// priority fee, or for the legacy ones the gas price. Like MinGasPrice, it's matched on the client.
+func MinPriorityFee(wei *big.Int) Preset {
+	return Preset{
+		Match: func(tx *client.Transaction) bool {
+			return tx.GasTipCap().Cmp(wei) >= 0
+		},
+	}
+}
+
+// Where returns the preset refined on the client by match, in addition to its own Match. It's how
+// the client side predicates like TxTypes are combined with a Fiber filter, or with each other:
+//
+//	presets.DEXTrades().Where(presets.TxTypes(types.DynamicFeeTxType).Match)
+func (p Preset) Where(match func(tx *client.Transaction) bool) Preset {
+	if p.Match == nil {
+		p.Match = match
+		return p
+	}
+
+	own := p.Match
+	p.Match = func(tx *client.Transaction) bool {
+		return own(tx) && match(tx)
+	}
+	return p
+}
+
+// Subscribe subscribes to the transactions matching the preset on c, like SubscribeNewTxs. It blocks
+// until the subscription ends, and closes ch.
+func (p Preset) Subscribe(c *client.Client, ch chan<- *client.Transaction, opts ...client.SubscriptionOption) error {
+	if p.Match == nil {
+		return c.SubscribeNewTxs(p.Filter, ch, opts...)
+	}
+
+	txs := make(chan *client.Transaction)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(ch)
+
+		for tx := range txs {
+			if p.Match(tx) {
+				ch <- tx
+			}
+		}
+	}()
+
+	err := c.SubscribeNewTxs(p.Filter, txs, opts...)
+	<-done
+	return err
+}
+
+// ERC20Transfers matches calls to transfer and transferFrom on the token contract.
+func ERC20Transfers(token string) Preset {
+	return Preset{
+		Filter: filter.New(filter.And(
+			filter.To(token),
+			filter.Or(
+				filter.MethodID(TransferMethodID),
+				filter.MethodID(TransferFromMethodID),
+			),
+		)),
+	}
+}
+
+// DEXTrades matches transactions sent to one of the DEXRouters.
+func DEXTrades() Preset {
+	ops := make([]filter.FilterOp, len(DEXRouters))
+	for i, router := range DEXRouters {
+		ops[i] = filter.To(router)
+	}
+
+	return Preset{Filter: filter.New(filter.Or(ops...))}
+}
+
+// HighValue matches transactions transferring at least minWei.
+func HighValue(minWei *big.Int) Preset {
+	return Preset{Filter: filter.New(filter.ValueGte(minWei))}
+}
+
+// ContractDeployments matches contract creations. The filter language can't match a missing
+// recipient, so this receives every transaction and filters on the client.
+func ContractDeployments() Preset {
+	return Preset{
+		Match: func(tx *client.Transaction) bool {
+			return tx.To == nil
+		},
+	}
+}
+
+// BlobTxs matches EIP-4844 blob transactions. The filter language can't match the transaction type,
+// so this receives every transaction and filters on the client.
+func BlobTxs() Preset {
+	return Preset{
+		Match: func(tx *client.Transaction) bool {
+			return tx.Type == client.BlobTxType
+		},
+	}
+}
+
+// TxTypes matches the transactions of any of the EIP-2718 types ts, like types.DynamicFeeTxType. Like
+// BlobTxs, it's matched on the client, so it doesn't reduce what Fiber streams: combine it with a
+// filter using Where.
+func TxTypes(ts ...uint8) Preset {
+	return Preset{
+		Match: func(tx *client.Transaction) bool {
+			for _, t := range ts {
+				if tx.Type == uint32(t) {
+					return true
+				}
+			}
+
+			return false
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package presets
+
+import (
+	"math/big"
+	"testing"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/filter"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestERC20Transfers(t *testing.T) {
+	usdc := "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"
+	root := ERC20Transfers(usdc).Filter.Root
+
+	if root.Operator != filter.AND || len(root.Children) != 2 {
+		t.Fatalf("expected AND of recipient and methods, got %+v", root)
+	}
+	if to := root.Children[0].Operand; to.Key != "to" || common.BytesToAddress(to.Value) != common.HexToAddress(usdc) {
+		t.Fatalf("unexpected recipient operand %v", to)
+	}
+	if methods := root.Children[1]; methods.Operator != filter.OR || len(methods.Children) != 2 {
+		t.Fatalf("expected OR of both transfer methods, got %+v", methods)
+	}
+}
+
+func TestDEXTrades(t *testing.T) {
+	root := DEXTrades().Filter.Root
+	if root.Operator != filter.OR || len(root.Children) != len(DEXRouters) {
+		t.Fatalf("expected OR of all routers, got %+v", root)
+	}
+}
+
+func TestHighValue(t *testing.T) {
+	op := HighValue(big.NewInt(1e18)).Filter.Root.Operand
+	if op.Key != "value_gte" || new(big.Int).SetBytes(op.Value).Cmp(big.NewInt(1e18)) != 0 {
+		t.Fatalf("unexpected operand %v", op)
+	}
+}
+
+func TestClientSideMatch(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	deployment, call, blob := &client.Transaction{}, &client.Transaction{To: &to, Type: 2}, &client.Transaction{To: &to, Type: 3}
+
+	if p := ContractDeployments(); p.Filter != nil || !p.Matches(deployment) || p.Matches(call) {
+		t.Fatal("ContractDeployments should only match transactions without recipient")
+	}
+	if p := BlobTxs(); p.Filter != nil || !p.Matches(blob) || p.Matches(call) {
+		t.Fatal("BlobTxs should only match blob transactions")
+	}
+	if p := TxTypes(0, 3); p.Filter != nil || !p.Matches(deployment) || !p.Matches(blob) || p.Matches(call) {
+		t.Fatal("TxTypes should only match the given types")
+	}
+	if !HighValue(big.NewInt(1)).Matches(call) {
+		t.Fatal("a preset without Match should match everything that passes the filter")
+	}
+}
+
+func TestWhere(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	call, blob := &client.Transaction{To: &to, Type: 2}, &client.Transaction{To: &to, Type: 3}
+
+	p := HighValue(big.NewInt(1)).Where(TxTypes(2).Match)
+	if p.Filter == nil || !p.Matches(call) || p.Matches(blob) {
+		t.Fatal("expected the filter to be kept and the predicate to apply")
+	}
+
+	p = ContractDeployments().Where(TxTypes(2).Match)
+	if p.Matches(call) || p.Matches(&client.Transaction{Type: 3}) || !p.Matches(&client.Transaction{Type: 2}) {
+		t.Fatal("expected both predicates to apply")
+	}
+}
+
+func TestGasPrice(t *testing.T) {
+	gwei := func(n int64) *big.Int { return new(big.Int).Mul(big.NewInt(n), big.NewInt(1e9)) }
+	p := MinGasPrice(gwei(10)).Where(MaxGasPrice(gwei(100)).Match).Where(MinPriorityFee(gwei(1)).Match)
+
+	for name, tt := range map[string]struct {
+		tx   *client.Transaction
+		want bool
+	}{
+		"legacy":             {&client.Transaction{GasPrice: gwei(20)}, true},
+		"underpriced legacy": {&client.Transaction{GasPrice: gwei(5)}, false},
+		"overpriced legacy":  {&client.Transaction{GasPrice: gwei(200)}, false},
+		"dynamic fee":        {&client.Transaction{Type: 2, MaxFee: gwei(30), PriorityFee: gwei(2)}, true},
+		"low tip":            {&client.Transaction{Type: 2, MaxFee: gwei(30), PriorityFee: big.NewInt(1e8)}, false},
+		"low max fee":        {&client.Transaction{Type: 2, MaxFee: gwei(5), PriorityFee: gwei(2)}, false},
+		"blob":               {&client.Transaction{Type: 3, MaxFee: gwei(50), PriorityFee: gwei(1)}, true},
+	} {
+		if got := p.Matches(tt.tx); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", name, tt.want, got)
+		}
+	}
+	if p.Filter != nil {
+		t.Fatal("expected the gas price to be matched on the client only")
+	}
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/protobuf/api"
+)
+
+// ErrNotFilterable is returned by UpdateFilter when no transaction subscription runs on the handle.
+var ErrNotFilterable = newCodedError(ErrCodeNotFilterable, "no filterable subscription running")
+
+// liveFilter is the filter of a transaction subscription, read every time its server stream is opened.
+type liveFilter struct {
+	mu    sync.Mutex
+	proto *api.TxFilter
+}
+
+func (l *liveFilter) get() *api.TxFilter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.proto
+}
+
+func (l *liveFilter) set(proto *api.TxFilter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.proto = proto
+}
+
+func withLiveFilter(l *liveFilter) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.filter = l
+	}
+}
+
+// UpdateFilter replaces the filter of the running transaction subscription by f, nil for all
+// transactions, for address lists that change at runtime. The server stream is re-established with
+// the new filter like by ForceReconnect, and the subscription keeps delivering to the same channel.
+// Transactions the server streams in between are missed, and the ones received before may still be
+// delivered. A malformed filter is rejected, and ErrNotFilterable is returned if no transaction
+// subscription runs on the handle.
+func (s *Subscription) UpdateFilter(f *filter.Filter) error {
+	proto := &api.TxFilter{}
+	if f != nil {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+		proto.Encoded = f.Encode()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.filter == nil || s.cancelStream == nil {
+		return ErrNotFilterable
+	}
+
+	s.filter.set(proto)
+	s.forceReconnect = true
+	s.cancelStream()
+	return nil
+}
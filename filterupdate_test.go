@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"google.golang.org/grpc"
+)
+
+// filterServer streams one transaction per subscription, and records the filters it was opened with.
+type filterServer struct {
+	api.UnimplementedAPIServer
+	filters chan []byte
+}
+
+func (s filterServer) SubscribeNewTxs(f *api.TxFilter, stream api.API_SubscribeNewTxsServer) error {
+	s.filters <- f.Encoded
+	if err := stream.Send(&eth.Transaction{Hash: bytes.Repeat([]byte{1}, 32)}); err != nil {
+		return err
+	}
+
+	<-stream.Context().Done()
+	return nil
+}
+
+func TestUpdateFilter(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	filters := make(chan []byte, 2)
+	api.RegisterAPIServer(server, filterServer{filters: filters})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	c := NewClient(lis.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var sub Subscription
+	if err := sub.UpdateFilter(nil); !errors.Is(err, ErrNotFilterable) {
+		t.Fatalf("expected ErrNotFilterable without subscription, got %v", err)
+	}
+
+	ch := make(chan *Transaction, 2)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.SubscribeNewTxsWithContext(ctx, nil, ch, WithHandle(&sub))
+	}()
+	if f := <-filters; len(f) != 0 {
+		t.Fatalf("expected no filter, got %s", f)
+	}
+	<-ch
+
+	if err := sub.UpdateFilter(&filter.Filter{Root: &filter.Node{Operator: filter.AND}}); !errors.Is(err, filter.ErrInvalidFilter) {
+		t.Fatalf("expected a malformed filter to be rejected, got %v", err)
+	}
+
+	f := filter.New(filter.To("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC"))
+	if err := sub.UpdateFilter(f); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-filters; !bytes.Equal(got, f.Encode()) {
+		t.Fatalf("expected the stream to be reopened with %s, got %s", f.Encode(), got)
+	}
+
+	// The subscription keeps delivering to the same channel
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("expected a transaction after the update")
+	}
+	if n := c.Snapshot().Subscriptions[0].Sessions; n != 2 {
+		t.Fatalf("expected 2 sessions, got %d", n)
+	}
+
+	cancel()
+	<-errc
+
+	var headers Subscription
+	go subscribe(&Client{}, StreamHeaders, make(chan int), []SubscriptionOption{WithHandle(&headers)}, fakeStream[int](), identity[int])
+	defer headers.Unsubscribe()
+	waitFor(t, func() bool { return headers.Stats().Connected })
+	if err := headers.UpdateFilter(f); !errors.Is(err, ErrNotFilterable) || Code(err) != ErrCodeNotFilterable {
+		t.Fatalf("expected ErrNotFilterable on headers, got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultHeaderCacheSize is the number of headers a HeaderCache keeps by default.
+const DefaultHeaderCacheSize = 128
+
+// HeaderCache keeps the last execution payload headers of the subscriptions it's given to with
+// WithHeaderCache, for strategy code to look up recent chain context without a node round trip. A
+// header for a number that's already cached, i.e. after a reorg, replaces the previous one. It's safe
+// for concurrent use.
+type HeaderCache struct {
+	ttl time.Duration
+
+	mu       sync.RWMutex
+	entries  []headerEntry // ring of the cached headers, by arrival
+	next     int
+	byNumber map[uint64]*headerEntry
+	byHash   map[common.Hash]*headerEntry
+	latest   *headerEntry
+}
+
+type headerEntry struct {
+	header *ExecutionPayloadHeader
+	added  time.Time
+}
+
+// NewHeaderCache returns a cache of the last size headers, or DefaultHeaderCacheSize if 0. If ttl
+// isn't 0, headers older than ttl aren't returned anymore.
+func NewHeaderCache(size int, ttl time.Duration) *HeaderCache {
+	if size <= 0 {
+		size = DefaultHeaderCacheSize
+	}
+
+	return &HeaderCache{
+		ttl:      ttl,
+		entries:  make([]headerEntry, size),
+		byNumber: make(map[uint64]*headerEntry, size),
+		byHash:   make(map[common.Hash]*headerEntry, size),
+	}
+}
+
+// WithHeaderCache adds the headers of the subscription to c, from header or payload streams.
+func WithHeaderCache(c *HeaderCache) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.headerCache = c
+	}
+}
+
+// Add adds the header to the cache, evicting the oldest one if it's full.
+func (c *HeaderCache) Add(h *ExecutionPayloadHeader) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &c.entries[c.next]
+	if old := e.header; old != nil {
+		if c.byHash[old.Hash] == e {
+			delete(c.byHash, old.Hash)
+		}
+		if c.byNumber[old.Number] == e {
+			delete(c.byNumber, old.Number)
+		}
+		if c.latest == e {
+			c.latest = nil
+		}
+	}
+
+	if replaced := c.byNumber[h.Number]; replaced != nil {
+		delete(c.byHash, replaced.header.Hash)
+	}
+
+	*e = headerEntry{header: h, added: time.Now()}
+	c.byNumber[h.Number] = e
+	c.byHash[h.Hash] = e
+	if c.latest == nil {
+		// The latest header was evicted, which only happens with tiny caches
+		for _, cached := range c.byNumber {
+			if c.latest == nil || cached.header.Number > c.latest.header.Number {
+				c.latest = cached
+			}
+		}
+	} else if h.Number >= c.latest.header.Number {
+		c.latest = e
+	}
+
+	c.next = (c.next + 1) % len(c.entries)
+}
+
+func (c *HeaderCache) live(e *headerEntry) (*ExecutionPayloadHeader, bool) {
+	if e == nil || (c.ttl > 0 && time.Since(e.added) > c.ttl) {
+		return nil, false
+	}
+
+	return e.header, true
+}
+
+// HeaderByNumber returns the cached header of the block with the given number.
+func (c *HeaderCache) HeaderByNumber(number uint64) (*ExecutionPayloadHeader, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.live(c.byNumber[number])
+}
+
+// HeaderByHash returns the cached header of the block with the given hash.
+func (c *HeaderCache) HeaderByHash(hash common.Hash) (*ExecutionPayloadHeader, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.live(c.byHash[hash])
+}
+
+// Latest returns the cached header with the highest number.
+func (c *HeaderCache) Latest() (*ExecutionPayloadHeader, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.live(c.latest)
+}
+
+// Len returns the number of cached headers, including the expired ones.
+func (c *HeaderCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.byNumber)
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/chainbound/fiber-go/hexenc"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Hex forms of the core types for logging and exports. Hashes are encoded once per message and
+// cached, the hex forms of addresses are cached across messages (see AddressHex). Use the hexenc package to append them to a buffer
+// instead.
+
+// HashHex returns the hex form of the transaction hash, like tx.Hash.Hex. The hash must not change
+// after the first call.
+func (tx *Transaction) HashHex() string {
+	return cachedHex(&tx.hashHex, tx.Hash)
+}
+
+// FromHex returns the checksummed hex form of the sender.
+func (tx *Transaction) FromHex() string {
+	return AddressHex(tx.From)
+}
+
+// ToHex returns the checksummed hex form of the receiver, "" for contract creations.
+func (tx *Transaction) ToHex() string {
+	if tx.To == nil {
+		return ""
+	}
+
+	return AddressHex(*tx.To)
+}
+
+// HashHex returns the hex form of the block hash, like h.Hash.Hex. The hash must not change after the
+// first call.
+func (h *ExecutionPayloadHeader) HashHex() string {
+	return cachedHex(&h.hashHex, h.Hash)
+}
+
+// cachedHex returns the hex form of hash cached in p, computing it on the first call. Concurrent first
+// calls may both compute it, which is harmless.
+func cachedHex(p *unsafe.Pointer, hash common.Hash) string {
+	if s := (*string)(atomic.LoadPointer(p)); s != nil {
+		return *s
+	}
+
+	s := hexenc.Hash(hash)
+	atomic.StorePointer(p, unsafe.Pointer(&s))
+	return s
+}
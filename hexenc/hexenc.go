@@ -0,0 +1,78 @@
+// Package hexenc contains append-style hex encoders for hashes, addresses and bytes, for logging and
+// export paths. They encode like the Hex methods of go-ethereum, without allocating when dst has
+// enough capacity.
+package hexenc
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const digits = "0123456789abcdef"
+
+// AppendBytes appends the 0x-prefixed, lowercase hex form of b to dst.
+func AppendBytes(dst []byte, b []byte) []byte {
+	dst = append(dst, '0', 'x')
+	for _, v := range b {
+		dst = append(dst, digits[v>>4], digits[v&0x0f])
+	}
+
+	return dst
+}
+
+// AppendHash appends the hex form of h to dst, like h.Hex.
+func AppendHash(dst []byte, h common.Hash) []byte {
+	return AppendBytes(dst, h[:])
+}
+
+// checksummer is the scratch state of the EIP-55 checksum, pooled so that it isn't allocated per address.
+type checksummer struct {
+	state crypto.KeccakState
+	sum   [32]byte
+}
+
+var checksummers = sync.Pool{
+	New: func() any {
+		return &checksummer{state: crypto.NewKeccakState()}
+	},
+}
+
+// AppendAddress appends the EIP-55 checksummed hex form of addr to dst, like addr.Hex.
+func AppendAddress(dst []byte, addr common.Address) []byte {
+	start := len(dst) + 2
+	dst = AppendBytes(dst, addr[:])
+	lower := dst[start:]
+
+	c := checksummers.Get().(*checksummer)
+	c.state.Reset()
+	c.state.Write(lower)
+	c.state.Read(c.sum[:])
+
+	// A letter is uppercased if the matching nibble of the hash of the lowercase form is at least 8
+	for i, ch := range lower {
+		nibble := c.sum[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if ch > '9' && nibble&0x0f >= 8 {
+			lower[i] = ch - 'a' + 'A'
+		}
+	}
+
+	checksummers.Put(c)
+	return dst
+}
+
+// Hash returns the hex form of h with a single allocation.
+func Hash(h common.Hash) string {
+	var buf [2 + 2*common.HashLength]byte
+	return string(AppendHash(buf[:0], h))
+}
+
+// Address returns the checksummed hex form of addr with a single allocation.
+func Address(addr common.Address) string {
+	var buf [2 + 2*common.AddressLength]byte
+	return string(AppendAddress(buf[:0], addr))
+}
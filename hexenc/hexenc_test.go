@@ -0,0 +1,38 @@
+package hexenc
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestEncoders(t *testing.T) {
+	addrs := []common.Address{
+		{},
+		common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"),
+		common.HexToAddress("0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"),
+		common.HexToAddress("0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB"),
+	}
+	for _, addr := range addrs {
+		if got := Address(addr); got != addr.Hex() {
+			t.Errorf("expected %s, got %s", addr.Hex(), got)
+		}
+	}
+
+	h := common.HexToHash("0x00ff10ab")
+	if got := Hash(h); got != h.Hex() {
+		t.Errorf("expected %s, got %s", h.Hex(), got)
+	}
+	if got := string(AppendBytes([]byte("input="), []byte{0xde, 0xad})); got != "input="+hexutil.Encode([]byte{0xde, 0xad}) {
+		t.Errorf("unexpected bytes encoding %s", got)
+	}
+
+	buf := make([]byte, 0, 128)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = AppendAddress(AppendHash(buf[:0], h), addrs[1])
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chainbound/fiber-go/intent"
+)
+
+// ErrIntentsUnsupported is returned by SendIntent while the Fiber API has no intent submission.
+var ErrIntentsUnsupported = newCodedError(ErrCodeIntentsUnsupported, "intent submission is not supported by the Fiber API yet")
+
+// SendIntent submits an EIP-712 signed intent, and returns its signing hash and a timestamp (us) like
+// SendTransaction. The signature is always checked locally first. Fiber doesn't accept intents yet, so
+// for now this returns ErrIntentsUnsupported for valid intents; the signature is part of the API so
+// order flow integrations can be written against it already.
+func (c *Client) SendIntent(ctx context.Context, signed *intent.Signed) (string, int64, error) {
+	if err := signed.Verify(); err != nil {
+		return "", 0, fmt.Errorf("verifying intent: %w", err)
+	}
+
+	return "", 0, ErrIntentsUnsupported
+}
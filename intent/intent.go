@@ -0,0 +1,95 @@
+// Package intent implements EIP-712 typed data hashing and signing for intents and orders
+// submitted through Fiber.
+package intent
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+// ErrInvalidSignature is returned when a signature doesn't recover to the expected signer.
+var ErrInvalidSignature = errors.New("invalid intent signature")
+
+// TypedData is an EIP-712 typed data message.
+type TypedData = apitypes.TypedData
+
+// Signed is typed data signed by Signer.
+type Signed struct {
+	Data TypedData
+	// Signature is the 65 byte [R || S || V] signature, with V 27 or 28.
+	Signature []byte
+	Signer    common.Address
+}
+
+// Hash returns the EIP-712 signing hash of data: keccak256("\x19\x01" || domainSeparator || hashStruct(message)).
+func Hash(data TypedData) (common.Hash, error) {
+	hash, _, err := apitypes.TypedDataAndHash(data)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("hashing typed data: %w", err)
+	}
+
+	return common.BytesToHash(hash), nil
+}
+
+// Sign signs data with key.
+func Sign(data TypedData, key *ecdsa.PrivateKey) (*Signed, error) {
+	hash, err := Hash(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := crypto.Sign(hash.Bytes(), key)
+	if err != nil {
+		return nil, fmt.Errorf("signing typed data: %w", err)
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+
+	return &Signed{
+		Data:      data,
+		Signature: sig,
+		Signer:    crypto.PubkeyToAddress(key.PublicKey),
+	}, nil
+}
+
+// Recover returns the address that signed data. V can be 0, 1, 27 or 28.
+func Recover(data TypedData, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidSignature, crypto.SignatureLength, len(sig))
+	}
+
+	hash, err := Hash(data)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	sig = common.CopyBytes(sig)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// Verify checks that the signature recovers to Signer.
+func (s *Signed) Verify() error {
+	signer, err := Recover(s.Data, s.Signature)
+	if err != nil {
+		return err
+	}
+
+	if signer != s.Signer {
+		return fmt.Errorf("%w: signed by %s, expected %s", ErrInvalidSignature, signer, s.Signer)
+	}
+
+	return nil
+}
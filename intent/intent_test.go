@@ -0,0 +1,74 @@
+package intent
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+func order() TypedData {
+	return TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Order": {
+				{Name: "maker", Type: "address"},
+				{Name: "amount", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Order",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Test",
+			ChainId:           math.NewHexOrDecimal256(1),
+			VerifyingContract: "0x000000000000000000000000000000000000dEaD",
+		},
+		Message: apitypes.TypedDataMessage{
+			"maker":  "0x0000000000000000000000000000000000000001",
+			"amount": big.NewInt(100).String(),
+		},
+	}
+}
+
+func TestSignRecover(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+
+	signed, err := Sign(order(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := signed.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	signed.Signer = common.Address{1}
+	if err := signed.Verify(); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestHashChangesWithMessage(t *testing.T) {
+	a, err := Hash(order())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := order()
+	other.Message["amount"] = "101"
+	b, err := Hash(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a == b {
+		t.Fatal("expected different hashes for different messages")
+	}
+}
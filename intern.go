@@ -0,0 +1,120 @@
+package client
+
+import (
+	"sync"
+
+	"github.com/chainbound/fiber-go/hexenc"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultInternCapacity is the default number of address hex forms kept per generation of the cache.
+const DefaultInternCapacity = 1 << 16
+
+// internShards is the number of independently locked shards of the hex table.
+const internShards = 16
+
+// interner caches a value per address in a bounded two-generation table: when the current generation
+// is full it becomes the previous one, and entries still in use are promoted on their next lookup. The
+// mempool stream repeats the same hot addresses millions of times, so this saves computing their hex
+// forms over and over. Decoding doesn't go through it: the addresses are values, and the receiver of a
+// transaction is stored with it, see ProtoToTx.
+type interner[V any] struct {
+	mu       sync.Mutex
+	max      int
+	cur      map[common.Address]V
+	prev     map[common.Address]V
+	newValue func(common.Address) V
+}
+
+func newInterner[V any](max int, newValue func(common.Address) V) *interner[V] {
+	return &interner[V]{
+		max:      max,
+		cur:      map[common.Address]V{},
+		newValue: newValue,
+	}
+}
+
+func (i *interner[V]) get(addr common.Address) V {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.max <= 0 {
+		return i.newValue(addr)
+	}
+
+	if v, ok := i.cur[addr]; ok {
+		return v
+	}
+
+	v, ok := i.prev[addr]
+	if !ok {
+		v = i.newValue(addr)
+	}
+
+	if len(i.cur) >= i.max {
+		i.prev = i.cur
+		i.cur = make(map[common.Address]V, i.max)
+	}
+
+	i.cur[addr] = v
+	return v
+}
+
+func (i *interner[V]) resize(max int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.max = max
+	i.cur = map[common.Address]V{}
+	i.prev = nil
+}
+
+// shardedInterner spreads the addresses over several interners, so that concurrent subscriptions
+// don't contend on a single lock.
+type shardedInterner[V any] [internShards]*interner[V]
+
+func newShardedInterner[V any](max int, newValue func(common.Address) V) *shardedInterner[V] {
+	var s shardedInterner[V]
+	for i := range s {
+		s[i] = newInterner(shardCapacity(max), newValue)
+	}
+
+	return &s
+}
+
+// shardCapacity returns the capacity of a shard of an interner with a capacity of max.
+func shardCapacity(max int) int {
+	if max <= 0 {
+		return 0
+	}
+
+	return (max + internShards - 1) / internShards
+}
+
+func (s *shardedInterner[V]) get(addr common.Address) V {
+	// Addresses are uniformly distributed
+	return s[addr[0]%internShards].get(addr)
+}
+
+func (s *shardedInterner[V]) resize(max int) {
+	for _, i := range s {
+		i.resize(shardCapacity(max))
+	}
+}
+
+var hexTable = newShardedInterner(DefaultInternCapacity, func(addr common.Address) string {
+	return hexenc.Address(addr)
+})
+
+// SetInternCapacity sets the number of address hex forms kept per generation of the cache of AddressHex,
+// and clears it. A capacity of 0 disables the cache.
+func SetInternCapacity(n int) {
+	hexTable.resize(n)
+}
+
+// AddressHex returns the checksummed hex form of addr, cached for frequently seen addresses. The
+// strings are shared, which is safe since they're immutable.
+func AddressHex(addr common.Address) string {
+	return hexTable.get(addr)
+}
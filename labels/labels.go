@@ -0,0 +1,165 @@
+// Package labels contains an address book that maps addresses to human-readable names and tags.
+// A registry can be attached to a subscription to label streamed transactions.
+package labels
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Label is the human-readable description of an address.
+type Label struct {
+	Name string   `json:"name"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// HasTag returns true if the label has the given tag.
+func (l *Label) HasTag(tag string) bool {
+	for _, t := range l.Tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Entry is a label for a single address, as used in the JSON and CSV formats.
+type Entry struct {
+	Address common.Address `json:"address"`
+	Label
+}
+
+// Registry maps addresses to labels. It is safe for concurrent use, and can be updated while
+// subscriptions are using it.
+type Registry struct {
+	mu     sync.RWMutex
+	labels map[common.Address]*Label
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		labels: make(map[common.Address]*Label),
+	}
+}
+
+// Lookup returns the label of addr, or nil if there's none. The returned label must not be modified.
+func (r *Registry) Lookup(addr common.Address) *Label {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.labels[addr]
+}
+
+// Set labels addr, replacing any existing label.
+func (r *Registry) Set(addr common.Address, name string, tags ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.labels[addr] = &Label{Name: name, Tags: tags}
+}
+
+// Delete removes the label of addr.
+func (r *Registry) Delete(addr common.Address) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.labels, addr)
+}
+
+// Len returns the number of labeled addresses.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.labels)
+}
+
+// Replace atomically replaces all labels with the given entries.
+func (r *Registry) Replace(entries []Entry) {
+	labels := make(map[common.Address]*Label, len(entries))
+	for _, e := range entries {
+		label := e.Label
+		labels[e.Address] = &label
+	}
+
+	r.mu.Lock()
+	r.labels = labels
+	r.mu.Unlock()
+}
+
+// Add adds (or replaces) the labels of all entries.
+func (r *Registry) Add(entries []Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range entries {
+		label := e.Label
+		r.labels[e.Address] = &label
+	}
+}
+
+// LoadJSON adds the labels in r, which should contain a JSON array of entries:
+//
+//	[{"address": "0x...", "name": "USDC", "tags": ["erc20", "stablecoin"]}]
+func (r *Registry) LoadJSON(rd io.Reader) error {
+	var entries []Entry
+	if err := json.NewDecoder(rd).Decode(&entries); err != nil {
+		return fmt.Errorf("decoding labels: %w", err)
+	}
+
+	r.Add(entries)
+	return nil
+}
+
+// LoadCSV adds the labels in r, which should contain "address,name,tags" records with
+// the tags separated by semicolons. A header row is skipped.
+func (r *Registry) LoadCSV(rd io.Reader) error {
+	reader := csv.NewReader(rd)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var entries []Entry
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading labels: %w", err)
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+
+		if len(record) < 2 {
+			return fmt.Errorf("line %d: expected at least address and name", line)
+		}
+
+		if !common.IsHexAddress(record[0]) {
+			return fmt.Errorf("line %d: invalid address %q", line, record[0])
+		}
+
+		entry := Entry{
+			Address: common.HexToAddress(record[0]),
+			Label:   Label{Name: record[1]},
+		}
+
+		if len(record) > 2 && record[2] != "" {
+			entry.Tags = strings.Split(record[2], ";")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	r.Add(entries)
+	return nil
+}
@@ -0,0 +1,48 @@
+package labels
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+
+func TestLoadJSON(t *testing.T) {
+	r := NewRegistry()
+	err := r.LoadJSON(strings.NewReader(`[{"address": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "name": "USDC", "tags": ["erc20"]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	label := r.Lookup(usdc)
+	if label == nil || label.Name != "USDC" || !label.HasTag("erc20") {
+		t.Fatalf("unexpected label %+v", label)
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	r := NewRegistry()
+	err := r.LoadCSV(strings.NewReader("address,name,tags\n0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48,USDC,erc20;stablecoin\n0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D,Uniswap V2 Router\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Len() != 2 {
+		t.Fatalf("expected 2 labels, got %d", r.Len())
+	}
+
+	if label := r.Lookup(usdc); label == nil || !label.HasTag("stablecoin") {
+		t.Fatalf("unexpected label %+v", label)
+	}
+
+	if err := r.LoadCSV(strings.NewReader("notanaddress,foo\n")); err == nil {
+		t.Fatal("expected error for invalid address")
+	}
+
+	r.Delete(usdc)
+	if r.Lookup(usdc) != nil {
+		t.Fatal("expected label to be deleted")
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import "fmt"
+
+// ErrInvalidState is returned by Connect and Close when the client is in a state they can't start from,
+// like a Close while Connect is still running.
+var ErrInvalidState = newCodedError(ErrCodeInvalidState, "invalid client state")
+
+// ClientState is a stage of the lifecycle of a Client, which goes StateNew → StateConnecting →
+// StateReady → StateClosing → StateClosed. A failed Connect goes back to StateNew, to be retried.
+type ClientState int
+
+const (
+	// StateNew is the state of a client that isn't connected, before Connect or after it failed.
+	StateNew ClientState = iota
+	// StateConnecting is the state of a client while Connect runs.
+	StateConnecting
+	// StateReady is the state of a connected client.
+	StateReady
+	// StateClosing is the state of a client while Close runs. Sends and subscriptions fail with
+	// ErrClientClosed from then on.
+	StateClosing
+	// StateClosed is the state of a closed client.
+	StateClosed
+)
+
+func (s ClientState) String() string {
+	switch s {
+	case StateNew:
+		return "new"
+	case StateConnecting:
+		return "connecting"
+	case StateReady:
+		return "ready"
+	case StateClosing:
+		return "closing"
+	case StateClosed:
+		return "closed"
+	}
+
+	return fmt.Sprintf("ClientState(%d)", int(s))
+}
+
+// State returns the stage of the lifecycle the client is in.
+func (c *Client) State() ClientState {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	return c.state.lifecycle
+}
+
+// transition moves the client to the state to if it's in one of from, and returns the state it was in
+// and whether it moved.
+func (c *Client) transition(to ClientState, from ...ClientState) (ClientState, bool) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	prev := c.state.lifecycle
+	for _, s := range from {
+		if s == prev {
+			c.state.lifecycle = to
+			if to == StateClosing {
+				c.state.closed = make(chan struct{})
+			}
+			return prev, true
+		}
+	}
+
+	return prev, false
+}
+
+// closed returns a channel closed once the client is closed, nil if it isn't closing.
+func (c *Client) closed() <-chan struct{} {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	return c.state.closed
+}
+
+// finishClose moves the closing client to StateClosed, unblocking the concurrent Close calls.
+func (c *Client) finishClose() {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	c.state.lifecycle = StateClosed
+	close(c.state.closed)
+}
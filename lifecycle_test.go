@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"google.golang.org/grpc"
+)
+
+func TestLifecycle(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, api.UnimplementedAPIServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	// A failed Connect leaves the client unconnected, to be retried
+	c := NewClient(lis.Addr().String(), WithConnectTimeout(time.Nanosecond))
+	if err := c.Connect(context.Background()); err == nil || c.State() != StateNew {
+		t.Fatalf("expected a failed connect to go back to new, got %v in %s", err, c.State())
+	}
+
+	c = NewClient(lis.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.Connect(ctx); err != nil || c.State() != StateReady {
+		t.Fatalf("expected a ready client, got %v in %s", err, c.State())
+	}
+	conn := c.conn
+	if err := c.Connect(ctx); err != nil || c.conn != conn {
+		t.Fatalf("expected connecting again to do nothing, got %v", err)
+	}
+
+	if err := c.Close(); err != nil || c.State() != StateClosed {
+		t.Fatalf("expected a closed client, got %v in %s", err, c.State())
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("expected closing again to do nothing, got %v", err)
+	}
+	if err := c.Connect(ctx); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+	if err := c.beginSend(); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected sends to fail with ErrClientClosed, got %v", err)
+	}
+
+	// A client that never connected has nothing to close
+	if err := NewClient(lis.Addr().String()).Close(); err != nil {
+		t.Fatalf("expected closing an unconnected client to succeed, got %v", err)
+	}
+
+	connecting := NewClient(lis.Addr().String())
+	connecting.state.lifecycle = StateConnecting
+	if err := connecting.Close(); !errors.Is(err, ErrInvalidState) || Code(err) != ErrCodeInvalidState {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+	if err := connecting.Connect(ctx); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+}
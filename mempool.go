@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultGaugeWindow is the window of a MempoolGauge by default.
+const DefaultGaugeWindow = time.Minute
+
+// Number of time buckets a MempoolGauge aggregates its window in.
+const gaugeBuckets = 60
+
+// MempoolPressure is a reading of a MempoolGauge over its window.
+type MempoolPressure struct {
+	// Received is the number of transactions received in the window.
+	Received uint64
+	// TxsPerSecond is the rate of the transactions received in the window.
+	TxsPerSecond float64
+	// AvgGasPrice is the average price per gas (wei) the received transactions bid: their gas price,
+	// or for the dynamic fee ones their max fee, capped at the last base fee plus their priority fee.
+	// It's nil without transactions.
+	AvgGasPrice *big.Int
+	// Pending is the number of transactions received in the window that weren't included yet.
+	Pending int
+	// Included is the number of transactions in the blocks of the window.
+	Included uint64
+	// PendingToIncluded is the number of transactions received per transaction included in the
+	// window, 0 without blocks. Above 1 the mempool grows faster than blocks drain it.
+	PendingToIncluded float64
+}
+
+type gaugeBucket struct {
+	slot     int64
+	received uint64
+	included uint64
+	gasPrice big.Int
+}
+
+// MempoolGauge derives rolling mempool congestion metrics from the transaction stream and the blocks,
+// for pricing logic to react to. Transactions are added with AddTransaction and blocks with
+// AddPayload, or both by Run.
+type MempoolGauge struct {
+	mu      sync.Mutex
+	width   time.Duration
+	buckets []gaugeBucket
+	// pending are the transactions received in the window and not included yet, with when
+	pending map[common.Hash]time.Time
+	// expired is the slot of the bucket the pending transactions were last expired in
+	expired int64
+	baseFee *big.Int
+}
+
+// NewMempoolGauge returns a gauge over the last window, 0 for DefaultGaugeWindow.
+func NewMempoolGauge(window time.Duration) *MempoolGauge {
+	if window <= 0 {
+		window = DefaultGaugeWindow
+	}
+
+	width := window / gaugeBuckets
+	if width <= 0 {
+		width = 1
+	}
+
+	return &MempoolGauge{
+		width:   width,
+		buckets: make([]gaugeBucket, gaugeBuckets),
+		pending: make(map[common.Hash]time.Time),
+	}
+}
+
+func (g *MempoolGauge) bucket(t time.Time) *gaugeBucket {
+	slot := t.UnixNano() / int64(g.width)
+	b := &g.buckets[slot%int64(len(g.buckets))]
+	if b.slot != slot {
+		*b = gaugeBucket{slot: slot}
+	}
+
+	return b
+}
+
+func (g *MempoolGauge) window() time.Duration {
+	return g.width * time.Duration(len(g.buckets))
+}
+
+// AddTransaction adds a pending transaction.
+func (g *MempoolGauge) AddTransaction(tx *Transaction) {
+	g.addTransaction(tx, time.Now())
+}
+
+func (g *MempoolGauge) addTransaction(tx *Transaction, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// At most once per bucket, so that the pending transactions stay bounded even if nobody reads
+	// the gauge
+	if slot := now.UnixNano() / int64(g.width); slot != g.expired {
+		g.expire(now)
+		g.expired = slot
+	}
+
+	if _, ok := g.pending[tx.Hash]; ok {
+		return
+	}
+	g.pending[tx.Hash] = now
+
+	b := g.bucket(now)
+	b.received++
+	if price := g.bid(tx); price != nil {
+		b.gasPrice.Add(&b.gasPrice, price)
+	}
+}
+
+// bid returns the price per gas tx bids given the last base fee.
+func (g *MempoolGauge) bid(tx *Transaction) *big.Int {
+	if tx.Type == 0 || tx.Type == 1 || tx.MaxFee == nil {
+		return tx.GasPrice
+	}
+
+	if g.baseFee != nil && tx.PriorityFee != nil {
+		if capped := new(big.Int).Add(g.baseFee, tx.PriorityFee); capped.Cmp(tx.MaxFee) < 0 {
+			return capped
+		}
+	}
+
+	return tx.MaxFee
+}
+
+// AddPayload adds a block, whose transactions aren't pending anymore.
+func (g *MempoolGauge) AddPayload(p *ExecutionPayload) {
+	g.addPayload(p, time.Now())
+}
+
+func (g *MempoolGauge) addPayload(p *ExecutionPayload, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if p.Header != nil && p.Header.BaseFeePerGas != nil {
+		g.baseFee = p.Header.BaseFeePerGas
+	}
+
+	g.bucket(now).included += uint64(len(p.Transactions))
+	for _, tx := range p.Transactions {
+		delete(g.pending, tx.Hash)
+	}
+}
+
+// Pressure returns the metrics over the window.
+func (g *MempoolGauge) Pressure() MempoolPressure {
+	return g.pressure(time.Now())
+}
+
+func (g *MempoolGauge) pressure(now time.Time) MempoolPressure {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	cutoff := g.expire(now)
+
+	var p MempoolPressure
+	var gasPrice big.Int
+	first := cutoff.UnixNano()/int64(g.width) + 1
+	for i := range g.buckets {
+		b := &g.buckets[i]
+		if b.slot < first || b.slot > now.UnixNano()/int64(g.width) {
+			continue
+		}
+
+		p.Received += b.received
+		p.Included += b.included
+		gasPrice.Add(&gasPrice, &b.gasPrice)
+	}
+
+	p.TxsPerSecond = float64(p.Received) / g.window().Seconds()
+	if p.Received > 0 {
+		p.AvgGasPrice = gasPrice.Div(&gasPrice, new(big.Int).SetUint64(p.Received))
+	}
+	p.Pending = len(g.pending)
+	if p.Included > 0 {
+		p.PendingToIncluded = float64(p.Received) / float64(p.Included)
+	}
+
+	return p
+}
+
+// expire drops the pending transactions received before the window, and returns its start.
+func (g *MempoolGauge) expire(now time.Time) time.Time {
+	cutoff := now.Add(-g.window())
+	for hash, seen := range g.pending {
+		if !seen.After(cutoff) {
+			delete(g.pending, hash)
+		}
+	}
+
+	return cutoff
+}
+
+// Run subscribes to transactions and execution payloads on c, and adds them to the gauge. It blocks
+// until ctx is done or a subscription fails.
+func (g *MempoolGauge) Run(ctx context.Context, c *Client) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 2)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := fn()
+			if ctx.Err() == nil {
+				errc <- err
+			}
+			cancel()
+		}()
+	}
+
+	run(func() error {
+		return NewPipeline(TxStream(nil)).
+			Sink(SinkFunc[*Transaction](func(ctx context.Context, tx *Transaction) error {
+				g.AddTransaction(tx)
+				return nil
+			})).
+			Run(ctx, c)
+	})
+	run(func() error {
+		return NewPipeline(ExecutionPayloadStream()).
+			Sink(SinkFunc[*ExecutionPayload](func(ctx context.Context, p *ExecutionPayload) error {
+				g.AddPayload(p)
+				return nil
+			})).
+			Run(ctx, c)
+	})
+
+	wg.Wait()
+	select {
+	case err := <-errc:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMempoolGauge(t *testing.T) {
+	g := NewMempoolGauge(time.Minute)
+	start := time.Unix(1700000000, 0)
+
+	tx := func(i int64, typ uint32, price int64) *Transaction {
+		tx := &Transaction{Hash: common.BigToHash(big.NewInt(i)), Type: typ}
+		if typ == 2 {
+			tx.MaxFee, tx.PriorityFee = big.NewInt(price), big.NewInt(1)
+		} else {
+			tx.GasPrice = big.NewInt(price)
+		}
+		return tx
+	}
+
+	g.addTransaction(tx(1, 0, 10), start)
+	g.addTransaction(tx(1, 0, 10), start) // duplicate
+	g.addTransaction(tx(2, 0, 20), start.Add(time.Second))
+	// Bids the base fee plus its priority fee, 5
+	g.addPayload(&ExecutionPayload{Header: &ExecutionPayloadHeader{BaseFeePerGas: big.NewInt(4)}, Transactions: []*Transaction{tx(1, 0, 10)}}, start.Add(2*time.Second))
+	g.addTransaction(tx(3, 2, 100), start.Add(3*time.Second))
+
+	p := g.pressure(start.Add(4 * time.Second))
+	if p.Received != 3 || p.Included != 1 || p.Pending != 2 || p.PendingToIncluded != 3 || p.TxsPerSecond != 3.0/60 {
+		t.Fatalf("unexpected pressure %+v", p)
+	}
+	if p.AvgGasPrice.Int64() != (10+20+5)/3 {
+		t.Fatalf("expected an average gas price of 11, got %s", p.AvgGasPrice)
+	}
+
+	// Everything but the last transaction fell out of the window
+	p = g.pressure(start.Add(62 * time.Second))
+	if p.Received != 1 || p.Included != 0 || p.Pending != 1 || p.PendingToIncluded != 0 || p.AvgGasPrice.Int64() != 5 {
+		t.Fatalf("unexpected pressure after a minute %+v", p)
+	}
+
+	if p := g.pressure(start.Add(time.Hour)); p.Received != 0 || p.Pending != 0 || p.AvgGasPrice != nil {
+		t.Fatalf("unexpected pressure after an hour %+v", p)
+	}
+}
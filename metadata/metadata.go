@@ -0,0 +1,71 @@
+// Package metadata contains the canonical gRPC metadata keys used by Fiber, with typed setters
+// and getters, so integrators building their own interceptors don't have to hardcode them.
+package metadata
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// MD is gRPC metadata, as sent and received by Fiber.
+type MD = metadata.MD
+
+const (
+	// APIKeyKey carries the API key of the client.
+	APIKeyKey = "x-api-key"
+	// ClientVersionKey carries the name and version of the client library, e.g. "fiber-go/v1.2.0". Fiber
+	// doesn't require it, the client only sends it if enabled (see client.WithVersionMetadata).
+	ClientVersionKey = "x-client-version"
+	// FilterEchoKey carries the filter of a transaction subscription as parsed and normalized by the
+	// server, in the encoded form of filter.Filter. Servers that support it send it in the response header.
+	FilterEchoKey = "x-filter-echo"
+)
+
+// WithAPIKey returns a copy of ctx with the API key set on the outgoing metadata.
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, APIKeyKey, key)
+}
+
+// WithClientVersion returns a copy of ctx with the client version set on the outgoing metadata.
+func WithClientVersion(ctx context.Context, version string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, ClientVersionKey, version)
+}
+
+// APIKey returns the API key in md.
+func APIKey(md metadata.MD) (string, bool) {
+	return get(md, APIKeyKey)
+}
+
+// ClientVersion returns the client version in md.
+func ClientVersion(md metadata.MD) (string, bool) {
+	return get(md, ClientVersionKey)
+}
+
+// FilterEcho returns the encoded filter echoed by the server in md.
+func FilterEcho(md metadata.MD) ([]byte, bool) {
+	echo, ok := get(md, FilterEchoKey)
+	return []byte(echo), ok
+}
+
+// IncomingAPIKey returns the API key of an incoming request, for use in server interceptors.
+func IncomingAPIKey(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return APIKey(md)
+}
+
+// OutgoingAPIKey returns the API key set on the outgoing metadata of ctx, for use in client interceptors.
+func OutgoingAPIKey(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	return APIKey(md)
+}
+
+// get returns the last value of key in md.
+func get(md metadata.MD, key string) (string, bool) {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[len(values)-1], true
+}
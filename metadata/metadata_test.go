@@ -0,0 +1,26 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestOutgoing(t *testing.T) {
+	ctx := WithAPIKey(context.Background(), "key")
+	ctx = WithClientVersion(ctx, "fiber-go/v0.0.0")
+
+	if key, ok := OutgoingAPIKey(ctx); !ok || key != "key" {
+		t.Fatalf("expected api key, got %q", key)
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if version, ok := ClientVersion(md); !ok || version != "fiber-go/v0.0.0" {
+		t.Fatalf("expected client version, got %q", version)
+	}
+
+	if _, ok := FilterEcho(md); ok {
+		t.Fatal("expected no filter echo")
+	}
+}
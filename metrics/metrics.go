@@ -0,0 +1,254 @@
+// Package metrics is a small registry of counters and histograms served in the Prometheus text
+// exposition format, for scraping the client's metrics (see client.WithMetrics) into existing
+// Prometheus and Grafana setups without depending on the Prometheus client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultLatencyBuckets are histogram buckets for latencies in seconds, from 10µs to 1s.
+var DefaultLatencyBuckets = []float64{
+	0.00001, 0.000025, 0.00005, 0.0001, 0.00025, 0.0005,
+	0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1,
+}
+
+// Registry holds metric families. It's an http.Handler serving them.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+	names    map[string]family
+}
+
+type family interface {
+	write(w io.Writer)
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]family)}
+}
+
+// register registers the family made by create under name, or returns the one already registered.
+func (r *Registry) register(name string, create func() family) family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f, ok := r.names[name]; ok {
+		return f
+	}
+
+	f := create()
+	r.names[name] = f
+	r.families = append(r.families, f)
+	return f
+}
+
+// Write writes all the metrics in the text exposition format.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	families := append([]family(nil), r.families...)
+	r.mu.Unlock()
+
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.Write(w)
+}
+
+// vec holds the series of a family by label values.
+type vec[S any] struct {
+	name, help, kind string
+	labels           []string
+	create           func() *S
+
+	mu     sync.RWMutex
+	series map[string]*S
+}
+
+func (v *vec[S]) with(values []string) *S {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", v.name, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	v.mu.RLock()
+	s, ok := v.series[key]
+	v.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s, ok = v.series[key]; !ok {
+		s = v.create()
+		v.series[key] = s
+	}
+	return s
+}
+
+// each calls fn with the label pairs of every series, sorted.
+func (v *vec[S]) each(fn func(labels string, s *S)) {
+	v.mu.RLock()
+	keys := make([]string, 0, len(v.series))
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]*S, len(keys))
+	for i, key := range keys {
+		series[i] = v.series[key]
+	}
+	v.mu.RUnlock()
+
+	for i, key := range keys {
+		var pairs []string
+		if len(v.labels) > 0 {
+			for j, value := range strings.Split(key, "\xff") {
+				pairs = append(pairs, fmt.Sprintf("%s=%s", v.labels[j], strconv.Quote(value)))
+			}
+		}
+		fn(strings.Join(pairs, ","), series[i])
+	}
+}
+
+func (v *vec[S]) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, v.kind)
+}
+
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// Counter is a family of counters.
+type Counter struct {
+	v *vec[uint64]
+}
+
+// Counter registers a counter family with the given label names, or returns the one registered under
+// name. It panics if that one isn't a counter with the same labels.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	f := r.register(name, func() family {
+		return &Counter{&vec[uint64]{
+			name: name, help: help, kind: "counter", labels: labels,
+			create: func() *uint64 { return new(uint64) },
+			series: make(map[string]*uint64),
+		}}
+	})
+
+	c, ok := f.(*Counter)
+	if !ok || !sameLabels(c.v.labels, labels) {
+		panic(fmt.Sprintf("metrics: %s is registered with another type or labels", name))
+	}
+	return c
+}
+
+func sameLabels(a, b []string) bool {
+	return strings.Join(a, ",") == strings.Join(b, ",")
+}
+
+// Add adds n to the counter with the given label values.
+func (c *Counter) Add(n uint64, values ...string) {
+	atomic.AddUint64(c.v.with(values), n)
+}
+
+// Value returns the value of the counter with the given label values.
+func (c *Counter) Value(values ...string) uint64 {
+	return atomic.LoadUint64(c.v.with(values))
+}
+
+func (c *Counter) write(w io.Writer) {
+	c.v.header(w)
+	c.v.each(func(labels string, n *uint64) {
+		fmt.Fprintf(w, "%s%s %d\n", c.v.name, braces(labels), atomic.LoadUint64(n))
+	})
+}
+
+// Histogram is a family of histograms.
+type Histogram struct {
+	v       *vec[histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative, with +Inf last
+	count  uint64
+	sum    uint64 // float64 bits
+}
+
+// Histogram registers a histogram family with the given upper bounds and label names, or returns the
+// one registered under name. It panics if that one isn't a histogram with the same labels.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	f := r.register(name, func() family {
+		buckets := append([]float64(nil), buckets...)
+		sort.Float64s(buckets)
+
+		h := &Histogram{buckets: buckets}
+		h.v = &vec[histogram]{
+			name: name, help: help, kind: "histogram", labels: labels,
+			create: func() *histogram { return &histogram{counts: make([]uint64, len(buckets)+1)} },
+			series: make(map[string]*histogram),
+		}
+		return h
+	})
+
+	h, ok := f.(*Histogram)
+	if !ok || !sameLabels(h.v.labels, labels) {
+		panic(fmt.Sprintf("metrics: %s is registered with another type or labels", name))
+	}
+	return h
+}
+
+// Observe records v in the histogram with the given label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	s := h.v.with(values)
+	atomic.AddUint64(&s.counts[sort.SearchFloat64s(h.buckets, v)], 1)
+	atomic.AddUint64(&s.count, 1)
+	for {
+		old := atomic.LoadUint64(&s.sum)
+		if atomic.CompareAndSwapUint64(&s.sum, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Count returns the number of observations of the histogram with the given label values.
+func (h *Histogram) Count(values ...string) uint64 {
+	return atomic.LoadUint64(&h.v.with(values).count)
+}
+
+func (h *Histogram) write(w io.Writer) {
+	h.v.header(w)
+	h.v.each(func(labels string, s *histogram) {
+		sep := ""
+		if labels != "" {
+			sep = ","
+		}
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += atomic.LoadUint64(&s.counts[i])
+			fmt.Fprintf(w, "%s_bucket{%s%sle=\"%s\"} %d\n", h.v.name, labels, sep, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+		}
+		cumulative += atomic.LoadUint64(&s.counts[len(h.buckets)])
+		fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", h.v.name, labels, sep, cumulative)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.v.name, braces(labels), strconv.FormatFloat(math.Float64frombits(atomic.LoadUint64(&s.sum)), 'g', -1, 64))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.v.name, braces(labels), cumulative)
+	})
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	msgs := r.Counter("fiber_messages_total", "Messages received.", "stream")
+	latency := r.Histogram("fiber_send_seconds", "Send latency.", []float64{0.01, 0.001})
+
+	msgs.Add(2, "txs")
+	msgs.Add(1, "beacon_blocks")
+	latency.Observe(0.0005)
+	latency.Observe(0.005)
+	latency.Observe(2)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	expected := `# HELP fiber_messages_total Messages received.
+# TYPE fiber_messages_total counter
+fiber_messages_total{stream="beacon_blocks"} 1
+fiber_messages_total{stream="txs"} 2
+# HELP fiber_send_seconds Send latency.
+# TYPE fiber_send_seconds histogram
+fiber_send_seconds_bucket{le="0.001"} 1
+fiber_send_seconds_bucket{le="0.01"} 2
+fiber_send_seconds_bucket{le="+Inf"} 3
+fiber_send_seconds_sum 2.0055
+fiber_send_seconds_count 3
+`
+	if got := rec.Body.String(); got != expected {
+		t.Fatalf("unexpected exposition:\n%s", got)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Fatal("expected the text format")
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	r := NewRegistry()
+	if r.Counter("c", "", "a") != r.Counter("c", "", "a") {
+		t.Fatal("expected the registered counter")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	r.Histogram("c", "", nil, "a")
+}
@@ -0,0 +1,304 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MigrationPolicy configures when Migrate moves a subscription to another endpoint. Zero values take
+// the defaults.
+type MigrationPolicy struct {
+	// Window is the period the p99 latency of the active endpoint is computed over. Default 5m.
+	Window time.Duration
+	// ProbeInterval is how often the alternatives are probed. Default 5m.
+	ProbeInterval time.Duration
+	// ProbeDuration is how long an alternative is subscribed to measure its latency. Default 1m.
+	ProbeDuration time.Duration
+	// Ratio is how many times slower than the best alternative the active endpoint must be to migrate.
+	// Default 1.5.
+	Ratio float64
+	// Handover is how long the old endpoint keeps delivering after a migration, so that no message is
+	// lost while switching. Duplicates are dropped. Default 12s.
+	Handover time.Duration
+	// OnMigrate is called on every migration.
+	OnMigrate func(MigrationEvent)
+}
+
+func (p *MigrationPolicy) defaults() {
+	if p.Window == 0 {
+		p.Window = 5 * time.Minute
+	}
+	if p.ProbeInterval == 0 {
+		p.ProbeInterval = 5 * time.Minute
+	}
+	if p.ProbeDuration == 0 {
+		p.ProbeDuration = time.Minute
+	}
+	if p.Ratio == 0 {
+		p.Ratio = 1.5
+	}
+	if p.Handover == 0 {
+		p.Handover = 12 * time.Second
+	}
+}
+
+// MigrationEvent explains why Migrate moved to another endpoint.
+type MigrationEvent struct {
+	At   time.Time
+	From string
+	To   string
+	// Reason is a human-readable explanation.
+	Reason string
+	// Latencies (p99) of the old endpoint over the window and of the new one during the probe, 0 when
+	// the migration was caused by a failure.
+	FromP99Ms float64
+	ToP99Ms   float64
+	// Err is the error that ended the old subscription, if it failed.
+	Err error
+}
+
+// migrationLeg is a subscription on one of the endpoints. Its messages are only forwarded while it's
+// active.
+type migrationLeg[T any] struct {
+	client  *Client
+	sub     Subscription
+	ch      chan T
+	active  int32
+	started time.Time
+
+	done chan struct{}
+	err  error
+}
+
+// stop unsubscribes and waits for the source to return, retrying since Unsubscribe is a no-op until the
+// subscription started.
+func (l *migrationLeg[T]) stop() {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		l.sub.Unsubscribe()
+
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// p99 returns the p99 latency of the leg in ms since from, 0 without samples.
+func (l *migrationLeg[T]) p99(from time.Time) float64 {
+	return l.sub.Report(from, time.Now()).LatencyP99Ms
+}
+
+// Migrate subscribes with src on the first of clients, and moves the subscription to another client
+// when it fails, or when its p99 latency degrades past policy.Ratio times the latency of the best
+// alternative, which are probed every policy.ProbeInterval. Latency is only measured on execution
+// payloads and headers, other streams only migrate on failure.
+//
+// It blocks until ctx is done or every client failed in a row without delivering a message. It never
+// closes ch.
+func Migrate[T any](ctx context.Context, src Source[T], ch chan<- T, policy MigrationPolicy, clients []*Client, opts ...SubscriptionOption) error {
+	return migrate(ctx, src, ch, policy, func() []*Client { return clients }, opts...)
+}
+
+// migrate is Migrate on the clients returned by clients, which is called again on every failure and
+// probe, so that clients that became available since are used.
+func migrate[T any](ctx context.Context, src Source[T], ch chan<- T, policy MigrationPolicy, clients func() []*Client, opts ...SubscriptionOption) error {
+	initial := clients()
+	if len(initial) == 0 {
+		return fmt.Errorf("migrate: no clients")
+	}
+	policy.defaults()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		dedup    = newDedupSet(DefaultDedupWindow)
+		forwards sync.WaitGroup
+		mu       sync.Mutex
+		legs     []*migrationLeg[T]
+	)
+
+	start := func(c *Client, active bool) *migrationLeg[T] {
+		l := &migrationLeg[T]{client: c, ch: make(chan T), started: time.Now(), done: make(chan struct{})}
+		if active {
+			l.active = 1
+		}
+
+		go func() {
+			l.err = src(c, l.ch, append(opts[:len(opts):len(opts)], WithHandle(&l.sub))...)
+			close(l.done)
+		}()
+
+		forwards.Add(1)
+		go func() {
+			defer forwards.Done()
+
+			for {
+				select {
+				case msg, ok := <-l.ch:
+					if !ok {
+						return
+					}
+					if atomic.LoadInt32(&l.active) == 0 {
+						continue
+					}
+					if key, ok := dedupKey(msg); ok && !dedup.add(key) {
+						continue
+					}
+
+					select {
+					case ch <- msg:
+					case <-ctx.Done():
+						return
+					}
+				case <-l.done:
+					return
+				}
+			}
+		}()
+
+		mu.Lock()
+		running := legs[:0]
+		for _, other := range legs {
+			select {
+			case <-other.done:
+			default:
+				running = append(running, other)
+			}
+		}
+		legs = append(running, l)
+		mu.Unlock()
+		return l
+	}
+
+	defer func() {
+		mu.Lock()
+		all := legs
+		mu.Unlock()
+
+		for _, l := range all {
+			l.stop()
+		}
+		forwards.Wait()
+	}()
+
+	notify := func(e MigrationEvent) {
+		e.At = time.Now()
+		if policy.OnMigrate != nil {
+			policy.OnMigrate(e)
+		}
+	}
+
+	active := start(initial[0], true)
+	failures := 0
+
+	probe := time.NewTimer(policy.ProbeInterval)
+	defer probe.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-active.done:
+			if active.sub.Stats().Messages > 0 {
+				failures = 0
+			}
+			failures++
+			current := clients()
+			if failures >= len(current) {
+				return fmt.Errorf("all endpoints failed: %w", active.err)
+			}
+
+			// The next client after the failed one, or the first if it's gone
+			index := 0
+			for i, c := range current {
+				if c == active.client {
+					index = (i + 1) % len(current)
+				}
+			}
+			old := active
+			active = start(current[index], true)
+			notify(MigrationEvent{
+				From:   old.client.target,
+				To:     active.client.target,
+				Reason: fmt.Sprintf("subscription failed: %v", old.err),
+				Err:    old.err,
+			})
+
+		case <-probe.C:
+			if next, event, ok := probeAlternatives(ctx, active, clients(), policy, start); ok {
+				old := active
+				atomic.StoreInt32(&next.active, 1)
+				active = next
+				notify(event)
+
+				time.AfterFunc(policy.Handover, old.stop)
+			}
+
+			probe.Reset(policy.ProbeInterval)
+		}
+	}
+}
+
+// probeAlternatives subscribes on every client but the active one for policy.ProbeDuration, and returns
+// the fastest one if the active endpoint is policy.Ratio times slower. The other probes are stopped.
+func probeAlternatives[T any](ctx context.Context, active *migrationLeg[T], clients []*Client, policy MigrationPolicy, start func(*Client, bool) *migrationLeg[T]) (*migrationLeg[T], MigrationEvent, bool) {
+	activeP99 := active.p99(time.Now().Add(-policy.Window))
+	if activeP99 == 0 {
+		return nil, MigrationEvent{}, false
+	}
+
+	var probes []*migrationLeg[T]
+	for _, c := range clients {
+		if c != active.client {
+			probes = append(probes, start(c, false))
+		}
+	}
+	if len(probes) == 0 {
+		return nil, MigrationEvent{}, false
+	}
+
+	timer := time.NewTimer(policy.ProbeDuration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	case <-active.done:
+	}
+
+	var best *migrationLeg[T]
+	var bestP99 float64
+	for _, p := range probes {
+		if p99 := p.p99(p.started); p99 > 0 && (best == nil || p99 < bestP99) {
+			best, bestP99 = p, p99
+		}
+	}
+
+	migrate := ctx.Err() == nil && best != nil && activeP99 > policy.Ratio*bestP99
+	for _, p := range probes {
+		if !migrate || p != best {
+			p.stop()
+		}
+	}
+
+	if !migrate {
+		return nil, MigrationEvent{}, false
+	}
+
+	return best, MigrationEvent{
+		From:      active.client.target,
+		To:        best.client.target,
+		Reason:    fmt.Sprintf("p99 latency %.0fms is over %.1fx the %.0fms of the best alternative", activeP99, policy.Ratio, bestP99),
+		FromP99Ms: activeP99,
+		ToP99Ms:   bestP99,
+	}, true
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// migrationSource is a Source of 3 headers per client, delays[c] old when received, failing on the clients in failing.
+func migrationSource(delays map[*Client]time.Duration, failing map[*Client]error) Source[*ExecutionPayloadHeader] {
+	return func(c *Client, ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error {
+		if err := failing[c]; err != nil {
+			return err
+		}
+
+		now := time.Now().Add(-delays[c])
+		headers := make([]*ExecutionPayloadHeader, 3)
+		for i := range headers {
+			headers[i] = &ExecutionPayloadHeader{
+				Number:    uint64(i),
+				Hash:      common.BigToHash(big.NewInt(int64(i))),
+				Timestamp: uint64(now.Unix()),
+			}
+		}
+
+		return subscribe(c, "execution_headers", ch, opts, fakeStream(headers...), identity[*ExecutionPayloadHeader])
+	}
+}
+
+func TestMigrateOnLatency(t *testing.T) {
+	slow, fast := &Client{target: "slow"}, &Client{target: "fast"}
+	src := migrationSource(map[*Client]time.Duration{slow: 10 * time.Second}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan MigrationEvent, 1)
+	policy := MigrationPolicy{
+		Window:        time.Minute,
+		ProbeInterval: 20 * time.Millisecond,
+		ProbeDuration: 50 * time.Millisecond,
+		Handover:      10 * time.Millisecond,
+		OnMigrate:     func(e MigrationEvent) { events <- e },
+	}
+
+	ch := make(chan *ExecutionPayloadHeader, 16)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Migrate(ctx, src, ch, policy, []*Client{slow, fast})
+	}()
+
+	e := <-events
+	if e.From != "slow" || e.To != "fast" || e.FromP99Ms <= e.ToP99Ms {
+		t.Fatalf("unexpected migration %+v", e)
+	}
+	if len(ch) != 3 {
+		t.Fatalf("expected the 3 headers once, got %d", len(ch))
+	}
+
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestMigrateOnFailure(t *testing.T) {
+	broken, ok := &Client{target: "broken"}, &Client{target: "ok"}
+	errBroken := errors.New("broken")
+	src := migrationSource(nil, map[*Client]error{broken: errBroken})
+
+	events := make(chan MigrationEvent, 1)
+	policy := MigrationPolicy{OnMigrate: func(e MigrationEvent) { events <- e }}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan *ExecutionPayloadHeader, 16)
+	go Migrate(ctx, src, ch, policy, []*Client{broken, ok})
+
+	if e := <-events; e.To != "ok" || !errors.Is(e.Err, errBroken) {
+		t.Fatalf("unexpected migration %+v", e)
+	}
+	if header := <-ch; header.Number != 0 {
+		t.Fatalf("expected the first header, got %d", header.Number)
+	}
+
+	// Every client failing in a row ends the migration
+	err := Migrate(ctx, src, ch, policy, []*Client{broken, broken})
+	if !errors.Is(err, errBroken) {
+		t.Fatalf("expected the last failure, got %v", err)
+	}
+}
+
+func TestMigrateLiveClients(t *testing.T) {
+	broken, ok := &Client{target: "broken"}, &Client{target: "ok"}
+	src := migrationSource(nil, map[*Client]error{broken: errors.New("broken")})
+
+	// Only the broken client is available when the migration starts
+	var calls int32
+	clients := func() []*Client {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return []*Client{broken}
+		}
+		return []*Client{broken, ok}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan *ExecutionPayloadHeader, 16)
+	go migrate(ctx, src, ch, MigrationPolicy{}, clients)
+
+	select {
+	case header := <-ch:
+		if header.Number != 0 {
+			t.Fatalf("expected the first header, got %d", header.Number)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected a failover to the client that became available")
+	}
+}
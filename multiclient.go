@@ -0,0 +1,358 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chainbound/fiber-go/filter"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ErrNoHealthyEndpoint is returned by the sends of a MultiClient when none of its endpoints is usable.
+var ErrNoHealthyEndpoint = newCodedError(ErrCodeNoHealthyEndpoint, "no healthy endpoint")
+
+// DefaultHealthInterval is how often a MultiClient tries to recover its failed endpoints by default.
+const DefaultHealthInterval = 5 * time.Second
+
+// MultiClient keeps connections to several Fiber endpoints, like one per region, and fails over to a
+// healthy one when an endpoint goes down. Sends go to the first healthy endpoint, in the order they
+// were given, and subscriptions migrate between endpoints with Migrate.
+type MultiClient struct {
+	opts     []ClientOption
+	policy   MigrationPolicy
+	interval time.Duration
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// endpoint is one of the endpoints of a MultiClient. The client owns the connection and runs the
+// subscriptions. The send streams don't survive a failure, so once they do, they're reopened on the
+// same connection by a new sender.
+type endpoint struct {
+	target    string
+	client    *Client
+	sender    *Client
+	connected bool
+	down      bool
+}
+
+// NewMultiClient returns a client for the endpoints at targets, each configured with opts.
+func NewMultiClient(targets []string, opts ...ClientOption) *MultiClient {
+	m := &MultiClient{
+		opts:     opts,
+		interval: DefaultHealthInterval,
+		stop:     make(chan struct{}),
+	}
+
+	for _, target := range targets {
+		m.endpoints = append(m.endpoints, &endpoint{target: target})
+	}
+
+	return m
+}
+
+// Migration sets the policy the subscriptions migrate between endpoints with.
+func (m *MultiClient) Migration(policy MigrationPolicy) *MultiClient {
+	m.policy = policy
+	return m
+}
+
+// HealthInterval sets how often the failed endpoints are recovered, DefaultHealthInterval by default.
+func (m *MultiClient) HealthInterval(d time.Duration) *MultiClient {
+	m.interval = d
+	return m
+}
+
+// Connect connects to all the endpoints, and blocks until they're connected or ctx is done. It only
+// fails if no endpoint could be connected: the others are retried in the background.
+func (m *MultiClient) Connect(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(m.endpoints))
+	for i, e := range m.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			errs[i] = m.connect(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	if len(m.Clients()) == 0 {
+		// Report the first endpoint, the preferred one
+		return fmt.Errorf("connecting to %s: %w", m.endpoints[0].target, errs[0])
+	}
+
+	m.wg.Add(1)
+	go m.recover()
+	return nil
+}
+
+// connect connects the endpoint from scratch.
+func (m *MultiClient) connect(ctx context.Context, e *endpoint) error {
+	c := NewClient(e.target, m.opts...)
+	if err := c.Connect(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	e.client, e.sender = c, c
+	e.connected, e.down = true, false
+	m.mu.Unlock()
+
+	return nil
+}
+
+// reopen reopens the send streams of the endpoint on its connection.
+func (m *MultiClient) reopen(ctx context.Context, e *endpoint) error {
+	sender := NewClientWithConn(e.client.conn, m.opts...)
+	if err := sender.Connect(ctx); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	old := e.sender
+	e.sender, e.down = sender, false
+	m.mu.Unlock()
+
+	if old != e.client {
+		old.Close()
+	}
+
+	return nil
+}
+
+// recover periodically reconnects the endpoints that failed.
+func (m *MultiClient) recover() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		var unconnected, down []*endpoint
+		for _, e := range m.endpoints {
+			if !e.connected {
+				unconnected = append(unconnected, e)
+			} else if e.down {
+				down = append(down, e)
+			}
+		}
+		m.mu.Unlock()
+
+		for _, e := range unconnected {
+			ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+			m.connect(ctx, e)
+			cancel()
+		}
+
+		// The connections reconnect by themselves, only the send streams need to be reopened
+		for _, e := range down {
+			if e.client.conn.GetState() != connectivity.Ready {
+				e.client.conn.Connect()
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), m.interval)
+			m.reopen(ctx, e)
+			cancel()
+		}
+	}
+}
+
+// Clients returns the clients of the connected endpoints, in the order they were given.
+func (m *MultiClient) Clients() []*Client {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var clients []*Client
+	for _, e := range m.endpoints {
+		if e.connected {
+			clients = append(clients, e.client)
+		}
+	}
+
+	return clients
+}
+
+// Healthy returns the targets of the endpoints sends can go to.
+func (m *MultiClient) Healthy() []string {
+	var targets []string
+	for _, e := range m.usable() {
+		targets = append(targets, e.target)
+	}
+
+	return targets
+}
+
+func (m *MultiClient) usable() []*endpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var usable []*endpoint
+	for _, e := range m.endpoints {
+		if !e.connected || e.down {
+			continue
+		}
+
+		switch e.client.conn.GetState() {
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			continue
+		}
+
+		usable = append(usable, e)
+	}
+
+	return usable
+}
+
+// send runs fn on the sender of the first healthy endpoint, failing over to the next ones while it
+// returns an error caused by the endpoint. Sending the same signed transaction again is safe, since
+// it has the same hash.
+func (m *MultiClient) send(ctx context.Context, fn func(*Client) error) error {
+	err := ErrNoHealthyEndpoint
+	for _, e := range m.usable() {
+		m.mu.Lock()
+		sender := e.sender
+		m.mu.Unlock()
+
+		if err = fn(sender); err == nil || ctx.Err() != nil || !endpointFailure(err) {
+			return err
+		}
+
+		m.mu.Lock()
+		if e.sender == sender {
+			e.down = true
+		}
+		m.mu.Unlock()
+		err = fmt.Errorf("sending to %s: %w", e.target, err)
+	}
+
+	return err
+}
+
+// endpointFailure returns whether err is a failure of the endpoint, rather than a rejection of the
+// request that any endpoint would return.
+func endpointFailure(err error) bool {
+	var seqErr *SequenceError
+	var vetoErr *VetoError
+	if errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrClientClosed) || errors.As(err, &seqErr) || errors.As(err, &vetoErr) {
+		return false
+	}
+
+	return !errors.Is(err, ErrInvalidTx) && !errors.Is(err, ErrUnauthenticated)
+}
+
+// SendTransaction sends the transaction to the first healthy endpoint. See Client.SendTransaction.
+func (m *MultiClient) SendTransaction(ctx context.Context, tx *types.Transaction) (hash string, timestamp int64, err error) {
+	err = m.send(ctx, func(c *Client) (err error) {
+		hash, timestamp, err = c.SendTransaction(ctx, tx)
+		return err
+	})
+	return hash, timestamp, err
+}
+
+// SendRawTransaction sends the raw transaction to the first healthy endpoint. See Client.SendRawTransaction.
+func (m *MultiClient) SendRawTransaction(ctx context.Context, rawTx []byte) (hash string, timestamp int64, err error) {
+	err = m.send(ctx, func(c *Client) (err error) {
+		hash, timestamp, err = c.SendRawTransaction(ctx, rawTx)
+		return err
+	})
+	return hash, timestamp, err
+}
+
+// SendTransactionSequence sends the sequence to the first healthy endpoint. See
+// Client.SendTransactionSequenceResult.
+func (m *MultiClient) SendTransactionSequence(ctx context.Context, txs ...*types.Transaction) (res *SequenceResult, err error) {
+	err = m.send(ctx, func(c *Client) (err error) {
+		res, err = c.SendTransactionSequenceResult(ctx, txs...)
+		return err
+	})
+	return res, err
+}
+
+// SendRawTransactionSequence sends the raw sequence to the first healthy endpoint. See
+// Client.SendRawTransactionSequenceResult.
+func (m *MultiClient) SendRawTransactionSequence(ctx context.Context, rawTxs ...[]byte) (res *SequenceResult, err error) {
+	err = m.send(ctx, func(c *Client) (err error) {
+		res, err = c.SendRawTransactionSequenceResult(ctx, rawTxs...)
+		return err
+	})
+	return res, err
+}
+
+// subscribeMulti runs src on the connected endpoints with Migrate, failing over and migrating between
+// them per the migration policy, until ctx is done. The endpoints recovered in the meantime are used
+// on the next failover or probe.
+func subscribeMulti[T any](ctx context.Context, m *MultiClient, src Source[T], ch chan<- T, opts ...SubscriptionOption) error {
+	if len(m.Clients()) == 0 {
+		return ErrNoHealthyEndpoint
+	}
+
+	return migrate(ctx, src, ch, m.policy, m.Clients, opts...)
+}
+
+// SubscribeNewTxs subscribes to the new transactions matching f on the endpoints until ctx is done.
+func (m *MultiClient) SubscribeNewTxs(ctx context.Context, f *filter.Filter, ch chan<- *Transaction, opts ...SubscriptionOption) error {
+	return subscribeMulti(ctx, m, TxStream(f), ch, opts...)
+}
+
+// SubscribeNewExecutionPayloadHeaders subscribes to the new execution payload headers on the endpoints
+// until ctx is done.
+func (m *MultiClient) SubscribeNewExecutionPayloadHeaders(ctx context.Context, ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error {
+	return subscribeMulti(ctx, m, ExecutionHeaderStream(), ch, opts...)
+}
+
+// SubscribeNewExecutionPayloads subscribes to the new execution payloads on the endpoints until ctx is done.
+func (m *MultiClient) SubscribeNewExecutionPayloads(ctx context.Context, ch chan<- *ExecutionPayload, opts ...SubscriptionOption) error {
+	return subscribeMulti(ctx, m, ExecutionPayloadStream(), ch, opts...)
+}
+
+// SubscribeNewBeaconBlocks subscribes to the new beacon blocks on the endpoints until ctx is done.
+func (m *MultiClient) SubscribeNewBeaconBlocks(ctx context.Context, ch chan<- *BeaconBlock, opts ...SubscriptionOption) error {
+	return subscribeMulti(ctx, m, BeaconBlockStream(), ch, opts...)
+}
+
+// Close stops the recovery of the endpoints and closes their clients. Closing again returns the
+// result of the first call.
+func (m *MultiClient) Close() error {
+	m.closeOnce.Do(func() {
+		close(m.stop)
+		m.wg.Wait()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		for _, e := range m.endpoints {
+			if !e.connected {
+				continue
+			}
+
+			if e.sender != e.client {
+				e.sender.Close()
+			}
+			if err := e.client.Close(); err != nil && m.closeErr == nil {
+				m.closeErr = fmt.Errorf("closing %s: %w", e.target, err)
+			}
+		}
+	})
+
+	return m.closeErr
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+)
+
+// rawTxServer acknowledges raw transactions with its id as the timestamp.
+type rawTxServer struct {
+	api.UnimplementedAPIServer
+	id int64
+}
+
+func (s *rawTxServer) SendRawTransaction(stream api.API_SendRawTransactionServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		hash := crypto.Keccak256Hash(msg.RawTx).Hex()
+		if err := stream.Send(&api.TransactionResponse{Hash: hash, Timestamp: s.id}); err != nil {
+			return err
+		}
+	}
+}
+
+func startServer(t *testing.T, id int64) (*grpc.Server, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, &rawTxServer{id: id})
+	go server.Serve(lis)
+
+	return server, lis.Addr().String()
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	first, firstAddr := startServer(t, 1)
+	second, secondAddr := startServer(t, 2)
+	defer second.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	m := NewMultiClient([]string{firstAddr, secondAddr}).HealthInterval(10 * time.Millisecond)
+	if err := m.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if _, from, err := m.SendRawTransaction(ctx, []byte{1}); err != nil || from != 1 {
+		t.Fatalf("expected the first endpoint to be used, got %d %v", from, err)
+	}
+
+	first.Stop()
+	if _, from, err := m.SendRawTransaction(ctx, []byte{2}); err != nil || from != 2 {
+		t.Fatalf("expected a failover to the second endpoint, got %d %v", from, err)
+	}
+	if healthy := m.Healthy(); len(healthy) != 1 || healthy[0] != secondAddr {
+		t.Fatalf("expected only the second endpoint to be healthy, got %v", healthy)
+	}
+
+	second.Stop()
+	if _, _, err := m.SendRawTransaction(ctx, []byte{3}); err == nil {
+		t.Fatal("expected the send to fail")
+	}
+
+	// Closing twice is fine, the deferred Close runs again
+	m.Close()
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrNonceUnknown is returned by NonceManager.Next for a sender it has no nonce for, without a
+// NonceSource.
+var ErrNonceUnknown = newCodedError(ErrCodeNonceUnknown, "nonce unknown")
+
+// NonceSource returns the next nonce of an account, counting its pending transactions.
+// *ethclient.Client implements it.
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager hands out the nonces of senders for their next transactions, so that high frequency
+// senders don't have to wait for inclusions or ask a node between sends. It tracks the nonces it
+// handed out, and the inclusions from the execution payload stream (see AddPayload and Run). It's safe
+// for concurrent use.
+type NonceManager struct {
+	source NonceSource
+
+	mu       sync.Mutex
+	accounts map[common.Address]*nonceAccount
+}
+
+type nonceAccount struct {
+	// included is the nonce after the last included transaction, next the nonce to hand out.
+	included, next uint64
+}
+
+// NewNonceManager returns a manager fetching the nonces of the senders it doesn't know yet from src,
+// which can be nil if they're all set with Set.
+func NewNonceManager(src NonceSource) *NonceManager {
+	return &NonceManager{source: src, accounts: make(map[common.Address]*nonceAccount)}
+}
+
+// Set sets the next nonce of a sender, e.g. from the application's own bookkeeping at startup.
+func (m *NonceManager) Set(sender common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.accounts[sender] = &nonceAccount{included: nonce, next: nonce}
+}
+
+// Next reserves the next nonce of a sender and returns it. The first call for a sender unknown to the
+// manager fetches its nonce from the NonceSource, or fails with ErrNonceUnknown without one.
+func (m *NonceManager) Next(ctx context.Context, sender common.Address) (uint64, error) {
+	m.mu.Lock()
+	a, ok := m.accounts[sender]
+	m.mu.Unlock()
+
+	if !ok {
+		if m.source == nil {
+			return 0, fmt.Errorf("%w for %s", ErrNonceUnknown, sender)
+		}
+
+		nonce, err := m.source.PendingNonceAt(ctx, sender)
+		if err != nil {
+			return 0, fmt.Errorf("getting the nonce of %s: %w", sender, err)
+		}
+
+		m.mu.Lock()
+		// Set, or reserved by a concurrent call, in the meantime
+		if a, ok = m.accounts[sender]; !ok {
+			a = &nonceAccount{included: nonce, next: nonce}
+			m.accounts[sender] = a
+		}
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nonce := a.next
+	a.next++
+	return nonce, nil
+}
+
+// Release gives back a nonce returned by Next whose transaction wasn't sent, so that it's handed out
+// again. Only the last nonce handed out can be given back: for an earlier one, the transactions after
+// it couldn't be included, so call Reset instead.
+func (m *NonceManager) Release(sender common.Address, nonce uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.accounts[sender]
+	if !ok || a.next != nonce+1 || nonce < a.included {
+		return false
+	}
+
+	a.next = nonce
+	return true
+}
+
+// Reset forgets a sender, whose nonce is fetched from the NonceSource again by the next call to Next,
+// e.g. after its pending transactions were dropped.
+func (m *NonceManager) Reset(sender common.Address) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.accounts, sender)
+}
+
+// Pending returns the number of nonces handed out to a sender whose transactions weren't included yet.
+func (m *NonceManager) Pending(sender common.Address) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, ok := m.accounts[sender]; ok {
+		return a.next - a.included
+	}
+
+	return 0
+}
+
+// AddPayload records the inclusions of the transactions of the known senders in a block. A
+// transaction sent without the manager, e.g. from another process, moves the nonce of its sender past
+// it.
+func (m *NonceManager) AddPayload(p *ExecutionPayload) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, tx := range p.Transactions {
+		a, ok := m.accounts[tx.From]
+		if !ok || tx.Nonce < a.included {
+			continue
+		}
+
+		a.included = tx.Nonce + 1
+		if a.next < a.included {
+			a.next = a.included
+		}
+	}
+}
+
+// Run subscribes to execution payloads on c and adds them to the manager. It blocks until ctx is done
+// or the subscription fails.
+func (m *NonceManager) Run(ctx context.Context, c *Client) error {
+	return NewPipeline(ExecutionPayloadStream()).
+		Sink(SinkFunc[*ExecutionPayload](func(ctx context.Context, p *ExecutionPayload) error {
+			m.AddPayload(p)
+			return nil
+		})).
+		Run(ctx, c)
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type nonceSourceFunc func(ctx context.Context, account common.Address) (uint64, error)
+
+func (f nonceSourceFunc) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return f(ctx, account)
+}
+
+func TestNonceManager(t *testing.T) {
+	alice, bob := common.HexToAddress("0x01"), common.HexToAddress("0x02")
+
+	fetched := 0
+	m := NewNonceManager(nonceSourceFunc(func(_ context.Context, account common.Address) (uint64, error) {
+		fetched++
+		return 5, nil
+	}))
+
+	for want := uint64(5); want < 8; want++ {
+		if nonce, err := m.Next(context.Background(), alice); err != nil || nonce != want {
+			t.Fatalf("expected nonce %d, got %d, %v", want, nonce, err)
+		}
+	}
+	if fetched != 1 || m.Pending(alice) != 3 {
+		t.Fatalf("expected 3 pending nonces from one fetch, got %d from %d", m.Pending(alice), fetched)
+	}
+
+	// Only the last nonce can be given back
+	if m.Release(alice, 6) || !m.Release(alice, 7) {
+		t.Fatal("expected only nonce 7 to be released")
+	}
+	if nonce, _ := m.Next(context.Background(), alice); nonce != 7 {
+		t.Fatalf("expected the released nonce, got %d", nonce)
+	}
+
+	// Nonce 9 of bob was sent by another process
+	m.Set(bob, 8)
+	m.AddPayload(&ExecutionPayload{Transactions: []*Transaction{
+		{From: alice, Nonce: 5},
+		{From: alice, Nonce: 6},
+		{From: bob, Nonce: 8},
+		{From: bob, Nonce: 9},
+	}})
+	if m.Pending(alice) != 1 || m.Pending(bob) != 0 {
+		t.Fatalf("unexpected pending nonces %d and %d", m.Pending(alice), m.Pending(bob))
+	}
+	if nonce, _ := m.Next(context.Background(), bob); nonce != 10 {
+		t.Fatalf("expected nonce 10, got %d", nonce)
+	}
+
+	m.Reset(alice)
+	if nonce, _ := m.Next(context.Background(), alice); nonce != 5 || fetched != 2 {
+		t.Fatalf("expected the nonce to be fetched again, got %d", nonce)
+	}
+
+	if _, err := NewNonceManager(nil).Next(context.Background(), alice); !errors.Is(err, ErrNonceUnknown) {
+		t.Fatalf("expected ErrNonceUnknown, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chainbound/fiber-go/metadata"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SendOptions configures a single send call. Attach them to the context passed to any of the
+// Send* methods with WithSendOptions.
+type SendOptions struct {
+	// VerifyEncoding round-trips the transaction through its binary (RLP) encoding and through
+	// the protobuf wire encoding before sending it, and fails the send if either of them doesn't
+	// hash to the original transaction hash. This catches signer / chain ID construction bugs
+	// at submission time, at the cost of some extra work on the hot path.
+	VerifyEncoding bool
+
+	// Compress sends the message gzip compressed. This pays off for sequences with many large
+	// transactions, but only adds latency to small sends. Compressed sends use their own stream,
+	// which is opened on the first compressed send.
+	Compress bool
+
+	// Header and Trailer, if set, receive the response header and trailer metadata of the stream
+	// the send went over. Send streams are shared, so these are per stream rather than per call,
+	// and the trailer is only available once the stream failed.
+	Header  *metadata.MD
+	Trailer *metadata.MD
+
+	// ReplacementUUID tracks the sequences sent with it under this UUID, for CancelSequence and
+	// ReplaceSequence, see OutstandingSequences. The Fiber API doesn't carry it yet, so it's only
+	// tracked locally.
+	ReplacementUUID string
+}
+
+type sendOptionsKey struct{}
+
+// WithSendOptions returns a copy of ctx carrying the given send options.
+func WithSendOptions(ctx context.Context, opts SendOptions) context.Context {
+	return context.WithValue(ctx, sendOptionsKey{}, opts)
+}
+
+// SendOptionsFromContext returns the send options ctx carries, the zero options if none, for wrappers
+// and fakes of the send methods.
+func SendOptionsFromContext(ctx context.Context) SendOptions {
+	if ctx == nil {
+		return SendOptions{}
+	}
+
+	opts, _ := ctx.Value(sendOptionsKey{}).(SendOptions)
+	return opts
+}
+
+// verifyEncoding checks that tx survives both the binary and the protobuf round-trip unchanged.
+func verifyEncoding(tx *types.Transaction) error {
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("marshaling transaction: %w", err)
+	}
+
+	decoded := new(types.Transaction)
+	if err := decoded.UnmarshalBinary(raw); err != nil {
+		return fmt.Errorf("unmarshaling transaction: %w", err)
+	}
+
+	if decoded.Hash() != tx.Hash() {
+		return fmt.Errorf("binary encoding hashes to %s, expected %s", decoded.Hash(), tx.Hash())
+	}
+
+	proto, err := TxToProto(tx)
+	if err != nil {
+		return fmt.Errorf("converting to protobuf: %w", err)
+	}
+
+	native := ProtoToTx(proto).ToNative()
+	if native == nil {
+		return fmt.Errorf("protobuf encoding lost transaction type %d", tx.Type())
+	}
+
+	if native.Hash() != tx.Hash() {
+		return fmt.Errorf("protobuf encoding hashes to %s, expected %s", native.Hash(), tx.Hash())
+	}
+
+	return nil
+}
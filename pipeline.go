@@ -0,0 +1,280 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/labels"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultDedupWindow is the number of recent messages a pipeline remembers to drop duplicates.
+const DefaultDedupWindow = 1 << 16
+
+// Source subscribes to a stream, sending messages on ch until the subscription ends.
+type Source[T any] func(c *Client, ch chan<- T, opts ...SubscriptionOption) error
+
+// TxStream is a Source of new transactions matching f.
+func TxStream(f *filter.Filter) Source[*Transaction] {
+	return func(c *Client, ch chan<- *Transaction, opts ...SubscriptionOption) error {
+		return c.SubscribeNewTxs(f, ch, opts...)
+	}
+}
+
+// ExecutionHeaderStream is a Source of new execution payload headers.
+func ExecutionHeaderStream() Source[*ExecutionPayloadHeader] {
+	return (*Client).SubscribeNewExecutionPayloadHeaders
+}
+
+// ExecutionPayloadStream is a Source of new execution payloads.
+func ExecutionPayloadStream() Source[*ExecutionPayload] {
+	return (*Client).SubscribeNewExecutionPayloads
+}
+
+// BeaconBlockStream is a Source of new beacon blocks.
+func BeaconBlockStream() Source[*BeaconBlock] {
+	return (*Client).SubscribeNewBeaconBlocks
+}
+
+// Sink is the end of a pipeline. An error from Write stops the pipeline.
+type Sink[T any] interface {
+	Write(ctx context.Context, msg T) error
+}
+
+// SinkFunc is a Sink calling a function for every message.
+type SinkFunc[T any] func(ctx context.Context, msg T) error
+
+func (f SinkFunc[T]) Write(ctx context.Context, msg T) error {
+	return f(ctx, msg)
+}
+
+// ChanSink is a Sink sending every message on ch. It never closes ch.
+func ChanSink[T any](ch chan<- T) Sink[T] {
+	return SinkFunc[T](func(ctx context.Context, msg T) error {
+		select {
+		case ch <- msg:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// LabelEnricher returns an enricher setting the address labels of transactions from registry.
+func LabelEnricher(registry *labels.Registry) func(*Transaction) {
+	return func(tx *Transaction) {
+		labelTx(registry, tx)
+	}
+}
+
+// PipelineStats is a snapshot of the counters of a pipeline.
+type PipelineStats struct {
+	Subscription SubscriptionStats
+	// Received is the number of messages that entered the pipeline.
+	Received uint64
+	// Duplicates is the number of messages dropped by Dedup.
+	Duplicates uint64
+	// Delivered is the number of messages written to the sink.
+	Delivered uint64
+}
+
+// Pipeline runs a subscription through dedup, enrichment and a sink, with a single lifecycle. Build it
+// with NewPipeline and the chained methods, then call Run.
+type Pipeline[T any] struct {
+	// Accessed atomically, first for 64-bit alignment
+	received uint64
+	dups     uint64
+	sent     uint64
+
+	source Source[T]
+	opts   []SubscriptionOption
+	dedup  *dedupSet
+	enrich []func(context.Context, T)
+	sink   Sink[T]
+	sub    Subscription
+}
+
+// NewPipeline returns a pipeline reading from src.
+func NewPipeline[T any](src Source[T]) *Pipeline[T] {
+	return &Pipeline[T]{source: src}
+}
+
+// Options sets the options of the underlying subscription.
+func (p *Pipeline[T]) Options(opts ...SubscriptionOption) *Pipeline[T] {
+	p.opts = append(p.opts, opts...)
+	return p
+}
+
+// Dedup drops messages with a hash seen in the last DefaultDedupWindow messages. Transactions and
+// execution payloads are compared by hash, beacon blocks by state root.
+func (p *Pipeline[T]) Dedup() *Pipeline[T] {
+	p.dedup = newDedupSet(DefaultDedupWindow)
+	return p
+}
+
+// Enrich adds functions that are run on every message, in order, before the sink.
+func (p *Pipeline[T]) Enrich(fns ...func(T)) *Pipeline[T] {
+	for _, fn := range fns {
+		fn := fn
+		p.enrich = append(p.enrich, func(_ context.Context, msg T) { fn(msg) })
+	}
+	return p
+}
+
+// EnrichContext is Enrich with functions that also get the context passed to Run, to read values
+// like a tenant or strategy ID.
+func (p *Pipeline[T]) EnrichContext(fns ...func(context.Context, T)) *Pipeline[T] {
+	p.enrich = append(p.enrich, fns...)
+	return p
+}
+
+// Sink sets where the messages end up.
+func (p *Pipeline[T]) Sink(sink Sink[T]) *Pipeline[T] {
+	p.sink = sink
+	return p
+}
+
+// Stats returns the counters of the pipeline.
+func (p *Pipeline[T]) Stats() PipelineStats {
+	return PipelineStats{
+		Subscription: p.sub.Stats(),
+		Received:     atomic.LoadUint64(&p.received),
+		Duplicates:   atomic.LoadUint64(&p.dups),
+		Delivered:    atomic.LoadUint64(&p.sent),
+	}
+}
+
+// Run subscribes on c and blocks until ctx is done, the subscription fails or the sink returns an error.
+// The values of ctx are visible to the enrichers, the sink and the subscription (see WithValues).
+func (p *Pipeline[T]) Run(ctx context.Context, c *Client) error {
+	if p.sink == nil {
+		return fmt.Errorf("pipeline has no sink")
+	}
+
+	ch := make(chan T)
+	errc := make(chan error, 1)
+	opts := append([]SubscriptionOption{WithValues(ctx)}, p.opts...)
+	opts = append(opts, WithHandle(&p.sub))
+	go func() {
+		errc <- p.source(c, ch, opts...)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			p.stop(ch, errc)
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return <-errc
+			}
+
+			if err := p.process(ctx, msg); err != nil {
+				p.stop(ch, errc)
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("writing to sink: %w", err)
+			}
+		}
+	}
+}
+
+func (p *Pipeline[T]) process(ctx context.Context, msg T) error {
+	atomic.AddUint64(&p.received, 1)
+
+	if p.dedup != nil {
+		if key, ok := dedupKey(msg); ok && !p.dedup.add(key) {
+			atomic.AddUint64(&p.dups, 1)
+			return nil
+		}
+	}
+
+	for _, fn := range p.enrich {
+		fn(ctx, msg)
+	}
+
+	if err := p.sink.Write(ctx, msg); err != nil {
+		return err
+	}
+
+	atomic.AddUint64(&p.sent, 1)
+	return nil
+}
+
+// stop unsubscribes and waits for the source to return. The subscription may not have started yet,
+// in which case Unsubscribe is a no-op, so it's retried until the source is done.
+func (p *Pipeline[T]) stop(ch <-chan T, errc <-chan error) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		p.sub.Unsubscribe()
+
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				ch = nil
+			}
+		case <-errc:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func dedupKey(msg any) (common.Hash, bool) {
+	switch m := msg.(type) {
+	case *Transaction:
+		return m.Hash, true
+	case *ExecutionPayloadHeader:
+		return m.Hash, true
+	case *ExecutionPayload:
+		return m.Header.Hash, true
+	case *BeaconBlock:
+		return m.StateRoot, true
+	}
+
+	return common.Hash{}, false
+}
+
+// dedupSet remembers between max and 2*max recently added hashes, in two generations.
+type dedupSet struct {
+	mu   sync.Mutex
+	max  int
+	cur  map[common.Hash]struct{}
+	prev map[common.Hash]struct{}
+}
+
+func newDedupSet(max int) *dedupSet {
+	return &dedupSet{
+		max: max,
+		cur: make(map[common.Hash]struct{}),
+	}
+}
+
+// add returns false if h was already in the set.
+func (s *dedupSet) add(h common.Hash) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.cur[h]; ok {
+		return false
+	}
+	if _, ok := s.prev[h]; ok {
+		return false
+	}
+
+	if len(s.cur) >= s.max {
+		s.prev = s.cur
+		s.cur = make(map[common.Hash]struct{}, s.max)
+	}
+
+	s.cur[h] = struct{}{}
+	return true
+}
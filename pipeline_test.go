@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"google.golang.org/grpc"
+)
+
+func headerSource(msgs ...*eth.ExecutionPayloadHeader) Source[*ExecutionPayloadHeader] {
+	return func(c *Client, ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error {
+		return subscribe(c, "test", ch, opts, fakeStream(msgs...), ProtoToHeader)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	var numbers []uint64
+	sink := SinkFunc[*ExecutionPayloadHeader](func(_ context.Context, h *ExecutionPayloadHeader) error {
+		numbers = append(numbers, h.Number)
+		if len(numbers) == 2 {
+			return errors.New("done")
+		}
+		return nil
+	})
+
+	enriched := 0
+	p := NewPipeline(headerSource(
+		&eth.ExecutionPayloadHeader{BlockNumber: 1, BlockHash: []byte{1}},
+		&eth.ExecutionPayloadHeader{BlockNumber: 1, BlockHash: []byte{1}},
+		&eth.ExecutionPayloadHeader{BlockNumber: 2, BlockHash: []byte{2}},
+	)).Dedup().Enrich(func(*ExecutionPayloadHeader) { enriched++ }).Sink(sink)
+
+	err := p.Run(context.Background(), &Client{})
+	if err == nil || err.Error() != "writing to sink: done" {
+		t.Fatalf("expected the sink error, got %v", err)
+	}
+
+	if len(numbers) != 2 || numbers[0] != 1 || numbers[1] != 2 {
+		t.Fatalf("expected blocks 1 and 2, got %v", numbers)
+	}
+
+	stats := p.Stats()
+	if stats.Received != 3 || stats.Duplicates != 1 || stats.Delivered != 1 || enriched != 2 {
+		t.Fatalf("unexpected stats %+v, enriched %d", stats, enriched)
+	}
+	if stats.Subscription.Connected {
+		t.Fatal("subscription should be stopped")
+	}
+}
+
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan *ExecutionPayloadHeader, 1)
+
+	p := NewPipeline(headerSource(&eth.ExecutionPayloadHeader{BlockNumber: 1})).Sink(ChanSink(ch))
+
+	errc := make(chan error)
+	go func() { errc <- p.Run(ctx, &Client{}) }()
+
+	<-ch
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestPipelineValues(t *testing.T) {
+	type tenantKey struct{}
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), tenantKey{}, "alice"))
+	defer cancel()
+
+	var streamTenant, subscriptionTenant, pipelineTenant any
+	open := func(ctx context.Context, opts ...grpc.CallOption) (recvStream[*eth.ExecutionPayloadHeader], error) {
+		streamTenant = ctx.Value(tenantKey{})
+		return fakeStream(&eth.ExecutionPayloadHeader{BlockNumber: 1})(ctx, opts...)
+	}
+	src := func(c *Client, ch chan<- *ExecutionPayloadHeader, opts ...SubscriptionOption) error {
+		return subscribe(c, "test", ch, opts, open, ProtoToHeader)
+	}
+
+	err := NewPipeline[*ExecutionPayloadHeader](src).
+		Options(WithEnricher(func(ctx context.Context, _ any) { subscriptionTenant = ctx.Value(tenantKey{}) })).
+		EnrichContext(func(ctx context.Context, _ *ExecutionPayloadHeader) { pipelineTenant = ctx.Value(tenantKey{}) }).
+		Sink(SinkFunc[*ExecutionPayloadHeader](func(context.Context, *ExecutionPayloadHeader) error {
+			cancel()
+			return nil
+		})).
+		Run(ctx, &Client{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the pipeline to be cancelled, got %v", err)
+	}
+
+	if streamTenant != "alice" || subscriptionTenant != "alice" || pipelineTenant != "alice" {
+		t.Fatalf("expected the tenant everywhere, got %v %v %v", streamTenant, subscriptionTenant, pipelineTenant)
+	}
+}
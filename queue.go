@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// queue is a bounded FIFO between the receive loop and the delivery to the consumer.
+// When full, the oldest message is dropped, unless the policy says otherwise.
+type queue[T any] struct {
+	mu     sync.Mutex
+	items  []T
+	head   int
+	max    int
+	notify chan struct{}
+	// policy is what push does when the queue is full. With BlockWhenFull, the producer calls wait
+	// first.
+	policy Backpressure
+	// space is signaled when a message is popped, for wait.
+	space chan struct{}
+	// spin is for how long pop busy-polls before waiting, see WithBusyPoll.
+	spin time.Duration
+	// peak is the largest number of messages queued at once.
+	peak int
+}
+
+func newQueue[T any](max int) *queue[T] {
+	return &queue[T]{
+		max:    max,
+		notify: make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}
+}
+
+// push appends v, and returns true if that caused a message to be dropped: the oldest one, or v itself
+// with DropNewest.
+func (q *queue[T]) push(v T) bool {
+	q.mu.Lock()
+	dropped := false
+	if q.len() >= q.max && q.policy == DropNewest {
+		q.mu.Unlock()
+		return true
+	}
+	if q.len() >= q.max {
+		var zero T
+		q.items[q.head] = zero
+		q.head++
+		q.compact()
+		dropped = true
+	}
+
+	q.items = append(q.items, v)
+	if n := q.len(); n > q.peak {
+		q.peak = n
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return dropped
+}
+
+// compact moves the queued messages to the start of the slice once the consumed prefix dominates
+// it, so that the slice doesn't grow while the queue is full and drops its oldest messages.
+func (q *queue[T]) compact() {
+	if q.head > len(q.items)/2 {
+		n := copy(q.items, q.items[q.head:])
+		q.items = q.items[:n]
+		q.head = 0
+	}
+}
+
+func (q *queue[T]) len() int {
+	return len(q.items) - q.head
+}
+
+// wait waits until the queue isn't full, or interrupt is closed. It's only safe with a single producer.
+func (q *queue[T]) wait(ctx context.Context, interrupt <-chan struct{}) error {
+	for {
+		q.mu.Lock()
+		full := q.len() >= q.max
+		q.mu.Unlock()
+		if !full {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-interrupt:
+			return nil
+		case <-q.space:
+		}
+	}
+}
+
+// Len returns the number of queued messages.
+func (q *queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.len()
+}
+
+// Peak returns the largest number of messages queued at once.
+func (q *queue[T]) Peak() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.peak
+}
+
+// pop removes the oldest message, waiting for one if the queue is empty.
+func (q *queue[T]) pop(ctx context.Context) (T, error) {
+	var spinUntil time.Time
+	if q.spin > 0 {
+		spinUntil = time.Now().Add(q.spin)
+	}
+
+	for {
+		q.mu.Lock()
+		if q.len() > 0 {
+			v := q.items[q.head]
+			var zero T
+			q.items[q.head] = zero
+			q.head++
+			q.compact()
+			q.mu.Unlock()
+
+			select {
+			case q.space <- struct{}{}:
+			default:
+			}
+			return v, nil
+		}
+		q.mu.Unlock()
+
+		if !spinUntil.IsZero() && ctx.Err() == nil && time.Now().Before(spinUntil) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-q.notify:
+		}
+	}
+}
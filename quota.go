@@ -0,0 +1,99 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/chainbound/fiber-go/backoff"
+)
+
+// ErrQuotaExceeded is wrapped by every QuotaError.
+var ErrQuotaExceeded = newCodedError(ErrCodeQuotaExceeded, "quota exceeded")
+
+// QuotaError is returned when a subscription or a send would exceed the quota of the client. Nothing is
+// sent to the server, which may otherwise penalize the whole API key.
+type QuotaError struct {
+	// Limit is the exceeded limit, "streams" or "sends_per_second".
+	Limit string
+	Max   float64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v: %s limit of %g", ErrQuotaExceeded, e.Limit, e.Max)
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// Quota are the limits of an API key plan, enforced on the client. Zero values are unlimited.
+type Quota struct {
+	// MaxStreams is the maximum number of concurrent subscriptions. The send streams opened by
+	// Connect don't count.
+	MaxStreams int
+	// MaxSendsPerSecond is the maximum rate of sends, with bursts of up to SendBurst sends. A sequence
+	// counts as one send.
+	MaxSendsPerSecond float64
+	SendBurst         int
+}
+
+// WithQuota makes the client fail subscriptions and sends that would exceed q with a *QuotaError.
+// Share the option between the clients using the same API key.
+func WithQuota(q Quota) ClientOption {
+	guard := &quotaGuard{quota: q}
+	if q.MaxSendsPerSecond > 0 {
+		guard.sends = backoff.NewLimiter(q.MaxSendsPerSecond, q.SendBurst, 0)
+	}
+
+	return func(c *Client) {
+		c.quota = guard
+	}
+}
+
+// quotaGuard enforces a Quota, possibly for several clients.
+type quotaGuard struct {
+	quota Quota
+	sends *backoff.Limiter
+
+	mu      sync.Mutex
+	streams int
+}
+
+func (g *quotaGuard) acquireStream() error {
+	if g == nil || g.quota.MaxStreams <= 0 {
+		return nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.streams >= g.quota.MaxStreams {
+		return &QuotaError{Limit: "streams", Max: float64(g.quota.MaxStreams)}
+	}
+
+	g.streams++
+	return nil
+}
+
+func (g *quotaGuard) releaseStream() {
+	if g == nil || g.quota.MaxStreams <= 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.streams--
+}
+
+func (g *quotaGuard) allowSend() error {
+	if g == nil || g.sends == nil {
+		return nil
+	}
+
+	if !g.sends.Allow() {
+		return &QuotaError{Limit: "sends_per_second", Max: g.quota.MaxSendsPerSecond}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrMalformedTx is returned by SendRawTransactionHex for transactions that don't decode as signed
+// transactions, before anything is sent.
+var ErrMalformedTx = newCodedError(ErrCodeMalformedTx, "malformed transaction")
+
+// SendRawTransactionHex sends a hex encoded, signed transaction, like "0x02f8...", as most tooling
+// hands them around. The transaction is decoded and its signature checked first, so that a truncated
+// or unsigned transaction fails with ErrMalformedTx saying why, instead of being rejected by the
+// server. See SendRawTransaction.
+func (c *Client) SendRawTransactionHex(ctx context.Context, rawTx string) (string, int64, error) {
+	raw, err := DecodeRawTxHex(rawTx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return c.SendRawTransaction(ctx, raw)
+}
+
+// DecodeRawTxHex decodes s, with or without the 0x prefix, and checks it's a signed transaction, like
+// SendRawTransactionHex does before sending. It fails with ErrMalformedTx.
+func DecodeRawTxHex(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		s = "0x" + s
+	}
+
+	raw, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decoding hex: %v", ErrMalformedTx, err)
+	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrMalformedTx)
+	}
+
+	// go-ethereum can't decode blob transactions yet
+	if raw[0] == BlobTxType {
+		tx, _, err := DecodeBlobTx(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedTx, err)
+		}
+		if _, err := tx.Sender(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedTx, err)
+		}
+
+		return raw, nil
+	}
+
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("%w: decoding transaction: %v", ErrMalformedTx, err)
+	}
+	if _, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), &tx); err != nil {
+		return nil, fmt.Errorf("%w: recovering sender: %v", ErrMalformedTx, err)
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDecodeRawTxHex(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inner := &types.DynamicFeeTx{ChainID: common.Big1, Nonce: 1, Gas: 21000, GasTipCap: common.Big1, GasFeeCap: common.Big2}
+	signed, err := types.SignTx(types.NewTx(inner), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := signed.MarshalBinary()
+	unsigned, _ := types.NewTx(inner).MarshalBinary()
+
+	for _, s := range []string{hexutil.Encode(raw), strings.TrimPrefix(hexutil.Encode(raw), "0x"), " " + hexutil.Encode(raw) + "\n"} {
+		decoded, err := DecodeRawTxHex(s)
+		if err != nil || !bytes.Equal(decoded, raw) {
+			t.Fatalf("%q: expected the transaction, got %x, %v", s, decoded, err)
+		}
+	}
+
+	for _, s := range []string{"", "0x", "0xzz", "0x02f8", hexutil.Encode(raw[:len(raw)-1]), hexutil.Encode(unsigned)} {
+		if _, err := DecodeRawTxHex(s); !errors.Is(err, ErrMalformedTx) || Code(err) != ErrCodeMalformedTx {
+			t.Fatalf("%q: expected ErrMalformedTx, got %v", s, err)
+		}
+	}
+}
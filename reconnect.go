@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chainbound/fiber-go/backoff"
+
+	"google.golang.org/grpc"
+)
+
+// ErrForcedReconnect is recorded as the end of a stream session that was re-established by ForceReconnect.
+var ErrForcedReconnect = newCodedError(ErrCodeForcedReconnect, "forced reconnect")
+
+// ForceReconnect tears down the server stream of the running subscription and establishes a new one,
+// for when an external signal says the stream is degraded before the client notices. The subscription
+// keeps delivering to the same channel. It's a no-op if no subscription is running on the handle.
+func (s *Subscription) ForceReconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancelStream == nil {
+		return
+	}
+
+	s.forceReconnect = true
+	s.cancelStream()
+}
+
+// ForceReconnectAll calls ForceReconnect on all the running subscriptions of the client.
+func (c *Client) ForceReconnectAll() {
+	c.subsMu.Lock()
+	subs := make([]*Subscription, 0, len(c.subs))
+	for sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	c.subsMu.Unlock()
+
+	for _, sub := range subs {
+		sub.ForceReconnect()
+	}
+}
+
+// reconnectRequested returns whether a reconnect was forced since the last call.
+func (s *Subscription) reconnectRequested() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requested := s.forceReconnect
+	s.forceReconnect = false
+	return requested
+}
+
+// streamOpened records the cancel function of the current server stream.
+func (s *Subscription) streamOpened(cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cancelStream = cancel
+}
+
+// reconnected ends the current session on the handle and starts a new one.
+func (s *Subscription) reconnected(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.stats.disconnected(now, err)
+	s.stats.connected(now)
+}
+
+// openServer opens a server stream with its own context, so it can be torn down without ending the
+// subscription, and returns its receive function, which captures the response metadata.
+func openServer[P any](ctx context.Context, c *Client, sub *Subscription, stream StreamKind, open openFunc[P], callOpts []grpc.CallOption) (func() (P, error), error) {
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	server, err := open(streamCtx, callOpts...)
+	if err != nil {
+		cancelStream()
+		return nil, streamError(err)
+	}
+	sub.streamOpened(cancelStream)
+
+	cs, ok := server.(grpc.ClientStream)
+	if !ok {
+		return func() (P, error) {
+			msg, err := server.Recv()
+			return msg, streamError(err)
+		}, nil
+	}
+
+	captureTrailer := sub.captureMetadata(c, string(stream), cs)
+	return func() (P, error) {
+		msg, err := server.Recv()
+		if err != nil {
+			// The stream ended, so its trailer is available
+			captureTrailer()
+		}
+		return msg, streamError(err)
+	}, nil
+}
+
+// WithAutoResubscribe re-opens the server stream when it fails with a transient error (see
+// ErrStreamClosed), like an unavailable server or a reset connection, and keeps delivering to the same
+// channel. The attempts are spaced by policy, backoff.Default() if nil, and the subscription fails
+// with the last error if the policy gives up. onGap, if not nil, is called with the time between the
+// failure and the new stream, and the error that ended the previous one.
+func WithAutoResubscribe(policy backoff.Policy, onGap func(gap time.Duration, err error)) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		if policy == nil {
+			policy = backoff.Default()
+		}
+
+		cfg.resubscribe = policy
+		cfg.onGap = onGap
+	}
+}
+
+// reconnecting returns a receive function that re-opens the server stream with reopen when it was
+// torn down by ForceReconnect, or failed with a transient error with WithAutoResubscribe, instead of
+// failing.
+func reconnecting[P any](ctx context.Context, c *Client, cfg *subscriptionConfig, sub *Subscription, stream StreamKind, recv func() (P, error), reopen func() (func() (P, error), error)) func() (P, error) {
+	return func() (P, error) {
+		for {
+			msg, err := recv()
+			if err == nil || ctx.Err() != nil {
+				return msg, err
+			}
+
+			if sub.reconnectRequested() {
+				if err := c.reconnectLimiter.Wait(ctx); err != nil {
+					return msg, err
+				}
+
+				next, err := reopen()
+				if err != nil {
+					return msg, err
+				}
+
+				sub.reconnected(ErrForcedReconnect)
+				c.metrics.reconnected(stream)
+				recv = next
+				continue
+			}
+
+			if cfg.resubscribe == nil || !errors.Is(err, ErrStreamClosed) {
+				return msg, err
+			}
+
+			failed := time.Now()
+			next, retryErr := resubscribe(ctx, c, cfg.resubscribe, reopen)
+			if retryErr != nil {
+				return msg, retryErr
+			}
+
+			sub.reconnected(err)
+			c.metrics.reconnected(stream)
+			if cfg.onGap != nil {
+				cfg.onGap(time.Since(failed), err)
+			}
+			recv = next
+		}
+	}
+}
+
+// resubscribe re-opens the server stream with reopen, retrying per policy while it fails with a
+// transient error.
+func resubscribe[P any](ctx context.Context, c *Client, policy backoff.Policy, reopen func() (func() (P, error), error)) (func() (P, error), error) {
+	var recv func() (P, error)
+	err := backoff.Retry(ctx, policy, func(ctx context.Context) error {
+		if err := c.reconnectLimiter.Wait(ctx); err != nil {
+			return backoff.Permanent(err)
+		}
+
+		next, err := reopen()
+		if err != nil {
+			if !errors.Is(err, ErrStreamClosed) {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+
+		recv = next
+		return nil
+	})
+
+	return recv, err
+}
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"container/heap"
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// DefaultReconstructorPool is the number of pending transactions a Reconstructor keeps by default.
+const DefaultReconstructorPool = 1 << 15
+
+// ProvisionalBlock is the probable content of a block, assembled from its header and the pending
+// transactions seen before it, ahead of the execution payload.
+type ProvisionalBlock struct {
+	Header *ExecutionPayloadHeader
+	// Transactions are the pending transactions most likely to be in the block, in the order a
+	// builder maximizing tips would include them. Transactions that were never streamed are missing.
+	Transactions []*Transaction
+	// Verified is true if the transactions root of the header matches Transactions, in which case
+	// they are exactly the transactions of the block.
+	Verified bool
+	// ReceivedAt is when the header was received.
+	ReceivedAt time.Time
+}
+
+// Reconstructor assembles provisional blocks from the header and transaction streams. Pending
+// transactions are added with AddTransaction, and removed once they're in an execution payload.
+type Reconstructor struct {
+	mu      sync.Mutex
+	max     int
+	pending map[common.Hash]*Transaction
+	// insertion order, for evicting the oldest transactions when the pool is full
+	order []common.Hash
+}
+
+// NewReconstructor returns a reconstructor keeping up to max pending transactions, 0 for
+// DefaultReconstructorPool.
+func NewReconstructor(max int) *Reconstructor {
+	if max <= 0 {
+		max = DefaultReconstructorPool
+	}
+
+	return &Reconstructor{
+		max:     max,
+		pending: make(map[common.Hash]*Transaction),
+	}
+}
+
+// AddTransaction adds a pending transaction.
+func (r *Reconstructor) AddTransaction(tx *Transaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[tx.Hash]; ok {
+		return
+	}
+
+	for len(r.pending) >= r.max && len(r.order) > 0 {
+		delete(r.pending, r.order[0])
+		r.order = r.order[1:]
+	}
+
+	r.pending[tx.Hash] = tx
+	r.order = append(r.order, tx.Hash)
+}
+
+// Pending returns the number of pending transactions.
+func (r *Reconstructor) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pending)
+}
+
+// AddPayload removes the transactions of an execution payload from the pending ones.
+func (r *Reconstructor) AddPayload(p *ExecutionPayload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, tx := range p.Transactions {
+		delete(r.pending, tx.Hash)
+	}
+
+	// Drop the included hashes from the insertion order too
+	order := r.order[:0]
+	for _, hash := range r.order {
+		if _, ok := r.pending[hash]; ok {
+			order = append(order, hash)
+		}
+	}
+	r.order = order
+}
+
+// Provisional assembles the probable block for header: the pending transactions that pay at least the
+// base fee, by descending tip and in nonce order per sender, until their gas limits cover the gas used
+// by the block.
+func (r *Reconstructor) Provisional(header *ExecutionPayloadHeader) *ProvisionalBlock {
+	block := &ProvisionalBlock{Header: header, ReceivedAt: time.Now()}
+	baseFee := header.BaseFeePerGas
+	if baseFee == nil {
+		baseFee = new(big.Int)
+	}
+
+	// Pending transactions per sender, in nonce order
+	r.mu.Lock()
+	bySender := make(map[common.Address][]*Transaction)
+	for _, tx := range r.pending {
+		if effectiveTip(tx, baseFee) != nil {
+			bySender[tx.From] = append(bySender[tx.From], tx)
+		}
+	}
+	r.mu.Unlock()
+
+	var heads tipHeap
+	for _, txs := range bySender {
+		sort.Slice(txs, func(i, j int) bool { return txs[i].Nonce < txs[j].Nonce })
+		heads = append(heads, &tipEntry{txs: txs, tip: effectiveTip(txs[0], baseFee)})
+	}
+	heap.Init(&heads)
+
+	var gas uint64
+	for heads.Len() > 0 && gas < header.GasUsed {
+		head := heads[0]
+		tx := head.txs[0]
+		block.Transactions = append(block.Transactions, tx)
+		gas += tx.Gas
+
+		// Next transaction of the same sender, if its nonce follows
+		if len(head.txs) > 1 && head.txs[1].Nonce == tx.Nonce+1 {
+			head.txs = head.txs[1:]
+			head.tip = effectiveTip(head.txs[0], baseFee)
+			heap.Fix(&heads, 0)
+		} else {
+			heap.Pop(&heads)
+		}
+	}
+
+	root, ok := transactionsRoot(block.Transactions)
+	block.Verified = ok && root == header.TransactionsRoot
+	return block
+}
+
+// Run subscribes to transactions, headers and execution payloads on c. For every header it sends a
+// provisional block on provisional, and every payload is then sent on payloads, possibly nil to only
+// get provisional blocks. It blocks until ctx is done or a subscription fails, and never closes the channels.
+func (r *Reconstructor) Run(ctx context.Context, c *Client, provisional chan<- *ProvisionalBlock, payloads chan<- *ExecutionPayload) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errc := make(chan error, 3)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := fn()
+			if ctx.Err() == nil {
+				errc <- err
+			}
+			cancel()
+		}()
+	}
+
+	run(func() error {
+		return NewPipeline(TxStream(nil)).
+			Sink(SinkFunc[*Transaction](func(ctx context.Context, tx *Transaction) error {
+				r.AddTransaction(tx)
+				return nil
+			})).
+			Run(ctx, c)
+	})
+	run(func() error {
+		return NewPipeline(ExecutionHeaderStream()).
+			Sink(SinkFunc[*ExecutionPayloadHeader](func(ctx context.Context, header *ExecutionPayloadHeader) error {
+				return ChanSink(provisional).Write(ctx, r.Provisional(header))
+			})).
+			Run(ctx, c)
+	})
+	run(func() error {
+		return NewPipeline(ExecutionPayloadStream()).
+			Sink(SinkFunc[*ExecutionPayload](func(ctx context.Context, p *ExecutionPayload) error {
+				r.AddPayload(p)
+				if payloads == nil {
+					return nil
+				}
+				return ChanSink(payloads).Write(ctx, p)
+			})).
+			Run(ctx, c)
+	})
+
+	wg.Wait()
+	select {
+	case err := <-errc:
+		return err
+	default:
+		return ctx.Err()
+	}
+}
+
+// effectiveTip returns the tip per gas the transaction pays over baseFee, nil if it can't pay the base fee.
+func effectiveTip(tx *Transaction, baseFee *big.Int) *big.Int {
+	if tx.Type == types.LegacyTxType || tx.Type == types.AccessListTxType {
+		if tx.GasPrice == nil || tx.GasPrice.Cmp(baseFee) < 0 {
+			return nil
+		}
+		return new(big.Int).Sub(tx.GasPrice, baseFee)
+	}
+
+	if tx.MaxFee == nil || tx.PriorityFee == nil || tx.MaxFee.Cmp(baseFee) < 0 {
+		return nil
+	}
+
+	tip := new(big.Int).Sub(tx.MaxFee, baseFee)
+	if tip.Cmp(tx.PriorityFee) > 0 {
+		tip.Set(tx.PriorityFee)
+	}
+	return tip
+}
+
+// transactionsRoot returns the transactions root of a block with txs, false if one of them can't be
+// encoded.
+func transactionsRoot(txs []*Transaction) (common.Hash, bool) {
+	native := make(types.Transactions, len(txs))
+	for i, tx := range txs {
+		if native[i] = tx.ToNative(); native[i] == nil {
+			return common.Hash{}, false
+		}
+	}
+
+	return types.DeriveSha(native, trie.NewStackTrie(nil)), true
+}
+
+// tipEntry holds the pending transactions of a sender, the first one being next.
+type tipEntry struct {
+	txs []*Transaction
+	tip *big.Int
+}
+
+// tipHeap orders senders by the tip of their next transaction, highest first.
+type tipHeap []*tipEntry
+
+func (h tipHeap) Len() int           { return len(h) }
+func (h tipHeap) Less(i, j int) bool { return h[i].tip.Cmp(h[j].tip) > 0 }
+func (h tipHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *tipHeap) Push(x any)        { *h = append(*h, x.(*tipEntry)) }
+func (h *tipHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
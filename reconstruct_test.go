@@ -0,0 +1,78 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+func signedTx(t *testing.T, nonce uint64, tip int64) *Transaction {
+	t.Helper()
+
+	key, _ := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	to := common.HexToAddress("0x01")
+	tx, err := types.SignNewTx(key, types.NewLondonSigner(common.Big1), &types.DynamicFeeTx{
+		ChainID:   common.Big1,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(tip),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000,
+		To:        &to,
+		Value:     common.Big0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proto, err := TxToProto(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ProtoToTx(proto)
+}
+
+func TestReconstructor(t *testing.T) {
+	r := NewReconstructor(0)
+
+	// Same sender: nonce order beats the higher tip of the second transaction
+	first, second := signedTx(t, 0, 1), signedTx(t, 1, 5)
+	r.AddTransaction(second)
+	r.AddTransaction(first)
+
+	other := &Transaction{Hash: common.HexToHash("0xff"), From: common.HexToAddress("0x02"), Gas: 21000, MaxFee: big.NewInt(10), PriorityFee: big.NewInt(10), Type: 2}
+	r.AddTransaction(other)
+
+	root := types.DeriveSha(types.Transactions{first.ToNative(), second.ToNative()}, trie.NewStackTrie(nil))
+	header := &ExecutionPayloadHeader{BaseFeePerGas: big.NewInt(50), GasUsed: 42000, TransactionsRoot: root}
+
+	block := r.Provisional(header)
+	if len(block.Transactions) != 2 || block.Transactions[0] != first || block.Transactions[1] != second {
+		t.Fatalf("unexpected provisional transactions %v", block.Transactions)
+	}
+	if !block.Verified {
+		t.Fatal("provisional block should match the transactions root")
+	}
+
+	r.AddPayload(&ExecutionPayload{Header: header, Transactions: []*Transaction{first, second}})
+	if r.Pending() != 1 {
+		t.Fatalf("expected only the underpriced transaction to be pending, got %d", r.Pending())
+	}
+}
+
+func TestReconstructorEviction(t *testing.T) {
+	r := NewReconstructor(2)
+	for i := 1; i <= 3; i++ {
+		r.AddTransaction(&Transaction{Hash: common.BigToHash(big.NewInt(int64(i)))})
+	}
+
+	if r.Pending() != 2 {
+		t.Fatalf("expected 2 pending transactions, got %d", r.Pending())
+	}
+	if _, ok := r.pending[common.BigToHash(big.NewInt(1))]; ok {
+		t.Fatal("the oldest transaction should have been evicted")
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecordedMessage is a message kept by the flight recorder of a subscription, see WithFlightRecorder.
+type RecordedMessage struct {
+	Received time.Time `json:"received"`
+	Message  any       `json:"message"`
+}
+
+// RecorderDump is the content of a file written by the flight recorder of a subscription.
+type RecorderDump struct {
+	Stream   StreamKind `json:"stream"`
+	Reason   string     `json:"reason"`
+	DumpedAt time.Time  `json:"dumped_at"`
+	// Messages are the last messages received, oldest first.
+	Messages []RecordedMessage `json:"messages"`
+}
+
+// flightRecorder is a ring of the last messages received by a subscription. It's guarded by the mutex
+// of its handle.
+type flightRecorder struct {
+	dir    string
+	onDump func(path string, err error)
+
+	ring []RecordedMessage
+	next int
+	full bool
+}
+
+// WithFlightRecorder keeps the last n messages received by the subscription in memory, and writes them
+// with when they were received to a file in dir when they may explain a failure: when the consumer
+// reports an error with Subscription.ReportError, or when the subscription fails to decode a message.
+// If onDump isn't nil, it's called with the path of every file written, or with why it couldn't be.
+// The files hold a RecorderDump as JSON.
+func WithFlightRecorder(n int, dir string, onDump func(path string, err error)) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.recorder = n
+		cfg.recorderDir = dir
+		cfg.onDump = onDump
+	}
+}
+
+func (r *flightRecorder) record(msg any) {
+	r.ring[r.next] = RecordedMessage{Received: time.Now(), Message: msg}
+	if r.next++; r.next == len(r.ring) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// messages returns the recorded messages, oldest first.
+func (r *flightRecorder) messages() []RecordedMessage {
+	if !r.full {
+		return append([]RecordedMessage(nil), r.ring[:r.next]...)
+	}
+
+	return append(append([]RecordedMessage(nil), r.ring[r.next:]...), r.ring[:r.next]...)
+}
+
+// write writes dump to a new file in the directory of the recorder, and returns its path.
+func (r *flightRecorder) write(dump *RecorderDump) (string, error) {
+	f, err := os.CreateTemp(r.dir, fmt.Sprintf("%s-%s-*.json", dump.Stream, dump.DumpedAt.UTC().Format("20060102T150405Z")))
+	if err != nil {
+		return "", fmt.Errorf("creating flight recorder dump: %w", err)
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(dump)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("writing flight recorder dump: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+// record adds msg to the flight recorder of the handle, if it has one.
+func (s *Subscription) record(msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordLocked(msg)
+}
+
+func (s *Subscription) recordLocked(msg any) {
+	if s.recorder != nil {
+		s.recorder.record(msg)
+	}
+}
+
+// ReportError reports an error of the consumer with the messages of the subscription, which dumps its
+// flight recorder (see WithFlightRecorder). It returns the path of the file written, or "" if the
+// subscription has no flight recorder.
+func (s *Subscription) ReportError(err error) (string, error) {
+	return s.dump(err)
+}
+
+func (s *Subscription) dump(reason error) (string, error) {
+	s.mu.Lock()
+	r := s.recorder
+	if r == nil {
+		s.mu.Unlock()
+		return "", nil
+	}
+
+	dump := &RecorderDump{Stream: s.stream, DumpedAt: time.Now(), Messages: r.messages()}
+	s.mu.Unlock()
+
+	if reason != nil {
+		dump.Reason = reason.Error()
+	}
+
+	path, err := r.write(dump)
+	if r.onDump != nil {
+		r.onDump(path, err)
+	}
+
+	return path, err
+}
+
+// decodeFailure returns whether a subscription failed with err because it couldn't decode a message.
+func decodeFailure(err error) bool {
+	var unknown *UnknownTxTypeError
+	if errors.As(err, &unknown) {
+		return true
+	}
+
+	// What gRPC returns when the codec fails
+	s, ok := status.FromError(err)
+	return ok && s.Code() == codes.Internal && strings.Contains(s.Message(), "unmarshal")
+}
@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func readDump(t *testing.T, path string) (dump struct {
+	Stream   string `json:"stream"`
+	Reason   string `json:"reason"`
+	Messages []struct {
+		Message int `json:"message"`
+	} `json:"messages"`
+}) {
+	t.Helper()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, &dump); err != nil {
+		t.Fatal(err)
+	}
+
+	return dump
+}
+
+func TestFlightRecorder(t *testing.T) {
+	dir := t.TempDir()
+
+	var sub Subscription
+	ch := make(chan int, 5)
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithFlightRecorder(3, dir, nil)}, fakeStream(1, 2, 3, 4, 5), identity[int])
+	for i := 0; i < 5; i++ {
+		<-ch
+	}
+	defer sub.Unsubscribe()
+
+	path, err := sub.ReportError(errors.New("bad quote"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dump := readDump(t, path)
+	if dump.Stream != "test" || dump.Reason != "bad quote" || len(dump.Messages) != 3 {
+		t.Fatalf("unexpected dump %+v", dump)
+	}
+	for i, msg := range dump.Messages {
+		if msg.Message != i+3 {
+			t.Fatalf("expected the last 3 messages oldest first, got %+v", dump.Messages)
+		}
+	}
+
+	var unrecorded Subscription
+	if path, err := unrecorded.ReportError(errors.New("bad quote")); path != "" || err != nil {
+		t.Fatalf("expected no dump without a flight recorder, got %q %v", path, err)
+	}
+}
+
+func TestFlightRecorderDecodeFailure(t *testing.T) {
+	open := func(ctx context.Context, _ ...grpc.CallOption) (recvStream[int], error) {
+		i := 0
+		return recvFunc[int](func() (int, error) {
+			if i++; i <= 2 {
+				return i, nil
+			}
+			return 0, status.Error(codes.Internal, "grpc: failed to unmarshal the received message: proto: cannot parse invalid wire-format data")
+		}), nil
+	}
+
+	dumped := make(chan string, 1)
+	ch := make(chan int, 2)
+	err := subscribe(&Client{}, "test", ch, []SubscriptionOption{WithFlightRecorder(4, t.TempDir(), func(path string, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		dumped <- path
+	})}, open, identity[int])
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("expected the decode failure, got %v", err)
+	}
+
+	select {
+	case path := <-dumped:
+		if dump := readDump(t, path); len(dump.Messages) != 2 || dump.Reason != err.Error() {
+			t.Fatalf("unexpected dump %+v", dump)
+		}
+	default:
+		t.Fatal("expected the flight recorder to be dumped")
+	}
+}
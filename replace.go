@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrReplacementUnsupported is returned by CancelSequence and ReplaceSequence while the Fiber API has no
+// replacement UUIDs on sequences.
+var ErrReplacementUnsupported = newCodedError(ErrCodeReplacementUnsupported, "sequence replacement is not supported by the Fiber API yet")
+
+// replacements are the sequences sent with SendOptions.ReplacementUUID, by UUID.
+type replacements struct {
+	mu   sync.Mutex
+	seqs map[string][]common.Hash
+}
+
+// track records the hashes of the sequence sent with uuid, replacing the previous one with the same
+// UUID. It does nothing without a UUID.
+func (r *replacements) track(uuid string, hashes []common.Hash) {
+	if uuid == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.seqs == nil {
+		r.seqs = make(map[string][]common.Hash)
+	}
+	r.seqs[uuid] = append([]common.Hash(nil), hashes...)
+}
+
+func (r *replacements) has(uuid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.seqs[uuid]
+	return ok
+}
+
+// OutstandingSequences returns the hashes of the sequences sent with a SendOptions.ReplacementUUID, by
+// UUID, until they're forgotten with ForgetSequence. They're tracked locally: the Fiber API doesn't
+// carry the UUIDs yet.
+func (c *Client) OutstandingSequences() map[string][]common.Hash {
+	c.replacements.mu.Lock()
+	defer c.replacements.mu.Unlock()
+
+	seqs := make(map[string][]common.Hash, len(c.replacements.seqs))
+	for uuid, hashes := range c.replacements.seqs {
+		seqs[uuid] = append([]common.Hash(nil), hashes...)
+	}
+
+	return seqs
+}
+
+// ForgetSequence stops tracking the sequence sent with the replacement UUID uuid, once it was included
+// or expired.
+func (c *Client) ForgetSequence(uuid string) {
+	c.replacements.mu.Lock()
+	defer c.replacements.mu.Unlock()
+
+	delete(c.replacements.seqs, uuid)
+}
+
+// CancelSequence cancels the outstanding sequence sent with the replacement UUID uuid, see
+// OutstandingSequences. The sequence messages of the Fiber API don't carry a replacement UUID yet, so
+// for now this returns ErrReplacementUnsupported for the outstanding sequences, which stay outstanding.
+func (c *Client) CancelSequence(ctx context.Context, uuid string) error {
+	if err := c.checkReplacement(uuid); err != nil {
+		return fmt.Errorf("cancelling sequence: %w", err)
+	}
+
+	return ErrReplacementUnsupported
+}
+
+// ReplaceSequence replaces the outstanding sequence sent with the replacement UUID uuid by transactions,
+// like CancelSequence followed by SendTransactionSequenceResult with the same UUID. The transactions are
+// always checked locally first, and like CancelSequence, valid replacements return
+// ErrReplacementUnsupported for now.
+func (c *Client) ReplaceSequence(ctx context.Context, uuid string, transactions ...*types.Transaction) (*SequenceResult, error) {
+	if err := c.checkReplacement(uuid); err != nil {
+		return nil, fmt.Errorf("replacing sequence: %w", err)
+	}
+
+	for i, tx := range transactions {
+		if _, err := TxToProto(tx); err != nil {
+			return nil, fmt.Errorf("converting transaction %d: %w", i, err)
+		}
+	}
+
+	return nil, ErrReplacementUnsupported
+}
+
+// checkReplacement checks that uuid is the UUID of an outstanding sequence.
+func (c *Client) checkReplacement(uuid string) error {
+	if uuid == "" {
+		return errors.New("empty replacement uuid")
+	}
+	if !c.replacements.has(uuid) {
+		return fmt.Errorf("no outstanding sequence with replacement uuid %s", uuid)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+)
+
+// rawSequenceServer acknowledges raw transaction sequences.
+type rawSequenceServer struct {
+	api.UnimplementedAPIServer
+}
+
+func (s *rawSequenceServer) SendRawTransactionSequence(stream api.API_SendRawTransactionSequenceServer) error {
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		hashes := make([]common.Hash, len(msg.RawTxs))
+		for i, raw := range msg.RawTxs {
+			hashes[i] = crypto.Keccak256Hash(raw)
+		}
+		if err := stream.Send(sequenceResponse(hashes...)); err != nil {
+			return err
+		}
+	}
+}
+
+func TestReplaceSequence(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	api.RegisterAPIServer(server, &rawSequenceServer{})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(lis.Addr().String())
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	const uuid = "d3b07384-d9a0-4c9b-8a1d-8f4a8c3e7e21"
+	tx := signTx(t, &types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000})
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the sequences sent with a replacement UUID are tracked
+	if _, err := c.SendRawTransactionSequenceResult(ctx, raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CancelSequence(ctx, uuid); err == nil || errors.Is(err, ErrReplacementUnsupported) {
+		t.Fatalf("expected an unknown uuid to be rejected, got %v", err)
+	}
+
+	if _, err := c.SendRawTransactionSequenceResult(WithSendOptions(ctx, SendOptions{ReplacementUUID: uuid}), raw); err != nil {
+		t.Fatal(err)
+	}
+	if seqs := c.OutstandingSequences(); len(seqs) != 1 || len(seqs[uuid]) != 1 || seqs[uuid][0] != tx.Hash() {
+		t.Fatalf("unexpected outstanding sequences %v", seqs)
+	}
+
+	if err := c.CancelSequence(ctx, uuid); !errors.Is(err, ErrReplacementUnsupported) || Code(err) != ErrCodeReplacementUnsupported {
+		t.Fatalf("expected ErrReplacementUnsupported, got %v", err)
+	}
+	if _, err := c.ReplaceSequence(ctx, uuid, tx); !errors.Is(err, ErrReplacementUnsupported) {
+		t.Fatalf("expected ErrReplacementUnsupported, got %v", err)
+	}
+
+	// Invalid calls fail locally first
+	if err := c.CancelSequence(ctx, ""); err == nil || errors.Is(err, ErrReplacementUnsupported) {
+		t.Fatalf("expected an empty uuid to be rejected, got %v", err)
+	}
+	unsigned := types.NewTx(&types.LegacyTx{Gas: 21000})
+	if _, err := c.ReplaceSequence(ctx, uuid, unsigned); err == nil || errors.Is(err, ErrReplacementUnsupported) {
+		t.Fatalf("expected an unsigned transaction to be rejected, got %v", err)
+	}
+
+	c.ForgetSequence(uuid)
+	if seqs := c.OutstandingSequences(); len(seqs) != 0 {
+		t.Fatalf("expected the sequence to be forgotten, got %v", seqs)
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// SLAReport summarizes the health of one or more subscriptions over a time window in a
+// machine-readable form.
+type SLAReport struct {
+	From          time.Time            `json:"from"`
+	To            time.Time            `json:"to"`
+	Subscriptions []SubscriptionReport `json:"subscriptions"`
+}
+
+// SubscriptionReport summarizes a single subscription handle over a time window.
+type SubscriptionReport struct {
+	Stream StreamKind `json:"stream"`
+	// UptimePercent is the percentage of the window (counted from the first time the handle
+	// connected) that the stream was connected.
+	UptimePercent     float64 `json:"uptime_percent"`
+	GapCount          int     `json:"gap_count"`
+	TotalGapSeconds   float64 `json:"total_gap_seconds"`
+	LongestGapSeconds float64 `json:"longest_gap_seconds"`
+	Gaps              []Gap   `json:"gaps,omitempty"`
+	Messages          uint64  `json:"messages"`
+	// EstimatedLost is the number of messages missing from the stream, estimated from gaps
+	// in block numbers. Only available for execution payloads and headers.
+	EstimatedLost uint64 `json:"estimated_lost"`
+	// Latencies are measured from the block timestamp until the message was received. They
+	// are only available for execution payloads and headers.
+	LatencyP50Ms float64 `json:"latency_p50_ms,omitempty"`
+	LatencyP99Ms float64 `json:"latency_p99_ms,omitempty"`
+}
+
+// Gap is a period in which the subscription was disconnected.
+type Gap struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Cause           string    `json:"cause,omitempty"`
+}
+
+// NewSLAReport builds a report over the last window for the given subscription handles.
+func NewSLAReport(window time.Duration, subs ...*Subscription) *SLAReport {
+	to := time.Now()
+	from := to.Add(-window)
+
+	report := &SLAReport{
+		From:          from,
+		To:            to,
+		Subscriptions: make([]SubscriptionReport, len(subs)),
+	}
+
+	for i, sub := range subs {
+		report.Subscriptions[i] = sub.Report(from, to)
+	}
+
+	return report
+}
+
+// WriteJSON writes the report as indented JSON to w.
+func (r *SLAReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Report summarizes the subscription between from and to.
+func (s *Subscription) Report(from, to time.Time) SubscriptionReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := SubscriptionReport{Stream: s.stream}
+	if s.stats == nil || len(s.stats.sessions) == 0 {
+		return report
+	}
+
+	stats := s.stats
+	if first := stats.sessions[0].start; first.After(from) {
+		from = first
+	}
+
+	if !to.After(from) {
+		return report
+	}
+
+	var up time.Duration
+	for i, sess := range stats.sessions {
+		end := sess.end
+		if end.IsZero() {
+			end = to
+		}
+		up += overlap(sess.start, end, from, to)
+
+		if sess.end.IsZero() {
+			continue
+		}
+
+		// The gap lasts until the next session started, or until now if there's none.
+		gapEnd := to
+		if i+1 < len(stats.sessions) {
+			gapEnd = stats.sessions[i+1].start
+		}
+
+		d := overlap(sess.end, gapEnd, from, to)
+		if d <= 0 {
+			continue
+		}
+
+		gap := Gap{Start: sess.end, End: gapEnd, DurationSeconds: d.Seconds()}
+		if sess.err != nil {
+			gap.Cause = sess.err.Error()
+		}
+
+		report.Gaps = append(report.Gaps, gap)
+		report.GapCount++
+		report.TotalGapSeconds += d.Seconds()
+		if d.Seconds() > report.LongestGapSeconds {
+			report.LongestGapSeconds = d.Seconds()
+		}
+	}
+
+	report.UptimePercent = 100 * float64(up) / float64(to.Sub(from))
+
+	var hist [latencyBuckets]uint64
+	var samples uint64
+	fromSlot, toSlot := from.UnixNano()/int64(stats.width), to.UnixNano()/int64(stats.width)
+	for _, b := range stats.buckets {
+		if b.slot < fromSlot || b.slot > toSlot {
+			continue
+		}
+
+		report.Messages += b.messages
+		report.EstimatedLost += b.lost
+		for i, n := range b.latency {
+			hist[i] += uint64(n)
+			samples += uint64(n)
+		}
+	}
+
+	if samples > 0 {
+		report.LatencyP50Ms = percentile(hist[:], samples, 0.5)
+		report.LatencyP99Ms = percentile(hist[:], samples, 0.99)
+	}
+
+	return report
+}
+
+func percentile(hist []uint64, total uint64, p float64) float64 {
+	target := uint64(p * float64(total))
+	var seen uint64
+	for i, n := range hist {
+		seen += n
+		if seen > target {
+			return float64(latencyBucketBound(i)) / float64(time.Millisecond)
+		}
+	}
+
+	return float64(latencyBucketBound(len(hist)-1)) / float64(time.Millisecond)
+}
+
+// overlap returns the length of the intersection of [start, end] and [from, to].
+func overlap(start, end, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if end.Before(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
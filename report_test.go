@@ -0,0 +1,48 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSubscriptionReport(t *testing.T) {
+	t0 := time.Now().Add(-time.Hour).Truncate(time.Minute)
+	sub := &Subscription{stream: "execution_headers", stats: newStreamStats(24 * time.Hour)}
+
+	sub.stats.connected(t0)
+	for i := uint64(1); i <= 10; i++ {
+		// Block 5 never arrives
+		if i == 5 {
+			continue
+		}
+
+		ts := t0.Add(time.Duration(i) * 12 * time.Second)
+		sub.stats.received(ts.Add(100*time.Millisecond), &ExecutionPayloadHeader{Number: i, Timestamp: uint64(ts.Unix())})
+	}
+
+	sub.stats.disconnected(t0.Add(30*time.Minute), errors.New("stream reset"))
+	sub.stats.connected(t0.Add(36 * time.Minute))
+
+	report := sub.Report(t0, t0.Add(time.Hour))
+
+	if report.GapCount != 1 || report.TotalGapSeconds != 360 {
+		t.Fatalf("expected a single 6 minute gap, got %d gaps totalling %fs", report.GapCount, report.TotalGapSeconds)
+	}
+
+	if report.Gaps[0].Cause != "stream reset" {
+		t.Fatalf("unexpected gap cause %q", report.Gaps[0].Cause)
+	}
+
+	if report.UptimePercent != 90 {
+		t.Fatalf("expected 90%% uptime, got %f", report.UptimePercent)
+	}
+
+	if report.Messages != 9 || report.EstimatedLost != 1 {
+		t.Fatalf("expected 9 messages and 1 lost, got %d and %d", report.Messages, report.EstimatedLost)
+	}
+
+	if report.LatencyP99Ms < 100 || report.LatencyP99Ms > 120 {
+		t.Fatalf("unexpected p99 latency %fms", report.LatencyP99Ms)
+	}
+}
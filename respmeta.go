@@ -0,0 +1,132 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/metadata"
+
+	"google.golang.org/grpc"
+)
+
+// WithResponseMetadataHook calls fn with the response header of every stream the client opens, and
+// with the trailer of every subscription that ends. Server operational hints, like the shard
+// serving the stream or the limits that apply, arrive there. fn is called from its own goroutine for
+// headers, and must not block.
+func WithResponseMetadataHook(fn func(stream string, md metadata.MD, trailer bool)) ClientOption {
+	return func(c *Client) {
+		c.responseHook = fn
+	}
+}
+
+// watchHeader passes the header of s to the response metadata hook once it arrives.
+func (c *Client) watchHeader(stream string, s grpc.ClientStream) {
+	if c.responseHook == nil {
+		return
+	}
+
+	go func() {
+		if md, err := s.Header(); err == nil {
+			c.responseHook(stream, md, false)
+		}
+	}()
+}
+
+// capture fills the header and trailer requested in the send options from s, after a send that
+// returned err. Nothing is captured if the call was cancelled, since the stream may still be
+// waiting for the header, and the trailer is only captured if the stream ended.
+func (o SendOptions) capture(s grpc.ClientStream, err error, ended bool) {
+	if o.Header == nil && o.Trailer == nil {
+		return
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+
+	if o.Header != nil {
+		if md, err := s.Header(); err == nil {
+			*o.Header = md
+		}
+	}
+
+	if o.Trailer != nil && ended {
+		*o.Trailer = s.Trailer()
+	}
+}
+
+// Header returns the response header of the last subscription on the handle, nil if it didn't
+// arrive yet.
+func (s *Subscription) Header() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.header
+}
+
+// FilterEcho returns the filter of the last transaction subscription on the handle as the server
+// interpreted it, to compare with the filter that was sent (see filter.Filter.Describe). It returns
+// false until the response header arrived, or if the server doesn't echo filters.
+func (s *Subscription) FilterEcho() (*filter.Filter, bool) {
+	encoded, ok := metadata.FilterEcho(s.Header())
+	if !ok {
+		return nil, false
+	}
+
+	f, err := filter.Decode(encoded)
+	if err != nil {
+		return nil, false
+	}
+
+	return f, true
+}
+
+// Trailer returns the response trailer of the last subscription on the handle, nil while it's running.
+func (s *Subscription) Trailer() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.trailer
+}
+
+// captureMetadata records the header of stream on the handle once it arrives, and returns a function
+// recording the trailer, to be called once the stream failed. Both are ignored once the metadata of
+// another stream is captured on the handle.
+func (s *Subscription) captureMetadata(c *Client, name string, stream grpc.ClientStream) func() {
+	s.mu.Lock()
+	s.header, s.trailer = nil, nil
+	s.metadataGen++
+	gen := s.metadataGen
+	s.mu.Unlock()
+
+	go func() {
+		md, err := stream.Header()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.metadataGen == gen {
+			s.header = md
+		}
+		s.mu.Unlock()
+
+		if c.responseHook != nil {
+			c.responseHook(name, md, false)
+		}
+	}()
+
+	return func() {
+		md := stream.Trailer()
+
+		s.mu.Lock()
+		if s.metadataGen == gen {
+			s.trailer = md
+		}
+		s.mu.Unlock()
+
+		if c.responseHook != nil {
+			c.responseHook(name, md, true)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"google.golang.org/grpc/keepalive"
+)
+
+// How the buffers of RuntimeProfile are recommended: enough for the messages of a burst window at the
+// observed rate, and twice the peak of the current buffer, or its size if it overflowed. They're
+// rounded up to a power of two, from minProfileBuffer.
+const (
+	profileBurst     = 250 * time.Millisecond
+	minProfileBuffer = 16
+)
+
+// RuntimeProfile is the effective configuration of a client, with the buffer sizes recommended from
+// the performance of its subscriptions. Export it with Client.RuntimeProfile once the client ran under
+// its usual load, and apply it on the next startup with WithRuntimeProfile, instead of hand-tuning the
+// buffers.
+type RuntimeProfile struct {
+	CapturedAt time.Time `json:"captured_at"`
+	Target     string    `json:"target"`
+
+	ReadBufferSize               int           `json:"read_buffer_size"`
+	WriteBufferSize              int           `json:"write_buffer_size"`
+	ConnectTimeout               time.Duration `json:"connect_timeout_ns"`
+	KeepaliveInterval            time.Duration `json:"keepalive_interval_ns,omitempty"`
+	KeepaliveTimeout             time.Duration `json:"keepalive_timeout_ns,omitempty"`
+	KeepalivePermitWithoutStream bool          `json:"keepalive_permit_without_stream,omitempty"`
+	StreamCompression            string        `json:"stream_compression,omitempty"`
+
+	Streams []StreamProfile `json:"streams"`
+}
+
+// StreamProfile is the observed performance of the busiest running subscription to a stream.
+type StreamProfile struct {
+	Stream StreamKind `json:"stream"`
+	// MessagesPerSecond is the rate of the messages received while connected.
+	MessagesPerSecond float64 `json:"messages_per_second"`
+	// Buffer is the size of the delivery buffer, 0 if unbuffered, and PeakQueued its peak.
+	Buffer     int    `json:"buffer"`
+	PeakQueued int    `json:"peak_queued"`
+	Overflowed uint64 `json:"overflowed"`
+	// RecommendedBuffer is the delivery buffer size recommended for the stream, 0 for none.
+	RecommendedBuffer int `json:"recommended_buffer"`
+}
+
+// RuntimeProfile returns the effective configuration of the client, and the profiles of the streams of
+// its running subscriptions.
+func (c *Client) RuntimeProfile() *RuntimeProfile {
+	p := &RuntimeProfile{
+		CapturedAt:        time.Now(),
+		Target:            c.target,
+		ReadBufferSize:    c.dial.readBuffer,
+		WriteBufferSize:   c.dial.writeBuffer,
+		ConnectTimeout:    c.dial.connectTimeout,
+		StreamCompression: c.streamCompression,
+	}
+	if ka := c.dial.keepalive; ka != nil {
+		p.KeepaliveInterval = ka.Time
+		p.KeepaliveTimeout = ka.Timeout
+		p.KeepalivePermitWithoutStream = ka.PermitWithoutStream
+	}
+
+	c.subsMu.Lock()
+	subs := make([]*Subscription, 0, len(c.subs))
+	for sub := range c.subs {
+		subs = append(subs, sub)
+	}
+	c.subsMu.Unlock()
+
+	byStream := make(map[StreamKind]int)
+	for _, sub := range subs {
+		s := sub.streamProfile(p.CapturedAt)
+		if i, ok := byStream[s.Stream]; !ok {
+			byStream[s.Stream] = len(p.Streams)
+			p.Streams = append(p.Streams, s)
+		} else if s.RecommendedBuffer > p.Streams[i].RecommendedBuffer {
+			p.Streams[i] = s
+		}
+	}
+
+	return p
+}
+
+func (s *Subscription) streamProfile(now time.Time) StreamProfile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := StreamProfile{Stream: s.stream, Buffer: s.buffer}
+	if s.peakQueued != nil {
+		p.PeakQueued = s.peakQueued()
+	}
+	if s.stats != nil {
+		if uptime := s.stats.uptime(now); uptime > 0 {
+			p.MessagesPerSecond = float64(s.stats.messages) / uptime.Seconds()
+		}
+		p.Overflowed = s.stats.overflowed
+	}
+	p.RecommendedBuffer = p.recommendedBuffer()
+
+	return p
+}
+
+func (p StreamProfile) recommendedBuffer() int {
+	n := int(math.Ceil(p.MessagesPerSecond * profileBurst.Seconds()))
+	if peak := 2 * p.PeakQueued; peak > n {
+		n = peak
+	}
+	if p.Overflowed > 0 && 2*p.Buffer > n {
+		n = 2 * p.Buffer
+	}
+
+	// Less than a message per burst window doesn't need a buffer
+	if n <= 1 && p.Overflowed == 0 {
+		return 0
+	}
+
+	size := minProfileBuffer
+	for size < n {
+		size *= 2
+	}
+	return size
+}
+
+// WriteJSON writes the profile as indented JSON to w.
+func (p *RuntimeProfile) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// ReadRuntimeProfile reads a profile written by RuntimeProfile.WriteJSON.
+func ReadRuntimeProfile(r io.Reader) (*RuntimeProfile, error) {
+	p := new(RuntimeProfile)
+	if err := json.NewDecoder(r).Decode(p); err != nil {
+		return nil, fmt.Errorf("reading runtime profile: %w", err)
+	}
+
+	return p, nil
+}
+
+// WithRuntimeProfile applies a profile exported by Client.RuntimeProfile: its connection settings, and
+// the recommended buffers to the subscriptions that don't set their own with WithBackpressure or
+// WithPauseBuffer. Those buffers block when full, so no message is lost that an unbuffered
+// subscription would have delivered. Options after it override the connection settings.
+func WithRuntimeProfile(p *RuntimeProfile) ClientOption {
+	return func(c *Client) {
+		c.dial.readBuffer = p.ReadBufferSize
+		c.dial.writeBuffer = p.WriteBufferSize
+		c.dial.connectTimeout = p.ConnectTimeout
+		c.dial.keepalive = nil
+		if p.KeepaliveInterval > 0 {
+			c.dial.keepalive = &keepalive.ClientParameters{
+				Time:                p.KeepaliveInterval,
+				Timeout:             p.KeepaliveTimeout,
+				PermitWithoutStream: p.KeepalivePermitWithoutStream,
+			}
+		}
+		c.streamCompression = p.StreamCompression
+
+		c.profileBuffers = make(map[StreamKind]int, len(p.Streams))
+		for _, s := range p.Streams {
+			c.profileBuffers[s.Stream] = s.RecommendedBuffer
+		}
+	}
+}
+
+// applyProfile sets the buffer recommended by the runtime profile of the client, if any, to cfg.
+func (c *Client) applyProfile(stream StreamKind, cfg *subscriptionConfig) {
+	if n := c.profileBuffers[stream]; n > 0 && cfg.buffer == 0 && cfg.pauseBuffer == 0 {
+		cfg.buffer = n
+		cfg.backpressure = BlockWhenFull
+	}
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRuntimeProfile(t *testing.T) {
+	c := NewClient("x", WithBufferSizes(32<<10, 64<<10), WithKeepalive(time.Minute, 10*time.Second, true))
+
+	// Nobody reads, so the buffer fills up
+	ch := make(chan int)
+	var sub Subscription
+	go subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub), WithBackpressure(4, BlockWhenFull)}, fakeStream(1, 2, 3, 4, 5, 6), identity[int])
+	waitFor(t, func() bool { return sub.Stats().PeakQueued == 4 })
+
+	var buf bytes.Buffer
+	if err := c.RuntimeProfile().WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	sub.Unsubscribe()
+
+	p, err := ReadRuntimeProfile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Streams) != 1 || p.Streams[0].Stream != "test" || p.Streams[0].Buffer != 4 || p.Streams[0].RecommendedBuffer < 16 {
+		t.Fatalf("unexpected streams %+v", p.Streams)
+	}
+
+	next := NewClient("x", WithRuntimeProfile(p))
+	if next.dial.readBuffer != 32<<10 || next.dial.writeBuffer != 64<<10 || next.dial.keepalive.Time != time.Minute || !next.dial.keepalive.PermitWithoutStream {
+		t.Fatalf("unexpected dial configuration %+v", next.dial)
+	}
+
+	cfg := newSubscriptionConfig(nil)
+	next.applyProfile("test", cfg)
+	if cfg.buffer != p.Streams[0].RecommendedBuffer || cfg.backpressure != BlockWhenFull {
+		t.Fatalf("expected the recommended buffer, got %d", cfg.buffer)
+	}
+
+	// Explicit buffers win
+	cfg = newSubscriptionConfig([]SubscriptionOption{WithBackpressure(8, DropOldest)})
+	next.applyProfile("test", cfg)
+	if cfg.buffer != 8 || cfg.backpressure != DropOldest {
+		t.Fatalf("expected the explicit buffer, got %d", cfg.buffer)
+	}
+}
+
+func TestRecommendedBuffer(t *testing.T) {
+	for _, tt := range []struct {
+		profile StreamProfile
+		want    int
+	}{
+		{StreamProfile{MessagesPerSecond: 1}, 0},
+		{StreamProfile{MessagesPerSecond: 1000}, 256},
+		{StreamProfile{MessagesPerSecond: 10, PeakQueued: 40, Buffer: 64}, 128},
+		{StreamProfile{MessagesPerSecond: 10, Buffer: 64, Overflowed: 1}, 128},
+		{StreamProfile{MessagesPerSecond: 20}, 16},
+	} {
+		if got := tt.profile.recommendedBuffer(); got != tt.want {
+			t.Fatalf("%+v: expected %d, got %d", tt.profile, tt.want, got)
+		}
+	}
+}
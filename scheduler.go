@@ -0,0 +1,307 @@
+package client
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// ErrSendCancelled is the error of a scheduled send that was cancelled before firing.
+	ErrSendCancelled = newCodedError(ErrCodeSendCancelled, "scheduled send cancelled")
+	// ErrSchedulerStopped is the error of the sends scheduled on a scheduler that isn't running anymore.
+	ErrSchedulerStopped = newCodedError(ErrCodeSchedulerStopped, "scheduler stopped")
+)
+
+const (
+	// DefaultSchedulerTick is the resolution of the timer wheel of a Scheduler.
+	DefaultSchedulerTick = 100 * time.Microsecond
+	wheelSlots           = 4096
+	// The Go timers are only accurate to about a millisecond, so the scheduler spins for the last one.
+	schedulerSpin = time.Millisecond
+)
+
+// ScheduledSend is a transaction scheduled on a Scheduler. Its results are set once Done is closed.
+type ScheduledSend struct {
+	FireAt time.Time
+
+	// FiredAt is when the transaction was actually sent.
+	FiredAt   time.Time
+	Hash      string
+	Timestamp int64
+	Err       error
+
+	tx        *types.Transaction
+	raw       []byte
+	tick      uint64
+	cancelled int32
+	done      chan struct{}
+}
+
+// Done is closed once the transaction was sent, or failed to.
+func (s *ScheduledSend) Done() <-chan struct{} {
+	return s.done
+}
+
+// Cancel cancels the send if it didn't fire yet, and returns whether it did cancel it.
+func (s *ScheduledSend) Cancel() bool {
+	return atomic.CompareAndSwapInt32(&s.cancelled, 0, 1)
+}
+
+// Lateness returns how late the send fired compared to FireAt, once Done is closed.
+func (s *ScheduledSend) Lateness() time.Duration {
+	if s.FiredAt.IsZero() {
+		return 0
+	}
+
+	return s.FiredAt.Sub(s.FireAt)
+}
+
+func (s *ScheduledSend) finish(err error) {
+	s.Err = err
+	close(s.done)
+}
+
+// Scheduler sends transactions at given times with sub-millisecond accuracy, instead of sleeping on the
+// caller side, which jitters under GC pressure. Sends are kept in a timer wheel by a dedicated
+// goroutine, locked to its OS thread, which sleeps until a millisecond before the next one and spins
+// for the rest. The transactions are sent in order, so a send that fires while the previous one is
+// still waiting for its response is late.
+type Scheduler struct {
+	c     *Client
+	tick  time.Duration
+	start time.Time
+
+	mu      sync.Mutex
+	slots   [wheelSlots][]*ScheduledSend
+	pending int
+	next    uint64 // next tick to collect
+	stopped bool
+	notify  chan struct{}
+}
+
+// NewScheduler returns a scheduler sending on c. Call Run to start it.
+func NewScheduler(c *Client) *Scheduler {
+	return &Scheduler{
+		c:      c,
+		tick:   DefaultSchedulerTick,
+		start:  time.Now(),
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// Schedule sends tx at fireAt, or as soon as possible if it's in the past.
+func (s *Scheduler) Schedule(tx *types.Transaction, fireAt time.Time) *ScheduledSend {
+	return s.add(&ScheduledSend{FireAt: fireAt, tx: tx, done: make(chan struct{})})
+}
+
+// ScheduleRaw sends the RLP encoded transaction at fireAt, or as soon as possible if it's in the past.
+func (s *Scheduler) ScheduleRaw(rawTx []byte, fireAt time.Time) *ScheduledSend {
+	return s.add(&ScheduledSend{FireAt: fireAt, raw: rawTx, done: make(chan struct{})})
+}
+
+func (s *Scheduler) add(send *ScheduledSend) *ScheduledSend {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		send.finish(ErrSchedulerStopped)
+		return send
+	}
+
+	send.tick = s.tickOf(send.FireAt)
+	if send.tick < s.next {
+		send.tick = s.next
+	}
+	slot := &s.slots[send.tick%wheelSlots]
+	*slot = append(*slot, send)
+	s.pending++
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+
+	return send
+}
+
+func (s *Scheduler) tickOf(t time.Time) uint64 {
+	d := t.Sub(s.start)
+	if d < 0 {
+		return 0
+	}
+
+	return uint64(d / s.tick)
+}
+
+// collect removes the sends due at now from the wheel, in firing order.
+func (s *Scheduler) collect(now time.Time) []*ScheduledSend {
+	current := s.tickOf(now)
+	if current < s.next {
+		return nil
+	}
+
+	// The slots of a whole revolution hold all the ticks
+	last := current
+	if last-s.next >= wheelSlots {
+		last = s.next + wheelSlots - 1
+	}
+
+	var due []*ScheduledSend
+	for k := s.next; k <= last; k++ {
+		slot := &s.slots[k%wheelSlots]
+		kept := (*slot)[:0]
+		for _, send := range *slot {
+			if send.tick <= current {
+				due = append(due, send)
+			} else {
+				kept = append(kept, send)
+			}
+		}
+		for i := len(kept); i < len(*slot); i++ {
+			(*slot)[i] = nil
+		}
+		*slot = kept
+	}
+
+	s.next = current + 1
+	s.pending -= len(due)
+
+	sort.Slice(due, func(i, j int) bool { return due[i].FireAt.Before(due[j].FireAt) })
+	return due
+}
+
+// nearest returns the first tick with a send. There must be one.
+func (s *Scheduler) nearest() uint64 {
+	for k := s.next; k < s.next+wheelSlots; k++ {
+		for _, send := range s.slots[k%wheelSlots] {
+			if send.tick == k {
+				return k
+			}
+		}
+	}
+
+	// Everything is more than a revolution away
+	var min uint64
+	for _, slot := range s.slots {
+		for _, send := range slot {
+			if min == 0 || send.tick < min {
+				min = send.tick
+			}
+		}
+	}
+	return min
+}
+
+// Run runs the scheduler until ctx is done. The sends that didn't fire by then fail with ctx.Err().
+func (s *Scheduler) Run(ctx context.Context) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// Sends block until their response, so they're made on another goroutine to keep the wheel on time
+	sends := make(chan *ScheduledSend, wheelSlots)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for send := range sends {
+			s.send(ctx, send)
+		}
+	}()
+	defer func() {
+		close(sends)
+		<-sent
+	}()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		due := s.collect(time.Now())
+		var wake time.Time
+		pending := s.pending > 0
+		if pending {
+			wake = s.start.Add(time.Duration(s.nearest()) * s.tick)
+		}
+		s.mu.Unlock()
+
+		for _, send := range due {
+			for time.Now().Before(send.FireAt) {
+			}
+			sends <- send
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			s.stop(err)
+			return err
+		}
+
+		if !pending {
+			select {
+			case <-s.notify:
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		// Spin for the last millisecond, the next collect will be empty until then
+		sleep := time.Until(wake) - schedulerSpin
+		if sleep <= 0 {
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(sleep)
+
+		select {
+		case <-timer.C:
+		case <-s.notify:
+		case <-ctx.Done():
+		}
+	}
+}
+
+func (s *Scheduler) send(ctx context.Context, send *ScheduledSend) {
+	// Once fired, the send can't be cancelled anymore
+	if !atomic.CompareAndSwapInt32(&send.cancelled, 0, -1) {
+		send.finish(ErrSendCancelled)
+		return
+	}
+
+	send.FiredAt = time.Now()
+	var err error
+	if send.tx != nil {
+		send.Hash, send.Timestamp, err = s.c.SendTransaction(ctx, send.tx)
+	} else {
+		send.Hash, send.Timestamp, err = s.c.SendRawTransaction(ctx, send.raw)
+	}
+	send.finish(err)
+}
+
+// stop fails the sends that are left with err.
+func (s *Scheduler) stop(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	for i, slot := range s.slots {
+		for _, send := range slot {
+			send.finish(err)
+		}
+		s.slots[i] = nil
+	}
+	s.pending = 0
+}
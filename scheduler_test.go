@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	server, addr := startServer(t, 1)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(addr)
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	s := NewScheduler(c)
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- s.Run(runCtx) }()
+
+	now := time.Now()
+	later := s.ScheduleRaw([]byte{3}, now.Add(60*time.Millisecond))
+	first := s.ScheduleRaw([]byte{1}, now.Add(20*time.Millisecond))
+	cancelled := s.ScheduleRaw([]byte{2}, now.Add(40*time.Millisecond))
+	past := s.ScheduleRaw([]byte{0}, now.Add(-time.Second))
+	never := s.ScheduleRaw([]byte{4}, now.Add(time.Hour))
+
+	if !cancelled.Cancel() {
+		t.Fatal("expected the send to be cancelled")
+	}
+
+	for _, send := range []*ScheduledSend{past, first, cancelled, later} {
+		<-send.Done()
+	}
+	if cancelled.Err != ErrSendCancelled {
+		t.Fatalf("expected the cancelled send to fail, got %v", cancelled.Err)
+	}
+	for _, send := range []*ScheduledSend{past, first, later} {
+		if send.Err != nil || send.Hash == "" {
+			t.Fatalf("unexpected send result %q %v", send.Hash, send.Err)
+		}
+	}
+	if !first.FiredAt.Before(later.FiredAt) {
+		t.Fatal("expected the sends to fire in order")
+	}
+	for _, send := range []*ScheduledSend{first, later} {
+		// Loose bounds, the test machine may be busy
+		if late := send.Lateness(); late < 0 || late > 10*time.Millisecond {
+			t.Errorf("expected the send to fire on time, it's %v late", late)
+		}
+	}
+
+	stop()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("expected the scheduler to stop, got %v", err)
+	}
+	<-never.Done()
+	if never.Err != context.Canceled {
+		t.Fatalf("expected the pending send to fail, got %v", never.Err)
+	}
+	if send := s.ScheduleRaw([]byte{5}, time.Now()); send.Err != ErrSchedulerStopped {
+		t.Fatalf("expected the scheduler to be stopped, got %v", send.Err)
+	}
+}
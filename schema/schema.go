@@ -0,0 +1,106 @@
+// Package schema exposes the protobuf schema of the Fiber API, so that re-published messages can be
+// consumed in other languages without vendoring the .proto files.
+package schema
+
+import (
+	"fmt"
+	"io"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"github.com/chainbound/fiber-go/protobuf/types"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Files returns the descriptors of the Fiber .proto files.
+func Files() []protoreflect.FileDescriptor {
+	return []protoreflect.FileDescriptor{
+		types.File_types_proto,
+		eth.File_eth_proto,
+		api.File_api_proto,
+	}
+}
+
+// DescriptorSet returns the Fiber .proto files with all their imports, in dependency order. It's what
+// protoc --include_imports -o writes.
+func DescriptorSet() *descriptorpb.FileDescriptorSet {
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+
+	var add func(fd protoreflect.FileDescriptor)
+	add = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			add(imports.Get(i).FileDescriptor)
+		}
+
+		set.File = append(set.File, protodesc.ToFileDescriptorProto(fd))
+	}
+
+	for _, fd := range Files() {
+		add(fd)
+	}
+
+	return set
+}
+
+// WriteDescriptorSet writes the serialized DescriptorSet to w.
+func WriteDescriptorSet(w io.Writer) error {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(DescriptorSet())
+	if err != nil {
+		return fmt.Errorf("marshaling descriptor set: %w", err)
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// Message converts a streamed message to its protobuf message, which is one of eth.Transaction,
+// eth.ExecutionPayloadHeader, eth.ExecutionPayload and eth.CompactBeaconBlock.
+func Message(msg any) (proto.Message, error) {
+	switch m := msg.(type) {
+	case *client.Transaction:
+		return m.ToProto(), nil
+	case *client.ExecutionPayloadHeader:
+		return m.ToProto(), nil
+	case *client.ExecutionPayload:
+		return m.ToProto(), nil
+	case *client.BeaconBlock:
+		return m.ToProto(), nil
+	case proto.Message:
+		return m, nil
+	}
+
+	return nil, fmt.Errorf("no protobuf message for %T", msg)
+}
+
+// Marshal serializes a streamed message to canonical protobuf bytes, with deterministic field order.
+// The message type can be looked up in DescriptorSet with MessageName.
+func Marshal(msg any) ([]byte, error) {
+	m, err := Message(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.MarshalOptions{Deterministic: true}.Marshal(m)
+}
+
+// MessageName returns the full protobuf name of a streamed message, like "eth.Transaction".
+func MessageName(msg any) (protoreflect.FullName, error) {
+	m, err := Message(msg)
+	if err != nil {
+		return "", err
+	}
+
+	return m.ProtoReflect().Descriptor().FullName(), nil
+}
@@ -0,0 +1,101 @@
+package schema
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	client "github.com/chainbound/fiber-go"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestDescriptorSet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDescriptorSet(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(buf.Bytes(), &set); err != nil {
+		t.Fatal(err)
+	}
+
+	// The set must be self-contained
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := files.FindDescriptorByName("api.API"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	to := common.HexToAddress("0x01")
+	tx := &client.Transaction{
+		ChainID:     1,
+		Type:        2,
+		To:          &to,
+		From:        common.HexToAddress("0x02"),
+		Hash:        common.HexToHash("0x03"),
+		Gas:         21000,
+		GasPrice:    big.NewInt(0),
+		MaxFee:      big.NewInt(100),
+		PriorityFee: big.NewInt(2),
+		Value:       big.NewInt(1e18),
+		V:           1,
+		R:           []byte{4},
+		S:           []byte{5},
+	}
+
+	b, err := Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded eth.Transaction
+	if err := proto.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(&decoded, tx.ToProto()) {
+		t.Fatalf("round trip mismatch: %v", &decoded)
+	}
+	if back := client.ProtoToTx(&decoded); back.Hash != tx.Hash || *back.To != to || back.Value.Cmp(tx.Value) != 0 {
+		t.Fatalf("unexpected transaction %+v", back)
+	}
+
+	// Consumers without the generated code decode with the descriptor set
+	files, err := protodesc.NewFiles(DescriptorSet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := MessageName(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := files.FindDescriptorByName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := dynamicpb.NewMessage(desc.(protoreflect.MessageDescriptor))
+	if err := proto.Unmarshal(b, msg); err != nil {
+		t.Fatal(err)
+	}
+	if gas := msg.Get(msg.Descriptor().Fields().ByName("gas")).Uint(); gas != 21000 {
+		t.Fatalf("expected gas 21000, got %d", gas)
+	}
+}
+
+func TestMarshalUnknown(t *testing.T) {
+	if _, err := Marshal(42); err == nil {
+		t.Fatal("expected an error for a non-message")
+	}
+}
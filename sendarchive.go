@@ -0,0 +1,308 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrNoSendArchive is returned by Replay on a client without a send archive.
+var ErrNoSendArchive = newCodedError(ErrCodeNoSendArchive, "no send archive")
+
+// maxArchiveLine bounds the records of a send archive, which hold blob transactions with their sidecar.
+const maxArchiveLine = 16 << 20
+
+// ArchivedTx is a transaction recorded in a SendArchive.
+type ArchivedTx struct {
+	Hash common.Hash   `json:"hash"`
+	Raw  hexutil.Bytes `json:"raw"`
+	// Send numbers the sends of the archive, from 1. The transactions of a sequence share it, and
+	// Index is their position out of Count.
+	Send   uint64    `json:"send"`
+	Index  int       `json:"index"`
+	Count  int       `json:"count"`
+	SentAt time.Time `json:"sent_at"`
+}
+
+// SendArchive records the raw transactions sent by a client, one JSON object per line, before they're
+// sent. After an outage, when it isn't known which sends propagated, Client.Replay re-submits them.
+// It's safe for concurrent use.
+type SendArchive struct {
+	path string
+
+	mu   sync.Mutex
+	file *os.File
+	send uint64
+	err  error
+}
+
+// OpenSendArchive opens the archive at path, creating it if needed. Every send is synced to disk
+// before the transactions are sent, which delays them by a write. A send torn by a crash while it was
+// written is discarded: its transactions weren't sent.
+func OpenSendArchive(path string) (*SendArchive, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("opening send archive: %w", err)
+	}
+
+	a := &SendArchive{path: path, file: f}
+	// The numbering continues from the last complete send
+	var complete int64
+	err = readSendArchive(f, func(tx *ArchivedTx, end int64) error {
+		if tx.Index == tx.Count-1 {
+			a.send, complete = tx.Send, end
+		}
+		return nil
+	})
+	if err == nil {
+		err = truncateSendArchive(f, complete)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// truncateSendArchive drops what follows the last complete send of f, which ends at size.
+func truncateSendArchive(f *os.File, size int64) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("opening send archive: %w", err)
+	}
+	if info.Size() == size {
+		return nil
+	}
+
+	if err := f.Truncate(size); err != nil {
+		return fmt.Errorf("truncating torn send: %w", err)
+	}
+
+	return f.Sync()
+}
+
+// WithSendArchive records all the transactions sent by the client, single and in sequences, in a,
+// after the send hooks let them through. Failing to record them doesn't fail the send, check
+// SendArchive.Err.
+func WithSendArchive(a *SendArchive) ClientOption {
+	return func(c *Client) {
+		c.sendArchive = a
+	}
+}
+
+// record appends the transactions of a send.
+func (a *SendArchive) record(txs []*OutboundTx) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.err != nil {
+		return
+	}
+
+	var buf []byte
+	now := time.Now()
+	for _, tx := range txs {
+		archived := ArchivedTx{Raw: tx.Raw, Send: a.send + 1, Index: tx.Index, Count: tx.Count, SentAt: now}
+		switch {
+		case tx.Tx != nil:
+			archived.Hash = tx.Tx.Hash()
+		case tx.Blob != nil:
+			archived.Hash = tx.Blob.Hash()
+		default:
+			archived.Hash = crypto.Keccak256Hash(tx.Raw)
+		}
+
+		b, err := json.Marshal(&archived)
+		if err != nil {
+			a.err = fmt.Errorf("encoding archived transaction: %w", err)
+			return
+		}
+		buf = append(append(buf, b...), '\n')
+	}
+
+	if _, err := a.file.Write(buf); err != nil {
+		a.err = fmt.Errorf("writing send archive: %w", err)
+		return
+	}
+	if err := a.file.Sync(); err != nil {
+		a.err = fmt.Errorf("syncing send archive: %w", err)
+		return
+	}
+
+	a.send++
+}
+
+// Transactions returns the archived transactions sent since since, in the order they were sent.
+func (a *SendArchive) Transactions(since time.Time) ([]*ArchivedTx, error) {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return nil, fmt.Errorf("opening send archive: %w", err)
+	}
+	defer f.Close()
+
+	var txs []*ArchivedTx
+	err = readSendArchive(f, func(tx *ArchivedTx, _ int64) error {
+		if !tx.SentAt.Before(since) {
+			txs = append(txs, tx)
+		}
+		return nil
+	})
+
+	return txs, err
+}
+
+// Err returns the error that made the archive unusable, if any.
+func (a *SendArchive) Err() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.err
+}
+
+// Close closes the file of the archive.
+func (a *SendArchive) Close() error {
+	return a.file.Close()
+}
+
+// readSendArchive calls fn with the records of r and the offset they end at. A partial last line is
+// ignored, it's a record torn while it was written.
+func readSendArchive(r io.Reader, fn func(tx *ArchivedTx, end int64) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxArchiveLine)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	})
+
+	var end int64
+	for line := 1; scanner.Scan(); line++ {
+		end += int64(len(scanner.Bytes())) + 1
+
+		tx := new(ArchivedTx)
+		if err := json.Unmarshal(scanner.Bytes(), tx); err != nil {
+			return fmt.Errorf("decoding send archive line %d: %w", line, err)
+		}
+
+		if err := fn(tx, end); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading send archive: %w", err)
+	}
+
+	return nil
+}
+
+// replayKey marks the context of the sends of Replay, which are already archived.
+type replayKey struct{}
+
+// archive records the transactions of a send in the send archive of the client, if any, unless
+// they're replayed.
+func (c *Client) archive(ctx context.Context, txs []*OutboundTx) {
+	if c.sendArchive != nil && (ctx == nil || ctx.Value(replayKey{}) == nil) {
+		c.sendArchive.record(txs)
+	}
+}
+
+// ReplayFilter returns whether to re-submit an archived transaction, e.g. if it isn't included yet.
+type ReplayFilter func(ctx context.Context, tx *ArchivedTx) (bool, error)
+
+// ReplayResult is the outcome of re-submitting an archived transaction.
+type ReplayResult struct {
+	Tx        *ArchivedTx
+	Hash      string
+	Timestamp int64
+	Err       error
+}
+
+// Replay re-submits the transactions of the send archive of the client (see WithSendArchive) sent
+// since since and accepted by filter, or all of them if filter is nil. They're re-submitted in the
+// order they were first sent, each transaction once: the accepted transactions of a sequence are
+// re-submitted as a sequence. Sending the same signed transaction twice is safe, since it has the same
+// hash. The replayed sends aren't archived again. A failed send doesn't stop the replay, it's in its
+// result. Replay only fails if the archive can't be read, filter fails or ctx is done.
+func (c *Client) Replay(ctx context.Context, since time.Time, filter ReplayFilter) ([]ReplayResult, error) {
+	if c.sendArchive == nil {
+		return nil, ErrNoSendArchive
+	}
+
+	txs, err := c.sendArchive.Transactions(since)
+	if err != nil {
+		return nil, err
+	}
+
+	// The transactions are archived already
+	ctx = context.WithValue(ctx, replayKey{}, true)
+
+	var results []ReplayResult
+	seen := make(map[common.Hash]bool, len(txs))
+	for start := 0; start < len(txs); {
+		// The transactions of a send are consecutive
+		end := start + 1
+		for end < len(txs) && txs[end].Send == txs[start].Send {
+			end++
+		}
+
+		var send []*ArchivedTx
+		for _, tx := range txs[start:end] {
+			if seen[tx.Hash] {
+				continue
+			}
+			seen[tx.Hash] = true
+
+			if filter != nil {
+				ok, err := filter(ctx, tx)
+				if err != nil {
+					return results, fmt.Errorf("filtering %s: %w", tx.Hash, err)
+				}
+				if !ok {
+					continue
+				}
+			}
+			send = append(send, tx)
+		}
+		start = end
+
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		switch {
+		case len(send) == 0:
+		case len(send) == 1 && send[0].Count <= 1:
+			hash, timestamp, err := c.SendRawTransaction(ctx, send[0].Raw)
+			results = append(results, ReplayResult{Tx: send[0], Hash: hash, Timestamp: timestamp, Err: err})
+		default:
+			raws := make([][]byte, len(send))
+			for i, tx := range send {
+				raws[i] = tx.Raw
+			}
+
+			res, err := c.SendRawTransactionSequenceResult(ctx, raws...)
+			for i, tx := range send {
+				result := ReplayResult{Tx: tx, Err: err}
+				if res != nil {
+					result.Hash, result.Timestamp = res.Items[i].Hash.Hex(), res.Items[i].Timestamp
+				}
+				results = append(results, result)
+			}
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestReplay(t *testing.T) {
+	server, addr := startServer(t, 1)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "sends.log")
+	archive, err := OpenSendArchive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClient(addr, WithSendArchive(archive))
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	start := time.Now()
+	for _, raw := range [][]byte{{1}, {2}, {3}} {
+		if _, _, err := c.SendRawTransaction(ctx, raw); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	included := crypto.Keccak256Hash([]byte{2})
+	results, err := c.Replay(ctx, start, func(_ context.Context, tx *ArchivedTx) (bool, error) {
+		return tx.Hash != included, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Hash != crypto.Keccak256Hash([]byte{1}).Hex() || results[1].Hash != crypto.Keccak256Hash([]byte{3}).Hex() || results[1].Err != nil {
+		t.Fatalf("unexpected replay %+v", results)
+	}
+
+	// The replays aren't archived again
+	if results, err := c.Replay(ctx, start, nil); err != nil || len(results) != 3 {
+		t.Fatalf("expected 3 transactions replayed, got %d %v", len(results), err)
+	}
+	if results, err := c.Replay(ctx, time.Now(), nil); err != nil || len(results) != 0 {
+		t.Fatalf("expected nothing to replay, got %d %v", len(results), err)
+	}
+
+	archive.Close()
+
+	// A send torn by a crash is discarded
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"hash":"0x01","raw":"0x`)
+	f.Close()
+
+	if archive, err = OpenSendArchive(path); err != nil || archive.send != 3 {
+		t.Fatalf("expected the numbering to continue after 3 sends, got %v", err)
+	}
+	if txs, err := archive.Transactions(start); err != nil || len(txs) != 3 {
+		t.Fatalf("expected 3 archived transactions, got %d %v", len(txs), err)
+	}
+	archive.Close()
+}
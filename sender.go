@@ -0,0 +1,83 @@
+package client
+
+import (
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SenderSource is where the From of a transaction comes from.
+type SenderSource uint8
+
+const (
+	// SenderUnknown is for transactions without a sender: the server didn't provide it, and it wasn't
+	// recovered. From is the zero address.
+	SenderUnknown SenderSource = iota
+	// SenderFromServer is for senders recovered by Fiber, which streams them with the transactions.
+	SenderFromServer
+	// SenderRecovered is for senders recovered from the signature by the client, see WithSenderRecovery.
+	SenderRecovered
+)
+
+func (s SenderSource) String() string {
+	switch s {
+	case SenderFromServer:
+		return "server"
+	case SenderRecovered:
+		return "recovered"
+	}
+
+	return "unknown"
+}
+
+// SenderRecovery is when a subscription recovers the senders of transactions from their signatures.
+type SenderRecovery int
+
+const (
+	// TrustServerSender uses the senders provided by the server, and leaves the others unknown. There's
+	// no ECDSA recovery on the client. This is the default.
+	TrustServerSender SenderRecovery = iota
+	// RecoverMissingSender recovers the senders the server didn't provide.
+	RecoverMissingSender
+	// AlwaysRecoverSender recovers every sender, for applications that must not depend on the server's.
+	AlwaysRecoverSender
+)
+
+// WithSenderRecovery sets when the senders of the streamed transactions (including the ones in
+// execution payloads) are recovered on the client. Fiber streams them with the transactions when it
+// knows them, which saves an ECDSA recovery per transaction, and Transaction.FromSource tells where each
+// came from. Blob transactions and transactions of unknown types can't be recovered.
+func WithSenderRecovery(r SenderRecovery) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.senderRecovery = r
+	}
+}
+
+// recoverSender sets the sender of tx according to r.
+func recoverSender(r SenderRecovery, tx *Transaction) {
+	if r == TrustServerSender || (r == RecoverMissingSender && tx.FromSource != SenderUnknown) {
+		return
+	}
+
+	native := tx.ToNative()
+	if native == nil {
+		return
+	}
+
+	from, err := types.Sender(types.LatestSignerForChainID(native.ChainId()), native)
+	if err != nil {
+		return
+	}
+
+	tx.From = from
+	tx.FromSource = SenderRecovered
+}
+
+// fromSource returns where the sender of a streamed transaction comes from.
+func fromSource(proto *eth.Transaction) SenderSource {
+	if len(proto.From) == 0 {
+		return SenderUnknown
+	}
+
+	return SenderFromServer
+}
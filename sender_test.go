@@ -0,0 +1,54 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSenderRecovery(t *testing.T) {
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender := crypto.PubkeyToAddress(pk.PublicKey)
+
+	signed, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{ChainID: common.Big1, Nonce: 1, Gas: 21000, GasTipCap: common.Big1, GasFeeCap: common.Big2}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withSender := func() *eth.Transaction { return txToProto(signed, sender) }
+	withoutSender := func() *eth.Transaction {
+		proto := txToProto(signed, sender)
+		proto.From = nil
+		return proto
+	}
+
+	for _, tt := range []struct {
+		recovery SenderRecovery
+		proto    *eth.Transaction
+		source   SenderSource
+	}{
+		{TrustServerSender, withSender(), SenderFromServer},
+		{TrustServerSender, withoutSender(), SenderUnknown},
+		{RecoverMissingSender, withSender(), SenderFromServer},
+		{RecoverMissingSender, withoutSender(), SenderRecovered},
+		{AlwaysRecoverSender, withSender(), SenderRecovered},
+	} {
+		ch := make(chan *Transaction, 1)
+		var sub Subscription
+		go subscribe(&Client{}, "txs", ch, []SubscriptionOption{WithHandle(&sub), WithSenderRecovery(tt.recovery)}, fakeStream(tt.proto), ProtoToTx)
+
+		tx := <-ch
+		sub.Unsubscribe()
+		if tx.FromSource != tt.source {
+			t.Fatalf("%d: expected a %s sender, got %s", tt.recovery, tt.source, tx.FromSource)
+		}
+		if tt.source != SenderUnknown && tx.From != sender {
+			t.Fatalf("%d: expected sender %s, got %s", tt.recovery, sender, tx.From)
+		}
+	}
+}
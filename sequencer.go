@@ -0,0 +1,331 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/chainbound/fiber-go/hexenc"
+	"github.com/chainbound/fiber-go/protobuf/api"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SequenceStats is a snapshot of the sequence sends of a client.
+type SequenceStats struct {
+	// InFlight is the number of sequences sent that didn't get a response yet.
+	InFlight int `json:"in_flight"`
+	// Queued is the number of sequences waiting for an in-flight slot.
+	Queued int `json:"queued"`
+	// MaxInFlight is the in-flight limit per stream, 0 if there is none.
+	MaxInFlight int `json:"max_in_flight"`
+}
+
+// WithMaxInFlightSequences limits the number of sequences that are sent but not yet answered to n per
+// stream. Further sequences are queued, and sent in submission order when a response frees a slot.
+// 0 means no limit.
+func WithMaxInFlightSequences(n int) ClientOption {
+	return func(c *Client) {
+		c.maxInFlightSequences = n
+	}
+}
+
+type sequenceStream[Req any] interface {
+	Send(Req) error
+	Recv() (*api.TxSequenceResponse, error)
+}
+
+type sequenceResult struct {
+	res *api.TxSequenceResponse
+	err error
+}
+
+// sequencer correlates the responses on a sequence stream with the submissions. The server answers
+// in order, so sends are serialized and every response goes to the oldest pending submission.
+type sequencer[Req any] struct {
+	stream sequenceStream[Req]
+	slots  chan struct{}
+
+	// sendMu serializes the sends, which may block on flow control, without holding up receive.
+	sendMu sync.Mutex
+
+	mu      sync.Mutex
+	pending []chan sequenceResult
+	queued  int
+	err     error
+}
+
+func newSequencer[Req any](stream sequenceStream[Req], max int) *sequencer[Req] {
+	s := &sequencer[Req]{stream: stream}
+	if max > 0 {
+		s.slots = make(chan struct{}, max)
+	}
+
+	go s.receive()
+	return s
+}
+
+// submit sends req and waits for its response. Waiting for a slot is FIFO, since blocked channel
+// senders are woken in order.
+func (s *sequencer[Req]) submit(ctx context.Context, req Req) (*api.TxSequenceResponse, error) {
+	if s.slots != nil {
+		s.mu.Lock()
+		s.queued++
+		s.mu.Unlock()
+
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.queued--
+			s.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+
+	done := make(chan sequenceResult, 1)
+
+	s.sendMu.Lock()
+	s.mu.Lock()
+	if s.slots != nil {
+		s.queued--
+	}
+	if s.err != nil {
+		err := s.err
+		s.mu.Unlock()
+		s.sendMu.Unlock()
+		s.release()
+		return nil, err
+	}
+
+	s.pending = append(s.pending, done)
+	s.mu.Unlock()
+
+	err := s.stream.Send(req)
+	s.sendMu.Unlock()
+	if err != nil {
+		// The stream is broken. Unless receive failed the submission already, it's not pending anymore.
+		if s.abandon(done) {
+			s.release()
+		}
+		return nil, sendError(err)
+	}
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		// The slot is released when the response arrives
+		return nil, ctx.Err()
+	}
+}
+
+// abandon removes done from the pending submissions, and returns false if it wasn't pending.
+func (s *sequencer[Req]) abandon(done chan sequenceResult) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, pending := range s.pending {
+		if pending == done {
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *sequencer[Req]) release() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+func (s *sequencer[Req]) receive() {
+	for {
+		res, err := s.stream.Recv()
+
+		s.mu.Lock()
+		if err != nil {
+			err = sendError(err)
+			s.err = err
+			pending := s.pending
+			s.pending = nil
+			s.mu.Unlock()
+
+			for _, done := range pending {
+				done <- sequenceResult{err: err}
+				s.release()
+			}
+			return
+		}
+
+		if len(s.pending) == 0 {
+			// Unsolicited response, there's nobody to correlate it with
+			s.mu.Unlock()
+			continue
+		}
+
+		done := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+
+		done <- sequenceResult{res: res}
+		s.release()
+	}
+}
+
+// recvErr returns the error that ended the stream, nil while it's open.
+func (s *sequencer[Req]) recvErr() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.err
+}
+
+func (s *sequencer[Req]) stats() (inFlight, queued int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.pending), s.queued
+}
+
+// sequencerFor returns the sequencer stored in p for stream, creating it first if needed.
+func sequencerFor[Req any](c *Client, p **sequencer[Req], stream sequenceStream[Req]) *sequencer[Req] {
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+
+	if *p == nil || (*p).stream != stream {
+		*p = newSequencer(stream, c.maxInFlightSequences)
+	}
+
+	return *p
+}
+
+// SequenceStats returns the in-flight and queued sequences over all the sequence streams.
+func (c *Client) SequenceStats() SequenceStats {
+	c.seqMu.Lock()
+	defer c.seqMu.Unlock()
+
+	stats := SequenceStats{MaxInFlight: c.maxInFlightSequences}
+	add := func(inFlight, queued int) {
+		stats.InFlight += inFlight
+		stats.Queued += queued
+	}
+
+	if c.txSeq != nil {
+		add(c.txSeq.stats())
+	}
+	if c.rawTxSeq != nil {
+		add(c.rawTxSeq.stats())
+	}
+	if c.gzTxSeq != nil {
+		add(c.gzTxSeq.stats())
+	}
+	if c.gzRawTxSeq != nil {
+		add(c.gzRawTxSeq.stats())
+	}
+
+	return stats
+}
+
+var (
+	// ErrSequenceRejected is returned when the server didn't accept every transaction of a sequence.
+	ErrSequenceRejected = newCodedError(ErrCodeSequenceRejected, "sequence partially rejected")
+	// ErrSequenceReordered is returned when the server accepted a sequence in a different order.
+	ErrSequenceReordered = newCodedError(ErrCodeSequenceReordered, "sequence reordered")
+)
+
+// SequenceError is returned when the response to a sequence doesn't match what was sent. Index is the
+// first affected transaction, and Result has the position the server reported for every transaction.
+type SequenceError struct {
+	Err    error
+	Index  int
+	Result *SequenceResult
+}
+
+func (e *SequenceError) Error() string {
+	return fmt.Sprintf("transaction %d: %v", e.Index, e.Err)
+}
+
+func (e *SequenceError) Unwrap() error {
+	return e.Err
+}
+
+// SequenceResult is the response to a sequence, in submission order.
+type SequenceResult struct {
+	Items []SequenceItem
+}
+
+// SequenceItem is the response for one transaction of a sequence.
+type SequenceItem struct {
+	Hash common.Hash
+	// Position is the index of the transaction in the response, -1 if the server didn't accept it.
+	Position int
+	// Timestamp is when the Fiber node received the transaction (us since the Unix epoch), 0 if it
+	// didn't accept it. It's the only server timing in the API.
+	Timestamp int64
+}
+
+// ReceivedAt returns Timestamp as a time, the zero time if the server didn't accept the transaction.
+func (i SequenceItem) ReceivedAt() time.Time {
+	if i.Timestamp == 0 {
+		return time.Time{}
+	}
+
+	return time.UnixMicro(i.Timestamp)
+}
+
+// Hashes returns the hashes of the transactions, like SendTransactionSequence.
+func (r *SequenceResult) Hashes() []string {
+	hashes := make([]string, len(r.Items))
+	for i, item := range r.Items {
+		hashes[i] = hexenc.Hash(item.Hash)
+	}
+
+	return hashes
+}
+
+// Timestamp returns the timestamp of the first transaction, like SendTransactionSequence.
+func (r *SequenceResult) Timestamp() int64 {
+	if len(r.Items) == 0 {
+		return 0
+	}
+
+	return r.Items[0].Timestamp
+}
+
+// verifySequence matches the response to a sequence with the hashes that were sent.
+func verifySequence(sent []common.Hash, res *api.TxSequenceResponse) (*SequenceResult, error) {
+	positions := make(map[common.Hash]int, len(res.SequenceResponse))
+	for i, response := range res.SequenceResponse {
+		positions[common.HexToHash(response.Hash)] = i
+	}
+
+	result := &SequenceResult{Items: make([]SequenceItem, len(sent))}
+	var err *SequenceError
+	for i, hash := range sent {
+		item := SequenceItem{Hash: hash, Position: -1}
+
+		if pos, ok := positions[hash]; ok {
+			item.Position = pos
+			item.Timestamp = res.SequenceResponse[pos].Timestamp
+
+			if pos != i && err == nil {
+				err = &SequenceError{Err: ErrSequenceReordered, Index: i, Result: result}
+			}
+		} else if err == nil || err.Err != ErrSequenceRejected {
+			// A rejection is reported over a reordering
+			err = &SequenceError{Err: ErrSequenceRejected, Index: i, Result: result}
+		}
+
+		result.Items[i] = item
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
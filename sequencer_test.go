@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// echoSequenceStream answers every sequence, in order, with the hash of its first raw transaction.
+type echoSequenceStream struct {
+	mu       sync.Mutex
+	sent     chan *api.RawTxSequenceMsg
+	inFlight int
+	max      int
+}
+
+func (s *echoSequenceStream) Send(msg *api.RawTxSequenceMsg) error {
+	s.mu.Lock()
+	s.inFlight++
+	if s.inFlight > s.max {
+		s.max = s.inFlight
+	}
+	s.mu.Unlock()
+
+	s.sent <- msg
+	return nil
+}
+
+func (s *echoSequenceStream) Recv() (*api.TxSequenceResponse, error) {
+	msg := <-s.sent
+
+	s.mu.Lock()
+	s.inFlight--
+	s.mu.Unlock()
+
+	return &api.TxSequenceResponse{SequenceResponse: []*api.TransactionResponse{{Hash: string(msg.RawTxs[0])}}}, nil
+}
+
+func TestSequencerCorrelation(t *testing.T) {
+	stream := &echoSequenceStream{sent: make(chan *api.RawTxSequenceMsg, 100)}
+	seq := newSequencer[*api.RawTxSequenceMsg](stream, 2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			want := fmt.Sprint(i)
+			res, err := seq.submit(context.Background(), &api.RawTxSequenceMsg{RawTxs: [][]byte{[]byte(want)}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := res.SequenceResponse[0].Hash; got != want {
+				t.Errorf("submission %s got the response for %s", want, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stream.max > 2 {
+		t.Fatalf("expected at most 2 sequences in flight, got %d", stream.max)
+	}
+	if inFlight, queued := seq.stats(); inFlight != 0 || queued != 0 {
+		t.Fatalf("expected an idle sequencer, got %d in flight and %d queued", inFlight, queued)
+	}
+}
+
+// gatedSequenceStream blocks the sends of the sequences in block until block is closed, fails the
+// sends of the sequences in fail, and answers once respond is closed.
+type gatedSequenceStream struct {
+	block   chan struct{}
+	blocked map[string]bool
+	fail    map[string]bool
+	respond chan struct{}
+	sent    chan *api.RawTxSequenceMsg
+}
+
+func (s *gatedSequenceStream) Send(msg *api.RawTxSequenceMsg) error {
+	name := string(msg.RawTxs[0])
+	if s.fail[name] {
+		return errors.New("send failed")
+	}
+	if s.blocked[name] {
+		<-s.block
+	}
+
+	s.sent <- msg
+	return nil
+}
+
+func (s *gatedSequenceStream) Recv() (*api.TxSequenceResponse, error) {
+	<-s.respond
+	msg := <-s.sent
+	return &api.TxSequenceResponse{SequenceResponse: []*api.TransactionResponse{{Hash: string(msg.RawTxs[0])}}}, nil
+}
+
+func TestSequencerBlockedSend(t *testing.T) {
+	stream := &gatedSequenceStream{
+		block:   make(chan struct{}),
+		blocked: map[string]bool{"b": true},
+		fail:    map[string]bool{"x": true},
+		respond: make(chan struct{}),
+		sent:    make(chan *api.RawTxSequenceMsg, 10),
+	}
+	seq := newSequencer[*api.RawTxSequenceMsg](stream, 2)
+	submit := func(name string) chan error {
+		errc := make(chan error, 1)
+		go func() {
+			res, err := seq.submit(context.Background(), &api.RawTxSequenceMsg{RawTxs: [][]byte{[]byte(name)}})
+			if err == nil && res.SequenceResponse[0].Hash != name {
+				err = fmt.Errorf("submission %s got the response for %s", name, res.SequenceResponse[0].Hash)
+			}
+			errc <- err
+		}()
+		return errc
+	}
+
+	// Failed sends release their slot
+	for i := 0; i < 2; i++ {
+		if err := <-submit("x"); err == nil {
+			t.Fatal("expected the send to fail")
+		}
+	}
+	a := submit("a")
+	for deadline := time.Now().Add(time.Second); len(stream.sent) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("expected a to be sent")
+		}
+	}
+
+	// The send of b is stuck on flow control, which doesn't hold up the response to a
+	b := submit("b")
+	time.Sleep(10 * time.Millisecond)
+	close(stream.respond)
+	select {
+	case err := <-a:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a blocked send held up the responses")
+	}
+
+	close(stream.block)
+	if err := <-b; err != nil {
+		t.Fatal(err)
+	}
+	if inFlight, queued := seq.stats(); inFlight != 0 || queued != 0 {
+		t.Fatalf("expected an idle sequencer, got %d in flight and %d queued", inFlight, queued)
+	}
+}
+
+func sequenceResponse(hashes ...common.Hash) *api.TxSequenceResponse {
+	res := &api.TxSequenceResponse{}
+	for i, hash := range hashes {
+		res.SequenceResponse = append(res.SequenceResponse, &api.TransactionResponse{Hash: hash.Hex(), Timestamp: int64(i + 1)})
+	}
+	return res
+}
+
+func TestVerifySequence(t *testing.T) {
+	a, b, c := common.Hash{1}, common.Hash{2}, common.Hash{3}
+
+	res, err := verifySequence([]common.Hash{a, b, c}, sequenceResponse(a, b, c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Items[2].Position != 2 || res.Timestamp() != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+
+	res, err = verifySequence([]common.Hash{a, b, c}, sequenceResponse(a, c, b))
+	var seqErr *SequenceError
+	if !errors.As(err, &seqErr) || !errors.Is(err, ErrSequenceReordered) || seqErr.Index != 1 {
+		t.Fatalf("expected a reordering at 1, got %v", err)
+	}
+	if res.Items[1].Position != 2 || res.Items[2].Position != 1 {
+		t.Fatalf("unexpected positions %+v", res.Items)
+	}
+
+	res, err = verifySequence([]common.Hash{a, b, c}, sequenceResponse(a, c))
+	if !errors.As(err, &seqErr) || !errors.Is(err, ErrSequenceRejected) || seqErr.Index != 1 {
+		t.Fatalf("expected a rejection at 1, got %v", err)
+	}
+	if res.Items[1].Position != -1 || res.Items[1].Timestamp != 0 {
+		t.Fatalf("unexpected item %+v", res.Items[1])
+	}
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClientClosed is returned by sends and subscriptions started while the client is closing.
+	ErrClientClosed = newCodedError(ErrCodeClientClosed, "client closed")
+	// ErrShutdownTimeout is returned by Close when a shutdown phase didn't finish in time.
+	ErrShutdownTimeout = newCodedError(ErrCodeShutdownTimeout, "shutdown timeout")
+)
+
+// ShutdownOrder is the order in which Close stops subscriptions and drains sends.
+type ShutdownOrder int
+
+const (
+	// SubscriptionsFirst stops the subscriptions, then waits for the sends in flight.
+	SubscriptionsFirst ShutdownOrder = iota
+	// SendsFirst waits for the sends in flight, then stops the subscriptions.
+	SendsFirst
+)
+
+// ShutdownConfig configures a graceful Close. In both phases, 0 means waiting without timeout.
+type ShutdownConfig struct {
+	Order ShutdownOrder
+	// SubscriptionTimeout is how long to wait for the subscriptions to return after unsubscribing them.
+	SubscriptionTimeout time.Duration
+	// SendTimeout is how long to wait for the sends in flight to get their response.
+	SendTimeout time.Duration
+}
+
+// WithShutdown makes Close shut down gracefully: new sends and subscriptions fail with ErrClientClosed,
+// the running subscriptions are stopped and the sends in flight are drained in the configured order,
+// and only then are the streams and the connection closed. Without it, Close closes everything at once.
+func WithShutdown(cfg ShutdownConfig) ClientOption {
+	return func(c *Client) {
+		c.shutdown = &cfg
+	}
+}
+
+// shutdownState tracks the sends in flight and the lifecycle of the client.
+type shutdownState struct {
+	mu        sync.Mutex
+	lifecycle ClientState
+	// closed is closed once the client is, made when it starts closing
+	closed  chan struct{}
+	sends   sync.WaitGroup
+	drained chan struct{}
+}
+
+// beginSend registers a send in flight, failing if the client is closing or the send quota is
+// exhausted. endSend must be called when the send is done.
+func (c *Client) beginSend() error {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	if c.state.lifecycle >= StateClosing {
+		return ErrClientClosed
+	}
+	if err := c.quota.allowSend(); err != nil {
+		return err
+	}
+
+	c.state.sends.Add(1)
+	return nil
+}
+
+func (c *Client) endSend() {
+	c.state.sends.Done()
+}
+
+func (c *Client) isClosing() bool {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	return c.state.lifecycle >= StateClosing
+}
+
+// gracefulShutdown runs the shutdown phases in the configured order, and returns the errors of the
+// phases that timed out.
+func (c *Client) gracefulShutdown() error {
+	// Already StateClosing when called by Close
+	c.state.mu.Lock()
+	if c.state.lifecycle < StateClosing {
+		c.state.lifecycle = StateClosing
+	}
+	c.state.mu.Unlock()
+
+	phases := []func() error{c.stopSubscriptions, c.drainSends}
+	if c.shutdown.Order == SendsFirst {
+		phases[0], phases[1] = phases[1], phases[0]
+	}
+
+	var err error
+	for _, phase := range phases {
+		if phaseErr := phase(); phaseErr != nil {
+			if err == nil {
+				err = phaseErr
+			} else {
+				err = fmt.Errorf("%w; %v", err, phaseErr)
+			}
+		}
+	}
+
+	return err
+}
+
+func (c *Client) stopSubscriptions() error {
+	c.subsMu.Lock()
+	subs := make([]*Subscription, 0, len(c.subs))
+	for sub := range c.subs {
+		subs = append(subs, sub)
+	}
+
+	done := make(chan struct{})
+	if len(c.subs) == 0 {
+		close(done)
+	} else {
+		c.state.drained = done
+	}
+	c.subsMu.Unlock()
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+
+	if !wait(done, c.shutdown.SubscriptionTimeout) {
+		return fmt.Errorf("stopping subscriptions: %w", ErrShutdownTimeout)
+	}
+	return nil
+}
+
+func (c *Client) drainSends() error {
+	done := make(chan struct{})
+	go func() {
+		c.state.sends.Wait()
+		close(done)
+	}()
+
+	if !wait(done, c.shutdown.SendTimeout) {
+		return fmt.Errorf("draining sends: %w", ErrShutdownTimeout)
+	}
+	return nil
+}
+
+// wait waits for done to be closed, at most timeout if it's not 0, and returns whether it was.
+func wait(done <-chan struct{}, timeout time.Duration) bool {
+	if timeout == 0 {
+		<-done
+		return true
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -0,0 +1,123 @@
+package client
+
+import (
+	"hash/maphash"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultSimilarityWindow is the number of transactions a SimilarityDetector remembers by default.
+const DefaultSimilarityWindow = 1 << 14
+
+// DefaultSimilarityMinInput is the calldata length under which transactions are never flagged by
+// default: a method ID and one argument, which plain transfers and approvals easily share.
+const DefaultSimilarityMinInput = 4 + 32
+
+// Similarity describes the recent transactions with the same calldata as a transaction, from other
+// senders. It commonly indicates copy-trading or competing MEV bots.
+type Similarity struct {
+	// First is the first transaction seen with the calldata.
+	First     common.Hash
+	FirstFrom common.Address
+	// Senders is the number of distinct senders seen with the calldata, including this one.
+	Senders int
+}
+
+// SimilarityDetector flags transactions with the same calldata as a recent one from another sender.
+// It's safe for concurrent use, and can be shared between subscriptions.
+type SimilarityDetector struct {
+	seed     maphash.Seed
+	minInput int
+
+	mu   sync.Mutex
+	max  int
+	cur  map[uint64]*calldataEntry
+	prev map[uint64]*calldataEntry
+}
+
+type calldataEntry struct {
+	first     common.Hash
+	firstFrom common.Address
+	senders   map[common.Address]struct{}
+}
+
+// NewSimilarityDetector returns a detector remembering the calldata of the last window transactions
+// (at least), 0 for DefaultSimilarityWindow, ignoring the ones with less than minInput bytes of calldata.
+func NewSimilarityDetector(window, minInput int) *SimilarityDetector {
+	if window <= 0 {
+		window = DefaultSimilarityWindow
+	}
+
+	return &SimilarityDetector{
+		seed:     maphash.MakeSeed(),
+		minInput: minInput,
+		max:      window,
+		cur:      make(map[uint64]*calldataEntry),
+	}
+}
+
+// WithSimilarityDetector sets the Similar field of the streamed transactions using d.
+func WithSimilarityDetector(d *SimilarityDetector) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.similarity = d
+	}
+}
+
+// Observe records tx, and returns its similarity to the recent transactions, nil if no other sender
+// used the same calldata.
+func (d *SimilarityDetector) Observe(tx *Transaction) *Similarity {
+	if len(tx.Input) < d.minInput {
+		return nil
+	}
+
+	var h maphash.Hash
+	h.SetSeed(d.seed)
+	h.Write(tx.Input)
+	key := h.Sum64()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.cur[key]
+	if !ok {
+		if entry, ok = d.prev[key]; ok {
+			// Keep recurring calldata in the current generation
+			d.insert(key, entry)
+		}
+	}
+
+	if !ok {
+		d.insert(key, &calldataEntry{
+			first:     tx.Hash,
+			firstFrom: tx.From,
+			senders:   map[common.Address]struct{}{tx.From: {}},
+		})
+		return nil
+	}
+
+	if entry.first == tx.Hash {
+		return nil
+	}
+
+	entry.senders[tx.From] = struct{}{}
+	if len(entry.senders) < 2 {
+		// Same sender, e.g. a replacement
+		return nil
+	}
+
+	return &Similarity{
+		First:     entry.first,
+		FirstFrom: entry.firstFrom,
+		Senders:   len(entry.senders),
+	}
+}
+
+func (d *SimilarityDetector) insert(key uint64, entry *calldataEntry) {
+	if len(d.cur) >= d.max {
+		d.prev = d.cur
+		d.cur = make(map[uint64]*calldataEntry, d.max)
+	}
+
+	d.cur[key] = entry
+}
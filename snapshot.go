@@ -0,0 +1,130 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Snapshot is the state of a client and all its streams at one point in time, for bug reports and
+// incident captures. It serializes to JSON.
+type Snapshot struct {
+	Taken       time.Time `json:"taken"`
+	Target      string    `json:"target"`
+	State       string    `json:"state"`
+	ConnectedAt time.Time `json:"connected_at"`
+
+	Subscriptions []StreamSnapshot   `json:"subscriptions"`
+	SendStreams   []SendStreamStatus `json:"send_streams"`
+	Sequences     SequenceStats      `json:"sequences"`
+
+	// ReconnectTokens is the number of reconnects the reconnect limiter allows right now, nil
+	// without a limiter.
+	ReconnectTokens *float64 `json:"reconnect_tokens,omitempty"`
+}
+
+// StreamSnapshot is the state of a running subscription.
+type StreamSnapshot struct {
+	SubscriptionStats
+	LastMessage time.Time `json:"last_message"`
+	// LastError is the error that ended the last failed session on the handle.
+	LastError string `json:"last_error,omitempty"`
+	// Sessions is the number of times the stream was established on the handle, within retention.
+	Sessions int `json:"sessions"`
+}
+
+// SendStreamStatus is the state of a send stream.
+type SendStreamStatus struct {
+	Name string `json:"name"`
+	Open bool   `json:"open"`
+}
+
+// track registers a running subscription for Snapshot.
+func (c *Client) track(sub *Subscription) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	if c.subs == nil {
+		c.subs = make(map[*Subscription]struct{})
+	}
+	c.subs[sub] = struct{}{}
+}
+
+func (c *Client) untrack(sub *Subscription) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+
+	delete(c.subs, sub)
+	if len(c.subs) == 0 && c.state.drained != nil {
+		close(c.state.drained)
+		c.state.drained = nil
+	}
+}
+
+// Snapshot captures the state of the client and its running subscriptions. All the subscription
+// handles are locked together, so the streams are captured at the same instant.
+func (c *Client) Snapshot() *Snapshot {
+	c.subsMu.Lock()
+	subs := make([]*Subscription, 0, len(c.subs))
+	for sub := range c.subs {
+		sub.mu.Lock()
+		subs = append(subs, sub)
+	}
+
+	snap := &Snapshot{
+		Taken:       time.Now(),
+		Target:      c.target,
+		ConnectedAt: c.connectedAt,
+	}
+
+	for _, sub := range subs {
+		stream := StreamSnapshot{SubscriptionStats: sub.statsLocked()}
+		if sub.stats != nil {
+			stream.LastMessage = sub.stats.lastMessage
+			stream.Sessions = len(sub.stats.sessions)
+			if err := sub.stats.lastError(); err != nil {
+				stream.LastError = err.Error()
+			}
+		}
+
+		snap.Subscriptions = append(snap.Subscriptions, stream)
+	}
+
+	for _, sub := range subs {
+		sub.mu.Unlock()
+	}
+	c.subsMu.Unlock()
+
+	if c.conn != nil {
+		snap.State = c.conn.GetState().String()
+	}
+
+	c.gzMu.Lock()
+	snap.SendStreams = []SendStreamStatus{
+		{Name: "send_tx", Open: c.txStream != nil},
+		{Name: "send_raw_tx", Open: c.rawTxStream != nil},
+		{Name: "send_tx_sequence", Open: c.txSeqStream != nil},
+		{Name: "send_raw_tx_sequence", Open: c.rawTxSeqStream != nil},
+		{Name: "gz_send_tx", Open: c.gzTxStream != nil},
+		{Name: "gz_send_raw_tx", Open: c.gzRawTxStream != nil},
+		{Name: "gz_send_tx_sequence", Open: c.gzTxSeqStream != nil},
+		{Name: "gz_send_raw_tx_sequence", Open: c.gzRawTxSeqStream != nil},
+	}
+	c.gzMu.Unlock()
+
+	snap.Sequences = c.SequenceStats()
+
+	if c.reconnectLimiter != nil {
+		tokens := c.reconnectLimiter.Tokens()
+		snap.ReconnectTokens = &tokens
+	}
+
+	return snap
+}
+
+// WriteJSON writes the snapshot as indented JSON.
+func (s *Snapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/chainbound/fiber-go/spool"
+	"google.golang.org/protobuf/proto"
+)
+
+// WithDiskBuffer puts a disk-backed queue in dir between the stream and the delivery to the consumer.
+// Messages are written to disk as soon as they are received, and delivered in order when the consumer
+// reads them, so hours of execution payloads can be captured while a downstream system is unavailable.
+// At most maxBytes (0 means no limit) are kept on disk: messages that don't fit anymore are dropped.
+// Messages left on disk when the subscription ends are delivered first by the next subscription
+// using the same directory.
+func WithDiskBuffer(dir string, maxBytes int64) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.spoolDir = dir
+		cfg.spoolMaxBytes = maxBytes
+	}
+}
+
+// spoolRecv returns a receive function that reads from a disk queue, which is fed from recv by a
+// separate goroutine. The returned function closes the queue.
+func spoolRecv[P any](ctx context.Context, cfg *subscriptionConfig, recv func() (P, error)) (func() (P, error), func(), error) {
+	q, err := spool.Open(cfg.spoolDir, cfg.spoolMaxBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening disk buffer: %w", err)
+	}
+
+	sub := cfg.handle
+	sub.mu.Lock()
+	sub.spooled = q.Len
+	sub.mu.Unlock()
+
+	// streamDone is cancelled when the stream fails, after which the queue is drained and
+	// the stream error is returned.
+	streamDone, cancelStream := context.WithCancel(ctx)
+	var streamErr error
+
+	go func() {
+		defer cancelStream()
+
+		for {
+			msg, err := recv()
+			if err != nil {
+				streamErr = err
+				return
+			}
+
+			record, err := proto.Marshal(any(msg).(proto.Message))
+			if err != nil {
+				streamErr = fmt.Errorf("encoding message for disk buffer: %w", err)
+				return
+			}
+
+			if err := q.Push(record); errors.Is(err, spool.ErrFull) {
+				sub.dropped(1)
+			} else if err != nil {
+				streamErr = err
+				return
+			}
+		}
+	}()
+
+	msgType := reflect.TypeOf((*P)(nil)).Elem().Elem()
+
+	next := func() (P, error) {
+		var zero P
+
+		record, err := q.Pop(streamDone)
+		if err != nil {
+			if ctx.Err() != nil {
+				return zero, ctx.Err()
+			}
+
+			// streamDone is only cancelled after streamErr is set
+			if errors.Is(err, context.Canceled) {
+				return zero, streamErr
+			}
+
+			return zero, err
+		}
+
+		msg := reflect.New(msgType).Interface().(P)
+		if err := proto.Unmarshal(record, any(msg).(proto.Message)); err != nil {
+			return zero, fmt.Errorf("decoding message from disk buffer: %w", err)
+		}
+
+		return msg, nil
+	}
+
+	return next, func() {
+		q.Close()
+
+		sub.mu.Lock()
+		sub.spooled = nil
+		sub.mu.Unlock()
+	}, nil
+}
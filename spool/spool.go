@@ -0,0 +1,366 @@
+// Package spool implements a disk-backed FIFO queue of byte records, used to buffer streams
+// on disk while their consumer is unavailable.
+//
+// Records are appended to numbered segment files in a directory. Segments are deleted once they
+// have been read completely. Segments left behind by a previous process are recovered when the
+// queue is opened and read first, so records are delivered at least once. A record torn by a crash
+// at the end of a segment is discarded.
+package spool
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const segmentSuffix = ".seg"
+
+// DefaultSegmentSize is the size after which a new segment file is started.
+const DefaultSegmentSize = 64 << 20
+
+var (
+	// ErrFull is returned by Push when the record doesn't fit in the size cap.
+	ErrFull = errors.New("spool: queue full")
+	// ErrClosed is returned when using a closed queue.
+	ErrClosed = errors.New("spool: queue closed")
+)
+
+type segment struct {
+	id   uint64
+	size int64
+}
+
+// Queue is a disk-backed FIFO queue. It is safe for concurrent use by one writer and one reader.
+type Queue struct {
+	dir         string
+	maxBytes    int64
+	segmentSize int64
+
+	mu       sync.Mutex
+	segments []segment // oldest first, the last one is being written
+	writer   *os.File
+	reader   *os.File
+	readOff  int64
+	size     int64 // unread bytes
+	records  int
+	closed   bool
+	notify   chan struct{}
+}
+
+// Open opens (or creates) the queue in dir, which will hold at most maxBytes of unread records.
+// A maxBytes of 0 means no limit.
+func Open(dir string, maxBytes int64) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("spool: creating directory: %w", err)
+	}
+
+	q := &Queue{
+		dir:         dir,
+		maxBytes:    maxBytes,
+		segmentSize: DefaultSegmentSize,
+		notify:      make(chan struct{}, 1),
+	}
+
+	if maxBytes > 0 && maxBytes/4 < q.segmentSize {
+		q.segmentSize = maxBytes / 4
+	}
+
+	if err := q.recover(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+func (q *Queue) path(id uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", id, segmentSuffix))
+}
+
+// recover picks up the segments of a previous process.
+func (q *Queue) recover() error {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("spool: reading directory: %w", err)
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		q.segments = append(q.segments, segment{id: id})
+	}
+
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].id < q.segments[j].id })
+
+	// Count the recovered records
+	for i := range q.segments {
+		seg := &q.segments[i]
+		n, size, err := countRecords(q.path(seg.id))
+		if err != nil {
+			return err
+		}
+
+		seg.size = size
+		q.size += size
+		q.records += n
+	}
+
+	// Always write to a fresh segment
+	var next uint64
+	if len(q.segments) > 0 {
+		next = q.segments[len(q.segments)-1].id + 1
+	}
+
+	return q.startSegment(next)
+}
+
+// countRecords returns the number of complete records of the segment at path and their size, and
+// truncates the segment to them if it ends with a torn record.
+func countRecords(path string) (int, int64, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, 0, fmt.Errorf("spool: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, fmt.Errorf("spool: %w", err)
+	}
+
+	var n int
+	var off int64
+	var hdr [4]byte
+	for off < info.Size() {
+		var end int64
+		if off+4 <= info.Size() {
+			if _, err := f.ReadAt(hdr[:], off); err != nil {
+				return 0, 0, fmt.Errorf("spool: reading record: %w", err)
+			}
+			end = off + 4 + int64(binary.BigEndian.Uint32(hdr[:]))
+		}
+
+		if end == 0 || end > info.Size() {
+			if err := f.Truncate(off); err != nil {
+				return 0, 0, fmt.Errorf("spool: truncating torn record: %w", err)
+			}
+			break
+		}
+
+		off = end
+		n++
+	}
+
+	return n, off, nil
+}
+
+func (q *Queue) startSegment(id uint64) error {
+	f, err := os.OpenFile(q.path(id), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("spool: creating segment: %w", err)
+	}
+
+	if q.writer != nil {
+		q.writer.Close()
+	}
+
+	q.writer = f
+	q.segments = append(q.segments, segment{id: id})
+	return nil
+}
+
+// Push appends a record to the queue.
+func (q *Queue) Push(record []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return ErrClosed
+	}
+
+	n := int64(len(record) + 4)
+	if q.maxBytes > 0 && q.size+n > q.maxBytes {
+		return ErrFull
+	}
+
+	current := &q.segments[len(q.segments)-1]
+	if current.size > 0 && current.size+n > q.segmentSize {
+		if err := q.startSegment(current.id + 1); err != nil {
+			return err
+		}
+		current = &q.segments[len(q.segments)-1]
+	}
+
+	buf := make([]byte, n)
+	binary.BigEndian.PutUint32(buf, uint32(len(record)))
+	copy(buf[4:], record)
+
+	if _, err := q.writer.Write(buf); err != nil {
+		return fmt.Errorf("spool: writing record: %w", err)
+	}
+
+	current.size += n
+	q.size += n
+	q.records++
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Pop removes the oldest record from the queue, waiting for one if it is empty.
+func (q *Queue) Pop(ctx context.Context) ([]byte, error) {
+	for {
+		record, err := q.tryPop()
+		if err != nil || record != nil {
+			return record, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-q.notify:
+		}
+	}
+}
+
+func (q *Queue) tryPop() ([]byte, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrClosed
+	}
+
+	for q.records > 0 {
+		seg := q.segments[0]
+		if q.reader == nil {
+			f, err := os.Open(q.path(seg.id))
+			if err != nil {
+				return nil, fmt.Errorf("spool: opening segment: %w", err)
+			}
+			q.reader = f
+			q.readOff = 0
+		}
+
+		// Done with this segment: delete it, unless it's the one being written
+		if q.readOff >= seg.size {
+			if len(q.segments) == 1 {
+				return nil, nil
+			}
+
+			q.reader.Close()
+			q.reader = nil
+			os.Remove(q.path(seg.id))
+			q.segments = q.segments[1:]
+			continue
+		}
+
+		var hdr [4]byte
+		if _, err := q.reader.ReadAt(hdr[:], q.readOff); err != nil {
+			return nil, fmt.Errorf("spool: reading record: %w", err)
+		}
+
+		record := make([]byte, binary.BigEndian.Uint32(hdr[:]))
+		if _, err := q.reader.ReadAt(record, q.readOff+4); err != nil {
+			return nil, fmt.Errorf("spool: reading record: %w", err)
+		}
+
+		n := int64(len(record) + 4)
+		q.readOff += n
+		q.size -= n
+		q.records--
+		return record, nil
+	}
+
+	return nil, nil
+}
+
+// Len returns the number of unread records.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.records
+}
+
+// Size returns the number of unread bytes on disk.
+func (q *Queue) Size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.size
+}
+
+// Close closes the queue. Unread records stay on disk and are recovered by the next Open.
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+
+	if q.reader != nil {
+		q.reader.Close()
+	}
+
+	// Remove the write segment if it's empty, to not leave empty files behind.
+	current := q.segments[len(q.segments)-1]
+	err := q.writer.Close()
+	if current.size == 0 {
+		os.Remove(q.path(current.id))
+	}
+
+	// A partially read segment can't be resumed at its offset, drop the read records from it.
+	if q.readOff > 0 && len(q.segments) > 0 {
+		if rerr := q.rewriteHead(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return err
+}
+
+// rewriteHead drops the records already read from the oldest segment.
+func (q *Queue) rewriteHead() error {
+	path := q.path(q.segments[0].id)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+
+	if q.readOff >= int64(len(data)) {
+		return os.Remove(path)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data[q.readOff:], 0o644); err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+
+	return os.Rename(tmp, path)
+}
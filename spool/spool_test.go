@@ -0,0 +1,141 @@
+package spool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQueue(t *testing.T) {
+	q, err := Open(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	// Small segments, to exercise rotation
+	q.segmentSize = 64
+
+	for i := 0; i < 20; i++ {
+		if err := q.Push([]byte(fmt.Sprintf("record %d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if q.Len() != 20 {
+		t.Fatalf("expected 20 records, got %d", q.Len())
+	}
+
+	for i := 0; i < 20; i++ {
+		record, err := q.Pop(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(record) != fmt.Sprintf("record %d", i) {
+			t.Fatalf("expected record %d, got %q", i, record)
+		}
+	}
+
+	if q.Size() != 0 || len(q.segments) != 1 {
+		t.Fatalf("expected a single empty segment, got size %d and %d segments", q.Size(), len(q.segments))
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	q, err := Open(t.TempDir(), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if err := q.Push(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := q.Push(make([]byte, 10)); !errors.Is(err, ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+}
+
+func TestQueueRecover(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		q.Push([]byte{byte(i)})
+	}
+
+	if _, err := q.Pop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	q.Close()
+
+	q, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if q.Len() != 2 {
+		t.Fatalf("expected 2 recovered records, got %d", q.Len())
+	}
+
+	for i := 1; i < 3; i++ {
+		record, err := q.Pop(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record[0] != byte(i) {
+			t.Fatalf("expected record %d, got %d", i, record[0])
+		}
+	}
+}
+
+func TestQueueTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	q, err := Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q.Push([]byte("first"))
+	q.Push([]byte("second"))
+	q.Close()
+
+	// Crash in the middle of writing the second record
+	path := filepath.Join(dir, fmt.Sprintf("%020d%s", 0, segmentSuffix))
+	if err := os.Truncate(path, 4+5+4+3); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Len() != 1 {
+		t.Fatalf("expected 1 recovered record, got %d", q.Len())
+	}
+	if record, err := q.Pop(context.Background()); err != nil || string(record) != "first" {
+		t.Fatalf("expected the first record, got %q (%v)", record, err)
+	}
+	q.Push([]byte("third"))
+	q.Close()
+
+	// The torn record is gone for good
+	q, err = Open(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	if record, err := q.Pop(context.Background()); err != nil || string(record) != "third" {
+		t.Fatalf("expected the third record, got %q (%v)", record, err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package ssz implements the SimpleSerialize merkleization primitives needed to compute the
+// hash tree roots of consensus layer containers.
+// See https://github.com/ethereum/consensus-specs/blob/dev/ssz/simple-serialize.md
+package ssz
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// Root is a 32 byte merkle root (or chunk).
+type Root = [32]byte
+
+const maxDepth = 64
+
+var zeroHashes [maxDepth + 1]Root
+
+func init() {
+	for i := 1; i <= maxDepth; i++ {
+		zeroHashes[i] = hash(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+func hash(a, b Root) Root {
+	var buf [64]byte
+	copy(buf[:32], a[:])
+	copy(buf[32:], b[:])
+	return sha256.Sum256(buf[:])
+}
+
+// depth returns the depth of a tree with room for n chunks.
+func depth(n int) int {
+	if n <= 1 {
+		return 0
+	}
+
+	return bits.Len(uint(n - 1))
+}
+
+// Merkleize returns the root of the binary merkle tree of chunks, padded with zero chunks up to limit
+// chunks (rounded up to a power of two). A limit smaller than len(chunks) means no limit.
+func Merkleize(chunks []Root, limit int) Root {
+	if limit < len(chunks) {
+		limit = len(chunks)
+	}
+
+	d := depth(limit)
+	if len(chunks) == 0 {
+		return zeroHashes[d]
+	}
+
+	layer := make([]Root, len(chunks))
+	copy(layer, chunks)
+
+	for i := 0; i < d; i++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[i])
+		}
+
+		next := layer[:len(layer)/2]
+		for j := range next {
+			next[j] = hash(layer[2*j], layer[2*j+1])
+		}
+		layer = next
+	}
+
+	return layer[0]
+}
+
+// MixInLength mixes the length of a list into the root of its contents.
+func MixInLength(root Root, length uint64) Root {
+	var l Root
+	binary.LittleEndian.PutUint64(l[:8], length)
+	return hash(root, l)
+}
+
+// Pack splits b into right-padded 32 byte chunks.
+func Pack(b []byte) []Root {
+	chunks := make([]Root, (len(b)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], b[i*32:])
+	}
+
+	return chunks
+}
+
+// Container returns the root of a container with the given field roots.
+func Container(fields ...Root) Root {
+	return Merkleize(fields, len(fields))
+}
+
+// Uint64 returns the root of a uint64.
+func Uint64(v uint64) Root {
+	var r Root
+	binary.LittleEndian.PutUint64(r[:8], v)
+	return r
+}
+
+// Uint256 returns the root of a uint256, given in big endian as used by go-ethereum.
+func Uint256(bigEndian []byte) Root {
+	var r Root
+	for i := 0; i < len(bigEndian) && i < 32; i++ {
+		r[i] = bigEndian[len(bigEndian)-1-i]
+	}
+
+	return r
+}
+
+// ByteVector returns the root of a fixed size byte vector of n bytes. Shorter inputs are zero-padded.
+func ByteVector(b []byte, n int) Root {
+	buf := make([]byte, n)
+	copy(buf, b)
+	return Merkleize(Pack(buf), (n+31)/32)
+}
+
+// ByteList returns the root of a byte list with a maximum length of max bytes.
+func ByteList(b []byte, max int) Root {
+	return MixInLength(Merkleize(Pack(b), (max+31)/32), uint64(len(b)))
+}
+
+// Uint64List returns the root of a list of uint64 with a maximum length of max.
+func Uint64List(vs []uint64, max int) Root {
+	buf := make([]byte, 8*len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint64(buf[8*i:], v)
+	}
+
+	return MixInLength(Merkleize(Pack(buf), (max*8+31)/32), uint64(len(vs)))
+}
+
+// Vector returns the root of a fixed size vector of n composite elements with the given roots.
+func Vector(roots []Root, n int) Root {
+	return Merkleize(roots, n)
+}
+
+// List returns the root of a list of composite elements with the given roots and a maximum length of max.
+func List(roots []Root, max int) Root {
+	return MixInLength(Merkleize(roots, max), uint64(len(roots)))
+}
+
+// Bitvector returns the root of a bitvector of n bits.
+func Bitvector(b []byte, n int) Root {
+	return ByteVector(b, (n+7)/8)
+}
+
+// ErrInvalidBitlist is returned for bitlists without the trailing delimiter bit.
+var ErrInvalidBitlist = errors.New("ssz: bitlist missing delimiter bit")
+
+// Bitlist returns the root of an SSZ encoded bitlist (with its delimiter bit) with a maximum of max bits.
+func Bitlist(b []byte, max int) (Root, error) {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return Root{}, ErrInvalidBitlist
+	}
+
+	last := b[len(b)-1]
+	msb := bits.Len8(last) - 1
+	length := (len(b)-1)*8 + msb
+
+	// Strip the delimiter
+	buf := make([]byte, len(b))
+	copy(buf, b)
+	buf[len(buf)-1] &^= 1 << msb
+
+	chunks := Pack(buf)
+	// Trailing zero chunks don't change the root, but they may exceed the limit when the delimiter
+	// was the only bit in its chunk.
+	for len(chunks) > (length+255)/256 {
+		chunks = chunks[:len(chunks)-1]
+	}
+
+	return MixInLength(Merkleize(chunks, (max+255)/256), uint64(length)), nil
+}
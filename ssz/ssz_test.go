@@ -0,0 +1,58 @@
+package ssz
+
+import (
+	"testing"
+)
+
+func TestMerkleize(t *testing.T) {
+	if Merkleize(nil, 8) != zeroHashes[3] {
+		t.Fatal("empty tree with 8 leaves should be the depth 3 zero hash")
+	}
+
+	a, b := Uint64(1), Uint64(2)
+	if Merkleize([]Root{a, b}, 2) != hash(a, b) {
+		t.Fatal("two leaf tree mismatch")
+	}
+
+	if Merkleize([]Root{a, b, a}, 4) != hash(hash(a, b), hash(a, zeroHashes[0])) {
+		t.Fatal("odd leaf tree should be padded with zero chunks")
+	}
+
+	if Merkleize([]Root{a}, 4) != hash(hash(a, zeroHashes[0]), zeroHashes[1]) {
+		t.Fatal("tree should be padded up to the limit")
+	}
+}
+
+func TestBitlist(t *testing.T) {
+	// Bits 1,0,1 followed by the delimiter
+	root, err := Bitlist([]byte{0b1101}, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var chunk Root
+	chunk[0] = 0b101
+	if expected := MixInLength(Merkleize([]Root{chunk}, 8), 3); root != expected {
+		t.Fatal("bitlist root mismatch")
+	}
+
+	// Empty bitlist: just the delimiter
+	root, err = Bitlist([]byte{0b1}, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != MixInLength(zeroHashes[3], 0) {
+		t.Fatal("empty bitlist root mismatch")
+	}
+
+	if _, err := Bitlist([]byte{0}, 2048); err != ErrInvalidBitlist {
+		t.Fatalf("expected ErrInvalidBitlist, got %v", err)
+	}
+}
+
+func TestUint256(t *testing.T) {
+	r := Uint256([]byte{0x01, 0x02})
+	if r[0] != 0x02 || r[1] != 0x01 {
+		t.Fatalf("expected little endian encoding, got %x", r[:2])
+	}
+}
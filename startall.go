@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StartSpec is a subscription to start with StartAll, see Start.
+type StartSpec struct {
+	name string
+	run  func(c *Client, opts ...SubscriptionOption) error
+}
+
+// Start returns the spec of a subscription to src delivering on ch, named name in the errors of StartAll.
+func Start[T any](name string, src Source[T], ch chan<- T, opts ...SubscriptionOption) StartSpec {
+	return StartSpec{
+		name: name,
+		run: func(c *Client, extra ...SubscriptionOption) error {
+			return src(c, ch, append(opts[:len(opts):len(opts)], extra...)...)
+		},
+	}
+}
+
+// StartError is returned by StartAll when some of the subscriptions failed before they were live.
+type StartError struct {
+	// Failed are the errors of the subscriptions that failed, by name.
+	Failed map[string]error
+}
+
+func (e *StartError) Error() string {
+	names := make([]string, 0, len(e.Failed))
+	for name := range e.Failed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failures := make([]string, len(names))
+	for i, name := range names {
+		failures[i] = fmt.Sprintf("%s: %v", name, e.Failed[name])
+	}
+
+	return "starting subscriptions: " + strings.Join(failures, "; ")
+}
+
+const (
+	specStarting = iota
+	specLive
+	specFailed
+)
+
+// StartGroup is a group of subscriptions started together by StartAll.
+type StartGroup struct {
+	names  []string
+	cancel context.CancelFunc
+
+	mu       sync.Mutex
+	states   []int
+	starting int
+	failed   map[string]error
+	errs     []error
+
+	// settled is closed once every subscription is live or failed, ready once they're all live.
+	settled chan struct{}
+	ready   chan struct{}
+	wg      sync.WaitGroup
+}
+
+// StartAll starts the subscriptions of specs in parallel, instead of one after the other, and blocks
+// until they're all live, i.e. their streams are open. The subscriptions run until ctx is done or Stop
+// is called. If some fail before they're live, StartAll returns a *StartError with their errors once
+// the others are live: those keep running, call Stop to tear them down as well.
+func (c *Client) StartAll(ctx context.Context, specs ...StartSpec) (*StartGroup, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &StartGroup{
+		names:    make([]string, len(specs)),
+		cancel:   cancel,
+		states:   make([]int, len(specs)),
+		starting: len(specs),
+		errs:     make([]error, len(specs)),
+		settled:  make(chan struct{}),
+		ready:    make(chan struct{}),
+	}
+
+	if len(specs) == 0 {
+		close(g.settled)
+		close(g.ready)
+		return g, nil
+	}
+
+	for i, spec := range specs {
+		g.names[i] = spec.name
+		g.wg.Add(1)
+		go func(i int, spec StartSpec) {
+			defer g.wg.Done()
+			g.returned(i, spec.run(c, withContext(ctx), withOnLive(func() { g.live(i) })))
+		}(i, spec)
+	}
+
+	<-g.settled
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.failed) > 0 {
+		return g, &StartError{Failed: g.failed}
+	}
+
+	return g, nil
+}
+
+// live records that the subscription i is live.
+func (g *StartGroup) live(i int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.states[i] == specStarting {
+		g.states[i] = specLive
+		g.settle()
+	}
+}
+
+// returned records that the subscription i returned err.
+func (g *StartGroup) returned(i int, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.errs[i] = err
+	if g.states[i] != specStarting {
+		return
+	}
+
+	// A subscription returning before it was live failed to start, even without an error
+	if err == nil {
+		err = errors.New("subscription ended before it was live")
+	}
+
+	g.states[i] = specFailed
+	if g.failed == nil {
+		g.failed = make(map[string]error)
+	}
+	g.failed[g.names[i]] = err
+	g.settle()
+}
+
+func (g *StartGroup) settle() {
+	if g.starting--; g.starting == 0 {
+		close(g.settled)
+		if g.failed == nil {
+			close(g.ready)
+		}
+	}
+}
+
+// Ready is closed once all the subscriptions are live. It's never closed if one of them failed to start.
+func (g *StartGroup) Ready() <-chan struct{} {
+	return g.ready
+}
+
+// Stop stops the subscriptions and waits for them to return.
+func (g *StartGroup) Stop() {
+	g.cancel()
+	g.wg.Wait()
+}
+
+// Wait waits for the subscriptions to return, and returns the error of the first one that failed, in
+// the order of the specs.
+func (g *StartGroup) Wait() error {
+	g.wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, err := range g.errs {
+		if err != nil {
+			return fmt.Errorf("%s: %w", g.names[i], err)
+		}
+	}
+
+	return nil
+}
+
+// withOnLive calls fn once the subscription is live.
+func withOnLive(fn func()) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.onLive = fn
+	}
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// fakeSource is a Source of the messages, opened with fakeStream.
+func fakeSource[T any](msgs ...T) Source[T] {
+	return func(c *Client, ch chan<- T, opts ...SubscriptionOption) error {
+		return subscribe(c, "test", ch, opts, fakeStream(msgs...), identity[T])
+	}
+}
+
+func TestStartAll(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := &Client{}
+	ints := make(chan int, 1)
+	strs := make(chan string, 1)
+	g, err := c.StartAll(ctx, Start("ints", fakeSource(1), ints), Start("strs", fakeSource("a"), strs))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-g.Ready():
+	default:
+		t.Fatal("expected the group to be ready")
+	}
+	if <-ints != 1 || <-strs != "a" {
+		t.Fatal("unexpected messages")
+	}
+
+	g.Stop()
+	if err := g.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	failing := Source[int](func(c *Client, ch chan<- int, opts ...SubscriptionOption) error {
+		return subscribe(c, "test", ch, opts, func(context.Context, ...grpc.CallOption) (recvStream[int], error) {
+			return nil, errors.New("unavailable")
+		}, identity[int])
+	})
+	g, err = c.StartAll(ctx, Start("ints", fakeSource(1), make(chan int, 1)), Start("failing", failing, make(chan int)))
+	var startErr *StartError
+	if !errors.As(err, &startErr) || len(startErr.Failed) != 1 || startErr.Failed["failing"] == nil {
+		t.Fatalf("expected the failing subscription to be reported, got %v", err)
+	}
+	select {
+	case <-g.Ready():
+		t.Fatal("expected the group not to be ready")
+	default:
+	}
+	g.Stop()
+}
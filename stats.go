@@ -0,0 +1,185 @@
+package client
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultStatsRetention is for how long a subscription handle keeps statistics by default.
+const DefaultStatsRetention = 30 * 24 * time.Hour
+
+const (
+	// Number of time buckets statistics are aggregated in, regardless of retention.
+	statsBuckets = 1440
+	// Latency histogram: bucket i holds latencies up to 2^(i/4) ms.
+	latencyBuckets   = 64
+	latencyPerDouble = 4
+	// Maximum number of gaps kept.
+	maxGaps = 1024
+)
+
+// sequenced is implemented by messages that carry a monotonically increasing position with a
+// wall clock time, which is used to estimate message loss and latency.
+type sequenced interface {
+	position() (uint64, time.Time)
+}
+
+func (h *ExecutionPayloadHeader) position() (uint64, time.Time) {
+	return h.Number, time.Unix(int64(h.Timestamp), 0)
+}
+
+func (p *ExecutionPayload) position() (uint64, time.Time) {
+	return p.Header.position()
+}
+
+type session struct {
+	start, end time.Time
+	err        error
+}
+
+type statsBucket struct {
+	slot     int64
+	messages uint64
+	lost     uint64
+	latency  [latencyBuckets]uint32
+}
+
+// streamStats keeps the statistics of a subscription handle. It is not safe for concurrent use.
+type streamStats struct {
+	width    time.Duration
+	buckets  []statsBucket
+	sessions []session
+
+	lastPos uint64
+	hasPos  bool
+
+	messages    uint64
+	dropped     uint64
+	shed        uint64
+	stale       uint64
+	overflowed  uint64
+	lastMessage time.Time
+}
+
+func newStreamStats(retention time.Duration) *streamStats {
+	width := retention / statsBuckets
+	if width < time.Second {
+		width = time.Second
+	}
+
+	return &streamStats{
+		width:   width,
+		buckets: make([]statsBucket, statsBuckets),
+	}
+}
+
+func (s *streamStats) bucket(t time.Time) *statsBucket {
+	slot := t.UnixNano() / int64(s.width)
+	b := &s.buckets[slot%int64(len(s.buckets))]
+	if b.slot != slot {
+		*b = statsBucket{slot: slot}
+	}
+
+	return b
+}
+
+func (s *streamStats) retention() time.Duration {
+	return s.width * time.Duration(len(s.buckets))
+}
+
+func (s *streamStats) connected(now time.Time) {
+	s.sessions = append(s.sessions, session{start: now})
+
+	// Drop sessions that fell out of retention, but keep a bounded number of them.
+	cutoff := now.Add(-s.retention())
+	for len(s.sessions) > 1 && (len(s.sessions) > maxGaps || (!s.sessions[0].end.IsZero() && s.sessions[0].end.Before(cutoff))) {
+		s.sessions = s.sessions[1:]
+	}
+}
+
+func (s *streamStats) disconnected(now time.Time, err error) {
+	if s == nil || len(s.sessions) == 0 {
+		return
+	}
+
+	last := &s.sessions[len(s.sessions)-1]
+	if last.end.IsZero() {
+		last.end = now
+		last.err = err
+	}
+}
+
+// uptime returns for how long the sessions within retention were connected.
+func (s *streamStats) uptime(now time.Time) time.Duration {
+	var d time.Duration
+	for _, session := range s.sessions {
+		end := session.end
+		if end.IsZero() {
+			end = now
+		}
+		d += end.Sub(session.start)
+	}
+
+	return d
+}
+
+func (s *streamStats) isConnected() bool {
+	return len(s.sessions) > 0 && s.sessions[len(s.sessions)-1].end.IsZero()
+}
+
+// lastError returns the error that ended the last session that failed.
+func (s *streamStats) lastError() error {
+	for i := len(s.sessions) - 1; i >= 0; i-- {
+		if s.sessions[i].err != nil {
+			return s.sessions[i].err
+		}
+	}
+
+	return nil
+}
+
+func (s *streamStats) received(now time.Time, msg any) {
+	s.messages++
+	s.lastMessage = now
+	b := s.bucket(now)
+	b.messages++
+
+	seq, ok := msg.(sequenced)
+	if !ok {
+		return
+	}
+
+	pos, ts := seq.position()
+	if s.hasPos && pos > s.lastPos+1 {
+		b.lost += pos - s.lastPos - 1
+	}
+
+	// Reorgs can move the position backwards, only count forward gaps.
+	if !s.hasPos || pos > s.lastPos {
+		s.lastPos = pos
+		s.hasPos = true
+	}
+
+	if !ts.IsZero() {
+		b.latency[latencyBucket(now.Sub(ts))]++
+	}
+}
+
+func latencyBucket(d time.Duration) int {
+	ms := float64(d) / float64(time.Millisecond)
+	if ms <= 1 {
+		return 0
+	}
+
+	i := int(math.Ceil(math.Log2(ms) * latencyPerDouble))
+	if i >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+
+	return i
+}
+
+// latencyBucketBound returns the upper bound of histogram bucket i.
+func latencyBucketBound(i int) time.Duration {
+	return time.Duration(math.Pow(2, float64(i)/latencyPerDouble) * float64(time.Millisecond))
+}
@@ -0,0 +1,51 @@
+package client
+
+// StreamKind is a kind of Fiber stream. It's the stream of SubscriptionStats, reports and recorder
+// dumps, and the stream label of the metrics, so it converts to the same names.
+type StreamKind string
+
+const (
+	StreamTxs      StreamKind = "txs"
+	StreamHeaders  StreamKind = "execution_headers"
+	StreamPayloads StreamKind = "execution_payloads"
+	StreamBeacon   StreamKind = "beacon_blocks"
+	// StreamSends are the streams transactions are sent on.
+	StreamSends StreamKind = "sends"
+)
+
+// StreamKinds returns the kinds of the streams, subscriptions first.
+func StreamKinds() []StreamKind {
+	return []StreamKind{StreamTxs, StreamHeaders, StreamPayloads, StreamBeacon, StreamSends}
+}
+
+// ParseStreamKind returns the kind named s, for configuration files and flags.
+func ParseStreamKind(s string) (StreamKind, bool) {
+	for _, k := range StreamKinds() {
+		if string(k) == s {
+			return k, true
+		}
+	}
+
+	return "", false
+}
+
+func (k StreamKind) String() string {
+	return string(k)
+}
+
+// Class returns the class of the messages of the stream, which drop policies are configured by. It's
+// false for the send streams.
+func (k StreamKind) Class() (MessageClass, bool) {
+	switch k {
+	case StreamTxs:
+		return Transactions, true
+	case StreamHeaders:
+		return ExecutionHeaders, true
+	case StreamPayloads:
+		return ExecutionPayloads, true
+	case StreamBeacon:
+		return BeaconBlocks, true
+	}
+
+	return 0, false
+}
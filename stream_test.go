@@ -0,0 +1,27 @@
+package client
+
+import "testing"
+
+func TestStreamKind(t *testing.T) {
+	classes := map[StreamKind]MessageClass{
+		StreamTxs:      Transactions,
+		StreamHeaders:  ExecutionHeaders,
+		StreamPayloads: ExecutionPayloads,
+		StreamBeacon:   BeaconBlocks,
+	}
+
+	for _, k := range StreamKinds() {
+		if parsed, ok := ParseStreamKind(k.String()); !ok || parsed != k {
+			t.Fatalf("%s: parsed as %q", k, parsed)
+		}
+
+		want, subscribed := classes[k]
+		if class, ok := k.Class(); ok != subscribed || class != want {
+			t.Fatalf("%s: unexpected class %s", k, class)
+		}
+	}
+
+	if _, ok := ParseStreamKind("blocks"); ok {
+		t.Fatal("expected an unknown stream")
+	}
+}
@@ -0,0 +1,1003 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chainbound/fiber-go/backoff"
+	"github.com/chainbound/fiber-go/labels"
+	"github.com/chainbound/fiber-go/metadata"
+	"github.com/chainbound/fiber-go/tokens"
+	"google.golang.org/grpc"
+)
+
+// Subscription is a handle to a subscription. The zero value is ready to use: pass it to any of the
+// Subscribe methods with WithHandle to observe (and later control) the subscription from another goroutine.
+// A handle can be reused when re-subscribing after an error, in which case its statistics
+// span all of the subscriptions and the time in between counts as a gap.
+type Subscription struct {
+	mu     sync.Mutex
+	stream StreamKind
+	stats  *streamStats
+	cancel context.CancelFunc
+
+	paused  bool
+	resumed chan struct{}
+	// pausing is closed by Pause, for a delivery blocked on a full pause buffer, see pauseSignal.
+	pausing chan struct{}
+	queued  func() int
+	spooled func() int
+	// peakQueued is the peak of the delivery buffer of the running subscription, and buffer its size.
+	peakQueued func() int
+	buffer     int
+	profile    *profiler
+
+	header  metadata.MD
+	trailer metadata.MD
+	// metadataGen counts the streams the metadata was captured from, so that the header of a previous
+	// stream arriving late doesn't overwrite the one of the current stream.
+	metadataGen uint64
+
+	cancelStream   context.CancelFunc
+	forceReconnect bool
+	// filter is the filter of the running transaction subscription, nil for other streams.
+	filter *liveFilter
+
+	onDrop func(n uint64)
+
+	recorder *flightRecorder
+}
+
+// SubscriptionStats is a snapshot of the counters of a subscription handle.
+type SubscriptionStats struct {
+	Stream StreamKind `json:"stream"`
+	// Connected is true while a subscription is running on the handle.
+	Connected bool `json:"connected"`
+	Paused    bool `json:"paused"`
+	// Messages is the number of messages received from the server.
+	Messages uint64 `json:"messages"`
+	// Dropped is the number of messages that were received but never delivered to the consumer.
+	Dropped uint64 `json:"dropped"`
+	// Shed is the number of dropped messages that were shed by the drop policy (see WithDropPolicy).
+	Shed uint64 `json:"shed"`
+	// Stale is the number of dropped messages that were too old to be delivered (see WithMaxAge).
+	Stale uint64 `json:"stale"`
+	// Overflowed is the number of dropped messages that didn't fit in the delivery buffer (see
+	// WithBackpressure and WithPauseBuffer).
+	Overflowed uint64 `json:"overflowed"`
+	// Queued is the number of messages waiting to be delivered, and PeakQueued the largest number
+	// that waited at once in the running subscription.
+	Queued     int `json:"queued"`
+	PeakQueued int `json:"peak_queued"`
+	// Spooled is the number of messages waiting in the disk buffer (see WithDiskBuffer).
+	Spooled int `json:"spooled"`
+	// Profile is the time spent per stage of the receive loop, if profiling is enabled (see WithProfiling).
+	Profile *StageProfile `json:"profile,omitempty"`
+}
+
+// Stats returns a snapshot of the counters of the subscription.
+func (s *Subscription) Stats() SubscriptionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.statsLocked()
+}
+
+func (s *Subscription) statsLocked() SubscriptionStats {
+	stats := SubscriptionStats{
+		Stream: s.stream,
+		Paused: s.paused,
+	}
+
+	if s.stats != nil {
+		stats.Connected = s.stats.isConnected()
+		stats.Messages = s.stats.messages
+		stats.Dropped = s.stats.dropped
+		stats.Shed = s.stats.shed
+		stats.Stale = s.stats.stale
+		stats.Overflowed = s.stats.overflowed
+	}
+
+	if s.queued != nil {
+		stats.Queued = s.queued()
+		stats.PeakQueued = s.peakQueued()
+	}
+
+	if s.spooled != nil {
+		stats.Spooled = s.spooled()
+	}
+
+	if s.profile != nil {
+		stats.Profile = s.profile.snapshot()
+	}
+
+	return stats
+}
+
+// Pause stops delivering messages to the consumer without tearing down the stream. Messages
+// received while paused are discarded, unless the subscription was started with WithPauseBuffer.
+func (s *Subscription) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.paused {
+		s.paused = true
+		s.resumed = make(chan struct{})
+		if s.pausing != nil {
+			close(s.pausing)
+		}
+	}
+}
+
+// Resume resumes delivering messages to the consumer, starting with the ones buffered
+// while paused (if any).
+func (s *Subscription) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.paused {
+		s.paused = false
+		close(s.resumed)
+		s.pausing = nil
+	}
+}
+
+// Paused returns true if the subscription is paused.
+func (s *Subscription) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.paused
+}
+
+// waitResumed blocks while the subscription is paused.
+func (s *Subscription) waitResumed(ctx context.Context) error {
+	s.mu.Lock()
+	paused, resumed := s.paused, s.resumed
+	s.mu.Unlock()
+
+	if !paused {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-resumed:
+		return nil
+	}
+}
+
+// pauseSignal returns a channel that's closed once the subscription is paused, already closed if it is.
+func (s *Subscription) pauseSignal() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pausing == nil {
+		s.pausing = make(chan struct{})
+		if s.paused {
+			close(s.pausing)
+		}
+	}
+
+	return s.pausing
+}
+
+func (s *Subscription) dropped(n uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats != nil {
+		s.stats.dropped += n
+	}
+	if s.onDrop != nil {
+		s.onDrop(n)
+	}
+}
+
+// SubscriptionOption configures a single subscription.
+type SubscriptionOption func(*subscriptionConfig)
+
+type subscriptionConfig struct {
+	handle    *Subscription
+	retention time.Duration
+
+	abandonTimeout time.Duration
+	onAbandon      func(sub *Subscription, blocked time.Duration)
+
+	labels      *labels.Registry
+	tokens      *tokens.Table
+	minUSDValue float64
+	similarity  *SimilarityDetector
+	// senderRecovery is set by WithSenderRecovery.
+	senderRecovery SenderRecovery
+	headerCache    *HeaderCache
+	enrichers      []func(ctx context.Context, msg any)
+	// values is the context whose values the subscription carries, see WithValues.
+	values context.Context
+	// parent cancels the subscription when done, for the WithContext variants of the Subscribe methods.
+	parent context.Context
+
+	pauseBuffer int
+	// buffer and backpressure are set by WithBackpressure.
+	buffer       int
+	backpressure Backpressure
+
+	firstMessageDeadline time.Duration
+	onNoFirstMessage     func(sub *Subscription)
+
+	spoolDir      string
+	spoolMaxBytes int64
+
+	profile bool
+
+	startAt  uint64
+	backfill BackfillSource
+	// backfillBuffer is set by WithBackfillBuffer.
+	backfillBuffer int
+
+	busyPoll time.Duration
+
+	unknownTxs  UnknownTxPolicy
+	onUnknownTx func(tx *Transaction)
+
+	// onShed is set if messages are dropped instead of waiting for a slow consumer, see WithDropPolicy.
+	onShed func()
+	// onDrop counts the dropped messages in the client's metrics, see WithMetrics.
+	onDrop func(n uint64)
+	// tracer is the tracer of the client, see WithTracer.
+	tracer Tracer
+	// onLive is called once the stream is open, see StartAll.
+	onLive func()
+	// filter is the filter of a transaction subscription, see UpdateFilter.
+	filter *liveFilter
+
+	maxAge time.Duration
+
+	resubscribe backoff.Policy
+	onGap       func(gap time.Duration, err error)
+
+	validation Validation
+
+	recorder    int
+	recorderDir string
+	onDump      func(path string, err error)
+}
+
+// ErrNoFirstMessage is returned by a subscription that didn't receive anything before its first message deadline.
+var ErrNoFirstMessage = newCodedError(ErrCodeNoFirstMessage, "no message received before first message deadline")
+
+// WithFirstMessageDeadline fails the subscription with ErrNoFirstMessage if nothing arrives within d
+// of subscribing. Some filters legitimately match nothing for a long time, but more often no messages
+// means a broken filter.
+func WithFirstMessageDeadline(d time.Duration) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.firstMessageDeadline = d
+		cfg.onNoFirstMessage = nil
+	}
+}
+
+// WithFirstMessageWarning calls fn if nothing arrives within d of subscribing, but keeps the
+// subscription running.
+func WithFirstMessageWarning(d time.Duration, fn func(sub *Subscription)) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.firstMessageDeadline = d
+		cfg.onNoFirstMessage = fn
+	}
+}
+
+// WithPauseBuffer buffers up to n messages while the subscription is paused, to be delivered on Resume.
+// If more messages arrive while paused, the oldest ones are dropped. Without it, messages received while
+// paused are discarded. Unless WithBackpressure is also used, the buffer holds back the stream once
+// it's full while the subscription isn't paused, so a slow consumer doesn't lose messages.
+func WithPauseBuffer(n int) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.pauseBuffer = n
+	}
+}
+
+// WithLabels labels the sender and receiver of every streamed transaction (including the ones
+// in execution payloads) using the given registry. The registry can be updated while the
+// subscription is running.
+func WithLabels(registry *labels.Registry) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.labels = registry
+	}
+}
+
+// ErrSubscriptionAbandoned is returned by a subscription that was torn down because its consumer stopped reading.
+var ErrSubscriptionAbandoned = newCodedError(ErrCodeSubscriptionAbandoned, "subscription abandoned by consumer")
+
+// WithAbandonTimeout considers the consumer gone if a message couldn't be delivered on the channel for d
+// (i.e. nobody read from it and its buffer is full). When that happens, the subscription is closed and
+// returns ErrSubscriptionAbandoned, instead of pinning the stream and its quota forever.
+func WithAbandonTimeout(d time.Duration) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.abandonTimeout = d
+	}
+}
+
+// WithMaxAge drops the messages that couldn't be delivered on the channel within d of being
+// received, instead of handing a slow consumer stale data. The age counts from when the subscription
+// received the message, after the disk buffer if any (see WithDiskBuffer). Stale messages are counted
+// by SubscriptionStats.Stale.
+func WithMaxAge(d time.Duration) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.maxAge = d
+	}
+}
+
+// stamp returns the time a message received now is aged from, or the zero time without a maximum age.
+func (cfg *subscriptionConfig) stamp() time.Time {
+	if cfg.maxAge <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now()
+}
+
+// staleOne counts a message dropped for being older than the maximum age.
+func (s *Subscription) staleOne() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stats != nil {
+		s.stats.dropped++
+		s.stats.stale++
+	}
+	if s.onDrop != nil {
+		s.onDrop(1)
+	}
+}
+
+// WithAbandonCallback is like WithAbandonTimeout, but calls fn instead of closing the subscription,
+// once every time the consumer has been blocked for d. The subscription keeps waiting for the consumer:
+// call Unsubscribe from fn to tear it down.
+func WithAbandonCallback(d time.Duration, fn func(sub *Subscription, blocked time.Duration)) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.abandonTimeout = d
+		cfg.onAbandon = fn
+	}
+}
+
+// WithHandle binds the subscription to the given handle.
+func WithHandle(sub *Subscription) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.handle = sub
+	}
+}
+
+// WithStatsRetention sets for how long the handle keeps statistics for reports. Defaults to
+// DefaultStatsRetention. It only has an effect the first time a handle is used.
+func WithStatsRetention(d time.Duration) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.retention = d
+	}
+}
+
+func newSubscriptionConfig(opts []SubscriptionOption) *subscriptionConfig {
+	cfg := &subscriptionConfig{
+		retention: DefaultStatsRetention,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.handle == nil {
+		cfg.handle = new(Subscription)
+	}
+	if cfg.values == nil {
+		cfg.values = context.Background()
+	}
+
+	return cfg
+}
+
+// Stream returns the name of the stream the subscription is on.
+func (s *Subscription) Stream() StreamKind {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stream
+}
+
+// Unsubscribe tears down the running subscription, if any. The subscribe call then
+// closes its channel and returns context.Canceled.
+func (s *Subscription) Unsubscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// profiler returns the profiler of the handle, creating it if needed.
+func (s *Subscription) profiler() *profiler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.profile == nil {
+		s.profile = new(profiler)
+	}
+
+	return s.profile
+}
+
+// resubscribing returns true if the handle was used before.
+func (s *Subscription) resubscribing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats != nil
+}
+
+func (s *Subscription) start(stream StreamKind, cfg *subscriptionConfig, cancel context.CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stream = stream
+	s.cancel = cancel
+	s.onDrop = cfg.onDrop
+	s.filter = cfg.filter
+	if cfg.recorder <= 0 {
+		s.recorder = nil
+	} else if s.recorder == nil || len(s.recorder.ring) != cfg.recorder {
+		s.recorder = &flightRecorder{ring: make([]RecordedMessage, cfg.recorder)}
+	}
+	if s.recorder != nil {
+		s.recorder.dir = cfg.recorderDir
+		s.recorder.onDump = cfg.onDump
+	}
+	if s.stats == nil {
+		s.stats = newStreamStats(cfg.retention)
+	}
+
+	s.stats.connected(time.Now())
+}
+
+func (s *Subscription) stop(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.disconnected(time.Now(), err)
+	s.queued = nil
+	s.peakQueued = nil
+	s.buffer = 0
+	s.cancelStream = nil
+	s.forceReconnect = false
+	s.filter = nil
+}
+
+func (s *Subscription) received(msg any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.received(time.Now(), msg)
+	s.recordLocked(msg)
+}
+
+// recvStream is the receiving side of a server stream. The streams of the gRPC client also implement
+// grpc.ClientStream, which gives access to the response metadata.
+type recvStream[P any] interface {
+	Recv() (P, error)
+}
+
+// recvFunc is a recvStream calling a function.
+type recvFunc[P any] func() (P, error)
+
+func (f recvFunc[P]) Recv() (P, error) {
+	return f()
+}
+
+// openFunc opens the server stream with the given call options.
+type openFunc[P any] func(ctx context.Context, opts ...grpc.CallOption) (recvStream[P], error)
+
+// subscribe runs the receive loop shared by all subscriptions: it opens the stream, converts every
+// message and delivers it on ch. It blocks until the stream fails, in which case it closes ch and
+// returns the error.
+func subscribe[P, T any](c *Client, stream StreamKind, ch chan<- T, opts []SubscriptionOption, open openFunc[P], convert func(P) T) error {
+	return subscribeFrom(c, stream, ch, opts, open, convert, nil)
+}
+
+// subscribeFrom is subscribe for streams that can be backfilled with bf when started with WithStartAt.
+func subscribeFrom[P, T any](c *Client, stream StreamKind, ch chan<- T, opts []SubscriptionOption, open openFunc[P], convert func(P) T, bf *backfiller[P]) error {
+	cfg := newSubscriptionConfig(opts)
+	sub := cfg.handle
+
+	if cfg.backfill != nil && bf == nil {
+		return fmt.Errorf("%s: %w", stream, ErrBackfillUnsupported)
+	}
+
+	if c.isClosing() {
+		return ErrClientClosed
+	}
+	c.configureDrops(stream, cfg)
+	c.applyProfile(stream, cfg)
+	cfg.onDrop = c.metrics.onDrop(stream)
+	convert = timedConvert(c.metrics, stream, convert)
+	cfg.tracer = c.tracer
+
+	if err := c.quota.acquireStream(); err != nil {
+		return err
+	}
+	defer c.quota.releaseStream()
+
+	if cfg.busyPoll > 0 {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+	}
+
+	ctx, cancel := context.WithCancel(valuesOnly{cfg.values})
+	defer cancel()
+	ctx = c.outgoingContext(ctx)
+	fail := &failure{cancel: cancel}
+
+	if parent := cfg.parent; parent != nil {
+		if err := parent.Err(); err != nil {
+			return err
+		}
+
+		go func() {
+			select {
+			case <-parent.Done():
+				fail.fail(parent.Err())
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	if sub.resubscribing() {
+		if err := c.reconnectLimiter.Wait(ctx); err != nil {
+			return err
+		}
+		c.metrics.reconnected(stream)
+	}
+
+	callOpts, err := c.compressionCallOptions()
+	if err != nil {
+		return err
+	}
+
+	var prof *profiler
+	if cfg.profile {
+		prof = sub.profiler()
+		callOpts = append(callOpts, prof.callOption())
+	}
+
+	reopen := func() (func() (P, error), error) {
+		return openServer(ctx, c, sub, stream, open, callOpts)
+	}
+
+	recv, err := reopen()
+	if err != nil {
+		return err
+	}
+	recv = reconnecting(ctx, c, cfg, sub, stream, recv, reopen)
+
+	if cfg.backfill != nil {
+		recv = backfillRecv(ctx, cfg, bf, recv)
+	}
+
+	if cfg.spoolDir != "" {
+		var closeSpool func()
+		if recv, closeSpool, err = spoolRecv(ctx, cfg, recv); err != nil {
+			return err
+		}
+		defer closeSpool()
+	}
+
+	if cfg.validation != NoValidation {
+		recv = validatingRecv(c, cfg, stream, recv)
+	}
+
+	sub.start(stream, cfg, cancel)
+	c.track(sub)
+	defer c.untrack(sub)
+	if cfg.onLive != nil {
+		cfg.onLive()
+	}
+	out := newDelivery(ctx, cancel, cfg, ch)
+
+	var firstMessage *time.Timer
+	if cfg.firstMessageDeadline > 0 {
+		received := sub.Stats().Messages
+		firstMessage = time.AfterFunc(cfg.firstMessageDeadline, func() {
+			// A message arrived, but is still being delivered
+			if sub.Stats().Messages > received {
+				return
+			}
+
+			if cfg.onNoFirstMessage != nil {
+				cfg.onNoFirstMessage(sub)
+			} else {
+				fail.fail(ErrNoFirstMessage)
+			}
+		})
+		defer firstMessage.Stop()
+	}
+
+	for {
+		if prof != nil {
+			err = recvProfiled(ctx, stream, prof, recv, convert, cfg, sub, out)
+		} else {
+			var proto P
+			if proto, err = recv(); err == nil {
+				msg := convert(proto)
+				span := cfg.startMessage(ctx, stream, msg)
+				var ok bool
+				if ok, err = cfg.screen(msg); ok {
+					cfg.enrich(msg)
+					sub.received(msg)
+
+					err = out.push(msg)
+				} else if err == nil {
+					sub.received(msg)
+					sub.dropped(1)
+				} else {
+					sub.record(msg)
+				}
+				endMessage(span, err)
+			}
+		}
+
+		if err == nil && firstMessage != nil {
+			firstMessage.Stop()
+			firstMessage = nil
+		}
+
+		if err != nil {
+			err = out.close(err)
+			if ctx.Err() != nil && !errors.Is(err, ErrSubscriptionAbandoned) {
+				err = ctx.Err()
+			}
+			if failed := fail.err(); failed != nil {
+				err = failed
+			}
+
+			if decodeFailure(err) {
+				sub.dump(err)
+			}
+
+			sub.stop(err)
+			close(ch)
+			return err
+		}
+	}
+}
+
+// recvProfiled is a single iteration of the receive loop, with every stage timed.
+func recvProfiled[P, T any](ctx context.Context, stream StreamKind, prof *profiler, recv func() (P, error), convert func(P) T, cfg *subscriptionConfig, sub *Subscription, out *delivery[T]) (err error) {
+	start := time.Now()
+	unmarshalBefore := atomic.LoadInt64(&prof.unmarshal)
+
+	proto, err := recv()
+	received := time.Now()
+
+	// The codec accounted for the unmarshaling inside recv, the rest was spent waiting
+	wait := received.Sub(start) - time.Duration(atomic.LoadInt64(&prof.unmarshal)-unmarshalBefore)
+	atomic.AddInt64(&prof.wait, int64(wait))
+	if err != nil {
+		return err
+	}
+
+	msg := convert(proto)
+	converted := time.Now()
+	atomic.AddInt64(&prof.convert, int64(converted.Sub(received)))
+
+	span := cfg.startMessage(ctx, stream, msg)
+	defer func() { endMessage(span, err) }()
+
+	if ok, err := cfg.screen(msg); !ok {
+		if err == nil {
+			sub.received(msg)
+			sub.dropped(1)
+		} else {
+			sub.record(msg)
+		}
+		return err
+	}
+
+	cfg.enrich(msg)
+	sub.received(msg)
+	enriched := time.Now()
+	atomic.AddInt64(&prof.enrich, int64(enriched.Sub(converted)))
+
+	err = out.push(msg)
+	atomic.AddInt64(&prof.send, int64(time.Since(enriched)))
+	atomic.AddUint64(&prof.messages, 1)
+
+	return err
+}
+
+// failure tears down a subscription from outside of the receive loop with a given error.
+type failure struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	cause  error
+}
+
+func (f *failure) fail(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.cause == nil {
+		f.cause = err
+		f.cancel()
+	}
+}
+
+func (f *failure) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.cause
+}
+
+// delivery hands messages from the receive loop to the consumer. Without buffering, messages are
+// delivered directly from the receive loop. Otherwise they go through a queue, which is drained by
+// a separate goroutine.
+type delivery[T any] struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	cfg    *subscriptionConfig
+	ch     chan<- T
+
+	queue *queue[stamped[T]]
+	done  chan struct{}
+	err   error
+}
+
+// stamped is a queued message, with when it was received if the subscription has a maximum age.
+type stamped[T any] struct {
+	msg      T
+	received time.Time
+}
+
+func newDelivery[T any](ctx context.Context, cancel context.CancelFunc, cfg *subscriptionConfig, ch chan<- T) *delivery[T] {
+	d := &delivery[T]{ctx: ctx, cancel: cancel, cfg: cfg, ch: ch}
+	size := cfg.bufferSize()
+	if size <= 0 {
+		return d
+	}
+
+	d.queue = newQueue[stamped[T]](size)
+	d.queue.policy = cfg.backpressure
+	d.queue.spin = cfg.busyPoll
+	d.done = make(chan struct{})
+
+	sub := cfg.handle
+	sub.mu.Lock()
+	sub.queued = d.queue.Len
+	sub.peakQueued = d.queue.Peak
+	sub.buffer = size
+	sub.mu.Unlock()
+
+	go d.run()
+	return d
+}
+
+func (d *delivery[T]) run() {
+	defer close(d.done)
+
+	for {
+		// Wait before popping, so that everything received while paused stays in the queue
+		var msg stamped[T]
+		err := d.cfg.handle.waitResumed(d.ctx)
+		if err == nil {
+			msg, err = d.queue.pop(d.ctx)
+		}
+		if err == nil {
+			err = deliverReceived(d.ctx, d.cfg, d.ch, msg.msg, msg.received)
+		}
+
+		if err != nil {
+			d.err = err
+			d.cancel()
+			return
+		}
+	}
+}
+
+func (d *delivery[T]) push(msg T) error {
+	if d.queue == nil {
+		if d.cfg.handle.Paused() {
+			d.cfg.handle.dropped(1)
+			return nil
+		}
+
+		return deliver(d.ctx, d.cfg, d.ch, msg)
+	}
+
+	select {
+	case <-d.done:
+		return d.err
+	default:
+	}
+
+	switch {
+	case d.queue.policy == BlockWhenFull:
+		if err := d.queue.wait(d.ctx, nil); err != nil {
+			return err
+		}
+	case d.cfg.buffer <= 0:
+		// Only a pause buffer: block like an unbuffered subscription, but drop the oldest once paused.
+		if err := d.queue.wait(d.ctx, d.cfg.handle.pauseSignal()); err != nil {
+			return err
+		}
+	}
+
+	if d.queue.push(stamped[T]{msg, d.cfg.stamp()}) {
+		d.cfg.handle.overflowed()
+	}
+
+	return nil
+}
+
+// close stops the delivery after the receive loop failed with err, and returns the error
+// the subscription should return.
+func (d *delivery[T]) close(err error) error {
+	if d.queue == nil {
+		return err
+	}
+
+	d.cancel()
+	<-d.done
+
+	// If the delivery failed first, that's what caused the receive loop to stop.
+	if d.err != nil && !errors.Is(d.err, context.Canceled) {
+		return d.err
+	}
+
+	return err
+}
+
+// deliver sends msg on ch, watching for an abandoned consumer if configured.
+func deliver[T any](ctx context.Context, cfg *subscriptionConfig, ch chan<- T, msg T) error {
+	return deliverReceived(ctx, cfg, ch, msg, cfg.stamp())
+}
+
+// deliverReceived is deliver for a message received at received, which is dropped once it's older
+// than the maximum age of the subscription, if any.
+func deliverReceived[T any](ctx context.Context, cfg *subscriptionConfig, ch chan<- T, msg T, received time.Time) error {
+	if cfg.maxAge > 0 && time.Since(received) >= cfg.maxAge {
+		cfg.handle.staleOne()
+		return nil
+	}
+
+	if cfg.onShed != nil {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			cfg.onShed()
+		}
+		return nil
+	}
+
+	if cfg.busyPoll > 0 && spinSend(ch, msg, cfg.busyPoll) {
+		return nil
+	}
+
+	if cfg.abandonTimeout <= 0 && cfg.maxAge <= 0 {
+		select {
+		case ch <- msg:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	// Fast path: the consumer is keeping up
+	select {
+	case ch <- msg:
+		return nil
+	default:
+	}
+
+	var stale <-chan time.Time
+	if cfg.maxAge > 0 {
+		staleTimer := time.NewTimer(cfg.maxAge - time.Since(received))
+		defer staleTimer.Stop()
+		stale = staleTimer.C
+	}
+
+	var timer *time.Timer
+	var abandoned <-chan time.Time
+	blockedSince := time.Now()
+	if cfg.abandonTimeout > 0 {
+		timer = time.NewTimer(cfg.abandonTimeout)
+		defer timer.Stop()
+		abandoned = timer.C
+	}
+
+	for {
+		select {
+		case ch <- msg:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-stale:
+			cfg.handle.staleOne()
+			return nil
+		case <-abandoned:
+			if cfg.onAbandon == nil {
+				return ErrSubscriptionAbandoned
+			}
+
+			cfg.onAbandon(cfg.handle, time.Since(blockedSince))
+			timer.Reset(cfg.abandonTimeout)
+		}
+	}
+}
+
+// enrich applies the configured enrichments to msg.
+func (cfg *subscriptionConfig) enrich(msg any) {
+	// First, for the enrichers using the sender
+	if cfg.senderRecovery != TrustServerSender {
+		switch m := msg.(type) {
+		case *Transaction:
+			recoverSender(cfg.senderRecovery, m)
+		case *ExecutionPayload:
+			for _, tx := range m.Transactions {
+				recoverSender(cfg.senderRecovery, tx)
+			}
+		}
+	}
+
+	if cfg.headerCache != nil {
+		switch m := msg.(type) {
+		case *ExecutionPayloadHeader:
+			cfg.headerCache.Add(m)
+		case *ExecutionPayload:
+			cfg.headerCache.Add(m.Header)
+		}
+	}
+
+	if tx, ok := msg.(*Transaction); ok && cfg.similarity != nil {
+		tx.Similar = cfg.similarity.Observe(tx)
+	}
+
+	if cfg.tokens != nil {
+		switch m := msg.(type) {
+		case *Transaction:
+			// Already valued by screen
+			if cfg.minUSDValue == 0 {
+				valueTx(cfg.tokens, m)
+			}
+		case *ExecutionPayload:
+			for _, tx := range m.Transactions {
+				valueTx(cfg.tokens, tx)
+			}
+		}
+	}
+
+	if cfg.labels != nil {
+		switch m := msg.(type) {
+		case *Transaction:
+			labelTx(cfg.labels, m)
+		case *ExecutionPayload:
+			for _, tx := range m.Transactions {
+				labelTx(cfg.labels, tx)
+			}
+		}
+	}
+
+	for _, fn := range cfg.enrichers {
+		fn(cfg.values, msg)
+	}
+}
+
+func labelTx(registry *labels.Registry, tx *Transaction) {
+	tx.FromLabel = registry.Lookup(tx.From)
+	if tx.To != nil {
+		tx.ToLabel = registry.Lookup(*tx.To)
+	}
+}
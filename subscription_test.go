@@ -0,0 +1,1018 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/backoff"
+	"github.com/chainbound/fiber-go/filter"
+	"github.com/chainbound/fiber-go/metadata"
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"github.com/chainbound/fiber-go/tokens"
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcmetadata "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// fakeStream returns an openFunc that yields the given messages and then blocks until the
+// stream is cancelled.
+func fakeStream[P any](msgs ...P) openFunc[P] {
+	return func(ctx context.Context, _ ...grpc.CallOption) (recvStream[P], error) {
+		i := 0
+		return recvFunc[P](func() (P, error) {
+			if i < len(msgs) {
+				i++
+				return msgs[i-1], nil
+			}
+
+			<-ctx.Done()
+			var zero P
+			return zero, ctx.Err()
+		}), nil
+	}
+}
+
+func identity[T any](v T) T { return v }
+
+func TestAbandonTimeout(t *testing.T) {
+	ch := make(chan int, 1)
+
+	errc := make(chan error)
+	go func() {
+		errc <- subscribe(&Client{}, "test", ch, []SubscriptionOption{WithAbandonTimeout(20 * time.Millisecond)}, fakeStream(1, 2, 3), identity[int])
+	}()
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrSubscriptionAbandoned) {
+			t.Fatalf("expected ErrSubscriptionAbandoned, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription wasn't abandoned")
+	}
+}
+
+func TestAbandonCallback(t *testing.T) {
+	var sub Subscription
+	ch := make(chan int)
+	called := make(chan time.Duration, 1)
+
+	errc := make(chan error)
+	go func() {
+		errc <- subscribe(&Client{}, "test", ch, []SubscriptionOption{
+			WithHandle(&sub),
+			WithAbandonCallback(20*time.Millisecond, func(s *Subscription, blocked time.Duration) {
+				called <- blocked
+				s.Unsubscribe()
+			}),
+		}, fakeStream(1), identity[int])
+	}()
+
+	if blocked := <-called; blocked < 20*time.Millisecond {
+		t.Fatalf("callback called too early: %s", blocked)
+	}
+
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled after Unsubscribe, got %v", err)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPauseBuffer(t *testing.T) {
+	var sub Subscription
+	sub.Pause()
+
+	ch := make(chan int)
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithPauseBuffer(2)}, fakeStream(1, 2, 3), identity[int])
+
+	waitFor(t, func() bool { return sub.Stats().Messages == 3 })
+
+	if stats := sub.Stats(); stats.Queued != 2 || stats.Dropped != 1 || !stats.Paused {
+		t.Fatalf("unexpected stats while paused: %+v", stats)
+	}
+
+	sub.Resume()
+	if a, b := <-ch, <-ch; a != 2 || b != 3 {
+		t.Fatalf("expected the 2 newest messages after resuming, got %d and %d", a, b)
+	}
+
+	sub.Unsubscribe()
+	if _, ok := <-ch; ok {
+		t.Fatal("expected channel to be closed")
+	}
+}
+
+func TestPauseBufferSlowConsumer(t *testing.T) {
+	var sub Subscription
+	ch := make(chan int)
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithPauseBuffer(2)}, fakeStream(1, 2, 3, 4, 5, 6), identity[int])
+
+	// Not paused, so a full buffer holds back the stream instead of dropping the oldest messages.
+	for i := 1; i <= 6; i++ {
+		time.Sleep(5 * time.Millisecond)
+		if v := <-ch; v != i {
+			t.Fatalf("expected message %d, got %d", i, v)
+		}
+	}
+
+	if stats := sub.Stats(); stats.Dropped != 0 || stats.Overflowed != 0 {
+		t.Fatalf("expected no drops while not paused: %+v", stats)
+	}
+
+	sub.Unsubscribe()
+}
+
+func TestBackpressure(t *testing.T) {
+	for _, tt := range []struct {
+		policy     Backpressure
+		delivered  []int
+		overflowed uint64
+	}{
+		{DropOldest, []int{4, 5}, 3},
+		{DropNewest, []int{1, 2}, 3},
+		{BlockWhenFull, []int{1, 2, 3, 4, 5}, 0},
+	} {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			var sub Subscription
+			// Keeps everything in the buffer until resumed
+			sub.Pause()
+
+			ch := make(chan int)
+			go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithBackpressure(2, tt.policy)}, fakeStream(1, 2, 3, 4, 5), identity[int])
+
+			if tt.policy == BlockWhenFull {
+				// The third message waits for room in the buffer
+				waitFor(t, func() bool { return sub.Stats().Messages == 3 && sub.Stats().Queued == 2 })
+				time.Sleep(10 * time.Millisecond)
+				if n := sub.Stats().Messages; n != 3 {
+					t.Fatalf("expected the stream to be held back, got %d messages", n)
+				}
+			} else {
+				waitFor(t, func() bool { return sub.Stats().Messages == 5 })
+			}
+
+			if stats := sub.Stats(); stats.Overflowed != tt.overflowed || stats.Dropped != tt.overflowed {
+				t.Fatalf("unexpected stats: %+v", stats)
+			}
+
+			sub.Resume()
+			for _, want := range tt.delivered {
+				if got := <-ch; got != want {
+					t.Fatalf("expected %d, got %d", want, got)
+				}
+			}
+
+			sub.Unsubscribe()
+			for range ch {
+			}
+		})
+	}
+}
+
+func TestPauseDiscard(t *testing.T) {
+	var sub Subscription
+	sub.Pause()
+
+	ch := make(chan int, 3)
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub)}, fakeStream(1, 2, 3), identity[int])
+
+	waitFor(t, func() bool { return sub.Stats().Dropped == 3 })
+	if len(ch) != 0 {
+		t.Fatal("expected no messages to be delivered while paused")
+	}
+
+	sub.Unsubscribe()
+}
+
+func TestFirstMessageDeadline(t *testing.T) {
+	ch := make(chan int)
+	err := subscribe(&Client{}, "test", ch, []SubscriptionOption{WithFirstMessageDeadline(10 * time.Millisecond)}, fakeStream[int](), identity[int])
+	if !errors.Is(err, ErrNoFirstMessage) {
+		t.Fatalf("expected ErrNoFirstMessage, got %v", err)
+	}
+
+	// A message before the deadline disarms it
+	var sub Subscription
+	ch = make(chan int, 1)
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithFirstMessageDeadline(10 * time.Millisecond)}, fakeStream(1), identity[int])
+
+	<-ch
+	time.Sleep(30 * time.Millisecond)
+	if !sub.Stats().Connected {
+		t.Fatal("subscription shouldn't fail after receiving a message")
+	}
+	sub.Unsubscribe()
+}
+
+func TestDiskBuffer(t *testing.T) {
+	var sub Subscription
+	dir := t.TempDir()
+	ch := make(chan *ExecutionPayloadHeader)
+
+	msgs := []*eth.ExecutionPayloadHeader{{BlockNumber: 1}, {BlockNumber: 2}, {BlockNumber: 3}}
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithDiskBuffer(dir, 0)}, fakeStream(msgs...), ProtoToHeader)
+
+	// Everything is spooled while nobody reads
+	waitFor(t, func() bool { return sub.Stats().Spooled >= 2 })
+
+	for i := uint64(1); i <= 3; i++ {
+		if header := <-ch; header.Number != i {
+			t.Fatalf("expected block %d, got %d", i, header.Number)
+		}
+	}
+
+	sub.Unsubscribe()
+}
+
+func TestProfiling(t *testing.T) {
+	var sub Subscription
+	ch := make(chan int, 3)
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithProfiling()}, fakeStream(1, 2, 3), identity[int])
+
+	waitFor(t, func() bool { return len(ch) == 3 })
+
+	profile := sub.Stats().Profile
+	if profile == nil || profile.Messages != 3 {
+		t.Fatalf("expected a profile of 3 messages, got %+v", profile)
+	}
+
+	sub.Unsubscribe()
+}
+
+// fakeBackfill serves headers and beacon blocks for every position, except missed slots.
+type fakeBackfill struct {
+	missed uint64
+}
+
+func (f fakeBackfill) ExecutionPayloadHeader(_ context.Context, number uint64) (*eth.ExecutionPayloadHeader, error) {
+	return &eth.ExecutionPayloadHeader{BlockNumber: number}, nil
+}
+
+func (f fakeBackfill) ExecutionPayload(context.Context, uint64) (*eth.ExecutionPayload, error) {
+	return nil, ErrBackfillUnsupported
+}
+
+func (f fakeBackfill) BeaconBlock(_ context.Context, slot uint64) (*eth.CompactBeaconBlock, error) {
+	if slot == f.missed {
+		return nil, nil
+	}
+	return &eth.CompactBeaconBlock{Slot: slot}, nil
+}
+
+func TestStartAt(t *testing.T) {
+	var sub Subscription
+	ch := make(chan *ExecutionPayloadHeader)
+
+	msgs := []*eth.ExecutionPayloadHeader{{BlockNumber: 5}, {BlockNumber: 6}}
+	go subscribeFrom(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithStartAt(2, fakeBackfill{})}, fakeStream(msgs...), ProtoToHeader, headerBackfiller)
+
+	for i := uint64(2); i <= 6; i++ {
+		if header := <-ch; header.Number != i {
+			t.Fatalf("expected block %d, got %d", i, header.Number)
+		}
+	}
+
+	sub.Unsubscribe()
+}
+
+func TestStartAtMissedSlot(t *testing.T) {
+	var sub Subscription
+	ch := make(chan *eth.CompactBeaconBlock)
+
+	msgs := []*eth.CompactBeaconBlock{{Slot: 4}}
+	go subscribeFrom(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithStartAt(1, fakeBackfill{missed: 2})}, fakeStream(msgs...), identity[*eth.CompactBeaconBlock], beaconBackfiller)
+
+	for _, slot := range []uint64{1, 3, 4} {
+		if block := <-ch; block.Slot != slot {
+			t.Fatalf("expected slot %d, got %d", slot, block.Slot)
+		}
+	}
+
+	sub.Unsubscribe()
+}
+
+func TestStartAtUnsupported(t *testing.T) {
+	err := subscribe(&Client{}, "test", make(chan int), []SubscriptionOption{WithStartAt(1, fakeBackfill{})}, fakeStream(1), identity[int])
+	if !errors.Is(err, ErrBackfillUnsupported) {
+		t.Fatalf("expected ErrBackfillUnsupported, got %v", err)
+	}
+}
+
+// slowBackfill serves headers after a delay.
+type slowBackfill struct {
+	fakeBackfill
+}
+
+func (f slowBackfill) ExecutionPayloadHeader(ctx context.Context, number uint64) (*eth.ExecutionPayloadHeader, error) {
+	time.Sleep(50 * time.Millisecond)
+	return f.fakeBackfill.ExecutionPayloadHeader(ctx, number)
+}
+
+func TestStartAtOverflow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The live stream overflows the buffer of 1 while the backfill is slow
+	cfg := &subscriptionConfig{startAt: 4, backfill: slowBackfill{}, backfillBuffer: 1}
+	recv, _ := fakeStream[*eth.ExecutionPayloadHeader]([]*eth.ExecutionPayloadHeader{{BlockNumber: 5}, {BlockNumber: 6}, {BlockNumber: 7}}...)(ctx)
+	next := backfillRecv(ctx, cfg, headerBackfiller, recv.Recv)
+
+	// Whatever was buffered before the overflow is returned in order, without gaps
+	for number := uint64(4); ; number++ {
+		header, err := next()
+		if err != nil {
+			if !errors.Is(err, ErrBackfillOverflow) || number < 6 {
+				t.Fatalf("expected ErrBackfillOverflow after block 5, got %v at block %d", err, number)
+			}
+			break
+		}
+		if header.BlockNumber != number {
+			t.Fatalf("expected block %d, got %d", number, header.BlockNumber)
+		}
+	}
+}
+
+func TestStartAtCaughtUp(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	live := make(chan *eth.ExecutionPayloadHeader, 16)
+	live <- &eth.ExecutionPayloadHeader{BlockNumber: 5}
+	recv := func() (*eth.ExecutionPayloadHeader, error) {
+		select {
+		case h := <-live:
+			return h, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	cfg := &subscriptionConfig{startAt: 4, backfill: fakeBackfill{}, backfillBuffer: 1}
+	next := backfillRecv(ctx, cfg, headerBackfiller, recv)
+	for number := uint64(4); number <= 5; number++ {
+		if header, err := next(); err != nil || header.BlockNumber != number {
+			t.Fatalf("expected block %d, got %v %v", number, header, err)
+		}
+	}
+
+	// Once caught up, the consumer can fall behind by more than the backfill buffer
+	for number := uint64(6); number <= 16; number++ {
+		live <- &eth.ExecutionPayloadHeader{BlockNumber: number}
+	}
+	time.Sleep(20 * time.Millisecond)
+	for number := uint64(6); number <= 16; number++ {
+		if header, err := next(); err != nil || header.BlockNumber != number {
+			t.Fatalf("expected block %d, got %v %v", number, header, err)
+		}
+	}
+}
+
+func TestRPCBackfillBlobTx(t *testing.T) {
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"result":{"number":"0x10","hash":"0x`+strings.Repeat("11", 32)+`",
+			"transactions":[{"type":"0x3","chainId":"0x1","nonce":"0x2","gas":"0x5208","gasPrice":"0x5",
+			"maxFeePerGas":"0x9","maxPriorityFeePerGas":"0x1","maxFeePerBlobGas":"0x3",
+			"blobVersionedHashes":["0x01`+strings.Repeat("22", 31)+`"],"to":"0x`+strings.Repeat("33", 20)+`",
+			"from":"0x`+strings.Repeat("44", 20)+`","hash":"0x`+strings.Repeat("55", 32)+`","input":"0x",
+			"value":"0x7","v":"0x1","yParity":"0x1","r":"0x8","s":"0x9","accessList":[]}]}}`)
+	}))
+	defer node.Close()
+
+	src, err := NewRPCBackfill(context.Background(), node.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	payload, err := src.ExecutionPayload(context.Background(), 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := ProtoToTx(payload.Transactions[0])
+	if tx.Type != BlobTxType || tx.MaxFee.Uint64() != 9 || tx.PriorityFee.Uint64() != 1 || tx.From != common.HexToAddress(strings.Repeat("44", 20)) {
+		t.Fatalf("unexpected transaction %+v", tx)
+	}
+}
+
+// metadataStream is a grpc.ClientStream that sends a header, then fails after its messages.
+type metadataStream struct {
+	grpc.ClientStream
+	msgs []int
+}
+
+func (s *metadataStream) Header() (metadata.MD, error) {
+	return grpcmetadata.Pairs("x-shard", "7"), nil
+}
+
+func (s *metadataStream) Trailer() metadata.MD {
+	return grpcmetadata.Pairs("x-reason", "maintenance")
+}
+
+func (s *metadataStream) Recv() (int, error) {
+	if len(s.msgs) == 0 {
+		return 0, errors.New("stream ended")
+	}
+
+	msg := s.msgs[0]
+	s.msgs = s.msgs[1:]
+	return msg, nil
+}
+
+func TestResponseMetadata(t *testing.T) {
+	var sub Subscription
+	var trailers []string
+	c := &Client{responseHook: func(stream string, md metadata.MD, trailer bool) {
+		if trailer {
+			trailers = append(trailers, md.Get("x-reason")...)
+		}
+	}}
+
+	open := func(context.Context, ...grpc.CallOption) (recvStream[int], error) {
+		return &metadataStream{msgs: []int{1}}, nil
+	}
+
+	ch := make(chan int, 1)
+	if err := subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub)}, open, identity[int]); err == nil {
+		t.Fatal("expected the stream error")
+	}
+
+	waitFor(t, func() bool { return sub.Header() != nil })
+	if got := sub.Header().Get("x-shard"); len(got) != 1 || got[0] != "7" {
+		t.Fatalf("unexpected header %v", sub.Header())
+	}
+	if got := sub.Trailer().Get("x-reason"); len(got) != 1 || got[0] != "maintenance" {
+		t.Fatalf("unexpected trailer %v", sub.Trailer())
+	}
+	if len(trailers) != 1 {
+		t.Fatalf("expected the hook to get the trailer, got %v", trailers)
+	}
+}
+
+// slowHeaderStream is a grpc.ClientStream whose header arrives once release is closed.
+type slowHeaderStream struct {
+	grpc.ClientStream
+	shard   string
+	release chan struct{}
+}
+
+func (s *slowHeaderStream) Header() (metadata.MD, error) {
+	<-s.release
+	return grpcmetadata.Pairs("x-shard", s.shard), nil
+}
+
+func (s *slowHeaderStream) Trailer() metadata.MD {
+	return grpcmetadata.Pairs("x-shard", s.shard)
+}
+
+func TestResponseMetadataReconnect(t *testing.T) {
+	var sub Subscription
+	c := &Client{}
+
+	first := &slowHeaderStream{shard: "1", release: make(chan struct{})}
+	firstTrailer := sub.captureMetadata(c, "test", first)
+
+	second := &slowHeaderStream{shard: "2", release: make(chan struct{})}
+	close(second.release)
+	sub.captureMetadata(c, "test", second)
+	waitFor(t, func() bool { return sub.Header() != nil })
+
+	// The header and trailer of the first stream arrive after the second stream was opened
+	close(first.release)
+	firstTrailer()
+	time.Sleep(10 * time.Millisecond)
+
+	if got := sub.Header().Get("x-shard"); len(got) != 1 || got[0] != "2" {
+		t.Fatalf("expected the header of the second stream, got %v", sub.Header())
+	}
+	if sub.Trailer() != nil {
+		t.Fatalf("expected no trailer while the second stream runs, got %v", sub.Trailer())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	c := &Client{target: "fiber.example.io"}
+	var sub Subscription
+	ch := make(chan int, 2)
+	go subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub)}, fakeStream(1, 2), identity[int])
+
+	waitFor(t, func() bool { return len(ch) == 2 })
+
+	snap := c.Snapshot()
+	if len(snap.Subscriptions) != 1 {
+		t.Fatalf("expected 1 subscription, got %+v", snap.Subscriptions)
+	}
+	stream := snap.Subscriptions[0]
+	if stream.Stream != "test" || stream.Messages != 2 || stream.LastMessage.IsZero() || stream.Sessions != 1 {
+		t.Fatalf("unexpected stream snapshot %+v", stream)
+	}
+
+	var buf strings.Builder
+	if err := snap.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"target": "fiber.example.io"`) {
+		t.Fatalf("unexpected JSON:\n%s", buf.String())
+	}
+
+	sub.Unsubscribe()
+	waitFor(t, func() bool { return len(c.Snapshot().Subscriptions) == 0 })
+}
+
+func TestForceReconnect(t *testing.T) {
+	c := &Client{}
+	var sub Subscription
+	ch := make(chan int, 4)
+
+	opened := 0
+	open := func(ctx context.Context, opts ...grpc.CallOption) (recvStream[int], error) {
+		opened++
+		return fakeStream(opened)(ctx, opts...)
+	}
+	go subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub)}, open, identity[int])
+
+	if msg := <-ch; msg != 1 {
+		t.Fatalf("expected 1, got %d", msg)
+	}
+
+	c.ForceReconnectAll()
+	if msg := <-ch; msg != 2 {
+		t.Fatalf("expected 2 from the new stream, got %d", msg)
+	}
+
+	if !sub.Stats().Connected {
+		t.Fatal("subscription should still be connected")
+	}
+	if snap := c.Snapshot(); snap.Subscriptions[0].Sessions != 2 || snap.Subscriptions[0].LastError != ErrForcedReconnect.Error() {
+		t.Fatalf("expected a forced reconnect in the snapshot, got %+v", snap.Subscriptions[0])
+	}
+	sub.Unsubscribe()
+}
+
+func TestAutoResubscribe(t *testing.T) {
+	c := &Client{}
+	var sub Subscription
+	ch := make(chan int, 4)
+
+	// The first stream fails after a message, and the server is unavailable for one attempt
+	opened := 0
+	unavailable := status.Error(codes.Unavailable, "connection reset")
+	open := func(ctx context.Context, opts ...grpc.CallOption) (recvStream[int], error) {
+		opened++
+		switch opened {
+		case 1:
+			sent := false
+			return recvFunc[int](func() (int, error) {
+				if sent {
+					return 0, unavailable
+				}
+				sent = true
+				return 1, nil
+			}), nil
+		case 2:
+			return nil, unavailable
+		case 3:
+			return fakeStream(2)(ctx, opts...)
+		default:
+			return nil, status.Error(codes.InvalidArgument, "bad filter")
+		}
+	}
+
+	gaps := make(chan error, 1)
+	policy := &backoff.Constant{Delay: time.Millisecond}
+	errc := make(chan error, 1)
+	go func() {
+		errc <- subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub), WithAutoResubscribe(policy, func(gap time.Duration, err error) {
+			gaps <- err
+		})}, open, identity[int])
+	}()
+
+	if first, second := <-ch, <-ch; first != 1 || second != 2 {
+		t.Fatalf("expected 1 and 2, got %d and %d", first, second)
+	}
+	if err := <-gaps; !errors.Is(err, ErrStreamClosed) {
+		t.Fatalf("expected the gap to report the transient error, got %v", err)
+	}
+	if n := c.Snapshot().Subscriptions[0].Sessions; n != 2 {
+		t.Fatalf("expected 2 sessions, got %d", n)
+	}
+
+	// Other errors still end the subscription
+	sub.ForceReconnect()
+	if err := <-errc; status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected the reopen error, got %v", err)
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	c := &Client{shutdown: &ShutdownConfig{SubscriptionTimeout: time.Second, SendTimeout: time.Second}}
+	var sub Subscription
+	ch := make(chan int, 4)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub)}, fakeStream(1), identity[int])
+	}()
+	<-ch
+
+	if err := c.beginSend(); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		c.endSend()
+	}()
+
+	if err := c.gracefulShutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Stats().Connected {
+		t.Fatal("subscription should be stopped")
+	}
+	if err := c.beginSend(); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+	if err := subscribe(c, "test", ch, nil, fakeStream(1), identity[int]); !errors.Is(err, ErrClientClosed) {
+		t.Fatalf("expected ErrClientClosed, got %v", err)
+	}
+}
+
+func TestDropPolicy(t *testing.T) {
+	c := NewClient("", WithDropPolicy(Transactions, ShedWhenSlow))
+	var sub, headers Subscription
+
+	// Nobody reads, so everything beyond the buffer is shed
+	ch := make(chan int, 2)
+	go subscribe(c, "txs", ch, []SubscriptionOption{WithHandle(&sub)}, fakeStream(1, 2, 3, 4, 5), identity[int])
+
+	deadline := time.Now().Add(time.Second)
+	for sub.Stats().Messages < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if stats := sub.Stats(); stats.Shed != 3 || stats.Dropped != 3 {
+		t.Fatalf("expected 3 shed messages, got %+v", stats)
+	}
+	if c.Shed(Transactions) != 3 {
+		t.Fatalf("expected 3 shed transactions, got %d", c.Shed(Transactions))
+	}
+	if <-ch != 1 || <-ch != 2 {
+		t.Fatal("expected the first messages to be delivered")
+	}
+	sub.Unsubscribe()
+
+	// Other classes keep waiting for the consumer
+	blocks := make(chan int)
+	go subscribe(c, "execution_headers", blocks, []SubscriptionOption{WithHandle(&headers)}, fakeStream(1, 2), identity[int])
+	time.Sleep(20 * time.Millisecond)
+	if <-blocks != 1 || <-blocks != 2 || c.Shed(ExecutionHeaders) != 0 {
+		t.Fatal("execution headers should never be dropped")
+	}
+	headers.Unsubscribe()
+}
+
+func TestMaxAge(t *testing.T) {
+	var sub Subscription
+	ch := make(chan int, 1)
+	go subscribe(NewClient(""), "txs", ch, []SubscriptionOption{WithHandle(&sub), WithMaxAge(50 * time.Millisecond)}, fakeStream(1, 2, 3), identity[int])
+	defer sub.Unsubscribe()
+
+	// 1 fills the buffer, 2 gets stale waiting for it after 50ms, and 3 is delivered once 1 is read
+	time.Sleep(75 * time.Millisecond)
+	for _, expected := range []int{1, 3} {
+		select {
+		case msg := <-ch:
+			if msg != expected {
+				t.Fatalf("expected message %d, got %d", expected, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected message %d", expected)
+		}
+	}
+
+	if stats := sub.Stats(); stats.Stale != 1 || stats.Dropped != 1 {
+		t.Fatalf("expected 1 stale message, got %+v", stats)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	quota := WithQuota(Quota{MaxStreams: 1, MaxSendsPerSecond: 1, SendBurst: 1})
+	c, other := NewClient("", quota), NewClient("", quota)
+
+	var sub Subscription
+	ch := make(chan int, 1)
+	go subscribe(c, "test", ch, []SubscriptionOption{WithHandle(&sub)}, fakeStream(1), identity[int])
+	<-ch
+
+	// The quota is shared by the clients of the option
+	var qerr *QuotaError
+	err := subscribe(other, "test", make(chan int), nil, fakeStream(1), identity[int])
+	if !errors.As(err, &qerr) || qerr.Limit != "streams" || !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected a stream quota error, got %v", err)
+	}
+
+	if err := c.beginSend(); err != nil {
+		t.Fatal(err)
+	}
+	c.endSend()
+	if err := other.beginSend(); !errors.As(err, &qerr) || qerr.Limit != "sends_per_second" {
+		t.Fatalf("expected a send quota error, got %v", err)
+	}
+
+	sub.Unsubscribe()
+}
+
+func TestSimilarityDetector(t *testing.T) {
+	input := make([]byte, DefaultSimilarityMinInput)
+	input[0] = 0xa9
+	tx := func(hash, from byte, input []byte) *Transaction {
+		return &Transaction{Hash: common.Hash{hash}, From: common.Address{from}, Input: input}
+	}
+
+	d := NewSimilarityDetector(0, DefaultSimilarityMinInput)
+	var sub Subscription
+	ch := make(chan *Transaction, 4)
+	go subscribe(&Client{}, "txs", ch, []SubscriptionOption{WithHandle(&sub), WithSimilarityDetector(d)},
+		fakeStream(tx(1, 1, input), tx(2, 1, input), tx(3, 2, input), tx(4, 3, input[:4])), identity[*Transaction])
+	defer sub.Unsubscribe()
+
+	if (<-ch).Similar != nil || (<-ch).Similar != nil {
+		t.Fatal("transactions of the same sender should not be flagged")
+	}
+	if similar := (<-ch).Similar; similar == nil || similar.First != (common.Hash{1}) || similar.FirstFrom != (common.Address{1}) || similar.Senders != 2 {
+		t.Fatalf("expected a copy of the first transaction, got %+v", similar)
+	}
+	if (<-ch).Similar != nil {
+		t.Fatal("short calldata should not be flagged")
+	}
+
+	// Old calldata is forgotten after two windows
+	d = NewSimilarityDetector(1, 0)
+	d.Observe(tx(1, 1, []byte{1}))
+	d.Observe(tx(2, 1, []byte{2}))
+	d.Observe(tx(3, 1, []byte{3}))
+	if d.Observe(tx(4, 2, []byte{1})) != nil {
+		t.Fatal("expected the calldata to be forgotten")
+	}
+}
+
+func TestBusyPoll(t *testing.T) {
+	var sub Subscription
+	ch := make(chan int)
+	go subscribe(&Client{}, "test", ch, []SubscriptionOption{WithHandle(&sub), WithBusyPoll(time.Millisecond), WithPauseBuffer(4)}, fakeStream(1, 2, 3), identity[int])
+	defer sub.Unsubscribe()
+
+	for i := 1; i <= 3; i++ {
+		if msg := <-ch; msg != i {
+			t.Fatalf("expected message %d, got %d", i, msg)
+		}
+	}
+}
+
+// BenchmarkDeliver measures a round trip of deliveries to a consumer of an unbuffered channel, which
+// spins too when busy-polling. Busy-polling only pays off with a core for each side, so run it on the
+// target host: on a single core, spinning is slower.
+func BenchmarkDeliver(b *testing.B) {
+	for _, spin := range []time.Duration{0, 50 * time.Microsecond} {
+		b.Run(fmt.Sprintf("spin=%s", spin), func(b *testing.B) {
+			cfg := &subscriptionConfig{busyPoll: spin}
+			ch, ack := make(chan int), make(chan int)
+			go func() {
+				for msg := range ch {
+					deliver(context.Background(), cfg, ack, msg)
+				}
+			}()
+			defer close(ch)
+
+			for i := 0; i < b.N; i++ {
+				deliver(context.Background(), cfg, ch, i)
+				if spin > 0 {
+					for !receiveNow(ack) {
+					}
+				} else {
+					<-ack
+				}
+			}
+		})
+	}
+}
+
+func receiveNow(ch <-chan int) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFilterEcho(t *testing.T) {
+	var sub Subscription
+	if _, ok := sub.FilterEcho(); ok {
+		t.Fatal("expected no echo before the header")
+	}
+
+	f := filter.New(filter.To("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC"))
+	sub.header = grpcmetadata.Pairs(metadata.FilterEchoKey, string(f.Encode()))
+	if echo, ok := sub.FilterEcho(); !ok || echo.Describe().String() != f.Describe().String() {
+		t.Fatalf("unexpected echo %v", echo)
+	}
+}
+
+func TestSubscribeWithContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	ch := make(chan int, 1)
+	err := subscribe(&Client{}, "test", ch, []SubscriptionOption{withContext(ctx)}, fakeStream(1), identity[int])
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+	if <-ch != 1 {
+		t.Fatal("expected the message before the deadline")
+	}
+	if _, ok := <-ch; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+
+	// A done context doesn't subscribe at all
+	if err := subscribe(&Client{}, "test", make(chan int), []SubscriptionOption{withContext(ctx)}, fakeStream(1), identity[int]); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+}
+
+func TestBatched(t *testing.T) {
+	var sub Subscription
+	src := func(c *Client, ch chan<- int, opts ...SubscriptionOption) error {
+		return subscribe(c, "test", ch, opts, fakeStream(1, 2, 3, 4, 5), identity[int])
+	}
+
+	ch := make(chan []int)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Batched[int](src, BatchOptions{MaxSize: 2, FlushInterval: 20 * time.Millisecond})(&Client{}, ch, WithHandle(&sub))
+	}()
+
+	var batches [][]int
+	for len(batches) < 3 {
+		batches = append(batches, <-ch)
+	}
+	if fmt.Sprint(batches) != "[[1 2] [3 4] [5]]" {
+		t.Fatalf("unexpected batches %v", batches)
+	}
+
+	for sub.Unsubscribe(); ; sub.Unsubscribe() {
+		if _, ok := <-ch; !ok {
+			break
+		}
+	}
+	<-errc
+
+	// A subscription failing to start still closes the channel
+	failing := func(*Client, chan<- int, ...SubscriptionOption) error { return ErrQuotaExceeded }
+	ch = make(chan []int)
+	go Batched[int](failing, BatchOptions{})(&Client{}, ch)
+	if _, ok := <-ch; ok {
+		t.Fatal("expected the channel to be closed")
+	}
+}
+
+func TestUnknownTxPolicy(t *testing.T) {
+	tx := func(hash byte, typ uint32) *Transaction {
+		return &Transaction{Hash: common.Hash{hash}, Type: typ}
+	}
+
+	var unknown []common.Hash
+	var sub Subscription
+	ch := make(chan *Transaction, 4)
+	go subscribe(&Client{}, "txs", ch, []SubscriptionOption{WithHandle(&sub), WithUnknownTxPolicy(SkipUnknownTxs, func(tx *Transaction) {
+		unknown = append(unknown, tx.Hash)
+	})}, fakeStream(tx(1, 2), tx(2, 0x7f), tx(3, 0)), identity[*Transaction])
+
+	if got := (<-ch).Hash; got != (common.Hash{1}) {
+		t.Fatalf("expected the first transaction, got %s", got)
+	}
+	if got := (<-ch).Hash; got != (common.Hash{3}) {
+		t.Fatalf("expected the unknown transaction to be skipped, got %s", got)
+	}
+	if stats := sub.Stats(); stats.Messages != 3 || stats.Dropped != 1 || len(unknown) != 1 {
+		t.Fatalf("expected the skip to be reported, got %+v %v", stats, unknown)
+	}
+	sub.Unsubscribe()
+
+	var payloadSub Subscription
+	payloads := make(chan *ExecutionPayload, 1)
+	go subscribe(&Client{}, "execution_payloads", payloads, []SubscriptionOption{WithHandle(&payloadSub), WithUnknownTxPolicy(SkipUnknownTxs, nil)},
+		fakeStream(&ExecutionPayload{Header: &ExecutionPayloadHeader{}, Transactions: []*Transaction{tx(1, 0x7f), tx(2, 1)}}), identity[*ExecutionPayload])
+	if txs := (<-payloads).Transactions; len(txs) != 1 || txs[0].Hash != (common.Hash{2}) {
+		t.Fatalf("expected the unknown transaction to be removed from the payload, got %v", txs)
+	}
+	payloadSub.Unsubscribe()
+
+	err := subscribe(&Client{}, "txs", make(chan *Transaction, 4), []SubscriptionOption{WithUnknownTxPolicy(FailOnUnknownTxs, nil)},
+		fakeStream(tx(1, 0), tx(2, 0x7f)), identity[*Transaction])
+	var typeErr *UnknownTxTypeError
+	if !errors.As(err, &typeErr) || typeErr.Type != 0x7f || typeErr.Hash != (common.Hash{2}) {
+		t.Fatalf("expected an unknown type error, got %v", err)
+	}
+}
+
+func TestMinUSDValue(t *testing.T) {
+	table := tokens.NewTable()
+	table.SetETHPrice(2000)
+
+	tx := func(hash byte, eth int64) *Transaction {
+		return &Transaction{Hash: common.Hash{hash}, Value: new(big.Int).Mul(big.NewInt(eth), big.NewInt(1e18))}
+	}
+
+	var sub Subscription
+	ch := make(chan *Transaction, 2)
+	go subscribe(&Client{}, "txs", ch, []SubscriptionOption{WithHandle(&sub), WithMinUSDValue(table, 50_000)},
+		fakeStream(tx(1, 1), tx(2, 25)), identity[*Transaction])
+	defer sub.Unsubscribe()
+
+	if got := <-ch; got.Hash != (common.Hash{2}) || got.USDValue != 50_000 {
+		t.Fatalf("expected the $50k transaction, got %s worth $%v", got.Hash, got.USDValue)
+	}
+	if stats := sub.Stats(); stats.Dropped != 1 {
+		t.Fatalf("expected the cheaper transaction to be dropped, got %+v", stats)
+	}
+}
+
+func TestHeaderCache(t *testing.T) {
+	header := func(number uint64, hash byte) *ExecutionPayloadHeader {
+		return &ExecutionPayloadHeader{Number: number, Hash: common.Hash{hash}}
+	}
+
+	cache := NewHeaderCache(3, 0)
+	var sub Subscription
+	ch := make(chan *ExecutionPayloadHeader, 4)
+	go subscribe(&Client{}, "execution_headers", ch, []SubscriptionOption{WithHandle(&sub), WithHeaderCache(cache)},
+		fakeStream(header(1, 1), header(2, 2), header(2, 3), header(3, 4)), identity[*ExecutionPayloadHeader])
+	for i := 0; i < 4; i++ {
+		<-ch
+	}
+	sub.Unsubscribe()
+
+	if h, ok := cache.HeaderByNumber(2); !ok || h.Hash != (common.Hash{3}) {
+		t.Fatalf("expected the reorged header, got %v", h)
+	}
+	if _, ok := cache.HeaderByHash(common.Hash{2}); ok {
+		t.Fatal("expected the replaced header to be gone")
+	}
+	if h, ok := cache.Latest(); !ok || h.Number != 3 {
+		t.Fatalf("expected the latest header, got %v", h)
+	}
+
+	// The first header was evicted by the fourth
+	if _, ok := cache.HeaderByNumber(1); ok || cache.Len() != 2 {
+		t.Fatalf("expected the oldest header to be evicted, %d cached", cache.Len())
+	}
+	cache.Add(header(4, 5))
+	if h, ok := cache.HeaderByHash(common.Hash{3}); !ok || h.Number != 2 {
+		t.Fatal("expected the reorged header to stay cached")
+	}
+
+	cache = NewHeaderCache(0, time.Millisecond)
+	cache.Add(header(1, 1))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.HeaderByNumber(1); ok {
+		t.Fatal("expected the header to expire")
+	}
+}
+
+func TestQueueBounded(t *testing.T) {
+	q := newQueue[int](2)
+	for i := 0; i < 100000; i++ {
+		q.push(i)
+	}
+
+	if c := cap(q.items); c > 8 {
+		t.Fatalf("expected a full queue not to grow, got a capacity of %d", c)
+	}
+	for _, expected := range []int{99998, 99999} {
+		if v, _ := q.pop(context.Background()); v != expected {
+			t.Fatalf("expected %d, got %d", expected, v)
+		}
+	}
+}
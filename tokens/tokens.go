@@ -0,0 +1,169 @@
+// Package tokens contains a table of token metadata and USD prices, to value transactions in dollars
+// across ETH and ERC-20 transfers. The prices are set by the application, e.g. from a price feed, and
+// can be updated while subscriptions use the table.
+package tokens
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Token is the metadata and price of a token.
+type Token struct {
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+	// PriceUSD is the price of a whole token in dollars. Unpriced tokens are worth nothing.
+	PriceUSD float64 `json:"price_usd"`
+}
+
+// Entry is a token at an address, as used in the JSON format.
+type Entry struct {
+	Address common.Address `json:"address"`
+	Token
+}
+
+// Mainnet are major mainnet tokens, without prices.
+var Mainnet = []Entry{
+	{common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), Token{Symbol: "WETH", Decimals: 18}},
+	{common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"), Token{Symbol: "USDC", Decimals: 6}},
+	{common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7"), Token{Symbol: "USDT", Decimals: 6}},
+	{common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F"), Token{Symbol: "DAI", Decimals: 18}},
+	{common.HexToAddress("0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599"), Token{Symbol: "WBTC", Decimals: 8}},
+}
+
+var (
+	transferMethod     = []byte{0xa9, 0x05, 0x9c, 0xbb}
+	transferFromMethod = []byte{0x23, 0xb8, 0x72, 0xdd}
+)
+
+// Table maps token addresses to their metadata and prices, along with the price of ETH. It is safe
+// for concurrent use.
+type Table struct {
+	mu     sync.RWMutex
+	eth    float64
+	tokens map[common.Address]Token
+}
+
+// NewTable returns a table with the given tokens, like Mainnet.
+func NewTable(entries ...Entry) *Table {
+	t := &Table{tokens: make(map[common.Address]Token, len(entries))}
+	t.Add(entries)
+
+	return t
+}
+
+// SetETHPrice sets the price of an ETH in dollars.
+func (t *Table) SetETHPrice(usd float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.eth = usd
+}
+
+// ETHPrice returns the price of an ETH in dollars.
+func (t *Table) ETHPrice() float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.eth
+}
+
+// Set sets the token at addr, replacing any existing one.
+func (t *Table) Set(addr common.Address, token Token) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tokens[addr] = token
+}
+
+// SetPrice sets the price of the token at addr, and returns false if there's no token at addr.
+func (t *Table) SetPrice(addr common.Address, usd float64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	token, ok := t.tokens[addr]
+	if ok {
+		token.PriceUSD = usd
+		t.tokens[addr] = token
+	}
+
+	return ok
+}
+
+// Lookup returns the token at addr.
+func (t *Table) Lookup(addr common.Address) (Token, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	token, ok := t.tokens[addr]
+	return token, ok
+}
+
+// Add adds (or replaces) the tokens of all entries.
+func (t *Table) Add(entries []Entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, e := range entries {
+		t.tokens[e.Address] = e.Token
+	}
+}
+
+// LoadJSON adds the tokens in r, which should contain a JSON array of entries:
+//
+//	[{"address": "0x...", "symbol": "USDC", "decimals": 6, "price_usd": 1}]
+func (t *Table) LoadJSON(r io.Reader) error {
+	var entries []Entry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return fmt.Errorf("decoding tokens: %w", err)
+	}
+
+	t.Add(entries)
+	return nil
+}
+
+// Value returns the dollar value moved by a transaction sent to to with value and input: its ETH value,
+// plus the amount of a transfer or transferFrom call on a token of the table.
+func (t *Table) Value(to *common.Address, value *big.Int, input []byte) float64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var usd float64
+	if value != nil && value.Sign() > 0 {
+		usd = worth(value, 18, t.eth)
+	}
+
+	if to == nil || len(input) < 4 {
+		return usd
+	}
+
+	token, ok := t.tokens[*to]
+	if !ok || token.PriceUSD == 0 {
+		return usd
+	}
+
+	// The amount is the last argument of both methods
+	switch {
+	case bytes.Equal(input[:4], transferMethod) && len(input) >= 4+2*32:
+		usd += worth(new(big.Int).SetBytes(input[4+32:4+2*32]), token.Decimals, token.PriceUSD)
+	case bytes.Equal(input[:4], transferFromMethod) && len(input) >= 4+3*32:
+		usd += worth(new(big.Int).SetBytes(input[4+2*32:4+3*32]), token.Decimals, token.PriceUSD)
+	}
+
+	return usd
+}
+
+// worth returns the dollar value of amount base units of a token with the decimals and price.
+func worth(amount *big.Int, decimals uint8, price float64) float64 {
+	units := new(big.Float).SetInt(amount)
+	units.Quo(units, new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+
+	f, _ := units.Float64()
+	return f * price
+}
@@ -0,0 +1,46 @@
+package tokens
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestValue(t *testing.T) {
+	table := NewTable(Mainnet...)
+	table.SetETHPrice(2000)
+	usdc := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	if !table.SetPrice(usdc, 1) || table.SetPrice(common.Address{1}, 1) {
+		t.Fatal("expected only the known token to be priced")
+	}
+
+	if err := table.LoadJSON(strings.NewReader(`[{"address": "0x0000000000000000000000000000000000000002", "symbol": "TKN", "decimals": 2, "price_usd": 3}]`)); err != nil {
+		t.Fatal(err)
+	}
+	tkn := common.HexToAddress("0x02")
+
+	word := func(v int64) []byte { return common.LeftPadBytes(big.NewInt(v).Bytes(), 32) }
+	transfer := append(append(common.FromHex("0xa9059cbb"), word(1)...), word(50_000e6)...)
+	transferFrom := append(append(append(common.FromHex("0x23b872dd"), word(1)...), word(2)...), word(150)...)
+
+	eth := new(big.Int).Mul(big.NewInt(25), big.NewInt(1e18))
+	for name, tc := range map[string]struct {
+		to    *common.Address
+		value *big.Int
+		input []byte
+		usd   float64
+	}{
+		"eth":           {&common.Address{}, eth, nil, 50_000},
+		"transfer":      {&usdc, nil, transfer, 50_000},
+		"transfer from": {&tkn, big.NewInt(1e18), transferFrom, 2004.5},
+		"unknown token": {&common.Address{1}, nil, transfer, 0},
+		"creation":      {nil, nil, transfer, 0},
+	} {
+		if usd := table.Value(tc.to, tc.value, tc.input); math.Abs(usd-tc.usd) > 1e-6 {
+			t.Errorf("%s: expected $%v, got $%v", name, tc.usd, usd)
+		}
+	}
+}
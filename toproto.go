@@ -0,0 +1,256 @@
+package client
+
+import (
+	"math/big"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+)
+
+// Conversions of the streamed types back to their protobuf messages, for re-publishing them. Converting
+// a message received from Fiber back gives the same protobuf message, except for the V of typed
+// transactions, which is kept as the y-parity. The codec package exposes these conversions with a
+// stability guarantee.
+
+// ToProto converts the transaction to its protobuf message.
+func (tx *Transaction) ToProto() *eth.Transaction {
+	proto := &eth.Transaction{
+		ChainId:     tx.ChainID,
+		Type:        tx.Type,
+		Nonce:       tx.Nonce,
+		GasPrice:    bigToUint64(tx.GasPrice),
+		MaxFee:      bigToUint64(tx.MaxFee),
+		PriorityFee: bigToUint64(tx.PriorityFee),
+		Gas:         tx.Gas,
+		From:        tx.From.Bytes(),
+		Hash:        tx.Hash.Bytes(),
+		Input:       tx.Input,
+		V:           tx.V,
+		R:           tx.R,
+		S:           tx.S,
+	}
+
+	if tx.To != nil {
+		proto.To = tx.To.Bytes()
+	}
+	if tx.Value != nil {
+		proto.Value = tx.Value.Bytes()
+	}
+
+	for _, tuple := range tx.AccessList {
+		keys := make([][]byte, len(tuple.StorageKeys))
+		for i, key := range tuple.StorageKeys {
+			keys[i] = key.Bytes()
+		}
+
+		proto.AccessList = append(proto.AccessList, &eth.AccessTuple{
+			Address:     tuple.Address.Bytes(),
+			StorageKeys: keys,
+		})
+	}
+
+	return proto
+}
+
+// ToProto converts the header to its protobuf message.
+func (h *ExecutionPayloadHeader) ToProto() *eth.ExecutionPayloadHeader {
+	proto := &eth.ExecutionPayloadHeader{
+		ParentHash:       h.ParentHash.Bytes(),
+		FeeRecipient:     h.FeeRecipient.Bytes(),
+		StateRoot:        h.StateRoot.Bytes(),
+		ReceiptsRoot:     h.ReceiptRoot.Bytes(),
+		LogsBloom:        h.LogsBloom.Bytes(),
+		PrevRandao:       h.PrevRandao.Bytes(),
+		BlockNumber:      h.Number,
+		GasLimit:         h.GasLimit,
+		GasUsed:          h.GasUsed,
+		Timestamp:        h.Timestamp,
+		ExtraData:        h.ExtraData,
+		BlockHash:        h.Hash.Bytes(),
+		TransactionsRoot: h.TransactionsRoot.Bytes(),
+	}
+
+	if h.BaseFeePerGas != nil {
+		proto.BaseFeePerGas = h.BaseFeePerGas.Bytes()
+	}
+	if h.WithdrawalsRoot != nil {
+		proto.WithdrawalsRoot = h.WithdrawalsRoot.Bytes()
+	}
+
+	return proto
+}
+
+// ToProto converts the payload to its protobuf message.
+func (p *ExecutionPayload) ToProto() *eth.ExecutionPayload {
+	txs := make([]*eth.Transaction, len(p.Transactions))
+	for i, tx := range p.Transactions {
+		txs[i] = tx.ToProto()
+	}
+
+	return &eth.ExecutionPayload{
+		Header:       p.Header.ToProto(),
+		Transactions: txs,
+	}
+}
+
+// ToProto converts the block to its protobuf message.
+func (b *BeaconBlock) ToProto() *eth.CompactBeaconBlock {
+	block := &eth.CompactBeaconBlock{
+		Slot:          b.Slot,
+		ProposerIndex: b.ProposerIndex,
+		ParentRoot:    b.ParentRoot.Bytes(),
+		StateRoot:     b.StateRoot.Bytes(),
+	}
+	if b.Body == nil {
+		return block
+	}
+
+	in := b.Body
+	body := &eth.CompactBeaconBlockBody{
+		RandaoReveal: in.RandaoReveal,
+		Graffiti:     in.Graffiti.Bytes(),
+	}
+	if in.Eth1Data != nil {
+		body.Eth1Data = &eth.Eth1Data{
+			DepositRoot:  in.Eth1Data.DepositRoot.Bytes(),
+			DepositCount: in.Eth1Data.DepositCount,
+			BlockHash:    in.Eth1Data.BlockHash.Bytes(),
+		}
+	}
+
+	for _, slashing := range in.ProposerSlashingsList {
+		body.ProposerSlashings = append(body.ProposerSlashings, &eth.ProposerSlashing{
+			Header_1: toProtoSignedBeaconBlockHeader(slashing.Header1),
+			Header_2: toProtoSignedBeaconBlockHeader(slashing.Header2),
+		})
+	}
+	for _, slashing := range in.AttesterSlashingsList {
+		body.AttesterSlashings = append(body.AttesterSlashings, &eth.AttesterSlashing{
+			Attestation_1: toProtoIndexedAttestation(slashing.Attestation1),
+			Attestation_2: toProtoIndexedAttestation(slashing.Attestation2),
+		})
+	}
+	for _, attestation := range in.AttestationsList {
+		body.Attestations = append(body.Attestations, &eth.Attestation{
+			AggregationBits: attestation.AggregationBits,
+			Data:            toProtoAttestationData(attestation.Data),
+			Signature:       attestation.Signature,
+		})
+	}
+	for _, deposit := range in.DepositsList {
+		body.Deposits = append(body.Deposits, toProtoDeposit(deposit))
+	}
+	for _, exit := range in.VoluntaryExitsList {
+		proto := &eth.SignedVoluntaryExit{Signature: exit.Signature}
+		if exit.Message != nil {
+			proto.Message = &eth.VoluntaryExit{
+				Epoch:          exit.Message.Epoch,
+				ValidatorIndex: exit.Message.ValidatorIndex,
+			}
+		}
+
+		body.VoluntaryExits = append(body.VoluntaryExits, proto)
+	}
+
+	if in.SyncAggregate != nil {
+		body.SyncAggregate = &eth.SyncAggregate{
+			SyncCommitteeBits:      in.SyncAggregate.SyncCommitteeBits,
+			SyncCommitteeSignature: in.SyncAggregate.SyncCommitteeSignature,
+		}
+	}
+
+	for _, change := range in.BlsToExecutionChangesList {
+		proto := &eth.SignedBLSToExecutionChange{Signature: change.Signature}
+		if change.Message != nil {
+			proto.Message = &eth.BLSToExecutionChange{
+				ValidatorIndex:     change.Message.ValidatorIndex,
+				FromBlsPubkey:      change.Message.FromBlsPubkey,
+				ToExecutionAddress: change.Message.ToExecutionAddress.Bytes(),
+			}
+		}
+
+		body.BlsToExecutionChanges = append(body.BlsToExecutionChanges, proto)
+	}
+
+	block.Body = body
+	return block
+}
+
+func toProtoSignedBeaconBlockHeader(header *SignedBeaconBlockHeader) *eth.SignedBeaconBlockHeader {
+	if header == nil {
+		return nil
+	}
+
+	proto := &eth.SignedBeaconBlockHeader{Signature: header.Signature}
+	if m := header.Message; m != nil {
+		proto.Message = &eth.BeaconBlockHeader{
+			Slot:          m.Slot,
+			ProposerIndex: m.ProposerIndex,
+			ParentRoot:    m.ParentRoot.Bytes(),
+			StateRoot:     m.StateRoot.Bytes(),
+			BodyRoot:      m.BodyRoot.Bytes(),
+		}
+	}
+
+	return proto
+}
+
+func toProtoIndexedAttestation(attestation *IndexedAttestation) *eth.IndexedAttestation {
+	if attestation == nil {
+		return nil
+	}
+
+	return &eth.IndexedAttestation{
+		AttestingIndices: attestation.AttestingIndicesList,
+		Data:             toProtoAttestationData(attestation.Data),
+		Signature:        attestation.Signature,
+	}
+}
+
+func toProtoAttestationData(data *AttestationData) *eth.AttestationData {
+	if data == nil {
+		return nil
+	}
+
+	return &eth.AttestationData{
+		Slot:            data.Slot,
+		Index:           data.Index,
+		BeaconBlockRoot: data.BeaconBlockRoot.Bytes(),
+		Source:          toProtoCheckpoint(data.Source),
+		Target:          toProtoCheckpoint(data.Target),
+	}
+}
+
+func toProtoCheckpoint(checkpoint *Checkpoint) *eth.Checkpoint {
+	if checkpoint == nil {
+		return nil
+	}
+
+	return &eth.Checkpoint{Epoch: checkpoint.Epoch, Root: checkpoint.Root.Bytes()}
+}
+
+func toProtoDeposit(deposit Deposit) *eth.Deposit {
+	proofs := make([][]byte, len(deposit.ProofList))
+	for i, proof := range deposit.ProofList {
+		proofs[i] = proof.Bytes()
+	}
+
+	proto := &eth.Deposit{Proof: proofs}
+	if d := deposit.Data; d != nil {
+		proto.Data = &eth.DepositData{
+			Pubkey:                d.Pubkey,
+			WithdrawalCredentials: d.WithdrawalCredentials.Bytes(),
+			Amount:                d.Amount,
+			Signature:             d.Signature,
+		}
+	}
+
+	return proto
+}
+
+func bigToUint64(n *big.Int) uint64 {
+	if n == nil {
+		return 0
+	}
+
+	return n.Uint64()
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// Tracer starts the spans of a client, see WithTracer. It's the subset of the OpenTelemetry tracing
+// API the client needs, so that an OpenTelemetry tracer plugs in with a small adapter, without the
+// client depending on it.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+}
+
+// Attribute is an attribute of a span. Value is a string or an int64.
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// The attributes of the spans of a client.
+const (
+	// AttrTimestamp is the timestamp (us) the Fiber node returned for a send.
+	AttrTimestamp = "fiber.timestamp"
+	// AttrReceivedAt is when a streamed message was received (us since the Unix epoch). The streams carry
+	// no server timing, so it's the closest there is to AttrTimestamp for the messages.
+	AttrReceivedAt  = "fiber.received_at"
+	AttrStream      = "fiber.stream"
+	AttrTxHash      = "fiber.tx.hash"
+	AttrBlockNumber = "fiber.block.number"
+	AttrBlockHash   = "fiber.block.hash"
+	AttrSlot        = "fiber.slot"
+)
+
+// WithTracer emits a span for every SendTransaction and SendRawTransaction call, and for every
+// subscription message, from its reception to its delivery on the channel. The sends share
+// long-lived streams, which gRPC interceptors (see WithInterceptors) only see as a single call, so
+// the send spans are what traces a transaction end to end: they carry its hash and the Fiber
+// timestamp (AttrTimestamp).
+func WithTracer(t Tracer) ClientOption {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+// startSpan starts a span with t, or a span doing nothing if t is nil.
+func startSpan(t Tracer, ctx context.Context, name string) (context.Context, Span) {
+	if t == nil {
+		return ctx, noopSpan{}
+	}
+
+	return t.Start(ctx, name)
+}
+
+// endSend ends the span of a send with its result.
+func endSend(span Span, hash string, timestamp int64, err error) {
+	if err != nil {
+		span.RecordError(err)
+	} else {
+		span.SetAttributes(Attribute{AttrTxHash, hash}, Attribute{AttrTimestamp, timestamp})
+	}
+	span.End()
+}
+
+// startMessage starts the span of a streamed message, received now.
+func (cfg *subscriptionConfig) startMessage(ctx context.Context, stream StreamKind, msg any) Span {
+	if cfg.tracer == nil {
+		return noopSpan{}
+	}
+
+	_, span := cfg.tracer.Start(ctx, "fiber."+string(stream))
+	span.SetAttributes(Attribute{AttrStream, string(stream)}, Attribute{AttrReceivedAt, time.Now().UnixMicro()})
+
+	switch msg := msg.(type) {
+	case *Transaction:
+		span.SetAttributes(Attribute{AttrTxHash, msg.Hash.Hex()})
+	case *ExecutionPayloadHeader:
+		span.SetAttributes(Attribute{AttrBlockNumber, int64(msg.Number)}, Attribute{AttrBlockHash, msg.Hash.Hex()})
+	case *ExecutionPayload:
+		if msg.Header != nil {
+			span.SetAttributes(Attribute{AttrBlockNumber, int64(msg.Header.Number)}, Attribute{AttrBlockHash, msg.Header.Hash.Hex()})
+		}
+	case *BeaconBlock:
+		span.SetAttributes(Attribute{AttrSlot, int64(msg.Slot)})
+	}
+
+	return span
+}
+
+// endMessage ends the span of a streamed message, with the error delivering it.
+func endMessage(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
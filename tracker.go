@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// InclusionEvent is emitted by a Tracker when a watched transaction is included in a block.
+type InclusionEvent struct {
+	Hash        common.Hash
+	BlockNumber uint64
+	BlockHash   common.Hash
+	// Index is the position of the transaction in the block.
+	Index int
+	// WatchedAt is when Watch was called, IncludedAt when the block was received.
+	WatchedAt  time.Time
+	IncludedAt time.Time
+
+	// Outcome is the execution outcome, if the tracker has an OutcomeSource. It's nil if fetching it
+	// failed, in which case OutcomeErr is set.
+	Outcome    *ExecutionOutcome
+	OutcomeErr error
+}
+
+// ExecutionOutcome is the result of executing an included transaction.
+type ExecutionOutcome struct {
+	// Status is types.ReceiptStatusSuccessful or types.ReceiptStatusFailed.
+	Status            uint64
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	// RevertReason is the decoded revert reason of a failed transaction, if it could be traced.
+	RevertReason string
+}
+
+// Reverted returns whether the transaction failed.
+func (o *ExecutionOutcome) Reverted() bool {
+	return o.Status == types.ReceiptStatusFailed
+}
+
+// OutcomeSource fetches the execution outcome of included transactions, usually from an RPC node.
+type OutcomeSource interface {
+	Outcome(ctx context.Context, hash common.Hash) (*ExecutionOutcome, error)
+}
+
+const (
+	// An RPC node may import a block after Fiber streams it, so missing receipts are retried.
+	outcomeRetries    = 5
+	outcomeRetryDelay = 500 * time.Millisecond
+)
+
+// Tracker watches transactions and reports their inclusion from the execution payload stream. Build
+// it with NewTracker and the chained methods, then call Run.
+type Tracker struct {
+	mu       sync.Mutex
+	watched  map[common.Hash]time.Time
+	outcomes OutcomeSource
+	opts     []SubscriptionOption
+}
+
+// NewTracker returns a tracker without watched transactions.
+func NewTracker() *Tracker {
+	return &Tracker{watched: make(map[common.Hash]time.Time)}
+}
+
+// Outcomes makes the tracker fetch the execution outcome of every included transaction from src,
+// and attach it to the inclusion event.
+func (t *Tracker) Outcomes(src OutcomeSource) *Tracker {
+	t.outcomes = src
+	return t
+}
+
+// Options sets the options of the underlying subscription.
+func (t *Tracker) Options(opts ...SubscriptionOption) *Tracker {
+	t.opts = append(t.opts, opts...)
+	return t
+}
+
+// Watch starts watching the transaction with the given hash. It's watched until it's included.
+func (t *Tracker) Watch(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.watched[hash]; !ok {
+		t.watched[hash] = time.Now()
+	}
+}
+
+// Unwatch stops watching the transaction with the given hash.
+func (t *Tracker) Unwatch(hash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.watched, hash)
+}
+
+// Watching returns the number of watched transactions.
+func (t *Tracker) Watching() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.watched)
+}
+
+// Run subscribes to execution payloads on c and sends an event on events for every watched transaction
+// that is included, until ctx is done or the subscription fails. It never closes events.
+func (t *Tracker) Run(ctx context.Context, c *Client, events chan<- *InclusionEvent) error {
+	return NewPipeline(ExecutionPayloadStream()).
+		Options(t.opts...).
+		Sink(SinkFunc[*ExecutionPayload](func(ctx context.Context, block *ExecutionPayload) error {
+			for _, event := range t.included(block) {
+				if t.outcomes != nil {
+					event.Outcome, event.OutcomeErr = t.outcome(ctx, event.Hash)
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			return nil
+		})).
+		Run(ctx, c)
+}
+
+// included returns the events for the watched transactions in block, and stops watching them.
+func (t *Tracker) included(block *ExecutionPayload) []*InclusionEvent {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var events []*InclusionEvent
+	for i, tx := range block.Transactions {
+		watchedAt, ok := t.watched[tx.Hash]
+		if !ok {
+			continue
+		}
+
+		delete(t.watched, tx.Hash)
+		events = append(events, &InclusionEvent{
+			Hash:        tx.Hash,
+			BlockNumber: block.Header.Number,
+			BlockHash:   block.Header.Hash,
+			Index:       i,
+			WatchedAt:   watchedAt,
+			IncludedAt:  now,
+		})
+	}
+
+	return events
+}
+
+func (t *Tracker) outcome(ctx context.Context, hash common.Hash) (*ExecutionOutcome, error) {
+	for attempt := 0; ; attempt++ {
+		outcome, err := t.outcomes.Outcome(ctx, hash)
+		if !errors.Is(err, ethereum.NotFound) || attempt == outcomeRetries {
+			return outcome, err
+		}
+
+		select {
+		case <-time.After(outcomeRetryDelay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// RPCOutcomes is an OutcomeSource backed by an execution client's JSON-RPC API. Receipts come from
+// eth_getTransactionReceipt, and revert reasons from debug_traceTransaction with the call tracer.
+type RPCOutcomes struct {
+	client *rpc.Client
+	trace  bool
+}
+
+// NewRPCOutcomes connects to the execution client at url. If trace is false, or the client doesn't
+// support tracing, outcomes have no revert reason.
+func NewRPCOutcomes(ctx context.Context, url string, trace bool) (*RPCOutcomes, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to execution client: %w", err)
+	}
+
+	return &RPCOutcomes{client: client, trace: trace}, nil
+}
+
+// Close closes the connection to the execution client.
+func (o *RPCOutcomes) Close() {
+	o.client.Close()
+}
+
+func (o *RPCOutcomes) Outcome(ctx context.Context, hash common.Hash) (*ExecutionOutcome, error) {
+	var receipt *struct {
+		Status            hexutil.Uint64 `json:"status"`
+		GasUsed           hexutil.Uint64 `json:"gasUsed"`
+		EffectiveGasPrice *hexutil.Big   `json:"effectiveGasPrice"`
+	}
+	if err := o.client.CallContext(ctx, &receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, fmt.Errorf("getting receipt of %s: %w", hash, err)
+	}
+	if receipt == nil {
+		return nil, fmt.Errorf("getting receipt of %s: %w", hash, ethereum.NotFound)
+	}
+
+	outcome := &ExecutionOutcome{
+		Status:  uint64(receipt.Status),
+		GasUsed: uint64(receipt.GasUsed),
+	}
+	if receipt.EffectiveGasPrice != nil {
+		outcome.EffectiveGasPrice = receipt.EffectiveGasPrice.ToInt()
+	}
+
+	if o.trace && outcome.Reverted() {
+		// Tracing is best effort, a node without the debug namespace still gives the receipt
+		outcome.RevertReason, _ = o.revertReason(ctx, hash)
+	}
+
+	return outcome, nil
+}
+
+func (o *RPCOutcomes) revertReason(ctx context.Context, hash common.Hash) (string, error) {
+	var call struct {
+		Error  string        `json:"error"`
+		Output hexutil.Bytes `json:"output"`
+	}
+	if err := o.client.CallContext(ctx, &call, "debug_traceTransaction", hash, map[string]string{"tracer": "callTracer"}); err != nil {
+		return "", fmt.Errorf("tracing %s: %w", hash, err)
+	}
+
+	if reason, err := abi.UnpackRevert(call.Output); err == nil {
+		return reason, nil
+	}
+
+	return call.Error, nil
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type fakeOutcomes struct {
+	missing int
+}
+
+func (f *fakeOutcomes) Outcome(ctx context.Context, hash common.Hash) (*ExecutionOutcome, error) {
+	if f.missing > 0 {
+		f.missing--
+		return nil, ethereum.NotFound
+	}
+
+	return &ExecutionOutcome{Status: types.ReceiptStatusFailed, GasUsed: 21000}, nil
+}
+
+func TestTracker(t *testing.T) {
+	watched, other := common.HexToHash("0x01"), common.HexToHash("0x02")
+	tracker := NewTracker().Outcomes(&fakeOutcomes{missing: 1})
+	tracker.Watch(watched)
+
+	block := &ExecutionPayload{
+		Header:       &ExecutionPayloadHeader{Number: 10, Hash: common.HexToHash("0xb0")},
+		Transactions: []*Transaction{{Hash: other}, {Hash: watched}},
+	}
+
+	events := tracker.included(block)
+	if len(events) != 1 || events[0].Hash != watched || events[0].Index != 1 || events[0].BlockNumber != 10 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if tracker.Watching() != 0 {
+		t.Fatal("included transaction should not be watched anymore")
+	}
+	if events := tracker.included(block); len(events) != 0 {
+		t.Fatalf("expected no events for an unwatched transaction, got %+v", events)
+	}
+
+	outcome, err := tracker.outcome(context.Background(), watched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !outcome.Reverted() || outcome.GasUsed != 21000 {
+		t.Fatalf("unexpected outcome: %+v", outcome)
+	}
+}
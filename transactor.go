@@ -0,0 +1,51 @@
+package client
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ContractBackend is the interface of go-ethereum's bind.ContractBackend, which abigen bindings are
+// created with. *ethclient.Client implements it.
+type ContractBackend interface {
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+	SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+}
+
+// Transactor implements go-ethereum's ethereum.TransactionSender by sending through Fiber, so that
+// go-ethereum tooling routes its transactions through Fiber without code changes. With a backend, it
+// also implements bind.ContractBackend: abigen bindings created with it call, estimate and filter
+// through the backend, usually a node, and send through Fiber.
+type Transactor struct {
+	ContractBackend
+	client *Client
+}
+
+var _ ethereum.TransactionSender = (*Transactor)(nil)
+
+// NewTransactor returns a transactor sending on c, with the backend for everything else. The backend
+// can be nil if only SendTransaction is used.
+func NewTransactor(c *Client, backend ContractBackend) *Transactor {
+	return &Transactor{ContractBackend: backend, client: c}
+}
+
+// SendTransaction sends tx through Fiber, like Client.SendTransaction.
+func (t *Transactor) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	_, _, err := t.client.SendTransaction(ctx, tx)
+	return err
+}
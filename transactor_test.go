@@ -0,0 +1,91 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/api"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+)
+
+// acceptingServer acknowledges every transaction.
+type acceptingServer struct {
+	api.UnimplementedAPIServer
+	received chan common.Hash
+}
+
+func (s acceptingServer) SendTransaction(stream api.API_SendTransactionServer) error {
+	for {
+		tx, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		s.received <- common.BytesToHash(tx.Hash)
+		if err := stream.Send(&api.TransactionResponse{Hash: common.BytesToHash(tx.Hash).Hex(), Timestamp: 1}); err != nil {
+			return err
+		}
+	}
+}
+
+// estimatingBackend serves gas estimates, and fails the test if it's asked to send.
+type estimatingBackend struct {
+	ContractBackend
+	t *testing.T
+}
+
+func (estimatingBackend) EstimateGas(context.Context, ethereum.CallMsg) (uint64, error) {
+	return 21000, nil
+}
+
+func (b estimatingBackend) SendTransaction(context.Context, *types.Transaction) error {
+	b.t.Fatal("sent through the backend")
+	return nil
+}
+
+func TestTransactor(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	received := make(chan common.Hash, 1)
+	api.RegisterAPIServer(server, acceptingServer{received: received})
+	go server.Serve(lis)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := NewClient(lis.Addr().String())
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{ChainID: common.Big1, Nonce: 1, Gas: 21000, GasTipCap: common.Big1, GasFeeCap: common.Big2}), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transactor := NewTransactor(c, estimatingBackend{t: t})
+	if gas, err := transactor.EstimateGas(ctx, ethereum.CallMsg{}); err != nil || gas != 21000 {
+		t.Fatalf("expected the estimate of the backend, got %d, %v", gas, err)
+	}
+	if err := transactor.SendTransaction(ctx, signed); err != nil {
+		t.Fatal(err)
+	}
+	if hash := <-received; hash != signed.Hash() {
+		t.Fatalf("expected %s to be sent through Fiber, got %s", signed.Hash(), hash)
+	}
+}
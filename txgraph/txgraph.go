@@ -0,0 +1,304 @@
+// Package txgraph builds a directed graph of the value and token flows between addresses seen in
+// the stream, over a sliding time window, for forensics and research. Graphs can be exported to
+// DOT (Graphviz) and GraphML.
+package txgraph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Ether is the token address of native ETH transfers.
+var Ether = common.Address{}
+
+// ERC-20 method IDs of transfer(address,uint256) and transferFrom(address,address,uint256).
+var (
+	transferID     = []byte{0xa9, 0x05, 0x9c, 0xbb}
+	transferFromID = []byte{0x23, 0xb8, 0x72, 0xdd}
+)
+
+// EdgeKey identifies the flow of one token between two addresses.
+type EdgeKey struct {
+	From  common.Address
+	To    common.Address
+	Token common.Address
+}
+
+// Edge is the aggregate of the transfers of a token from one address to another in the window.
+// First is the time of the first transfer since the edge last appeared in the window.
+type Edge struct {
+	EdgeKey
+	Value *big.Int
+	Count int
+	First time.Time
+	Last  time.Time
+}
+
+type transfer struct {
+	key   EdgeKey
+	value *big.Int
+	at    time.Time
+}
+
+// Graph is a flow graph over a sliding time window. It's safe for concurrent use.
+type Graph struct {
+	window time.Duration
+
+	mu        sync.Mutex
+	transfers []transfer
+	edges     map[EdgeKey]*Edge
+	latest    time.Time
+}
+
+// New returns a graph keeping the transfers of the last window, relative to the most recent
+// transfer. A window of 0 keeps everything.
+func New(window time.Duration) *Graph {
+	return &Graph{
+		window: window,
+		edges:  make(map[EdgeKey]*Edge),
+	}
+}
+
+// AddTransaction adds the ETH value and the ERC-20 transfer of tx, seen at the given time. Contract
+// creations and transactions without value or token transfer are ignored.
+func (g *Graph) AddTransaction(tx *client.Transaction, at time.Time) {
+	if tx.To == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if tx.Value != nil && tx.Value.Sign() > 0 {
+		g.add(transfer{key: EdgeKey{From: tx.From, To: *tx.To, Token: Ether}, value: tx.Value, at: at})
+	}
+
+	if from, to, value, ok := decodeTransfer(tx.From, tx.Input); ok {
+		g.add(transfer{key: EdgeKey{From: from, To: to, Token: *tx.To}, value: value, at: at})
+	}
+
+	g.evict()
+}
+
+// AddPayload adds all the transactions of an execution payload, at the block timestamp.
+func (g *Graph) AddPayload(p *client.ExecutionPayload) {
+	at := time.Unix(int64(p.Header.Timestamp), 0)
+	for _, tx := range p.Transactions {
+		g.AddTransaction(tx, at)
+	}
+}
+
+func decodeTransfer(sender common.Address, input []byte) (from, to common.Address, value *big.Int, ok bool) {
+	switch {
+	case len(input) == 4+2*32 && bytes.Equal(input[:4], transferID):
+		return sender, common.BytesToAddress(input[4:36]), new(big.Int).SetBytes(input[36:68]), true
+	case len(input) == 4+3*32 && bytes.Equal(input[:4], transferFromID):
+		return common.BytesToAddress(input[4:36]), common.BytesToAddress(input[36:68]), new(big.Int).SetBytes(input[68:100]), true
+	}
+
+	return common.Address{}, common.Address{}, nil, false
+}
+
+func (g *Graph) add(t transfer) {
+	g.transfers = append(g.transfers, t)
+	if t.at.After(g.latest) {
+		g.latest = t.at
+	}
+
+	edge, ok := g.edges[t.key]
+	if !ok {
+		edge = &Edge{EdgeKey: t.key, Value: new(big.Int), First: t.at, Last: t.at}
+		g.edges[t.key] = edge
+	}
+
+	edge.Value.Add(edge.Value, t.value)
+	edge.Count++
+	if t.at.Before(edge.First) {
+		edge.First = t.at
+	}
+	if t.at.After(edge.Last) {
+		edge.Last = t.at
+	}
+}
+
+// evict removes the transfers that fell out of the window. Transfers are assumed to be added
+// roughly in order, so only the oldest ones are checked.
+func (g *Graph) evict() {
+	if g.window == 0 {
+		return
+	}
+
+	cutoff := g.latest.Add(-g.window)
+	n := 0
+	for n < len(g.transfers) && g.transfers[n].at.Before(cutoff) {
+		t := g.transfers[n]
+		edge := g.edges[t.key]
+		edge.Value.Sub(edge.Value, t.value)
+		edge.Count--
+		if edge.Count == 0 {
+			delete(g.edges, t.key)
+		}
+		n++
+	}
+
+	if n > 0 {
+		g.transfers = append(g.transfers[:0], g.transfers[n:]...)
+	}
+}
+
+// Edges returns a copy of the edges, sorted by value, largest first.
+func (g *Graph) Edges() []Edge {
+	g.mu.Lock()
+	edges := make([]Edge, 0, len(g.edges))
+	for _, edge := range g.edges {
+		e := *edge
+		e.Value = new(big.Int).Set(edge.Value)
+		edges = append(edges, e)
+	}
+	g.mu.Unlock()
+
+	sort.Slice(edges, func(i, j int) bool {
+		if c := edges[i].Value.Cmp(edges[j].Value); c != 0 {
+			return c > 0
+		}
+		return edges[i].Last.After(edges[j].Last)
+	})
+
+	return edges
+}
+
+// nodes returns the addresses in the edges, sorted.
+func nodes(edges []Edge) []common.Address {
+	seen := make(map[common.Address]bool)
+	var addrs []common.Address
+	for _, e := range edges {
+		for _, addr := range []common.Address{e.From, e.To} {
+			if !seen[addr] {
+				seen[addr] = true
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+	return addrs
+}
+
+func tokenName(token common.Address) string {
+	if token == Ether {
+		return "ETH"
+	}
+	return client.AddressHex(token)
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	edges := g.Edges()
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph flows {\n")
+	for _, addr := range nodes(edges) {
+		fmt.Fprintf(&buf, "  %q;\n", client.AddressHex(addr))
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&buf, "  %q -> %q [label=%q, token=%q, value=%q, count=%d];\n",
+			client.AddressHex(e.From), client.AddressHex(e.To), fmt.Sprintf("%s %s", e.Value, tokenName(e.Token)), tokenName(e.Token), e.Value.String(), e.Count)
+	}
+	buf.WriteString("}\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID string `xml:"id,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+// WriteGraphML writes the graph as GraphML. Values are strings, since they don't fit the GraphML
+// numeric types.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	edges := g.Edges()
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "token", For: "edge", Name: "token", Type: "string"},
+			{ID: "value", For: "edge", Name: "value", Type: "string"},
+			{ID: "count", For: "edge", Name: "count", Type: "int"},
+			{ID: "first", For: "edge", Name: "first", Type: "string"},
+			{ID: "last", For: "edge", Name: "last", Type: "string"},
+		},
+	}
+	doc.Graph.ID = "flows"
+	doc.Graph.EdgeDefault = "directed"
+
+	for _, addr := range nodes(edges) {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: client.AddressHex(addr)})
+	}
+	for _, e := range edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: client.AddressHex(e.From),
+			Target: client.AddressHex(e.To),
+			Data: []graphMLData{
+				{Key: "token", Value: tokenName(e.Token)},
+				{Key: "value", Value: e.Value.String()},
+				{Key: "count", Value: fmt.Sprint(e.Count)},
+				{Key: "first", Value: e.First.UTC().Format(time.RFC3339)},
+				{Key: "last", Value: e.Last.UTC().Format(time.RFC3339)},
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encoding GraphML: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
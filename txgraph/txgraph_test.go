@@ -0,0 +1,80 @@
+package txgraph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	client "github.com/chainbound/fiber-go"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	alice = common.HexToAddress("0x01")
+	bob   = common.HexToAddress("0x02")
+	token = common.HexToAddress("0x03")
+)
+
+func transferInput(to common.Address, value int64) []byte {
+	input := append([]byte{}, transferID...)
+	input = append(input, common.LeftPadBytes(to.Bytes(), 32)...)
+	return append(input, common.LeftPadBytes(big.NewInt(value).Bytes(), 32)...)
+}
+
+func TestGraph(t *testing.T) {
+	g := New(time.Minute)
+	start := time.Unix(1000, 0)
+
+	g.AddTransaction(&client.Transaction{From: alice, To: &bob, Value: big.NewInt(5)}, start)
+	g.AddTransaction(&client.Transaction{From: alice, To: &bob, Value: big.NewInt(7)}, start.Add(time.Second))
+	g.AddTransaction(&client.Transaction{From: alice, To: &token, Value: new(big.Int), Input: transferInput(bob, 100)}, start.Add(2*time.Second))
+
+	edges := g.Edges()
+	if len(edges) != 2 {
+		t.Fatalf("expected 2 edges, got %+v", edges)
+	}
+	if edges[0].Token != token || edges[0].Value.Int64() != 100 || edges[0].To != bob {
+		t.Fatalf("unexpected token edge %+v", edges[0])
+	}
+	if edges[1].Token != Ether || edges[1].Value.Int64() != 12 || edges[1].Count != 2 {
+		t.Fatalf("unexpected ETH edge %+v", edges[1])
+	}
+
+	// Only the token transfer stays in the window
+	g.AddTransaction(&client.Transaction{From: bob, To: &alice, Value: big.NewInt(1)}, start.Add(time.Minute+time.Second+time.Millisecond))
+	for _, e := range g.Edges() {
+		if e.From == alice && e.Token == Ether {
+			t.Fatalf("expected the ETH edge to be evicted, got %+v", e)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	g := New(0)
+	g.AddTransaction(&client.Transaction{From: alice, To: &bob, Value: big.NewInt(5)}, time.Unix(1000, 0))
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(dot.String(), `"`+alice.Hex()+`" -> "`+bob.Hex()+`"`) {
+		t.Fatalf("missing edge in DOT output:\n%s", dot.String())
+	}
+
+	var graphml bytes.Buffer
+	if err := g.WriteGraphML(&graphml); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal(graphml.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 1 {
+		t.Fatalf("unexpected GraphML document:\n%s", graphml.String())
+	}
+}
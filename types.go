@@ -2,7 +2,10 @@ package client
 
 import (
 	"math/big"
+	"math/bits"
+	"unsafe"
 
+	"github.com/chainbound/fiber-go/labels"
 	"github.com/chainbound/fiber-go/protobuf/eth"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -11,9 +14,11 @@ import (
 // ==================== TRANSACTION ====================
 
 type Transaction struct {
-	ChainID     uint32
-	To          *common.Address
-	From        common.Address
+	ChainID uint32
+	To      *common.Address
+	From    common.Address
+	// FromSource is where From comes from.
+	FromSource  SenderSource
 	Gas         uint64
 	GasPrice    *big.Int
 	Hash        common.Hash
@@ -27,8 +32,27 @@ type Transaction struct {
 	R           []byte
 	S           []byte
 	AccessList  types.AccessList
+
+	// Labels of the sender and receiver, if the subscription has a label registry (see WithLabels)
+	// and the address is in it.
+	FromLabel *labels.Label
+	ToLabel   *labels.Label
+
+	// Similar is set if the subscription has a similarity detector (see WithSimilarityDetector) and
+	// a recent transaction from another sender had the same calldata.
+	Similar *Similarity
+
+	// USDValue is the dollar value the transaction moves in ETH and token transfers, if the
+	// subscription has a token table (see WithTokenTable).
+	USDValue float64
+
+	hashHex unsafe.Pointer // *string, see HashHex
+	// to is where To points once decoded, so that the receiver isn't allocated on its own.
+	to common.Address
 }
 
+// ToNative converts the transaction to a go-ethereum transaction. It's nil for blob transactions,
+// which go-ethereum can't represent yet (see BlobTx), and if the type is unknown (see KnownType).
 func (tx *Transaction) ToNative() *types.Transaction {
 	switch tx.Type {
 	case 0:
@@ -45,30 +69,32 @@ func (tx *Transaction) ToNative() *types.Transaction {
 		})
 	case 1:
 		return types.NewTx(&types.AccessListTx{
-			ChainID:  big.NewInt(int64(tx.ChainID)),
-			Nonce:    tx.Nonce,
-			GasPrice: tx.GasPrice,
-			Gas:      tx.Gas,
-			To:       tx.To,
-			Value:    tx.Value,
-			Data:     tx.Input,
-			V:        big.NewInt(int64(tx.V)),
-			R:        new(big.Int).SetBytes(tx.R),
-			S:        new(big.Int).SetBytes(tx.S),
+			ChainID:    big.NewInt(int64(tx.ChainID)),
+			Nonce:      tx.Nonce,
+			GasPrice:   tx.GasPrice,
+			Gas:        tx.Gas,
+			To:         tx.To,
+			Value:      tx.Value,
+			Data:       tx.Input,
+			AccessList: tx.AccessList,
+			V:          big.NewInt(int64(tx.V)),
+			R:          new(big.Int).SetBytes(tx.R),
+			S:          new(big.Int).SetBytes(tx.S),
 		})
 	case 2:
 		return types.NewTx(&types.DynamicFeeTx{
-			ChainID:   big.NewInt(int64(tx.ChainID)),
-			Nonce:     tx.Nonce,
-			GasFeeCap: tx.MaxFee,
-			GasTipCap: tx.PriorityFee,
-			Gas:       tx.Gas,
-			To:        tx.To,
-			Value:     tx.Value,
-			Data:      tx.Input,
-			V:         big.NewInt(int64(tx.V)),
-			R:         new(big.Int).SetBytes(tx.R),
-			S:         new(big.Int).SetBytes(tx.S),
+			ChainID:    big.NewInt(int64(tx.ChainID)),
+			Nonce:      tx.Nonce,
+			GasFeeCap:  tx.MaxFee,
+			GasTipCap:  tx.PriorityFee,
+			Gas:        tx.Gas,
+			To:         tx.To,
+			Value:      tx.Value,
+			Data:       tx.Input,
+			AccessList: tx.AccessList,
+			V:          big.NewInt(int64(tx.V)),
+			R:          new(big.Int).SetBytes(tx.R),
+			S:          new(big.Int).SetBytes(tx.S),
 		})
 	}
 
@@ -83,6 +109,11 @@ func TxToProto(tx *types.Transaction) (*eth.Transaction, error) {
 		return nil, err
 	}
 
+	return txToProto(tx, sender), nil
+}
+
+// txToProto converts a go-ethereum transaction with a known sender to a protobuf transaction.
+func txToProto(tx *types.Transaction, sender common.Address) *eth.Transaction {
 	var to []byte
 	if tx.To() != nil {
 		to = tx.To().Bytes()
@@ -101,6 +132,8 @@ func TxToProto(tx *types.Transaction) (*eth.Transaction, error) {
 				for j, key := range tuple.StorageKeys {
 					storageKeys[j] = key.Bytes()
 				}
+
+				acl[i].StorageKeys = storageKeys
 			}
 		}
 	}
@@ -123,17 +156,11 @@ func TxToProto(tx *types.Transaction) (*eth.Transaction, error) {
 		R:           r.Bytes(),
 		S:           s.Bytes(),
 		AccessList:  acl,
-	}, nil
+	}
 }
 
 // ProtoToTx converts a protobuf transaction to a go-ethereum transaction.
 func ProtoToTx(proto *eth.Transaction) *Transaction {
-	to := new(common.Address)
-
-	if len(proto.To) > 0 {
-		to = (*common.Address)(proto.To)
-	}
-
 	var acl []types.AccessTuple
 	if len(proto.AccessList) > 0 {
 		acl = make([]types.AccessTuple, len(proto.AccessList))
@@ -157,7 +184,7 @@ func ProtoToTx(proto *eth.Transaction) *Transaction {
 		}
 	}
 
-	return &Transaction{
+	tx := &Transaction{
 		ChainID:     proto.ChainId,
 		Type:        proto.Type,
 		Nonce:       proto.Nonce,
@@ -165,8 +192,8 @@ func ProtoToTx(proto *eth.Transaction) *Transaction {
 		MaxFee:      big.NewInt(int64(proto.MaxFee)),
 		PriorityFee: big.NewInt(int64(proto.PriorityFee)),
 		Gas:         proto.Gas,
-		To:          to,
 		From:        common.BytesToAddress(proto.From),
+		FromSource:  fromSource(proto),
 		Hash:        common.BytesToHash(proto.Hash),
 		Value:       new(big.Int).SetBytes(proto.Value),
 		Input:       proto.Input,
@@ -175,24 +202,43 @@ func ProtoToTx(proto *eth.Transaction) *Transaction {
 		S:           proto.S,
 		AccessList:  acl,
 	}
+
+	// A nil To means contract creation.
+	if len(proto.To) > 0 {
+		tx.to = common.BytesToAddress(proto.To)
+		tx.To = &tx.to
+	}
+
+	return tx
 }
 
 // ==================== EXECUTION PAYLOAD ====================
 
 type ExecutionPayloadHeader struct {
-	Number        uint64
-	Hash          common.Hash
-	ParentHash    common.Hash
-	PrevRandao    common.Hash
-	StateRoot     common.Hash
-	ReceiptRoot   common.Hash
-	FeeRecipient  common.Address
-	ExtraData     []byte
-	GasLimit      uint64
-	GasUsed       uint64
+	Number       uint64
+	Hash         common.Hash
+	ParentHash   common.Hash
+	PrevRandao   common.Hash
+	StateRoot    common.Hash
+	ReceiptRoot  common.Hash
+	FeeRecipient common.Address
+	ExtraData    []byte
+	GasLimit     uint64
+	GasUsed      uint64
+	// Timestamp is the block timestamp (s) set by the proposer. The streams carry no server timing,
+	// so the time a message was received is only known on the client, see SubscriptionStats.
 	Timestamp     uint64
 	LogsBloom     types.Bloom
 	BaseFeePerGas *big.Int
+
+	TransactionsRoot common.Hash
+	// Only: Capella
+	WithdrawalsRoot *common.Hash
+	// Only: Deneb. The streams don't carry these yet, see BeaconBlock.HashTreeRoot.
+	BlobGasUsed   *uint64
+	ExcessBlobGas *uint64
+
+	hashHex unsafe.Pointer // *string, see HashHex
 }
 
 type ExecutionPayload struct {
@@ -200,8 +246,9 @@ type ExecutionPayload struct {
 	Transactions []*Transaction
 }
 
+// ProtoToHeader converts a protobuf execution payload header.
 func ProtoToHeader(proto *eth.ExecutionPayloadHeader) *ExecutionPayloadHeader {
-	return &ExecutionPayloadHeader{
+	header := &ExecutionPayloadHeader{
 		Number:        proto.BlockNumber,
 		Hash:          common.BytesToHash(proto.BlockHash),
 		ParentHash:    common.BytesToHash(proto.ParentHash),
@@ -215,9 +262,19 @@ func ProtoToHeader(proto *eth.ExecutionPayloadHeader) *ExecutionPayloadHeader {
 		ExtraData:     proto.ExtraData,
 		FeeRecipient:  common.BytesToAddress(proto.FeeRecipient),
 		BaseFeePerGas: new(big.Int).SetBytes(proto.BaseFeePerGas),
+
+		TransactionsRoot: common.BytesToHash(proto.TransactionsRoot),
+	}
+
+	if proto.WithdrawalsRoot != nil {
+		root := common.BytesToHash(proto.WithdrawalsRoot)
+		header.WithdrawalsRoot = &root
 	}
+
+	return header
 }
 
+// ProtoToBlock converts a protobuf execution payload.
 func ProtoToBlock(proto *eth.ExecutionPayload) *ExecutionPayload {
 	header := proto.Header
 	txs := make([]*Transaction, len(proto.Transactions))
@@ -241,8 +298,10 @@ type BeaconBlock struct {
 	Body          *BeaconBlockBody
 }
 
+// BeaconBlockBody is the body of a beacon block. Its BLS signatures (96 bytes), BLS public keys
+// (48 bytes) and SSZ bitfields (variable length) don't fit in a common.Hash, so they're raw bytes.
 type BeaconBlockBody struct {
-	RandaoReveal              common.Hash
+	RandaoReveal              []byte
 	Eth1Data                  *Eth1Data
 	Graffiti                  common.Hash
 	ProposerSlashingsList     []ProposerSlashing
@@ -252,6 +311,8 @@ type BeaconBlockBody struct {
 	VoluntaryExitsList        []VoluntaryExit
 	SyncAggregate             *SyncAggregate
 	BlsToExecutionChangesList []ExecutionChange
+	// Only: Deneb. The stream doesn't carry these yet, see BeaconBlock.HashTreeRoot.
+	BlobKZGCommitments []KZGCommitment
 }
 
 type Eth1Data struct {
@@ -267,7 +328,7 @@ type ProposerSlashing struct {
 
 type SignedBeaconBlockHeader struct {
 	Message   *BeaconBlockHeader
-	Signature common.Hash
+	Signature []byte
 }
 
 type BeaconBlockHeader struct {
@@ -286,7 +347,7 @@ type AttesterSlashing struct {
 type IndexedAttestation struct {
 	AttestingIndicesList []uint64
 	Data                 *AttestationData
-	Signature            common.Hash
+	Signature            []byte
 }
 
 type AttestationData struct {
@@ -303,9 +364,9 @@ type Checkpoint struct {
 }
 
 type Attestation struct {
-	AggregationBits common.Hash
+	AggregationBits []byte
 	Data            *AttestationData
-	Signature       common.Hash
+	Signature       []byte
 }
 
 type Deposit struct {
@@ -314,15 +375,15 @@ type Deposit struct {
 }
 
 type DepositData struct {
-	Pubkey                common.Hash
+	Pubkey                []byte
 	WithdrawalCredentials common.Hash
 	Amount                uint64
-	Signature             common.Hash
+	Signature             []byte
 }
 
 type VoluntaryExit struct {
 	Message   *VoluntaryExitMessage
-	Signature common.Hash
+	Signature []byte
 }
 
 type VoluntaryExitMessage struct {
@@ -331,21 +392,74 @@ type VoluntaryExitMessage struct {
 }
 
 type SyncAggregate struct {
-	SyncCommitteeBits      common.Hash
-	SyncCommitteeSignature common.Hash
+	SyncCommitteeBits      []byte
+	SyncCommitteeSignature []byte
+}
+
+// Participation returns the number of sync committee members that signed the block.
+func (s *SyncAggregate) Participation() int {
+	return popCount(s.SyncCommitteeBits)
+}
+
+// ParticipationRate returns the fraction of the sync committee that signed the block.
+func (s *SyncAggregate) ParticipationRate() float64 {
+	if len(s.SyncCommitteeBits) == 0 {
+		return 0
+	}
+
+	return float64(s.Participation()) / float64(len(s.SyncCommitteeBits)*8)
 }
 
 type ExecutionChange struct {
 	Message   *ExecutionChangeMessage
-	Signature common.Hash
+	Signature []byte
 }
 
 type ExecutionChangeMessage struct {
 	ValidatorIndex     uint64
-	FromBlsPubkey      common.Hash
-	ToExecutionAddress common.Hash
+	FromBlsPubkey      []byte
+	ToExecutionAddress common.Address
+}
+
+// Slashed returns the indices of the validators slashed by the proposer and attester slashings in this
+// body, each once, in the order they first appear.
+func (b *BeaconBlockBody) Slashed() []uint64 {
+	var indices []uint64
+	slashed := make(map[uint64]bool)
+	add := func(idx uint64) {
+		if !slashed[idx] {
+			slashed[idx] = true
+			indices = append(indices, idx)
+		}
+	}
+
+	for _, slashing := range b.ProposerSlashingsList {
+		if slashing.Header1 != nil && slashing.Header1.Message != nil {
+			add(slashing.Header1.Message.ProposerIndex)
+		}
+	}
+
+	// An attester is slashed if it appears in both conflicting attestations.
+	for _, slashing := range b.AttesterSlashingsList {
+		if slashing.Attestation1 == nil || slashing.Attestation2 == nil {
+			continue
+		}
+
+		seen := make(map[uint64]struct{}, len(slashing.Attestation1.AttestingIndicesList))
+		for _, idx := range slashing.Attestation1.AttestingIndicesList {
+			seen[idx] = struct{}{}
+		}
+		for _, idx := range slashing.Attestation2.AttestingIndicesList {
+			if _, ok := seen[idx]; ok {
+				add(idx)
+			}
+		}
+	}
+
+	return indices
 }
 
+// ProtoToBeaconBlock converts a protobuf beacon block.
 func ProtoToBeaconBlock(block *eth.CompactBeaconBlock) *BeaconBlock {
 	body := block.GetBody()
 
@@ -355,7 +469,7 @@ func ProtoToBeaconBlock(block *eth.CompactBeaconBlock) *BeaconBlock {
 		ParentRoot:    common.BytesToHash(block.GetParentRoot()),
 		StateRoot:     common.BytesToHash(block.GetStateRoot()),
 		Body: &BeaconBlockBody{
-			RandaoReveal: common.BytesToHash(body.GetRandaoReveal()),
+			RandaoReveal: body.GetRandaoReveal(),
 			Eth1Data: &Eth1Data{
 				DepositRoot:  common.BytesToHash(body.GetEth1Data().GetDepositRoot()),
 				DepositCount: body.GetEth1Data().GetDepositCount(),
@@ -384,8 +498,8 @@ func ProtoToBeaconBlock(block *eth.CompactBeaconBlock) *BeaconBlock {
 	syncAggregate := body.GetSyncAggregate()
 	if syncAggregate != nil {
 		beacon.Body.SyncAggregate = &SyncAggregate{
-			SyncCommitteeBits:      common.BytesToHash(syncAggregate.GetSyncCommitteeBits()),
-			SyncCommitteeSignature: common.BytesToHash(syncAggregate.GetSyncCommitteeSignature()),
+			SyncCommitteeBits:      syncAggregate.GetSyncCommitteeBits(),
+			SyncCommitteeSignature: syncAggregate.GetSyncCommitteeSignature(),
 		}
 	}
 
@@ -393,10 +507,10 @@ func ProtoToBeaconBlock(block *eth.CompactBeaconBlock) *BeaconBlock {
 		beacon.Body.BlsToExecutionChangesList = append(beacon.Body.BlsToExecutionChangesList, ExecutionChange{
 			Message: &ExecutionChangeMessage{
 				ValidatorIndex:     change.GetMessage().GetValidatorIndex(),
-				FromBlsPubkey:      common.BytesToHash(change.GetMessage().GetFromBlsPubkey()),
-				ToExecutionAddress: common.BytesToHash(change.GetMessage().GetToExecutionAddress()),
+				FromBlsPubkey:      change.GetMessage().GetFromBlsPubkey(),
+				ToExecutionAddress: common.BytesToAddress(change.GetMessage().GetToExecutionAddress()),
 			},
-			Signature: common.BytesToHash(change.GetSignature()),
+			Signature: change.GetSignature(),
 		})
 	}
 
@@ -413,7 +527,7 @@ func fromProtoProposerSlashing(slashing *eth.ProposerSlashing) ProposerSlashing
 func fromProtoSignedBeaconBlockHeader(header *eth.SignedBeaconBlockHeader) *SignedBeaconBlockHeader {
 	return &SignedBeaconBlockHeader{
 		Message:   fromProtoBeaconBlockHeader(header.GetMessage()),
-		Signature: common.BytesToHash(header.GetSignature()),
+		Signature: header.GetSignature(),
 	}
 }
 
@@ -438,7 +552,7 @@ func fromProtoIndexedAttestation(attestation *eth.IndexedAttestation) *IndexedAt
 	return &IndexedAttestation{
 		AttestingIndicesList: attestation.GetAttestingIndices(),
 		Data:                 fromProtoAttestationData(attestation.GetData()),
-		Signature:            common.BytesToHash(attestation.GetSignature()),
+		Signature:            attestation.GetSignature(),
 	}
 }
 
@@ -461,9 +575,9 @@ func fromProtoCheckpoint(checkpoint *eth.Checkpoint) *Checkpoint {
 
 func fromProtoAttestation(attestation *eth.Attestation) Attestation {
 	return Attestation{
-		AggregationBits: common.BytesToHash(attestation.GetAggregationBits()),
+		AggregationBits: attestation.GetAggregationBits(),
 		Data:            fromProtoAttestationData(attestation.GetData()),
-		Signature:       common.BytesToHash(attestation.GetSignature()),
+		Signature:       attestation.GetSignature(),
 	}
 }
 
@@ -481,10 +595,10 @@ func fromProtoDeposit(deposit *eth.Deposit) Deposit {
 
 func fromProtoDepositData(data *eth.DepositData) *DepositData {
 	return &DepositData{
-		Pubkey:                common.BytesToHash(data.GetPubkey()),
+		Pubkey:                data.GetPubkey(),
 		WithdrawalCredentials: common.BytesToHash(data.GetWithdrawalCredentials()),
 		Amount:                data.GetAmount(),
-		Signature:             common.BytesToHash(data.GetSignature()),
+		Signature:             data.GetSignature(),
 	}
 }
 
@@ -494,6 +608,15 @@ func fromProtoVoluntaryExit(exit *eth.SignedVoluntaryExit) VoluntaryExit {
 			Epoch:          exit.GetMessage().GetEpoch(),
 			ValidatorIndex: exit.GetMessage().GetValidatorIndex(),
 		},
-		Signature: common.BytesToHash(exit.GetSignature()),
+		Signature: exit.GetSignature(),
 	}
 }
+
+func popCount(bitfield []byte) int {
+	var n int
+	for _, b := range bitfield {
+		n += bits.OnesCount8(b)
+	}
+
+	return n
+}
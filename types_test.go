@@ -0,0 +1,194 @@
+package client
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func signTx(t testing.TB, inner types.TxData) *types.Transaction {
+	t.Helper()
+
+	pk, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := types.SignTx(types.NewTx(inner), types.NewLondonSigner(common.Big1), pk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func TestVerifyEncoding(t *testing.T) {
+	to := common.HexToAddress("0xdc6C276D357e82C7D38D73061CEeD2e33990E5bC")
+	acl := types.AccessList{{
+		Address:     to,
+		StorageKeys: []common.Hash{common.HexToHash("0x01"), common.HexToHash("0x02")},
+	}}
+
+	txs := map[string]types.TxData{
+		"legacy": &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(10), Gas: 21000, To: &to, Value: big.NewInt(1)},
+		"access list": &types.AccessListTx{
+			ChainID: common.Big1, Nonce: 2, GasPrice: big.NewInt(10), Gas: 50000, To: &to, AccessList: acl,
+		},
+		"dynamic fee": &types.DynamicFeeTx{
+			ChainID: common.Big1, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(20), Gas: 50000,
+			To: &to, Value: big.NewInt(100), Data: []byte{0xa9, 0x05, 0x9c, 0xbb}, AccessList: acl,
+		},
+		"contract creation": &types.DynamicFeeTx{
+			ChainID: common.Big1, Nonce: 4, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(20), Gas: 500000,
+			Data: []byte{0x60, 0x80},
+		},
+	}
+
+	for name, inner := range txs {
+		t.Run(name, func(t *testing.T) {
+			if err := verifyEncoding(signTx(t, inner)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func BenchmarkProtoToTx(b *testing.B) {
+	to := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	proto, err := TxToProto(signTx(b, &types.DynamicFeeTx{
+		ChainID: common.Big1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(20), Gas: 50000, To: &to,
+	}))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ProtoToTx(proto)
+	}
+}
+
+func benchmarkAddressHex(b *testing.B, capacity int) {
+	SetInternCapacity(capacity)
+	defer SetInternCapacity(DefaultInternCapacity)
+
+	tx := ProtoToTx(&eth.Transaction{To: common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48").Bytes()})
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = tx.ToHex()
+	}
+}
+
+func BenchmarkAddressHex(b *testing.B) {
+	benchmarkAddressHex(b, DefaultInternCapacity)
+}
+
+func BenchmarkAddressHexNoCache(b *testing.B) {
+	benchmarkAddressHex(b, 0)
+}
+
+func TestShardedInterner(t *testing.T) {
+	i := newShardedInterner(internShards, func(addr common.Address) string { return addr.Hex() })
+
+	a := common.Address{1}
+	if i.get(a) != a.Hex() || len(i[1].cur) != 1 {
+		t.Fatal("expected the address to be interned in its shard")
+	}
+
+	// Every shard holds its share of the capacity
+	i.get(common.Address{1 + internShards})
+	if len(i[1].cur) != 1 || len(i[1].prev) != 1 {
+		t.Fatalf("expected the shard to rotate, got %d and %d entries", len(i[1].cur), len(i[1].prev))
+	}
+}
+
+func TestProtoToTxTo(t *testing.T) {
+	to := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	proto := &eth.Transaction{To: to.Bytes()}
+
+	// Every transaction has its own receiver, so that changing one doesn't affect the others
+	a, b := ProtoToTx(proto), ProtoToTx(proto)
+	*a.To = common.Address{}
+	if *b.To != to {
+		t.Fatal("expected the transactions not to share their receiver")
+	}
+}
+
+func TestInterner(t *testing.T) {
+	i := newInterner(2, func(addr common.Address) *common.Address { return &addr })
+
+	a, b, c := common.Address{1}, common.Address{2}, common.Address{3}
+	pa := i.get(a)
+	i.get(b)
+	i.get(c) // rotates a and b into the previous generation
+
+	if i.get(a) != pa {
+		t.Fatal("expected address to be promoted from the previous generation")
+	}
+
+	if len(i.cur) > 2 {
+		t.Fatalf("expected generation to be bounded, got %d entries", len(i.cur))
+	}
+}
+
+func TestHex(t *testing.T) {
+	to := common.HexToAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed")
+	tx := &Transaction{Hash: common.HexToHash("0xabc"), From: to, To: &to}
+
+	if tx.HashHex() != tx.Hash.Hex() || tx.HashHex() != tx.Hash.Hex() {
+		t.Fatalf("unexpected hash %s", tx.HashHex())
+	}
+	if tx.FromHex() != to.Hex() || tx.ToHex() != to.Hex() || (&Transaction{}).ToHex() != "" {
+		t.Fatal("unexpected address encoding")
+	}
+	if allocs := testing.AllocsPerRun(10, func() { tx.HashHex() }); allocs != 0 {
+		t.Fatalf("expected the hash to be cached, got %v allocations", allocs)
+	}
+}
+
+func TestSyncAggregateParticipation(t *testing.T) {
+	aggregate := &SyncAggregate{SyncCommitteeBits: make([]byte, 64)}
+	aggregate.SyncCommitteeBits[0] = 0xff
+	aggregate.SyncCommitteeBits[63] = 0x81
+
+	if n := aggregate.Participation(); n != 10 {
+		t.Fatalf("expected 10 participants, got %d", n)
+	}
+	if rate := aggregate.ParticipationRate(); rate != 10.0/512 {
+		t.Fatalf("expected a participation rate of 10/512, got %f", rate)
+	}
+	if rate := (&SyncAggregate{}).ParticipationRate(); rate != 0 {
+		t.Fatalf("expected no participation without bits, got %f", rate)
+	}
+}
+
+func TestBeaconBlockBodySlashed(t *testing.T) {
+	header := func(proposer uint64) *SignedBeaconBlockHeader {
+		return &SignedBeaconBlockHeader{Message: &BeaconBlockHeader{ProposerIndex: proposer}}
+	}
+	attesters := func(indices ...uint64) *IndexedAttestation {
+		return &IndexedAttestation{AttestingIndicesList: indices}
+	}
+
+	body := &BeaconBlockBody{
+		ProposerSlashingsList: []ProposerSlashing{{Header1: header(7), Header2: header(7)}},
+		AttesterSlashingsList: []AttesterSlashing{
+			{Attestation1: attesters(1, 2, 7), Attestation2: attesters(2, 3, 7)},
+			{Attestation1: attesters(2, 4), Attestation2: attesters(4, 2, 5)},
+			{Attestation1: attesters(9)},
+		},
+	}
+
+	slashed := body.Slashed()
+	if len(slashed) != 3 || slashed[0] != 7 || slashed[1] != 2 || slashed[2] != 4 {
+		t.Fatalf("expected validators 7, 2 and 4 to be slashed once, got %v", slashed)
+	}
+}
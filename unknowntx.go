@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// UnknownTxPolicy is what a subscription does with transactions of a type the client doesn't know,
+// like the types introduced by a fork the client wasn't updated for yet.
+type UnknownTxPolicy int
+
+const (
+	// DeliverUnknownTxs delivers the unknown transactions with the fields Fiber sent, which is the
+	// default. Their ToNative is nil, since go-ethereum can't encode them.
+	DeliverUnknownTxs UnknownTxPolicy = iota
+	// SkipUnknownTxs drops the unknown transactions, and counts them in SubscriptionStats.Dropped. A
+	// payload is delivered without them, so it doesn't match its transactions root anymore.
+	SkipUnknownTxs
+	// FailOnUnknownTxs fails the subscription with an *UnknownTxTypeError.
+	FailOnUnknownTxs
+)
+
+// UnknownTxTypeError is the error of a subscription that received a transaction of an unknown type
+// with FailOnUnknownTxs.
+type UnknownTxTypeError struct {
+	Type uint32
+	Hash common.Hash
+}
+
+func (e *UnknownTxTypeError) Error() string {
+	return fmt.Sprintf("transaction %s has unknown type %d", e.Hash, e.Type)
+}
+
+func (e *UnknownTxTypeError) Code() ErrorCode {
+	return ErrCodeUnknownTxType
+}
+
+// KnownType returns whether the client knows the type of the transaction.
+func (tx *Transaction) KnownType() bool {
+	switch tx.Type {
+	case types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType, BlobTxType:
+		return true
+	}
+
+	return false
+}
+
+// WithUnknownTxPolicy sets what the subscription does with transactions of an unknown type, alone or
+// in payloads. If onUnknown isn't nil, it's called with every one of them.
+func WithUnknownTxPolicy(policy UnknownTxPolicy, onUnknown func(tx *Transaction)) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.unknownTxs = policy
+		cfg.onUnknownTx = onUnknown
+	}
+}
+
+// screen applies the unknown transaction policy and the minimum USD value to msg, and returns
+// whether it should be delivered.
+func (cfg *subscriptionConfig) screen(msg any) (bool, error) {
+	if cfg.unknownTxs == DeliverUnknownTxs && cfg.onUnknownTx == nil && cfg.minUSDValue == 0 {
+		return true, nil
+	}
+
+	switch m := msg.(type) {
+	case *Transaction:
+		if ok, err := cfg.screenTx(m); !ok || cfg.minUSDValue == 0 {
+			return ok, err
+		}
+		return valueTx(cfg.tokens, m) >= cfg.minUSDValue, nil
+	case *ExecutionPayload:
+		kept := m.Transactions[:0]
+		for _, tx := range m.Transactions {
+			ok, err := cfg.screenTx(tx)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				kept = append(kept, tx)
+			}
+		}
+		m.Transactions = kept
+	}
+
+	return true, nil
+}
+
+func (cfg *subscriptionConfig) screenTx(tx *Transaction) (bool, error) {
+	if tx.KnownType() {
+		return true, nil
+	}
+
+	if cfg.onUnknownTx != nil {
+		cfg.onUnknownTx(tx)
+	}
+
+	switch cfg.unknownTxs {
+	case SkipUnknownTxs:
+		return false, nil
+	case FailOnUnknownTxs:
+		return false, &UnknownTxTypeError{Type: tx.Type, Hash: tx.Hash}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,29 @@
+package client
+
+import (
+	"github.com/chainbound/fiber-go/tokens"
+)
+
+// WithTokenTable sets the USDValue of every streamed transaction (including the ones in execution
+// payloads) with the prices of table. The table can be updated while the subscription is running.
+func WithTokenTable(table *tokens.Table) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.tokens = table
+	}
+}
+
+// WithMinUSDValue skips the streamed transactions moving less than min dollars, with the prices of
+// table, which the server filters can't express. It implies WithTokenTable(table). The skipped
+// transactions count as dropped. The transactions of execution payloads are all kept.
+func WithMinUSDValue(table *tokens.Table, min float64) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.tokens = table
+		cfg.minUSDValue = min
+	}
+}
+
+// valueTx sets the USDValue of tx, and returns it.
+func valueTx(table *tokens.Table, tx *Transaction) float64 {
+	tx.USDValue = table.Value(tx.To, tx.Value, tx.Input)
+	return tx.USDValue
+}
@@ -0,0 +1,194 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+)
+
+// Validation is how strictly a subscription checks that the messages from the server have the fields
+// they're expected to have. Missing fields otherwise convert to zero values, which can't be told apart
+// from real ones: a header without a base fee has a base fee of 0.
+type Validation int
+
+const (
+	// NoValidation delivers the messages as they are, which is the default.
+	NoValidation Validation = iota
+	// ReportInvalid counts the missing fields (see Client.ValidationErrors), and still delivers the
+	// messages.
+	ReportInvalid
+	// SkipInvalid counts the missing fields, and drops the invalid messages. They're counted in
+	// SubscriptionStats.Dropped.
+	SkipInvalid
+	// StrictValidation counts the missing fields, and fails the subscription with a *ValidationError.
+	StrictValidation
+)
+
+// ValidationError is the error of a subscription with StrictValidation that received an invalid
+// message.
+type ValidationError struct {
+	// Message is the type of the invalid message, like "execution_payload_header".
+	Message string
+	// Fields are the missing or malformed fields, like "base_fee_per_gas" or "transactions.hash",
+	// once per message.
+	Fields []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: missing or malformed %s", e.Message, strings.Join(e.Fields, ", "))
+}
+
+func (e *ValidationError) Code() ErrorCode {
+	return ErrCodeInvalidMessage
+}
+
+// WithValidation sets how strictly the subscription validates the messages from the server.
+func WithValidation(v Validation) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.validation = v
+	}
+}
+
+// ValidationErrors returns the number of invalid fields seen by the validating subscriptions of the
+// client, by message type and field, like "execution_payload_header.base_fee_per_gas".
+func (c *Client) ValidationErrors() map[string]uint64 {
+	c.validationMu.Lock()
+	defer c.validationMu.Unlock()
+
+	counts := make(map[string]uint64, len(c.validationErrors))
+	for field, n := range c.validationErrors {
+		counts[field] = n
+	}
+
+	return counts
+}
+
+func (c *Client) invalid(stream StreamKind, err *ValidationError) {
+	c.validationMu.Lock()
+	if c.validationErrors == nil {
+		c.validationErrors = make(map[string]uint64)
+	}
+	for _, field := range err.Fields {
+		c.validationErrors[err.Message+"."+field]++
+	}
+	c.validationMu.Unlock()
+
+	c.metrics.invalid(stream, err)
+}
+
+// validatingRecv validates the messages returned by recv.
+func validatingRecv[P any](c *Client, cfg *subscriptionConfig, stream StreamKind, recv func() (P, error)) func() (P, error) {
+	return func() (P, error) {
+		for {
+			proto, err := recv()
+			if err != nil {
+				return proto, err
+			}
+
+			invalid := validateMessage(proto)
+			if invalid == nil {
+				return proto, nil
+			}
+
+			c.invalid(stream, invalid)
+			switch cfg.validation {
+			case SkipInvalid:
+				cfg.handle.dropped(1)
+				continue
+			case StrictValidation:
+				var zero P
+				return zero, invalid
+			}
+
+			return proto, nil
+		}
+	}
+}
+
+// validateMessage returns the invalid fields of a message from the server, nil if it's valid or of a
+// type without a schema.
+func validateMessage(msg any) *ValidationError {
+	var v validator
+	switch m := msg.(type) {
+	case *eth.Transaction:
+		v.message = "transaction"
+		v.tx("", m)
+	case *eth.ExecutionPayloadHeader:
+		v.message = "execution_payload_header"
+		v.header("", m)
+	case *eth.ExecutionPayload:
+		v.message = "execution_payload"
+		if m.Header == nil {
+			v.missing("header")
+		} else {
+			v.header("header.", m.Header)
+		}
+		for _, tx := range m.Transactions {
+			v.tx("transactions.", tx)
+		}
+	case *eth.CompactBeaconBlock:
+		v.message = "beacon_block"
+		v.bytes("parent_root", m.ParentRoot, 32)
+		v.bytes("state_root", m.StateRoot, 32)
+		if m.Body == nil {
+			v.missing("body")
+		}
+	default:
+		return nil
+	}
+
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Message: v.message, Fields: v.fields}
+}
+
+// validator collects the invalid fields of a message.
+type validator struct {
+	message string
+	fields  []string
+}
+
+func (v *validator) missing(field string) {
+	for _, f := range v.fields {
+		if f == field {
+			return
+		}
+	}
+
+	v.fields = append(v.fields, field)
+}
+
+// bytes checks that the field has size bytes, or any number but 0 if size is 0.
+func (v *validator) bytes(field string, b []byte, size int) {
+	if len(b) == 0 || size > 0 && len(b) != size {
+		v.missing(field)
+	}
+}
+
+func (v *validator) tx(prefix string, tx *eth.Transaction) {
+	v.bytes(prefix+"hash", tx.Hash, 32)
+	v.bytes(prefix+"from", tx.From, 20)
+	// Contract creations have no recipient
+	if tx.To != nil && len(tx.To) != 20 {
+		v.missing(prefix + "to")
+	}
+	v.bytes(prefix+"r", tx.R, 0)
+	v.bytes(prefix+"s", tx.S, 0)
+}
+
+func (v *validator) header(prefix string, h *eth.ExecutionPayloadHeader) {
+	v.bytes(prefix+"block_hash", h.BlockHash, 32)
+	v.bytes(prefix+"parent_hash", h.ParentHash, 32)
+	v.bytes(prefix+"state_root", h.StateRoot, 32)
+	v.bytes(prefix+"receipts_root", h.ReceiptsRoot, 32)
+	v.bytes(prefix+"transactions_root", h.TransactionsRoot, 32)
+	v.bytes(prefix+"fee_recipient", h.FeeRecipient, 20)
+	v.bytes(prefix+"logs_bloom", h.LogsBloom, 256)
+	v.bytes(prefix+"base_fee_per_gas", h.BaseFeePerGas, 0)
+	if h.Timestamp == 0 {
+		v.missing(prefix + "timestamp")
+	}
+}
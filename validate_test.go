@@ -0,0 +1,68 @@
+package client
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+)
+
+func validHeader(number uint64) *eth.ExecutionPayloadHeader {
+	hash := bytes.Repeat([]byte{1}, 32)
+	return &eth.ExecutionPayloadHeader{
+		BlockNumber:      number,
+		BlockHash:        hash,
+		ParentHash:       hash,
+		StateRoot:        hash,
+		ReceiptsRoot:     hash,
+		TransactionsRoot: hash,
+		FeeRecipient:     bytes.Repeat([]byte{2}, 20),
+		LogsBloom:        make([]byte, 256),
+		BaseFeePerGas:    []byte{7},
+		Timestamp:        1700000000,
+	}
+}
+
+func TestValidation(t *testing.T) {
+	invalid := validHeader(2)
+	invalid.BaseFeePerGas = nil
+
+	for _, tt := range []struct {
+		validation Validation
+		delivered  []uint64
+	}{
+		{ReportInvalid, []uint64{1, 2, 3}},
+		{SkipInvalid, []uint64{1, 3}},
+		{StrictValidation, []uint64{1}},
+	} {
+		c := &Client{}
+		ch := make(chan *ExecutionPayloadHeader, 3)
+		var sub Subscription
+		errc := make(chan error, 1)
+		go func() {
+			errc <- subscribe(c, "execution_headers", ch, []SubscriptionOption{WithHandle(&sub), WithValidation(tt.validation)}, fakeStream(validHeader(1), invalid, validHeader(3)), ProtoToHeader)
+		}()
+
+		for _, want := range tt.delivered {
+			if h := <-ch; h.Number != want {
+				t.Fatalf("%d: expected header %d, got %d", tt.validation, want, h.Number)
+			}
+		}
+
+		if n := c.ValidationErrors()["execution_payload_header.base_fee_per_gas"]; n != 1 {
+			t.Fatalf("%d: expected the missing base fee to be counted, got %v", tt.validation, c.ValidationErrors())
+		}
+
+		if tt.validation != StrictValidation {
+			sub.Unsubscribe()
+			<-errc
+			continue
+		}
+
+		var verr *ValidationError
+		if err := <-errc; !errors.As(err, &verr) || verr.Fields[0] != "base_fee_per_gas" || Code(err) != ErrCodeInvalidMessage {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+	}
+}
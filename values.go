@@ -0,0 +1,44 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// WithValues makes the values of ctx, like a tenant or strategy ID, visible to the stages that handle
+// the subscription's messages: the enrichers of WithEnricher, and the gRPC interceptors of the stream.
+// Only the values are used: the cancellation and deadline of ctx don't apply to the subscription.
+func WithValues(ctx context.Context) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.values = ctx
+	}
+}
+
+// WithEnricher runs fn on every message of the subscription before it's delivered, with the context
+// passed to WithValues. Enrichers run in order, on the receive loop, so they must not block.
+func WithEnricher(fn func(ctx context.Context, msg any)) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.enrichers = append(cfg.enrichers, fn)
+	}
+}
+
+// valuesOnly is a context with the values of its parent, which is never done.
+type valuesOnly struct {
+	parent context.Context
+}
+
+func (valuesOnly) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (valuesOnly) Done() <-chan struct{}       { return nil }
+func (valuesOnly) Err() error                  { return nil }
+
+func (v valuesOnly) Value(key any) any {
+	return v.parent.Value(key)
+}
+
+// withContext ties the subscription to ctx: it carries its values, and ends with ctx.Err() once ctx is done.
+func withContext(ctx context.Context) SubscriptionOption {
+	return func(cfg *subscriptionConfig) {
+		cfg.values = ctx
+		cfg.parent = ctx
+	}
+}
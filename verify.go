@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultVerifyBlocks is the number of headers VerifyAgainstRPC checks by default, about two minutes of blocks.
+const DefaultVerifyBlocks = 10
+
+// ErrFeedDiverged is returned by VerifyAgainstRPC when streamed headers don't match the reference node.
+var ErrFeedDiverged = newCodedError(ErrCodeFeedDiverged, "feed diverged from the reference node")
+
+// Divergence is a streamed header that doesn't match the reference node.
+type Divergence struct {
+	Number   uint64
+	Streamed common.Hash
+	// Reference is the hash of the block on the reference node, zero if it didn't have the block.
+	Reference common.Hash
+	// Reason is a human-readable explanation.
+	Reason string
+}
+
+// VerifyReport is the outcome of VerifyAgainstRPC.
+type VerifyReport struct {
+	// Checked is the number of streamed headers compared to the reference node.
+	Checked     int
+	From, To    uint64
+	Divergences []Divergence
+}
+
+// VerifyAgainstRPC compares the next blocks streamed execution headers (DefaultVerifyBlocks if 0) to the
+// chain of the execution client at url, by number and hash, and checks that they link up by parent hash.
+// It's meant as a startup gate, to prove the integrity of the feed before trading. It returns once the
+// headers were checked or ctx is done, with an error wrapping ErrFeedDiverged if any didn't match, and
+// otherwise one wrapping the error of ctx if fewer than blocks were checked, along with the partial
+// report. A reorg at the tip during the check can show up as a divergence.
+func (c *Client) VerifyAgainstRPC(ctx context.Context, url string, blocks int) (*VerifyReport, error) {
+	if blocks <= 0 {
+		blocks = DefaultVerifyBlocks
+	}
+
+	ref, err := NewRPCBackfill(ctx, url, "")
+	if err != nil {
+		return nil, err
+	}
+	defer ref.Close()
+
+	v := verifier{
+		reference: ref.ExecutionPayloadHeader,
+		blocks:    blocks,
+		// The reference node may lag behind Fiber by a few seconds
+		retry: 500 * time.Millisecond,
+		lag:   30 * time.Second,
+	}
+	return v.verify(ctx, c, ExecutionHeaderStream())
+}
+
+type verifier struct {
+	reference func(ctx context.Context, number uint64) (*eth.ExecutionPayloadHeader, error)
+	blocks    int
+	// retry is how often a block the reference node doesn't have yet is asked for again, for up to lag.
+	retry, lag time.Duration
+}
+
+func (v *verifier) verify(ctx context.Context, c *Client, src Source[*ExecutionPayloadHeader]) (*VerifyReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *ExecutionPayloadHeader, v.blocks)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- src(c, ch, withContext(ctx))
+	}()
+
+	report := new(VerifyReport)
+	var prev *ExecutionPayloadHeader
+	for report.Checked < v.blocks && ctx.Err() == nil {
+		var h *ExecutionPayloadHeader
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return report, fmt.Errorf("subscribing to execution headers: %w", <-errc)
+			}
+			h = msg
+		case <-ctx.Done():
+			continue
+		}
+
+		if prev != nil && h.Number == prev.Number+1 && h.ParentHash != prev.Hash {
+			report.Divergences = append(report.Divergences, Divergence{
+				Number:   h.Number,
+				Streamed: h.Hash,
+				Reason:   fmt.Sprintf("parent hash %s isn't the hash of the previous header", h.ParentHash),
+			})
+		}
+		prev = h
+
+		ref, err := v.fetch(ctx, h.Number)
+		switch {
+		case ctx.Err() != nil:
+			continue
+		case errors.Is(err, ethereum.NotFound):
+			report.Divergences = append(report.Divergences, Divergence{
+				Number:   h.Number,
+				Streamed: h.Hash,
+				Reason:   fmt.Sprintf("block not on the reference node after %s", v.lag),
+			})
+		case err != nil:
+			return report, fmt.Errorf("verifying against the reference node: %w", err)
+		case common.BytesToHash(ref.BlockHash) != h.Hash:
+			report.Divergences = append(report.Divergences, Divergence{
+				Number:    h.Number,
+				Streamed:  h.Hash,
+				Reference: common.BytesToHash(ref.BlockHash),
+				Reason:    "hash doesn't match the reference node",
+			})
+		}
+
+		if report.Checked == 0 {
+			report.From = h.Number
+		}
+		report.To = h.Number
+		report.Checked++
+	}
+
+	if len(report.Divergences) > 0 {
+		return report, fmt.Errorf("%w: %d divergences in %d headers", ErrFeedDiverged, len(report.Divergences), report.Checked)
+	}
+	if report.Checked < v.blocks {
+		return report, fmt.Errorf("verified %d of %d headers: %w", report.Checked, v.blocks, ctx.Err())
+	}
+
+	return report, nil
+}
+
+// fetch returns the header of the block from the reference node, waiting for it to have the block.
+func (v *verifier) fetch(ctx context.Context, number uint64) (*eth.ExecutionPayloadHeader, error) {
+	deadline := time.Now().Add(v.lag)
+	for {
+		h, err := v.reference(ctx, number)
+		if !errors.Is(err, ethereum.NotFound) || time.Now().After(deadline) {
+			return h, err
+		}
+
+		select {
+		case <-time.After(v.retry):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/chainbound/fiber-go/protobuf/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestVerifyAgainstRPC(t *testing.T) {
+	hash := func(n byte) []byte { return common.BytesToHash([]byte{n}).Bytes() }
+
+	// The reference node has block 2 with another hash, and not block 4
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage
+			Params []json.RawMessage
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var number hexutil.Uint64
+		json.Unmarshal(req.Params[0], &number)
+
+		result := "null"
+		switch number {
+		case 1, 3:
+			result = fmt.Sprintf(`{"number":"%s","hash":"%s"}`, number, hexutil.Encode(hash(byte(number))))
+		case 2:
+			result = fmt.Sprintf(`{"number":"%s","hash":"%s"}`, number, hexutil.Encode(hash(9)))
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%s}`, req.ID, result)
+	}))
+	defer node.Close()
+
+	ref, err := NewRPCBackfill(context.Background(), node.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ref.Close()
+
+	// Block 3 doesn't link up with block 2
+	src := headerSource(
+		&eth.ExecutionPayloadHeader{BlockNumber: 1, BlockHash: hash(1)},
+		&eth.ExecutionPayloadHeader{BlockNumber: 2, BlockHash: hash(2), ParentHash: hash(1)},
+		&eth.ExecutionPayloadHeader{BlockNumber: 3, BlockHash: hash(3), ParentHash: hash(8)},
+		&eth.ExecutionPayloadHeader{BlockNumber: 4, BlockHash: hash(4), ParentHash: hash(3)},
+	)
+
+	v := verifier{reference: ref.ExecutionPayloadHeader, blocks: 4, retry: time.Millisecond, lag: 20 * time.Millisecond}
+	report, err := v.verify(context.Background(), &Client{}, src)
+	if !errors.Is(err, ErrFeedDiverged) {
+		t.Fatalf("expected ErrFeedDiverged, got %v", err)
+	}
+	if report.Checked != 4 || report.From != 1 || report.To != 4 || len(report.Divergences) != 3 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	for i, want := range []Divergence{
+		{Number: 2, Reference: common.BytesToHash(hash(9))},
+		{Number: 3},
+		{Number: 4},
+	} {
+		if d := report.Divergences[i]; d.Number != want.Number || d.Reference != want.Reference {
+			t.Fatalf("%d: unexpected divergence %+v", i, d)
+		}
+	}
+
+	// The first block matches
+	v.blocks = 1
+	if report, err := v.verify(context.Background(), &Client{}, src); err != nil || report.Checked != 1 {
+		t.Fatalf("expected a verified block, got %+v, %v", report, err)
+	}
+
+	// The reference node stops answering after the first block, so the check times out incomplete
+	v.blocks = 3
+	v.reference = func(ctx context.Context, number uint64) (*eth.ExecutionPayloadHeader, error) {
+		if number == 1 {
+			return ref.ExecutionPayloadHeader(ctx, number)
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if report, err := v.verify(ctx, &Client{}, src); !errors.Is(err, context.DeadlineExceeded) || report.Checked != 1 {
+		t.Fatalf("expected an incomplete check to fail, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+const modulePath = "github.com/chainbound/fiber-go"
+
+var (
+	versionOnce sync.Once
+	version     string
+)
+
+// Version returns the version of this library, from the build info of the binary: the module version
+// when it's a dependency, "(devel)" when built from its own tree or without module support.
+func Version() string {
+	versionOnce.Do(func() {
+		version = "(devel)"
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		if info.Main.Path == modulePath && info.Main.Version != "" {
+			version = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path != modulePath {
+				continue
+			}
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				dep = dep.Replace
+			}
+			version = dep.Version
+		}
+	})
+
+	return version
+}
+
+// WithVersionMetadata sends the name and version of this library (see Version) to the server with
+// every call, in the metadata.ClientVersionKey metadata.
+func WithVersionMetadata() ClientOption {
+	return func(c *Client) {
+		c.sendVersion = true
+	}
+}